@@ -1,28 +1,35 @@
 module github.com/vmware-tanzu/velero-plugin-for-vsphere
 
 require (
+	cloud.google.com/go/storage v1.9.0
 	github.com/Azure/azure-sdk-for-go v35.0.0+incompatible // indirect
+	github.com/Azure/azure-storage-blob-go v0.13.0
 	github.com/Azure/go-autorest/autorest/validation v0.2.0 // indirect
 	github.com/agiledragon/gomonkey v2.0.1+incompatible
 	github.com/aws/aws-sdk-go v1.29.19
 	github.com/evanphx/json-patch v4.5.0+incompatible
 	github.com/fsnotify/fsnotify v1.4.9 // indirect
+	github.com/fsouza/fake-gcs-server v1.19.4
 	github.com/gobwas/glob v0.2.3 // indirect
 	github.com/gofrs/uuid v3.2.0+incompatible // indirect
-	github.com/golang/groupcache v0.0.0-20190129154638-5b532d6fd5ef // indirect
 	github.com/google/uuid v1.1.1
 	github.com/hashicorp/go-hclog v0.8.0 // indirect
 	github.com/hashicorp/go-plugin v0.0.0-20190220160451-3f118e8ee104 // indirect
 	github.com/hashicorp/yamux v0.0.0-20181012175058-2f1d1f20f75d // indirect
 	github.com/json-iterator/go v1.1.9 // indirect
+	github.com/klauspost/compress v1.10.10
 	github.com/mitchellh/go-testing-interface v1.0.0 // indirect
 	github.com/pkg/errors v0.9.1
-	github.com/sirupsen/logrus v1.4.2
+	github.com/prometheus/client_golang v1.0.0
+	github.com/sirupsen/logrus v1.6.0
 	github.com/spf13/cobra v0.0.5
 	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.4.0
 	github.com/vmware-tanzu/astrolabe v0.1.1-0.20200623051247-ee7b9b06c94b
 	github.com/vmware-tanzu/velero v1.3.2
+	github.com/vmware/govmomi v0.22.2-0.20200329013745-f2eef8fc745f
+	golang.org/x/time v0.0.0-20191024005414-555d28b269f0
+	google.golang.org/api v0.26.0
 	gotest.tools v2.2.0+incompatible
 	k8s.io/api v0.17.3
 	k8s.io/apiextensions-apiserver v0.17.3
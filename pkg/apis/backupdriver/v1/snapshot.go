@@ -161,6 +161,10 @@ type CloneStatus struct {
 	// The handle of the resource that was cloned from the snapshot
 	// +optional
 	ResourceHandle core_v1.TypedLocalObjectReference `json:"resourceHandle"`
+	// The ID of the volume created from the snapshot.  It is recorded before the resource is created, so that a retried
+	// clone reuses the volume, and a failed or canceled clone deletes it
+	// +optional
+	VolumeID string `json:"volumeID,omitempty"`
 }
 
 // +genclient
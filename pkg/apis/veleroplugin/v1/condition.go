@@ -0,0 +1,72 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionStatus is the status of a Condition.
+// +kubebuilder:validation:Enum=True;False;Unknown
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// The types of the conditions of Uploads and Downloads.
+const (
+	// ConditionLeaseAcquired is True while a DataManager holds the lease to process the Upload or Download.
+	ConditionLeaseAcquired = "LeaseAcquired"
+	// ConditionTransferring is True while the data is being transferred.
+	ConditionTransferring = "Transferring"
+	// ConditionLocalSnapshotCleanedUp is True once the local snapshot of an Upload is deleted.
+	ConditionLocalSnapshotCleanedUp = "LocalSnapshotCleanedUp"
+	// ConditionRetryScheduled is True while a transfer that failed waits to be retried.
+	ConditionRetryScheduled = "RetryScheduled"
+	// ConditionVerified is True if the snapshot of an Upload was read back from the remote repository and matched
+	// its checksums the last time it was verified, and False if it did not.
+	ConditionVerified = "Verified"
+)
+
+// Condition describes one aspect of the state of an Upload or Download. It follows the
+// conventions of the conditions of the Kubernetes API.
+type Condition struct {
+	// Type of the condition, in CamelCase.
+	Type string `json:"type"`
+
+	// Status of the condition, one of True, False, Unknown.
+	Status ConditionStatus `json:"status"`
+
+	// ObservedGeneration is the generation of the resource the condition was set for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastTransitionTime is the last time the status of the condition changed.
+	// +nullable
+	LastTransitionTime meta_v1.Time `json:"lastTransitionTime"`
+
+	// Reason is a CamelCase reason for the last transition of the condition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human readable message about the last transition of the condition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
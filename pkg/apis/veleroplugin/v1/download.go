@@ -30,6 +30,31 @@ type DownloadSpec struct {
 	// The server's time is used for SnapshotTimestamp
 	// +optional
 	RestoreTimestamp *meta_v1.Time `json:"restoreTimestamp,omitempty"`
+
+	// BackupRepository is the name of the BackupRepository to download the snapshot from.
+	// +optional
+	BackupRepository string `json:"backupRepository,omitempty"`
+
+	// BackupStorageLocation is the name of the Velero BackupStorageLocation to download the snapshot from.
+	// It is ignored if BackupRepository is set. The default repository of the data manager is used
+	// if neither is set.
+	// +optional
+	BackupStorageLocation string `json:"backupStorageLocation,omitempty"`
+
+	// RepositoryParameters records the bucket, prefix, region and credentials profile of the
+	// BackupStorageLocation at the time the Download was created.
+	// +optional
+	RepositoryParameters map[string]string `json:"repositoryParameters,omitempty"`
+
+	// TransferStreams is the number of concurrent streams the snapshot is downloaded with.
+	// The default of the DataManager is used if it is not set.
+	// +optional
+	TransferStreams int32 `json:"transferStreams,omitempty"`
+
+	// StorageClass is the name of the StorageClass of the PersistentVolumeClaim being restored. The
+	// volume is placed on a datastore compatible with its storagepolicyname and datastoreurl parameters.
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
 }
 
 // DownloadPhase represents the lifecycle phase of a Download.
@@ -92,6 +117,38 @@ type DownloadStatus struct {
 	// +optional
 	// +nullable
 	NextRetryTimestamp *meta_v1.Time `json:"nextRetryTimestamp,omitempty"`
+
+	// Conditions are the latest observations of the state of the Download.
+	// +optional
+	// +nullable
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// Placement records the datastore the volume is placed on, if the Download has a StorageClass.
+	// +optional
+	// +nullable
+	Placement *VolumePlacement `json:"placement,omitempty"`
+}
+
+// VolumePlacement records the datastore a restored volume is placed on, and the StorageClass it is
+// placed for.
+type VolumePlacement struct {
+	// StorageClass is the name of the StorageClass the volume is placed for.
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// StoragePolicyName is the name of the storage policy of the StorageClass, if any.
+	// +optional
+	StoragePolicyName string `json:"storagePolicyName,omitempty"`
+
+	// DatastoreURL is the datastore URL of the StorageClass, if any.
+	// +optional
+	DatastoreURL string `json:"datastoreURL,omitempty"`
+
+	// Datastore is the managed object ID of the datastore the volume is placed on.
+	Datastore string `json:"datastore,omitempty"`
+
+	// DatastoreName is the name of the datastore the volume is placed on.
+	// +optional
+	DatastoreName string `json:"datastoreName,omitempty"`
 }
 
 // DownloadOperationProgress represents the progress of a
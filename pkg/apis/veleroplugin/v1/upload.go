@@ -31,6 +31,26 @@ type UploadSpec struct {
 
 	// UploadCancel indicates request to cancel ongoing upload.
 	UploadCancel bool `json:"uploadCancel,omitempty"`
+
+	// BackupRepository is the name of the BackupRepository to upload the snapshot to.
+	// +optional
+	BackupRepository string `json:"backupRepository,omitempty"`
+
+	// BackupStorageLocation is the name of the Velero BackupStorageLocation to upload the snapshot to.
+	// It is ignored if BackupRepository is set. The default repository of the data manager is used
+	// if neither is set.
+	// +optional
+	BackupStorageLocation string `json:"backupStorageLocation,omitempty"`
+
+	// RepositoryParameters records the bucket, prefix, region and credentials profile of the
+	// BackupStorageLocation at the time the Upload was created.
+	// +optional
+	RepositoryParameters map[string]string `json:"repositoryParameters,omitempty"`
+
+	// TransferStreams is the number of concurrent streams the snapshot is uploaded with.
+	// The default of the DataManager is used if it is not set.
+	// +optional
+	TransferStreams int32 `json:"transferStreams,omitempty"`
 }
 
 // UploadPhase represents the lifecycle phase of a Upload.
@@ -97,6 +117,47 @@ type UploadStatus struct {
 	// exponential backoff mechanism.
 	// +optional
 	CurrentBackOff int32 `json:"currentBackOff,omitempty"`
+
+	// EncryptionKeyID is the ID of the key the snapshot is encrypted with in the remote repository.
+	// It is empty if the snapshot is not encrypted.
+	// +optional
+	EncryptionKeyID string `json:"encryptionKeyID,omitempty"`
+
+	// Checkpoint records the part of the data of the snapshot that is committed to the
+	// remote repository. An Upload that is interrupted, e.g. as the DataManager processing
+	// it dies, resumes from its checkpoint on the node that picks it up.
+	// +optional
+	// +nullable
+	Checkpoint *UploadCheckpoint `json:"checkpoint,omitempty"`
+
+	// Conditions are the latest observations of the state of the Upload.
+	// +optional
+	// +nullable
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// Checksum is the checksum of the data of the snapshot recorded in the remote repository
+	// when the snapshot was uploaded, which the verification of the snapshot compares it against.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// VerificationTimestamp records the last time the snapshot was read back from the remote
+	// repository and verified against its checksums. The result is in the Verified condition.
+	// +optional
+	// +nullable
+	VerificationTimestamp *meta_v1.Time `json:"verificationTimestamp,omitempty"`
+}
+
+// UploadCheckpoint represents the part of the data of the snapshot
+// that an Upload committed to the remote repository.
+type UploadCheckpoint struct {
+	// CommittedBytes is the number of bytes of the data committed to the remote repository.
+	// +optional
+	CommittedBytes int64 `json:"committedBytes,omitempty"`
+
+	// Timestamp records the time the checkpoint was taken or resumed from.
+	// +optional
+	// +nullable
+	Timestamp *meta_v1.Time `json:"timestamp,omitempty"`
 }
 
 // UploadOperationProgress represents the progress of a
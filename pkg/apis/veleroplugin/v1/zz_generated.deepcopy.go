@@ -24,6 +24,23 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Download) DeepCopyInto(out *Download) {
 	*out = *in
@@ -108,6 +125,13 @@ func (in *DownloadSpec) DeepCopyInto(out *DownloadSpec) {
 		in, out := &in.RestoreTimestamp, &out.RestoreTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.RepositoryParameters != nil {
+		in, out := &in.RepositoryParameters, &out.RepositoryParameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -137,6 +161,18 @@ func (in *DownloadStatus) DeepCopyInto(out *DownloadStatus) {
 		in, out := &in.NextRetryTimestamp, &out.NextRetryTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(VolumePlacement)
+		**out = **in
+	}
 	return
 }
 
@@ -178,6 +214,26 @@ func (in *Upload) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UploadCheckpoint) DeepCopyInto(out *UploadCheckpoint) {
+	*out = *in
+	if in.Timestamp != nil {
+		in, out := &in.Timestamp, &out.Timestamp
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UploadCheckpoint.
+func (in *UploadCheckpoint) DeepCopy() *UploadCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(UploadCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UploadList) DeepCopyInto(out *UploadList) {
 	*out = *in
@@ -234,6 +290,13 @@ func (in *UploadSpec) DeepCopyInto(out *UploadSpec) {
 		in, out := &in.BackupTimestamp, &out.BackupTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.RepositoryParameters != nil {
+		in, out := &in.RepositoryParameters, &out.RepositoryParameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -263,6 +326,22 @@ func (in *UploadStatus) DeepCopyInto(out *UploadStatus) {
 		in, out := &in.NextRetryTimestamp, &out.NextRetryTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.Checkpoint != nil {
+		in, out := &in.Checkpoint, &out.Checkpoint
+		*out = new(UploadCheckpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VerificationTimestamp != nil {
+		in, out := &in.VerificationTimestamp, &out.VerificationTimestamp
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -275,3 +354,19 @@ func (in *UploadStatus) DeepCopy() *UploadStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumePlacement) DeepCopyInto(out *VolumePlacement) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumePlacement.
+func (in *VolumePlacement) DeepCopy() *VolumePlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumePlacement)
+	in.DeepCopyInto(out)
+	return out
+}
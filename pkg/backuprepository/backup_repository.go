@@ -0,0 +1,222 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuprepository
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	plugin_clientset "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sort"
+	"sync"
+)
+
+// Reference names the repository that a snapshot is copied to or from. BackupRepository takes precedence over
+// BackupStorageLocation. A repository may also be referred to by its Parameters only, e.g. a filesystem repository
+// configured in the plugin. The default repository of the data manager is referred to if all are empty.
+type Reference struct {
+	// BackupRepository is the name of a backupdriver.io BackupRepository.
+	BackupRepository string
+
+	// BackupStorageLocation is the name of a Velero BackupStorageLocation.
+	BackupStorageLocation string
+
+	// Parameters of the repository recorded when the Upload or Download was created. If set, they are used instead
+	// of the current parameters of the BackupStorageLocation, which may not exist in this cluster.
+	Parameters map[string]string
+}
+
+func NewReference(backupRepository string, backupStorageLocation string, parameters map[string]string) Reference {
+	if backupRepository != "" {
+		backupStorageLocation = ""
+		parameters = nil
+	}
+	return Reference{
+		BackupRepository:      backupRepository,
+		BackupStorageLocation: backupStorageLocation,
+		Parameters:            parameters,
+	}
+}
+
+func (r Reference) IsDefault() bool {
+	return r.BackupRepository == "" && r.BackupStorageLocation == "" && len(r.Parameters) == 0
+}
+
+func (r Reference) String() string {
+	switch {
+	case r.BackupRepository != "":
+		return "BackupRepository/" + r.BackupRepository
+	case r.BackupStorageLocation != "":
+		return "BackupStorageLocation/" + r.BackupStorageLocation
+	case len(r.Parameters) > 0:
+		// The parameters never hold credentials, and are printed in the order of their keys.
+		return fmt.Sprintf("Parameters/%v", r.Parameters)
+	default:
+		return "default"
+	}
+}
+
+var retrieveCredentials = utils.RetrieveCredentialsFromSecret
+
+/*
+ * Retrieve the parameters of the remote repository referred to, either from the
+ * BackupRepository or from the Velero Backup Storage Location(BSL).
+ */
+func RetrieveParams(ref Reference, logger logrus.FieldLogger) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	if ref.BackupRepository == "" {
+		if len(ref.Parameters) > 0 {
+			for key, value := range ref.Parameters {
+				params[key] = value
+			}
+		} else if ref.BackupStorageLocation != "" {
+			err := utils.RetrieveParamsFromBSL(ref.BackupStorageLocation, params, logger)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, errors.New("No repository is referred to")
+		}
+
+		// The credentials are not recorded with the parameters, they are always read from the Velero credentials secret.
+		err := retrieveCredentials(params, logger)
+		if err != nil {
+			return nil, err
+		}
+		return params, nil
+	}
+
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		logger.WithError(err).Errorf("Failed to get k8s inClusterConfig")
+		return nil, err
+	}
+	pluginClient, err := plugin_clientset.NewForConfig(config)
+	if err != nil {
+		logger.WithError(err).Errorf("Failed to get k8s clientset from the given config: %v", config)
+		return nil, err
+	}
+
+	repository, err := pluginClient.BackupdriverV1().BackupRepositories().Get(ref.BackupRepository, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get BackupRepository %s", ref.BackupRepository)
+	}
+
+	err = utils.ValidateBackupRepositoryParameters(repository.RepositoryDriver, repository.RepositoryParameters)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid BackupRepository %s", ref.BackupRepository)
+	}
+
+	for key, value := range repository.RepositoryParameters {
+		params[key] = value
+	}
+	params["provider"] = utils.GetProviderOfRepositoryDriver(repository.RepositoryDriver)
+	return params, nil
+}
+
+type petmCacheEntry struct {
+	petm astrolabe.ProtectedEntityTypeManager
+	// fingerprint of the parameters, including credentials, the PETM was created from
+	fingerprint string
+}
+
+// PETMCache keeps one remote repository PETM per repository referred to by Uploads and Downloads. The parameters of a
+// repository are retrieved on every lookup, and its PETM is rebuilt when they change, e.g. after a credential rotation.
+type PETMCache struct {
+	logger      logrus.FieldLogger
+	defaultPETM astrolabe.ProtectedEntityTypeManager
+
+	mutex   sync.Mutex
+	entries map[string]*petmCacheEntry
+
+	retrieveParams func(Reference, logrus.FieldLogger) (map[string]interface{}, error)
+	newPETM        func(map[string]interface{}, logrus.FieldLogger) (astrolabe.ProtectedEntityTypeManager, error)
+}
+
+// NewPETMCache creates a PETMCache. The defaultPETM is used for the default repository and may be nil if there is
+// no default repository.
+func NewPETMCache(defaultPETM astrolabe.ProtectedEntityTypeManager, logger logrus.FieldLogger) *PETMCache {
+	return &PETMCache{
+		logger:         logger,
+		defaultPETM:    defaultPETM,
+		entries:        make(map[string]*petmCacheEntry),
+		retrieveParams: RetrieveParams,
+		newPETM:        utils.GetRepositoryPETMFromParamsMap,
+	}
+}
+
+// Get returns the PETM of the repository referred to.
+func (c *PETMCache) Get(ref Reference) (astrolabe.ProtectedEntityTypeManager, error) {
+	log := c.logger.WithField("repository", ref.String())
+	if ref.IsDefault() {
+		if c.defaultPETM == nil {
+			return nil, errors.New("No default repository is configured")
+		}
+		return c.defaultPETM, nil
+	}
+
+	params, err := c.retrieveParams(ref, log)
+	if err != nil {
+		log.WithError(err).Error("Failed to retrieve the parameters of the repository")
+		return nil, err
+	}
+	fingerprint := paramsFingerprint(params)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[ref.String()]
+	if ok && entry.fingerprint == fingerprint {
+		return entry.petm, nil
+	}
+	if ok {
+		log.Info("The parameters of the repository have changed, rebuilding its PETM")
+	}
+
+	petm, err := c.newPETM(params, log)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to get PETM for the repository, provider=%v, bucket=%v", utils.GetProviderFromParamsMap(params), params["bucket"])
+		return nil, err
+	}
+	c.entries[ref.String()] = &petmCacheEntry{
+		petm:        petm,
+		fingerprint: fingerprint,
+	}
+	log.Infof("PETM is created for the repository, provider=%v, bucket=%v", utils.GetProviderFromParamsMap(params), params["bucket"])
+
+	return petm, nil
+}
+
+// paramsFingerprint returns a digest of the parameters, so that credentials are not kept around in plain text.
+func paramsFingerprint(params map[string]interface{}) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s=%v\n", key, params[key])
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuprepository
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/astrolabe/pkg/s3repository"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	"testing"
+)
+
+func TestNewReference(t *testing.T) {
+	params := map[string]string{"bucket": "velero"}
+	assert.True(t, NewReference("", "", nil).IsDefault())
+	assert.Equal(t, Reference{BackupRepository: "br-1"}, NewReference("br-1", "default", params))
+	assert.Equal(t, Reference{BackupStorageLocation: "default", Parameters: params}, NewReference("", "default", params))
+
+	fileSystemParams := map[string]string{"provider": "filesystem", "path": "/repository"}
+	ref := NewReference("", "", fileSystemParams)
+	assert.False(t, ref.IsDefault())
+	assert.Equal(t, "Parameters/map[path:/repository provider:filesystem]", ref.String())
+}
+
+func TestRetrieveParamsFromRecordedParameters(t *testing.T) {
+	defer func(f func(map[string]interface{}, logrus.FieldLogger) error) { retrieveCredentials = f }(retrieveCredentials)
+	retrieveCredentials = func(params map[string]interface{}, _ logrus.FieldLogger) error {
+		params["accessKeyId"] = "id"
+		params["secretAccessKey"] = "secret"
+		return nil
+	}
+
+	ref := NewReference("", "default", map[string]string{"region": "us-west-1", "bucket": "velero"})
+	params, err := RetrieveParams(ref, veleroplugintest.NewLogger())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"region": "us-west-1", "bucket": "velero", "accessKeyId": "id", "secretAccessKey": "secret"}, params)
+	assert.Equal(t, map[string]string{"region": "us-west-1", "bucket": "velero"}, ref.Parameters)
+}
+
+func TestPETMCacheGet(t *testing.T) {
+	repositoryParams := map[string]map[string]interface{}{
+		"BackupRepository/br-1":     {"region": "us-west-1", "bucket": "bucket-1"},
+		"BackupStorageLocation/bsl": {"region": "us-west-1", "bucket": "bucket-2"},
+	}
+	created := 0
+
+	defaultPETM := &s3repository.ProtectedEntityTypeManager{}
+	cache := NewPETMCache(defaultPETM, veleroplugintest.NewLogger())
+	cache.retrieveParams = func(ref Reference, _ logrus.FieldLogger) (map[string]interface{}, error) {
+		return repositoryParams[ref.String()], nil
+	}
+	cache.newPETM = func(_ map[string]interface{}, _ logrus.FieldLogger) (astrolabe.ProtectedEntityTypeManager, error) {
+		created++
+		return &s3repository.ProtectedEntityTypeManager{}, nil
+	}
+
+	petm, err := cache.Get(Reference{})
+	require.NoError(t, err)
+	assert.True(t, petm == defaultPETM)
+	assert.Equal(t, 0, created)
+
+	first, err := cache.Get(Reference{BackupRepository: "br-1"})
+	require.NoError(t, err)
+	again, err := cache.Get(Reference{BackupRepository: "br-1"})
+	require.NoError(t, err)
+	assert.True(t, first == again)
+	assert.Equal(t, 1, created)
+
+	_, err = cache.Get(Reference{BackupStorageLocation: "bsl"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+
+	// Rotated credentials rebuild the PETM of the repository.
+	repositoryParams["BackupRepository/br-1"]["secretAccessKey"] = "rotated"
+	rebuilt, err := cache.Get(Reference{BackupRepository: "br-1"})
+	require.NoError(t, err)
+	assert.True(t, first != rebuilt)
+	assert.Equal(t, 3, created)
+}
+
+func TestPETMCacheGetWithoutDefault(t *testing.T) {
+	cache := NewPETMCache(nil, veleroplugintest.NewLogger())
+	_, err := cache.Get(Reference{})
+	assert.Error(t, err)
+}
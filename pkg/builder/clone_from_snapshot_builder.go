@@ -0,0 +1,102 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	backupdriverv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/backupdriver/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CloneFromSnapshotBuilder builds CloneFromSnapshot objects
+type CloneFromSnapshotBuilder struct {
+	object *backupdriverv1api.CloneFromSnapshot
+}
+
+// ForCloneFromSnapshot is the constructor for a CloneFromSnapshotBuilder.
+func ForCloneFromSnapshot(ns, name string) *CloneFromSnapshotBuilder {
+	return &CloneFromSnapshotBuilder{
+		object: &backupdriverv1api.CloneFromSnapshot{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: backupdriverv1api.SchemeGroupVersion.String(),
+				Kind:       "CloneFromSnapshot",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns,
+				Name:      name,
+			},
+			Spec: backupdriverv1api.CloneFromSnapshotSpec{
+				Kind: "PersistentVolumeClaim",
+			},
+		},
+	}
+}
+
+// Result returns the built CloneFromSnapshot.
+func (b *CloneFromSnapshotBuilder) Result() *backupdriverv1api.CloneFromSnapshot {
+	return b.object
+}
+
+// ObjectMeta applies functional options to the CloneFromSnapshot's ObjectMeta.
+func (b *CloneFromSnapshotBuilder) ObjectMeta(opts ...ObjectMetaOpt) *CloneFromSnapshotBuilder {
+	for _, opt := range opts {
+		opt(b.object)
+	}
+
+	return b
+}
+
+// SnapshotID sets the snapshot ID to clone from.
+func (b *CloneFromSnapshotBuilder) SnapshotID(snapshotID string) *CloneFromSnapshotBuilder {
+	b.object.Spec.SnapshotID = snapshotID
+	return b
+}
+
+// Metadata sets the metadata overriding the one stored at snapshot time.
+func (b *CloneFromSnapshotBuilder) Metadata(metadata []byte) *CloneFromSnapshotBuilder {
+	b.object.Spec.Metadata = metadata
+	return b
+}
+
+// Kind sets the kind of the resource to create.
+func (b *CloneFromSnapshotBuilder) Kind(kind string) *CloneFromSnapshotBuilder {
+	b.object.Spec.Kind = kind
+	return b
+}
+
+// BackupRepository sets the backup repository to retrieve the snapshot from.
+func (b *CloneFromSnapshotBuilder) BackupRepository(name string) *CloneFromSnapshotBuilder {
+	b.object.Spec.BackupRepository = name
+	return b
+}
+
+// CloneCancel sets the CloneFromSnapshot's cancel flag.
+func (b *CloneFromSnapshotBuilder) CloneCancel(cancel bool) *CloneFromSnapshotBuilder {
+	b.object.Spec.CloneCancel = cancel
+	return b
+}
+
+// Phase sets the CloneFromSnapshot's phase.
+func (b *CloneFromSnapshotBuilder) Phase(phase backupdriverv1api.ClonePhase) *CloneFromSnapshotBuilder {
+	b.object.Status.Phase = phase
+	return b
+}
+
+// VolumeID sets the ID of the volume created for the CloneFromSnapshot.
+func (b *CloneFromSnapshotBuilder) VolumeID(volumeID string) *CloneFromSnapshotBuilder {
+	b.object.Status.VolumeID = volumeID
+	return b
+}
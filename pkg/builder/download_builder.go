@@ -110,4 +110,27 @@ func (b *DownloadBuilder) Retry(cnt int32) *DownloadBuilder {
 func (b *DownloadBuilder) NextRetryTimestamp(val time.Time) *DownloadBuilder {
 	b.object.Status.NextRetryTimestamp = &metav1.Time{Time: val}
 	return b
-}
\ No newline at end of file
+}
+// BackupRepository sets the name of the BackupRepository for the Download.
+func (b *DownloadBuilder) BackupRepository(name string) *DownloadBuilder {
+	b.object.Spec.BackupRepository = name
+	return b
+}
+
+// BackupStorageLocation sets the name of the Velero BackupStorageLocation for the Download.
+func (b *DownloadBuilder) BackupStorageLocation(name string) *DownloadBuilder {
+	b.object.Spec.BackupStorageLocation = name
+	return b
+}
+
+// RepositoryParameters sets the parameters of the BackupStorageLocation for the Download.
+func (b *DownloadBuilder) RepositoryParameters(params map[string]string) *DownloadBuilder {
+	b.object.Spec.RepositoryParameters = params
+	return b
+}
+
+// StorageClass sets the name of the StorageClass the Download places the volume for.
+func (b *DownloadBuilder) StorageClass(name string) *DownloadBuilder {
+	b.object.Spec.StorageClass = name
+	return b
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	backupdriverv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/backupdriver/v1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SnapshotBuilder builds Snapshot objects
+type SnapshotBuilder struct {
+	object *backupdriverv1api.Snapshot
+}
+
+// ForSnapshot is the constructor for a SnapshotBuilder.
+func ForSnapshot(ns, name string) *SnapshotBuilder {
+	return &SnapshotBuilder{
+		object: &backupdriverv1api.Snapshot{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: backupdriverv1api.SchemeGroupVersion.String(),
+				Kind:       "Snapshot",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns,
+				Name:      name,
+			},
+		},
+	}
+}
+
+// Result returns the built Snapshot.
+func (b *SnapshotBuilder) Result() *backupdriverv1api.Snapshot {
+	return b.object
+}
+
+// ObjectMeta applies functional options to the Snapshot's ObjectMeta.
+func (b *SnapshotBuilder) ObjectMeta(opts ...ObjectMetaOpt) *SnapshotBuilder {
+	for _, opt := range opts {
+		opt(b.object)
+	}
+
+	return b
+}
+
+// PVC sets the Snapshot's resource handle to the named PersistentVolumeClaim.
+func (b *SnapshotBuilder) PVC(name string) *SnapshotBuilder {
+	b.object.Spec.TypedLocalObjectReference = core_v1.TypedLocalObjectReference{
+		Kind: "PersistentVolumeClaim",
+		Name: name,
+	}
+	return b
+}
+
+// BackupRepository sets the Snapshot's backup repository.
+func (b *SnapshotBuilder) BackupRepository(name string) *SnapshotBuilder {
+	b.object.Spec.BackupRepository = name
+	return b
+}
+
+// SnapshotCancel sets the Snapshot's cancel flag.
+func (b *SnapshotBuilder) SnapshotCancel(cancel bool) *SnapshotBuilder {
+	b.object.Spec.SnapshotCancel = cancel
+	return b
+}
+
+// Phase sets the Snapshot's phase.
+func (b *SnapshotBuilder) Phase(phase backupdriverv1api.SnapshotPhase) *SnapshotBuilder {
+	b.object.Status.Phase = phase
+	return b
+}
+
+// SnapshotID sets the Snapshot's snapshot ID.
+func (b *SnapshotBuilder) SnapshotID(snapshotID string) *SnapshotBuilder {
+	b.object.Status.SnapshotID = snapshotID
+	return b
+}
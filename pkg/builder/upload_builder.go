@@ -110,4 +110,21 @@ func (b *UploadBuilder) NextRetryTimestamp(val time.Time) *UploadBuilder {
 func (b *UploadBuilder) CurrentBackOff(backoff int32) *UploadBuilder {
 	b.object.Status.CurrentBackOff = backoff
 	return b
-}
\ No newline at end of file
+}
+// BackupRepository sets the name of the BackupRepository for the Upload.
+func (b *UploadBuilder) BackupRepository(name string) *UploadBuilder {
+	b.object.Spec.BackupRepository = name
+	return b
+}
+
+// BackupStorageLocation sets the name of the Velero BackupStorageLocation for the Upload.
+func (b *UploadBuilder) BackupStorageLocation(name string) *UploadBuilder {
+	b.object.Spec.BackupStorageLocation = name
+	return b
+}
+
+// RepositoryParameters sets the parameters of the BackupStorageLocation for the Upload.
+func (b *UploadBuilder) RepositoryParameters(params map[string]string) *UploadBuilder {
+	b.object.Spec.RepositoryParameters = params
+	return b
+}
@@ -55,6 +55,10 @@ type InstallOptions struct {
 	SecretFile                        string
 	NoSecret                          bool
 	DryRun                            bool
+	RepositoryNFSServer               string
+	RepositoryNFSPath                 string
+	RepositoryMountPath               string
+	EncryptionKeySecret               string
 }
 
 func (o *InstallOptions) BindFlags(flags *pflag.FlagSet) {
@@ -70,6 +74,10 @@ func (o *InstallOptions) BindFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&o.DatamgrPodCPULimit, "datamgr-pod-cpu-limit", o.DatamgrPodCPULimit, `CPU limit for Datamgr pod. A value of "0" is treated as unbounded. Optional.`)
 	flags.StringVar(&o.DatamgrPodMemLimit, "datamgr-pod-mem-limit", o.DatamgrPodMemLimit, `memory limit for Datamgr pod. A value of "0" is treated as unbounded. Optional.`)
 	flags.BoolVar(&o.DryRun, "dry-run", o.DryRun, "generate resources, but don't send them to the cluster. Use with -o. Optional.")
+	flags.StringVar(&o.RepositoryNFSServer, "repository-nfs-server", o.RepositoryNFSServer, "NFS server of the share to mount into the datamgr pods as a filesystem repository. Optional.")
+	flags.StringVar(&o.RepositoryNFSPath, "repository-nfs-path", o.RepositoryNFSPath, "exported path of the NFS share to mount into the datamgr pods as a filesystem repository. Optional.")
+	flags.StringVar(&o.RepositoryMountPath, "repository-mount-path", o.RepositoryMountPath, "path the NFS share of the filesystem repository is mounted at in the datamgr pods. Optional.")
+	flags.StringVar(&o.EncryptionKeySecret, "encryption-key-secret", o.EncryptionKeySecret, "name of the secret, in the Velero namespace, with the keys to encrypt snapshots with before uploading them. Optional.")
 }
 
 func NewInstallOptions() *InstallOptions {
@@ -81,6 +89,7 @@ func NewInstallOptions() *InstallOptions {
 		DatamgrPodMemRequest:      install.DefaultDatamgrPodMemRequest,
 		DatamgrPodCPULimit:        install.DefaultDatamgrPodCPULimit,
 		DatamgrPodMemLimit:        install.DefaultDatamgrPodMemLimit,
+		RepositoryMountPath:       install.DefaultRepositoryMountPath,
 	}
 }
 
@@ -111,6 +120,11 @@ func (o *InstallOptions) AsDatamgrOptions() (*install.DatamgrOptions, error) {
 		PodAnnotations:                    o.PodAnnotations.Data(),
 		DatamgrPodResources:			   datamgrPodResources,
 		SecretData:                        secretData,
+		NoSecret:                          o.NoSecret,
+		RepositoryNFSServer:               o.RepositoryNFSServer,
+		RepositoryNFSPath:                 o.RepositoryNFSPath,
+		RepositoryMountPath:               o.RepositoryMountPath,
+		EncryptionKeySecret:               o.EncryptionKeySecret,
 	}, nil
 }
 
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshots
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/cmd"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/dataMover"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	"github.com/vmware-tanzu/velero/pkg/client"
+	"github.com/vmware-tanzu/velero/pkg/util/logging"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+type SnapshotsOptions struct {
+	BackupRepository      string
+	BackupStorageLocation string
+	Backup                string
+	logLevelFlag          *logging.LevelFlag
+}
+
+func (o *SnapshotsOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.BackupRepository, "backup-repository", o.BackupRepository, "name of the BackupRepository to list the snapshots of. Optional.")
+	flags.StringVar(&o.BackupStorageLocation, "backup-storage-location", o.BackupStorageLocation, "name of the Velero BackupStorageLocation whose repository to list the snapshots of. The default repository of the data manager is listed if neither it nor --backup-repository is specified. Optional.")
+	flags.StringVar(&o.Backup, "backup", o.Backup, "name of a Velero backup to only list the snapshots of. Optional.")
+	flags.Var(o.logLevelFlag, "log-level", "the level at which to log. Optional.")
+}
+
+func NewSnapshotsOptions() *SnapshotsOptions {
+	return &SnapshotsOptions{
+		logLevelFlag: logging.LogLevelFlag(logrus.WarnLevel),
+	}
+}
+
+func NewCommand(f client.Factory) *cobra.Command {
+	o := NewSnapshotsOptions()
+	c := &cobra.Command{
+		Use:   "snapshots",
+		Short: "List the snapshots in a remote repository",
+		Long: `List the snapshots in a remote repository, with the Velero backups they are part of.
+The repository may be shared with the clusters of other vCenters, whose snapshots are listed
+too, so that they are restored on this cluster. It is run in a data manager pod, e.g. with
+kubectl exec.`,
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Run(os.Stdout))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+	return c
+}
+
+func (o *SnapshotsOptions) Run(out io.Writer) error {
+	logger := logging.DefaultLogger(o.logLevelFlag.Parse(), logging.FormatText)
+	repository := backuprepository.NewReference(o.BackupRepository, o.BackupStorageLocation, nil)
+
+	mover, err := dataMover.NewRepositoryDataMoverFromCluster(make(map[string]interface{}), nil, logger)
+	if err != nil {
+		return err
+	}
+	snapshots, err := mover.IndexRepository(repository)
+	if err != nil {
+		return err
+	}
+	return printSnapshots(out, snapshots, o.Backup)
+}
+
+// printSnapshots prints a line per snapshot, only those of the given Velero backup if it is not empty.
+func printSnapshots(out io.Writer, snapshots []objectstore.IndexedSnapshot, backup string) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "SNAPSHOT\tVOLUME\tBACKUP\tTIMESTAMP")
+	for _, snapshot := range snapshots {
+		backupName, timestamp := "<none>", "<unknown>"
+		if snapshot.Origin != nil {
+			if snapshot.Origin.Backup != "" {
+				backupName = snapshot.Origin.Backup
+			}
+			timestamp = snapshot.Origin.Timestamp.UTC().Format(time.RFC3339)
+		}
+		if backup != "" && backupName != backup {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", snapshot.ID.String(), snapshot.ID.GetID(), backupName, timestamp)
+	}
+	return w.Flush()
+}
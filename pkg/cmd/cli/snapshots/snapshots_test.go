@@ -0,0 +1,58 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshots
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintSnapshots(t *testing.T) {
+	snapshots := []objectstore.IndexedSnapshot{
+		{
+			ID:     astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-1")),
+			Origin: &objectstore.SnapshotOrigin{Timestamp: time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC), Backup: "backup-1"},
+		},
+		{
+			ID:     astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-2", astrolabe.NewProtectedEntitySnapshotID("snap-2")),
+			Origin: &objectstore.SnapshotOrigin{Timestamp: time.Date(2020, 7, 2, 12, 0, 0, 0, time.UTC)},
+		},
+		{
+			ID: astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-3", astrolabe.NewProtectedEntitySnapshotID("snap-3")),
+		},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, printSnapshots(&out, snapshots, ""))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.Contains(t, lines[1], "backup-1")
+	assert.Contains(t, lines[1], "2020-07-01T12:00:00Z")
+	assert.Contains(t, lines[2], "<none>")
+	assert.Contains(t, lines[3], "<unknown>")
+
+	out.Reset()
+	assert.NoError(t, printSnapshots(&out, snapshots, "backup-1"))
+	lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "ivd:vol-1:snap-1")
+}
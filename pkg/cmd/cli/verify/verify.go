@@ -0,0 +1,151 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/cmd"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/dataMover"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/encryption"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	"github.com/vmware-tanzu/velero/pkg/client"
+	"github.com/vmware-tanzu/velero/pkg/cmd/util/flag"
+	"github.com/vmware-tanzu/velero/pkg/util/logging"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+type VerifyOptions struct {
+	BackupRepository      string
+	BackupStorageLocation string
+	Snapshots             flag.StringArray
+	TransferStreams       int
+	EncryptionKeySecret   string
+	logLevelFlag          *logging.LevelFlag
+}
+
+func (o *VerifyOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.BackupRepository, "backup-repository", o.BackupRepository, "name of the BackupRepository to verify. Optional.")
+	flags.StringVar(&o.BackupStorageLocation, "backup-storage-location", o.BackupStorageLocation, "name of the Velero BackupStorageLocation whose repository to verify. The default repository of the data manager is verified if neither it nor --backup-repository is specified. Optional.")
+	flags.Var(&o.Snapshots, "snapshot", "ID of a snapshot to verify, such as ivd:<volume>:<snapshot>. All the snapshots of the repository are verified if it is not specified. Optional, may be repeated.")
+	flags.IntVar(&o.TransferStreams, "transfer-streams", o.TransferStreams, "the number of concurrent streams the data of a snapshot is read with. Optional.")
+	flags.StringVar(&o.EncryptionKeySecret, "encryption-key-secret", o.EncryptionKeySecret, "name of the secret, in the Velero namespace, with the keys the encrypted snapshots are decrypted with. Encrypted snapshots fail the verification if it is not specified. Optional.")
+	flags.Var(o.logLevelFlag, "log-level", "the level at which to log. Optional.")
+}
+
+func NewVerifyOptions() *VerifyOptions {
+	return &VerifyOptions{
+		TransferStreams: objectstore.DefaultTransferStreams,
+		logLevelFlag:    logging.LogLevelFlag(logrus.WarnLevel),
+	}
+}
+
+func NewCommand(f client.Factory) *cobra.Command {
+	o := NewVerifyOptions()
+	c := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the snapshots in a remote repository",
+		Long: `Read the snapshots in a remote repository back and compare them against the checksums
+recorded when they were uploaded, to prove that they can be restored without restoring them.
+It is run in a data manager pod, e.g. with kubectl exec, and exits with an error if a snapshot
+fails the verification.`,
+		Run: func(c *cobra.Command, args []string) {
+			cmd.CheckError(o.Run(f, os.Stdout))
+		},
+	}
+
+	o.BindFlags(c.Flags())
+	return c
+}
+
+func (o *VerifyOptions) Run(f client.Factory, out io.Writer) error {
+	logger := logging.DefaultLogger(o.logLevelFlag.Parse(), logging.FormatText)
+	repository := backuprepository.NewReference(o.BackupRepository, o.BackupStorageLocation, nil)
+	var ids []astrolabe.ProtectedEntityID
+	for _, snapshot := range o.Snapshots {
+		id, err := astrolabe.NewProtectedEntityIDFromString(snapshot)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid snapshot %s", snapshot)
+		}
+		ids = append(ids, id)
+	}
+
+	var keyProvider encryption.KeyProvider
+	if o.EncryptionKeySecret != "" {
+		kubeClient, err := f.KubeClient()
+		if err != nil {
+			return err
+		}
+		keyProvider = encryption.NewSecretKeyProvider(kubeClient, f.Namespace(), o.EncryptionKeySecret)
+	}
+
+	mover, err := dataMover.NewRepositoryDataMoverFromCluster(make(map[string]interface{}), keyProvider, logger)
+	if err != nil {
+		return err
+	}
+
+	var verifications []objectstore.SnapshotVerification
+	if len(ids) == 0 {
+		verifications, err = mover.VerifyRepository(repository, o.TransferStreams)
+		if err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		verification, err := mover.VerifySnapshot(id, repository, o.TransferStreams)
+		if err != nil {
+			return err
+		}
+		verifications = append(verifications, verification)
+	}
+
+	return printVerifications(out, repository, verifications)
+}
+
+// printVerifications prints a line per snapshot, and returns an error if a snapshot failed the verification.
+func printVerifications(out io.Writer, repository backuprepository.Reference, verifications []objectstore.SnapshotVerification) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "SNAPSHOT\tRESULT\tBYTES\tCHECKSUM\tERROR")
+	var failed int
+	for _, verification := range verifications {
+		result, message := "Verified", ""
+		if !verification.Verified() {
+			result, message = "Failed", verification.Err.Error()
+			failed++
+		}
+		checksum := verification.Checksum
+		if checksum == "" {
+			checksum = "<none>"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", verification.ID.String(), result, verification.Size, checksum, message)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d snapshots in repository %s failed the verification", failed, len(verifications), repository.String())
+	}
+	fmt.Fprintf(out, "%d snapshots in repository %s are verified\n", len(verifications), repository.String())
+	return nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	"strings"
+	"testing"
+)
+
+func TestPrintVerifications(t *testing.T) {
+	repository := backuprepository.NewReference("", "default", nil)
+	verified := objectstore.SnapshotVerification{
+		ID:       astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-1")),
+		Checksum: "sha256:1234",
+		Size:     4096,
+	}
+	failed := objectstore.SnapshotVerification{
+		ID:  astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-2", astrolabe.NewProtectedEntitySnapshotID("snap-2")),
+		Err: errors.New("Chunk 5678 of ProtectedEntity ivd:vol-2:snap-2 is corrupted"),
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, printVerifications(&out, repository, []objectstore.SnapshotVerification{verified}))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[1], "Verified")
+	assert.Contains(t, lines[1], "sha256:1234")
+
+	out.Reset()
+	err := printVerifications(&out, repository, []objectstore.SnapshotVerification{verified, failed})
+	assert.EqualError(t, err, "1 of 2 snapshots in repository BackupStorageLocation/default failed the verification")
+	assert.Contains(t, out.String(), "is corrupted")
+	assert.Contains(t, out.String(), "<none>")
+}
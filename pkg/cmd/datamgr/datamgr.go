@@ -20,6 +20,8 @@ import (
 	"flag"
 	"fmt"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/cmd/cli/install"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/cmd/cli/snapshots"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/cmd/cli/verify"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/cmd/server"
 	"os"
 
@@ -52,6 +54,8 @@ func NewCommand(name string) *cobra.Command {
 	c.AddCommand(
 		server.NewCommand(f),
 		install.NewCommand(f),
+		verify.NewCommand(f),
+		snapshots.NewCommand(f),
 	)
 
 	// init and add the klog flags
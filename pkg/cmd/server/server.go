@@ -31,25 +31,40 @@ import (
 	"github.com/vmware-tanzu/astrolabe/pkg/ivd"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/cmd"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/controller"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/dataMover"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/encryption"
 	plugin_clientset "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned"
+	pluginscheme "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/scheme"
 	pluginInformers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/metrics"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/migration"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/retention"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/snapshotmgr"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/transferpolicy"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
 	"github.com/vmware-tanzu/velero/pkg/buildinfo"
 	"github.com/vmware-tanzu/velero/pkg/client"
 	"github.com/vmware-tanzu/velero/pkg/cmd/util/signals"
 	velero_clientset "github.com/vmware-tanzu/velero/pkg/generated/clientset/versioned"
-	"github.com/vmware-tanzu/velero/pkg/metrics"
 	"github.com/vmware-tanzu/velero/pkg/util/logging"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -64,6 +79,18 @@ const (
 	defaultVCConfigFromSecret bool = true
 
 	defaultControllerWorkers = 1
+	// the default number of Uploads and Downloads processed at the same time, and of transfers a node runs at once
+	defaultTransferWorkers        = 4
+	defaultMaxConcurrentTransfers = 4
+
+	// the default frequency of the maintenance of the remote repositories
+	defaultRepositoryMaintenanceFrequency = 24 * time.Hour
+	// the default frequency of the garbage collection of Uploads, Downloads and their Leases, and the time the done
+	// Uploads and Downloads are kept for
+	defaultGarbageCollectionFrequency = time.Hour
+	defaultGarbageCollectionTTL       = 72 * time.Hour
+	// the default frequency the retention policies are applied at
+	defaultRetentionFrequency = 24 * time.Hour
 	// the default TTL for a backup
 	//defaultBackupTTL = 30 * 24 * time.Hour
 )
@@ -80,6 +107,45 @@ type serverConfig struct {
 	clusterId          string
 	insecureFlag       bool
 	vcConfigFromSecret bool
+	// the name of the secret with the keys snapshots are encrypted with
+	encryptionKeySecret string
+	// how often the chains of incremental snapshots in the remote repositories are merged, 0 disables it
+	repositoryMaintenanceFrequency time.Duration
+	// the number of incremental snapshots a snapshot may depend on before it is merged into a synthetic full snapshot
+	maxChainDepth int
+	// the number of concurrent streams the data of a snapshot is transferred with, unless the Upload or Download
+	// overrides it
+	transferStreams int
+	// the number of bytes per second the transfers of the data manager share, such as 100Mi, unlimited if empty or 0
+	bandwidthLimit string
+	// the times of the day uploads are started in, such as 22:00-06:00, at any time if empty
+	transferWindows string
+	// the name of the config map, in the Velero namespace, that overrides the bandwidth limit and the transfer windows
+	transferPolicyConfigMap string
+	// the number of workers of the upload and download controllers
+	uploadWorkers   int
+	downloadWorkers int
+	// the number of uploads and downloads the data manager runs at the same time, unlimited if 0
+	maxConcurrentTransfers int
+	// how often the done Uploads and Downloads and the orphaned Leases are deleted, 0 disables it
+	garbageCollectionFrequency time.Duration
+	// the time the Uploads and Downloads are kept for once they are done
+	garbageCollectionTTL time.Duration
+	// how often the retention policies are applied to the remote repositories, 0 disables it
+	retentionFrequency time.Duration
+	// the retention policy of the repositories, such as keepDaily=7,keepWeekly=4,maxAge=2160h, all the snapshots are
+	// kept if empty
+	retentionPolicy string
+	// whether the snapshots of the Velero backups that do not exist anymore are deleted
+	retentionDeleteOrphans bool
+	// whether the snapshots that would be deleted are only reported
+	retentionDryRun bool
+	// the name of the config map, in the Velero namespace, that overrides the retention configuration
+	retentionPolicyConfigMap string
+	// how often the snapshots in the remote repositories are read back and verified against their checksums, 0 disables it
+	verificationFrequency time.Duration
+	// the name of the config map, in the Velero namespace, that maps the datastores of the snapshots of other vCenters to those of this one
+	restoreMappingConfigMap string
 }
 
 func NewCommand(f client.Factory) *cobra.Command {
@@ -94,6 +160,17 @@ func NewCommand(f client.Factory) *cobra.Command {
 			port:               utils.DefaultVCenterPort,
 			insecureFlag:       defaultInsecureFlag,
 			vcConfigFromSecret: defaultVCConfigFromSecret,
+
+			repositoryMaintenanceFrequency: defaultRepositoryMaintenanceFrequency,
+			maxChainDepth:                  objectstore.DefaultMaxChainDepth,
+			transferStreams:                objectstore.DefaultTransferStreams,
+			uploadWorkers:                  defaultTransferWorkers,
+			downloadWorkers:                defaultTransferWorkers,
+			maxConcurrentTransfers:         defaultMaxConcurrentTransfers,
+			garbageCollectionFrequency:     defaultGarbageCollectionFrequency,
+			garbageCollectionTTL:           defaultGarbageCollectionTTL,
+			retentionFrequency:             defaultRetentionFrequency,
+			restoreMappingConfigMap:        migration.DefaultConfigMapName,
 		}
 	)
 
@@ -146,6 +223,25 @@ func NewCommand(f client.Factory) *cobra.Command {
 	command.Flags().StringVar(&config.clusterId, "cluster-id", config.clusterId, "kubernetes cluster id. If specified, --use-secret should be set to False.")
 	command.Flags().BoolVar(&config.insecureFlag, "insecure-Flag", config.insecureFlag, "insecure flag. If specified, --use-secret should be set to False.")
 	command.Flags().BoolVar(&config.vcConfigFromSecret, "use-secret", config.vcConfigFromSecret, "retrieve VirtualCenter configuration from secret")
+	command.Flags().DurationVar(&config.repositoryMaintenanceFrequency, "repository-maintenance-frequency", config.repositoryMaintenanceFrequency, "how often to merge the chains of incremental snapshots in the remote repositories and to purge the deleted snapshots that no snapshot depends on anymore. Set to 0 to disable it.")
+	command.Flags().IntVar(&config.maxChainDepth, "max-chain-depth", config.maxChainDepth, "the number of incremental snapshots a snapshot may depend on before the repository maintenance merges it into a synthetic full snapshot")
+	command.Flags().IntVar(&config.transferStreams, "transfer-streams", config.transferStreams, "the number of concurrent streams the data of a snapshot is uploaded or downloaded with, unless the Upload or Download sets it")
+	command.Flags().IntVar(&config.uploadWorkers, "upload-workers", config.uploadWorkers, "the number of Uploads processed at the same time")
+	command.Flags().IntVar(&config.downloadWorkers, "download-workers", config.downloadWorkers, "the number of Downloads processed at the same time")
+	command.Flags().IntVar(&config.maxConcurrentTransfers, "max-concurrent-transfers", config.maxConcurrentTransfers, "the number of uploads and downloads the data manager runs at the same time. The others wait for one of them to complete, in the order they were queued. Set to 0 to disable the limit.")
+	command.Flags().DurationVar(&config.garbageCollectionFrequency, "garbage-collection-frequency", config.garbageCollectionFrequency, "how often to delete the Uploads and Downloads that are done for longer than the garbage collection TTL, and the Leases of the ones that do not exist anymore. Set to 0 to disable it.")
+	command.Flags().DurationVar(&config.retentionFrequency, "retention-frequency", config.retentionFrequency, "how often to apply the retention policies to the remote repositories. Set to 0 to disable it.")
+	command.Flags().StringVar(&config.retentionPolicy, "retention-policy", config.retentionPolicy, "the retention policy of the snapshots in the remote repositories that are not part of a Velero backup, such as keepDaily=7,keepWeekly=4,maxAge=2160h. All the snapshots are kept if it is empty.")
+	command.Flags().BoolVar(&config.retentionDeleteOrphans, "retention-delete-orphans", config.retentionDeleteOrphans, "delete the snapshots in the remote repositories of the Velero backups that do not exist anymore")
+	command.Flags().BoolVar(&config.retentionDryRun, "retention-dry-run", config.retentionDryRun, "only report the snapshots the retention would delete")
+	command.Flags().StringVar(&config.retentionPolicyConfigMap, "retention-policy-config-map", config.retentionPolicyConfigMap, "name of the config map, in the Velero namespace, whose defaultPolicy, repositoryPolicies, deleteOrphans and dryRun entries override the retention configuration. It is read every time the retention policies are applied.")
+	command.Flags().DurationVar(&config.verificationFrequency, "verification-frequency", config.verificationFrequency, "how often to read the snapshots in the remote repositories back and verify them against the checksums recorded when they were uploaded. The results are recorded in the Verified condition of the Uploads. Set to 0 to disable it.")
+	command.Flags().StringVar(&config.restoreMappingConfigMap, "restore-mapping-config-map", config.restoreMappingConfigMap, "name of the config map, in the Velero namespace, whose entries map the datacenters, datastores, StorageClasses and storage policies of the snapshots taken on another cluster to those of this one when they are restored, and whose strict entry rejects those that are not mapped. It is read before every download.")
+	command.Flags().DurationVar(&config.garbageCollectionTTL, "garbage-collection-ttl", config.garbageCollectionTTL, "how long the Uploads and Downloads are kept for once they are completed, failed or canceled")
+	command.Flags().StringVar(&config.bandwidthLimit, "bandwidth-limit", config.bandwidthLimit, "the number of bytes per second all the uploads and downloads of the data manager share, such as 100Mi. They are not limited if it is not specified.")
+	command.Flags().StringVar(&config.transferWindows, "transfer-windows", config.transferWindows, "comma separated times of the day, in the local time of the data manager, uploads are started in, such as 22:00-06:00. Uploads are started at any time if it is not specified.")
+	command.Flags().StringVar(&config.transferPolicyConfigMap, "transfer-policy-config-map", config.transferPolicyConfigMap, "name of the config map, in the Velero namespace, whose bandwidthLimit, repositoryBandwidthLimits and transferWindows entries override the bandwidth limit and the transfer windows. It is read before every transfer.")
+	command.Flags().StringVar(&config.encryptionKeySecret, "encryption-key-secret", config.encryptionKeySecret, "name of the secret, in the Velero namespace, with the keys to encrypt snapshots with before uploading them. Snapshots are not encrypted if it is not specified.")
 
 	return command
 }
@@ -158,15 +254,20 @@ type server struct {
 	veleroClient          velero_clientset.Interface
 	pluginClient          plugin_clientset.Interface
 	pluginInformerFactory pluginInformers.SharedInformerFactory
-	kubeInformerFactory   kubeinformers.SharedInformerFactory
-	ctx                   context.Context
-	cancelFunc            context.CancelFunc
-	logger                logrus.FieldLogger
-	logLevel              logrus.Level
-	metrics               *metrics.ServerMetrics
-	config                serverConfig
-	dataMover             *dataMover.DataMover
-	snapManager           *snapshotmgr.SnapshotManager
+	// backupdriverInformerFactory watches backupdriver CRs across all namespaces
+	backupdriverInformerFactory pluginInformers.SharedInformerFactory
+	kubeInformerFactory         kubeinformers.SharedInformerFactory
+	ctx                         context.Context
+	cancelFunc                  context.CancelFunc
+	logger                      logrus.FieldLogger
+	logLevel                    logrus.Level
+	metrics                     *metrics.ServerMetrics
+	config                      serverConfig
+	dataMover                   *dataMover.DataMover
+	snapManager                 *snapshotmgr.SnapshotManager
+	transferPolicy              *transferpolicy.Source
+	retentionConfig             *retention.Source
+	restoreMapping              *migration.Source
 }
 
 func (s *server) run() error {
@@ -177,6 +278,10 @@ func (s *server) run() error {
 		go s.runProfiler()
 	}
 
+	if s.metricsAddress != "" {
+		go s.runMetricsServer()
+	}
+
 	// Since s.namespace, which specifies where backups/restores/schedules/etc. should live,
 	// *could* be different from the namespace where the Velero server pod runs, check to make
 	// sure it exists, and fail fast if it doesn't.
@@ -256,10 +361,55 @@ func newServer(f client.Factory, config serverConfig, logger *logrus.Logger) (*s
 		logger.Infof("VC configuration provided by user for :%s", configParams[ivd.HostVcParamKey])
 	}
 
-	dataMover, err := dataMover.NewDataMoverFromCluster(configParams, logger)
+	var keyProvider encryption.KeyProvider
+	if config.encryptionKeySecret != "" {
+		logger.Infof("Snapshots will be encrypted with the keys in secret %s/%s", f.Namespace(), config.encryptionKeySecret)
+		keyProvider = encryption.NewSecretKeyProvider(kubeClient, f.Namespace(), config.encryptionKeySecret)
+	}
+
+	if config.uploadWorkers < 1 || config.downloadWorkers < 1 {
+		return nil, errors.New("newServer: upload-workers and download-workers must be at least 1")
+	}
+
+	if config.garbageCollectionTTL <= 0 {
+		return nil, errors.New("newServer: garbage-collection-ttl must be positive")
+	}
+
+	bandwidthLimit, err := transferpolicy.ParseBandwidth(config.bandwidthLimit)
+	if err != nil {
+		return nil, err
+	}
+	transferWindows, err := transferpolicy.ParseWindows(config.transferWindows)
+	if err != nil {
+		return nil, err
+	}
+	if bandwidthLimit > 0 {
+		logger.Infof("The transfers of the data manager are limited to %d bytes per second", bandwidthLimit)
+	}
+	if len(transferWindows) > 0 {
+		logger.Infof("Uploads are only started in the transfer windows %s", transferWindows.String())
+	}
+	transferPolicy := transferpolicy.NewSource(kubeClient, f.Namespace(), config.transferPolicyConfigMap, transferpolicy.Policy{
+		BandwidthLimit: bandwidthLimit,
+		Windows:        transferWindows,
+	})
+
+	retentionPolicy, err := retention.ParsePolicy(config.retentionPolicy)
+	if err != nil {
+		return nil, err
+	}
+	retentionConfig := retention.NewSource(kubeClient, f.Namespace(), config.retentionPolicyConfigMap, retention.Config{
+		Default:       retentionPolicy,
+		DeleteOrphans: config.retentionDeleteOrphans,
+		DryRun:        config.retentionDryRun,
+	})
+
+	dataMover, err := dataMover.NewDataMoverFromCluster(configParams, keyProvider, logger)
 	if err != nil {
 		return nil, err
 	}
+	restoreMapping := migration.NewSource(kubeClient, f.Namespace(), config.restoreMappingConfigMap)
+	dataMover.SetRestoreMapping(restoreMapping)
 
 	snapshotMgrConfig := make(map[string]string)
 	snapshotMgrConfig[utils.VolumeSnapshotterManagerLocation] = utils.VolumeSnapshotterDataServer
@@ -268,23 +418,33 @@ func newServer(f client.Factory, config serverConfig, logger *logrus.Logger) (*s
 		return nil, err
 	}
 
+	serverMetrics := metrics.NewServerMetrics()
+	serverMetrics.RegisterAllMetrics()
+	snapshotmgr.SetMetrics(serverMetrics, os.Getenv("NODE_NAME"))
+	snapshotmgr.SetRestoreMapping(restoreMapping)
+
 	ctx, cancelFunc := context.WithCancel(context.Background())
 
 	s := &server{
-		namespace:             f.Namespace(),
-		metricsAddress:        config.metricsAddress,
-		kubeClient:            kubeClient,
-		veleroClient:          veleroClient,
-		pluginClient:          pluginClient,
-		pluginInformerFactory: pluginInformers.NewSharedInformerFactoryWithOptions(pluginClient, utils.ResyncPeriod, pluginInformers.WithNamespace(f.Namespace())),
-		kubeInformerFactory:   kubeinformers.NewSharedInformerFactory(kubeClient, 0),
-		ctx:                   ctx,
-		cancelFunc:            cancelFunc,
-		logger:                logger,
-		logLevel:              logger.Level,
-		config:                config,
-		dataMover:             dataMover,
-		snapManager:           snapshotmgr,
+		namespace:                   f.Namespace(),
+		metricsAddress:              config.metricsAddress,
+		kubeClient:                  kubeClient,
+		veleroClient:                veleroClient,
+		pluginClient:                pluginClient,
+		pluginInformerFactory:       pluginInformers.NewSharedInformerFactoryWithOptions(pluginClient, utils.ResyncPeriod, pluginInformers.WithNamespace(f.Namespace())),
+		backupdriverInformerFactory: pluginInformers.NewSharedInformerFactory(pluginClient, utils.ResyncPeriod),
+		kubeInformerFactory:         kubeinformers.NewSharedInformerFactory(kubeClient, 0),
+		ctx:                         ctx,
+		cancelFunc:                  cancelFunc,
+		logger:                      logger,
+		logLevel:                    logger.Level,
+		metrics:                     serverMetrics,
+		config:                      config,
+		dataMover:                   dataMover,
+		snapManager:                 snapshotmgr,
+		transferPolicy:              transferPolicy,
+		retentionConfig:             retentionConfig,
+		restoreMapping:              restoreMapping,
 	}
 
 	return s, nil
@@ -316,6 +476,55 @@ func (s *server) runProfiler() {
 	}
 }
 
+func (s *server) runMetricsServer() {
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", promhttp.Handler())
+
+	s.logger.Infof("Starting metric server at address [%s]", s.metricsAddress)
+	if err := http.ListenAndServe(s.metricsAddress, metricsMux); err != nil {
+		s.logger.WithError(errors.WithStack(err)).Error("error running metrics http server")
+	}
+}
+
+// pendingUploads returns the number of Uploads that are not completed yet, and the time since the oldest of them was
+// created.
+func (s *server) pendingUploads() (int, time.Duration) {
+	uploads, err := s.pluginInformerFactory.Veleroplugin().V1().Uploads().Lister().List(labels.Everything())
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to list the Uploads for the metrics")
+		return 0, 0
+	}
+	count := 0
+	var oldest time.Time
+	for _, upload := range uploads {
+		switch upload.Status.Phase {
+		case pluginv1api.UploadPhaseCompleted, pluginv1api.UploadPhaseCleanupFailed, pluginv1api.UploadPhaseCanceled:
+			continue
+		}
+		count++
+		if oldest.IsZero() || upload.CreationTimestamp.Time.Before(oldest) {
+			oldest = upload.CreationTimestamp.Time
+		}
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return count, time.Since(oldest)
+}
+
+// newEventRecorder returns a recorder of the Events of the Uploads and Downloads, and of the claims of the volumes
+// they transfer, and of the BackupRepositoryClaims.
+func (s *server) newEventRecorder() record.EventRecorder {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(kubescheme.AddToScheme(scheme))
+	utilruntime.Must(pluginscheme.AddToScheme(scheme))
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartLogging(s.logger.Debugf)
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: s.kubeClient.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme, corev1.EventSource{Component: "datamgr-for-vsphere-plugin", Host: os.Getenv("NODE_NAME")})
+}
+
 func (s *server) runControllers() error {
 	s.logger.Info("Starting data manager controllers")
 
@@ -324,6 +533,10 @@ func (s *server) runControllers() error {
 	// Register controllers
 	s.logger.Info("Registering controllers")
 
+	// The uploads and downloads of the node share the transfer slots.
+	transferSlots := controller.NewTransferSlots(s.config.maxConcurrentTransfers)
+	eventRecorder := s.newEventRecorder()
+
 	uploadController := controller.NewUploadController(
 		s.logger,
 		s.pluginInformerFactory.Veleroplugin().V1().Uploads(),
@@ -332,6 +545,11 @@ func (s *server) runControllers() error {
 		s.dataMover,
 		s.snapManager,
 		os.Getenv("NODE_NAME"),
+		s.config.transferStreams,
+		s.transferPolicy,
+		transferSlots,
+		s.metrics,
+		eventRecorder,
 	)
 
 	downloadController := controller.NewDownloadController(
@@ -341,22 +559,175 @@ func (s *server) runControllers() error {
 		s.kubeClient,
 		s.dataMover,
 		os.Getenv("NODE_NAME"),
+		s.config.transferStreams,
+		s.transferPolicy,
+		s.restoreMapping,
+		transferSlots,
+		s.metrics,
+		eventRecorder,
+	)
+
+	snapshotController := controller.NewSnapshotController(
+		s.logger,
+		s.backupdriverInformerFactory.Backupdriver().V1().Snapshots(),
+		s.pluginClient.BackupdriverV1(),
+		s.backupdriverInformerFactory.Backupdriver().V1().BackupRepositories(),
+		s.pluginInformerFactory.Veleroplugin().V1().Uploads(),
+		s.kubeClient,
+		s.snapManager,
+		s.namespace,
+		os.Getenv("NODE_NAME"),
 	)
 
+	cloneFromSnapshotController := controller.NewCloneFromSnapshotController(
+		s.logger,
+		s.backupdriverInformerFactory.Backupdriver().V1().CloneFromSnapshots(),
+		s.pluginClient.BackupdriverV1(),
+		s.backupdriverInformerFactory.Backupdriver().V1().Snapshots(),
+		s.backupdriverInformerFactory.Backupdriver().V1().BackupRepositories(),
+		s.kubeClient,
+		s.snapManager,
+		s.namespace,
+		os.Getenv("NODE_NAME"),
+	)
+
+	backupRepositoryClaimController := controller.NewBackupRepositoryClaimController(
+		s.logger,
+		s.backupdriverInformerFactory.Backupdriver().V1().BackupRepositoryClaims(),
+		s.pluginClient.BackupdriverV1(),
+		s.kubeClient,
+		s.namespace,
+		os.Getenv("NODE_NAME"),
+		eventRecorder,
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		uploadController.Run(s.ctx, s.config.uploadWorkers)
+	}()
+
+	if s.config.repositoryMaintenanceFrequency > 0 {
+		repositoryMaintenanceController := controller.NewRepositoryMaintenanceController(
+			s.logger,
+			s.pluginInformerFactory.Veleroplugin().V1().Uploads(),
+			s.kubeClient,
+			s.dataMover,
+			s.namespace,
+			os.Getenv("NODE_NAME"),
+			s.config.repositoryMaintenanceFrequency,
+			s.config.maxChainDepth,
+		)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repositoryMaintenanceController.Run(s.ctx, 1)
+		}()
+	} else {
+		s.logger.Info("The maintenance of the remote repositories is disabled")
+	}
+
+	if s.config.retentionFrequency > 0 {
+		retentionController := controller.NewRetentionController(
+			s.logger,
+			s.pluginInformerFactory.Veleroplugin().V1().Uploads(),
+			s.kubeClient,
+			s.veleroClient,
+			s.dataMover,
+			s.snapManager,
+			s.namespace,
+			os.Getenv("NODE_NAME"),
+			s.config.retentionFrequency,
+			s.retentionConfig,
+		)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			retentionController.Run(s.ctx, 1)
+		}()
+	} else {
+		s.logger.Info("The retention policies of the remote repositories are disabled")
+	}
+
+	if s.config.verificationFrequency > 0 {
+		verificationController := controller.NewVerificationController(
+			s.logger,
+			s.pluginInformerFactory.Veleroplugin().V1().Uploads(),
+			s.pluginClient.VeleropluginV1(),
+			s.kubeClient,
+			s.dataMover,
+			s.namespace,
+			os.Getenv("NODE_NAME"),
+			s.config.verificationFrequency,
+			s.config.transferStreams,
+			eventRecorder,
+		)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			verificationController.Run(s.ctx, 1)
+		}()
+	} else {
+		s.logger.Info("The verification of the remote repositories is disabled")
+	}
+
+	if s.config.garbageCollectionFrequency > 0 {
+		garbageCollectionController := controller.NewGarbageCollectionController(
+			s.logger,
+			s.pluginInformerFactory.Veleroplugin().V1().Uploads(),
+			s.pluginInformerFactory.Veleroplugin().V1().Downloads(),
+			s.pluginClient.VeleropluginV1(),
+			s.kubeClient,
+			s.veleroClient,
+			s.namespace,
+			os.Getenv("NODE_NAME"),
+			s.config.garbageCollectionFrequency,
+			s.config.garbageCollectionTTL,
+			eventRecorder,
+		)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			garbageCollectionController.Run(s.ctx, 1)
+		}()
+	} else {
+		s.logger.Info("The garbage collection of Uploads and Downloads is disabled")
+	}
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		uploadController.Run(s.ctx, 1)
+		downloadController.Run(s.ctx, s.config.downloadWorkers)
 	}()
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		downloadController.Run(s.ctx, 1)
+		snapshotController.Run(s.ctx, 1)
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cloneFromSnapshotController.Run(s.ctx, 1)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		backupRepositoryClaimController.Run(s.ctx, 1)
+	}()
+
+	// The Upload informer is started with the controllers below.
+	s.metrics.RegisterPendingUploads(s.pendingUploads)
+
 	// SHARED INFORMERS HAVE TO BE STARTED AFTER ALL CONTROLLERS
 	go s.pluginInformerFactory.Start(ctx.Done())
+	go s.backupdriverInformerFactory.Start(ctx.Done())
 	go s.kubeInformerFactory.Start(ctx.Done())
 
 	s.logger.Info("Server started successfully")
@@ -0,0 +1,221 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	backupdriverapi "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/backupdriver/v1"
+	backupdriverclient "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/typed/backupdriver/v1"
+	backupdriverinformers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions/backupdriver/v1"
+	backupdriverlisters "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/listers/backupdriver/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+type backupRepositoryClaimController struct {
+	*genericController
+
+	kubeClient         kubernetes.Interface
+	backupdriverClient backupdriverclient.BackupdriverV1Interface
+	claimLister        backupdriverlisters.BackupRepositoryClaimLister
+	namespace          string
+	nodeName           string
+	// events records the Events of the claims, none are recorded if it is nil
+	events           record.EventRecorder
+	processClaimFunc func(*backupdriverapi.BackupRepositoryClaim) error
+}
+
+func NewBackupRepositoryClaimController(
+	logger logrus.FieldLogger,
+	claimInformer backupdriverinformers.BackupRepositoryClaimInformer,
+	backupdriverClient backupdriverclient.BackupdriverV1Interface,
+	kubeClient kubernetes.Interface,
+	namespace string,
+	nodeName string,
+	eventRecorder record.EventRecorder,
+) Interface {
+	c := &backupRepositoryClaimController{
+		genericController:  newGenericController("backuprepositoryclaim", logger),
+		kubeClient:         kubeClient,
+		backupdriverClient: backupdriverClient,
+		claimLister:        claimInformer.Lister(),
+		namespace:          namespace,
+		nodeName:           nodeName,
+		events:             eventRecorder,
+	}
+
+	c.syncHandler = c.processClaimItem
+	c.retryHandler = c.reEnqueueHandler
+	c.cacheSyncWaiters = append(
+		c.cacheSyncWaiters,
+		claimInformer.Informer().HasSynced,
+	)
+	c.processClaimFunc = c.processClaim
+
+	claimInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.enqueueClaimItem,
+			UpdateFunc: func(_, obj interface{}) { c.enqueueClaimItem(obj) },
+		},
+	)
+
+	return c
+}
+
+func (c *backupRepositoryClaimController) enqueueClaimItem(obj interface{}) {
+	claim := obj.(*backupdriverapi.BackupRepositoryClaim)
+
+	if claim.BackupRepository != "" {
+		c.logger.WithField("claim", claim.Namespace+"/"+claim.Name).Debug("BackupRepositoryClaim is already bound, skipping")
+		return
+	}
+
+	c.enqueue(obj)
+}
+
+func (c *backupRepositoryClaimController) processClaimItem(key string) error {
+	log := c.logger.WithField("key", key)
+	log.Info("Running processClaimItem")
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		log.WithError(err).Error("Failed to split the key of queue item")
+		return nil
+	}
+
+	claim, err := c.claimLister.BackupRepositoryClaims(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Error("BackupRepositoryClaim is not found")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to get BackupRepositoryClaim")
+	}
+
+	if claim.BackupRepository != "" {
+		return nil
+	}
+
+	return runWithLease(c.kubeClient, c.namespace, objectLeaseName(claimLeasePrefix, ns, name), c.nodeName, log, func() error {
+		// Don't mutate the shared cache
+		return c.processClaimFunc(claim.DeepCopy())
+	})
+}
+
+func (c *backupRepositoryClaimController) processClaim(claim *backupdriverapi.BackupRepositoryClaim) error {
+	log := c.logger.WithFields(logrus.Fields{
+		"namespace": claim.Namespace,
+		"name":      claim.Name,
+		"driver":    claim.RepositoryDriver,
+	})
+
+	if err := utils.ValidateBackupRepositoryParameters(claim.RepositoryDriver, claim.RepositoryParameters); err != nil {
+		// An invalid claim is not retried, it has to be fixed by the user, who is told why by the Event on it.
+		log.WithError(err).Error("Invalid BackupRepositoryClaim, no BackupRepository is provisioned for it")
+		if c.events != nil {
+			c.events.Eventf(claim, corev1.EventTypeWarning, "InvalidBackupRepositoryClaim",
+				"No BackupRepository is provisioned for the claim: %v", err)
+		}
+		return nil
+	}
+
+	repository, err := c.backupdriverClient.BackupRepositories().Create(newBackupRepositoryForClaim(claim))
+	if apierrors.IsAlreadyExists(err) {
+		// A previous attempt created the repository but did not get to bind the claim.
+		repository, err = c.backupdriverClient.BackupRepositories().Get(backupRepositoryNameForClaim(claim), metav1.GetOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create BackupRepository for BackupRepositoryClaim %s/%s", claim.Namespace, claim.Name)
+	}
+	log.Infof("BackupRepository %s is provisioned", repository.Name)
+
+	claim.BackupRepository = repository.Name
+	_, err = c.backupdriverClient.BackupRepositoryClaims(claim.Namespace).Update(claim)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to bind BackupRepositoryClaim %s/%s to BackupRepository %s", claim.Namespace, claim.Name, repository.Name)
+	}
+
+	log.Infof("BackupRepositoryClaim is bound to BackupRepository %s", repository.Name)
+	return nil
+}
+
+func (c *backupRepositoryClaimController) reEnqueueHandler(key string) error {
+	log := c.logger.WithField("key", key)
+	log.Infof("Re-adding failed BackupRepositoryClaim %s to the queue", key)
+	c.queue.AddRateLimited(key)
+	return nil
+}
+
+// backupRepositoryNameForClaim returns the name of the BackupRepository provisioned for a claim. It is derived from the
+// UID of the claim so that a retried claim finds the repository created by a previous attempt.
+func backupRepositoryNameForClaim(claim *backupdriverapi.BackupRepositoryClaim) string {
+	return "br-" + string(claim.UID)
+}
+
+// newBackupRepositoryForClaim builds the BackupRepository for a claim. The repository is restricted to the namespace of
+// the claim unless the claim lists the allowed namespaces itself.
+func newBackupRepositoryForClaim(claim *backupdriverapi.BackupRepositoryClaim) *backupdriverapi.BackupRepository {
+	allowedNamespaces := claim.AllowedNamespaces
+	if len(allowedNamespaces) == 0 {
+		allowedNamespaces = []string{claim.Namespace}
+	}
+
+	parameters := make(map[string]string)
+	for key, value := range claim.RepositoryParameters {
+		parameters[key] = value
+	}
+
+	return &backupdriverapi.BackupRepository{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: backupdriverapi.SchemeGroupVersion.String(),
+			Kind:       "BackupRepository",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: backupRepositoryNameForClaim(claim),
+		},
+		AllowedNamespaces:     allowedNamespaces,
+		RepositoryDriver:      claim.RepositoryDriver,
+		RepositoryParameters:  parameters,
+		BackupRepositoryClaim: claim.Name,
+	}
+}
+
+// checkBackupRepositoryAccess returns an error if the namespace ns may not use the named BackupRepository. An empty
+// repository name refers to the default repository of the data manager, which every namespace may use.
+func checkBackupRepositoryAccess(lister backupdriverlisters.BackupRepositoryLister, name string, ns string) error {
+	if name == "" {
+		return nil
+	}
+
+	repository, err := lister.Get(name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get BackupRepository %s", name)
+	}
+
+	for _, allowed := range repository.AllowedNamespaces {
+		if allowed == ns {
+			return nil
+		}
+	}
+	return errors.Errorf("namespace %s is not allowed to use BackupRepository %s", ns, name)
+}
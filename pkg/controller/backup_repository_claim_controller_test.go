@@ -0,0 +1,120 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	backupdriverapi "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/backupdriver/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/fake"
+	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"testing"
+)
+
+func TestProcessClaim(t *testing.T) {
+	tests := []struct {
+		name               string
+		claim              *backupdriverapi.BackupRepositoryClaim
+		expectedRepository string
+		expectedNamespaces []string
+		expectedEvent      string
+	}{
+		{
+			name: "Valid claim is bound to a repository restricted to its namespace",
+			claim: &backupdriverapi.BackupRepositoryClaim{
+				ObjectMeta:           metav1.ObjectMeta{Namespace: "app", Name: "claim-1", UID: "1234"},
+				RepositoryDriver:     utils.S3RepositoryDriver,
+				RepositoryParameters: map[string]string{"region": "us-west-1", "bucket": "velero"},
+			},
+			expectedRepository: "br-1234",
+			expectedNamespaces: []string{"app"},
+		},
+		{
+			name: "Allowed namespaces of the claim are kept",
+			claim: &backupdriverapi.BackupRepositoryClaim{
+				ObjectMeta:           metav1.ObjectMeta{Namespace: "app", Name: "claim-1", UID: "1234"},
+				RepositoryDriver:     utils.S3RepositoryDriver,
+				RepositoryParameters: map[string]string{"region": "us-west-1", "bucket": "velero"},
+				AllowedNamespaces:    []string{"app", "other"},
+			},
+			expectedRepository: "br-1234",
+			expectedNamespaces: []string{"app", "other"},
+		},
+		{
+			name: "Invalid claim is not bound",
+			claim: &backupdriverapi.BackupRepositoryClaim{
+				ObjectMeta:           metav1.ObjectMeta{Namespace: "app", Name: "claim-1", UID: "1234"},
+				RepositoryDriver:     utils.S3RepositoryDriver,
+				RepositoryParameters: map[string]string{"region": "us-west-1"},
+			},
+			expectedEvent: "Warning InvalidBackupRepositoryClaim",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.claim)
+			recorder := record.NewFakeRecorder(10)
+			c := &backupRepositoryClaimController{
+				genericController:  newGenericController("claim-test", veleroplugintest.NewLogger()),
+				backupdriverClient: client.BackupdriverV1(),
+				events:             recorder,
+			}
+
+			require.NoError(t, c.processClaim(test.claim.DeepCopy()))
+
+			claim, err := client.BackupdriverV1().BackupRepositoryClaims("app").Get("claim-1", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedRepository, claim.BackupRepository)
+
+			if test.expectedRepository != "" {
+				repository, err := client.BackupdriverV1().BackupRepositories().Get(test.expectedRepository, metav1.GetOptions{})
+				require.NoError(t, err)
+				assert.Equal(t, test.expectedNamespaces, repository.AllowedNamespaces)
+				assert.Equal(t, "claim-1", repository.BackupRepositoryClaim)
+			}
+
+			if test.expectedEvent != "" {
+				require.Len(t, recorder.Events, 1)
+				event := <-recorder.Events
+				assert.Contains(t, event, test.expectedEvent)
+				assert.Contains(t, event, "bucket")
+			} else {
+				assert.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
+
+func TestCheckBackupRepositoryAccess(t *testing.T) {
+	sharedInformers := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	require.NoError(t, sharedInformers.Backupdriver().V1().BackupRepositories().Informer().GetStore().Add(
+		&backupdriverapi.BackupRepository{
+			ObjectMeta:        metav1.ObjectMeta{Name: "br-1234"},
+			AllowedNamespaces: []string{"app"},
+		}))
+	lister := sharedInformers.Backupdriver().V1().BackupRepositories().Lister()
+
+	assert.NoError(t, checkBackupRepositoryAccess(lister, "", "any"))
+	assert.NoError(t, checkBackupRepositoryAccess(lister, "br-1234", "app"))
+	assert.Error(t, checkBackupRepositoryAccess(lister, "br-1234", "other"))
+	assert.Error(t, checkBackupRepositoryAccess(lister, "br-missing", "app"))
+}
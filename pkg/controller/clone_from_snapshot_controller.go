@@ -0,0 +1,551 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	backupdriverapi "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/backupdriver/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	backupdriverclient "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/typed/backupdriver/v1"
+	backupdriverinformers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions/backupdriver/v1"
+	backupdriverlisters "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/listers/backupdriver/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/snapshotmgr"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	core_v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+)
+
+type cloneFromSnapshotController struct {
+	*genericController
+
+	kubeClient       kubernetes.Interface
+	cloneClient      backupdriverclient.CloneFromSnapshotsGetter
+	cloneLister      backupdriverlisters.CloneFromSnapshotLister
+	snapshotLister   backupdriverlisters.SnapshotLister
+	repositoryLister backupdriverlisters.BackupRepositoryLister
+	namespace        string
+	nodeName         string
+	snapMgr          *snapshotmgr.SnapshotManager
+	clock            clock.Clock
+	processCloneFunc func(*backupdriverapi.CloneFromSnapshot) error
+}
+
+func NewCloneFromSnapshotController(
+	logger logrus.FieldLogger,
+	cloneInformer backupdriverinformers.CloneFromSnapshotInformer,
+	cloneClient backupdriverclient.CloneFromSnapshotsGetter,
+	snapshotInformer backupdriverinformers.SnapshotInformer,
+	repositoryInformer backupdriverinformers.BackupRepositoryInformer,
+	kubeClient kubernetes.Interface,
+	snapMgr *snapshotmgr.SnapshotManager,
+	namespace string,
+	nodeName string,
+) Interface {
+	c := &cloneFromSnapshotController{
+		genericController: newGenericController("clonefromsnapshot", logger),
+		kubeClient:        kubeClient,
+		cloneClient:       cloneClient,
+		cloneLister:       cloneInformer.Lister(),
+		snapshotLister:    snapshotInformer.Lister(),
+		repositoryLister:  repositoryInformer.Lister(),
+		namespace:         namespace,
+		nodeName:          nodeName,
+		snapMgr:           snapMgr,
+		clock:             &clock.RealClock{},
+	}
+
+	c.syncHandler = c.processCloneItem
+	c.retryHandler = c.reEnqueueHandler
+	c.cacheSyncWaiters = append(
+		c.cacheSyncWaiters,
+		cloneInformer.Informer().HasSynced,
+		snapshotInformer.Informer().HasSynced,
+		repositoryInformer.Informer().HasSynced,
+	)
+	c.processCloneFunc = c.processClone
+
+	cloneInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.enqueueCloneItem,
+			UpdateFunc: func(_, obj interface{}) { c.enqueueCloneItem(obj) },
+		},
+	)
+
+	return c
+}
+
+func (c *cloneFromSnapshotController) enqueueCloneItem(obj interface{}) {
+	req := obj.(*backupdriverapi.CloneFromSnapshot)
+
+	log := loggerForClone(c.logger, req)
+
+	if isClonePhaseTerminal(req.Status.Phase) {
+		log.Debug("CloneFromSnapshot CR is in a terminal phase, skipping")
+		return
+	}
+
+	log.Infof("Enqueueing clone")
+	c.enqueue(obj)
+}
+
+func (c *cloneFromSnapshotController) processCloneItem(key string) error {
+	log := c.logger.WithField("key", key)
+	log.Info("Running processCloneItem")
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		log.WithError(err).Error("Failed to split the key of queue item")
+		return nil
+	}
+
+	req, err := c.cloneLister.CloneFromSnapshots(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Error("CloneFromSnapshot is not found")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to get CloneFromSnapshot")
+	}
+
+	if isClonePhaseTerminal(req.Status.Phase) {
+		return nil
+	}
+
+	return runWithLease(c.kubeClient, c.namespace, objectLeaseName(cloneLeasePrefix, ns, name), c.nodeName, log, func() error {
+		// Don't mutate the shared cache
+		return c.processCloneFunc(req.DeepCopy())
+	})
+}
+
+func (c *cloneFromSnapshotController) processClone(req *backupdriverapi.CloneFromSnapshot) error {
+	log := loggerForClone(c.logger, req)
+	var err error
+
+	// retrieve clone request for its updated status from k8s api server and filter out terminated one
+	req, err = c.cloneClient.CloneFromSnapshots(req.Namespace).Get(req.Name, metav1.GetOptions{})
+	if err != nil {
+		log.WithError(err).Error("Failed to retrieve clone CR from kubernetes API server")
+		return errors.WithStack(err)
+	}
+
+	if isClonePhaseTerminal(req.Status.Phase) {
+		log.WithField("phase", req.Status.Phase).Info("The clone CR in kubernetes API server is in a terminal phase. Skipping it")
+		return nil
+	}
+
+	if req.Spec.CloneCancel || req.Status.Phase == backupdriverapi.ClonePhaseCanceling {
+		return c.cancelClone(req)
+	}
+
+	if req.Spec.Kind != "PersistentVolumeClaim" || (req.Spec.APIGroup != nil && *req.Spec.APIGroup != "") {
+		c.failClone(req, fmt.Sprintf("Cloning resource kind %s is not supported", req.Spec.Kind))
+		return nil
+	}
+
+	if err := checkBackupRepositoryAccess(c.repositoryLister, req.Spec.BackupRepository, req.Namespace); err != nil {
+		c.failClone(req, fmt.Sprintf("Failed to clone from snapshot %s. %v", req.Spec.SnapshotID, err))
+		return nil
+	}
+
+	pvc, err := c.pvcFromMetadata(req)
+	if err != nil {
+		c.failClone(req, fmt.Sprintf("Failed to retrieve the metadata of snapshot %s. %v", req.Spec.SnapshotID, err))
+		return nil
+	}
+
+	peID, err := astrolabe.NewProtectedEntityIDFromString(req.Spec.SnapshotID)
+	if err != nil {
+		c.failClone(req, fmt.Sprintf("Failed to get PEID from SnapshotID, %v. %v", req.Spec.SnapshotID, err))
+		return nil
+	}
+
+	log.Info("Clone starting")
+	req, err = c.patchClone(req, func(r *backupdriverapi.CloneFromSnapshot) {
+		r.Status.Phase = backupdriverapi.ClonePhaseInProgress
+		r.Status.Message = ""
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	pvName := clonedPVName(req)
+	var storageClass string
+	if pvc.Spec.StorageClassName != nil {
+		storageClass = *pvc.Spec.StorageClassName
+	}
+	req, volumeID, err := c.volumeIDForClone(req, pvName, peID, backuprepository.NewReference(req.Spec.BackupRepository, "", nil), storageClass)
+	if err != nil {
+		// The download behind CreateVolumeFromSnapshot has already been retried by the download controller.
+		c.failClone(req, fmt.Sprintf("Failed to create volume from snapshot %s. %v", peID.String(), err))
+		return nil
+	}
+
+	if err = c.createPVCAndPV(req, pvc, pvName, volumeID); err != nil {
+		errMsg := fmt.Sprintf("Failed to create PVC %s/%s for volume %s. %v", req.Namespace, pvc.Name, volumeID, err)
+		log.Error(errMsg)
+		_, patchErr := c.patchClone(req, func(r *backupdriverapi.CloneFromSnapshot) {
+			r.Status.Phase = backupdriverapi.ClonePhaseRetry
+			r.Status.Message = errMsg
+		})
+		if patchErr != nil {
+			log.WithError(patchErr).Error("Failed to patch CloneFromSnapshot to Retry")
+		}
+		return errors.New(errMsg)
+	}
+
+	req, err = c.patchClone(req, func(r *backupdriverapi.CloneFromSnapshot) {
+		r.Status.ResourceHandle = core_v1.TypedLocalObjectReference{
+			Kind: "PersistentVolumeClaim",
+			Name: pvc.Name,
+		}
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// The clone may have been canceled while the volume was being downloaded.
+	req, err = c.cloneClient.CloneFromSnapshots(req.Namespace).Get(req.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if req.Spec.CloneCancel {
+		return c.cancelClone(req)
+	}
+
+	req, err = c.patchClone(req, func(r *backupdriverapi.CloneFromSnapshot) {
+		r.Status.Phase = backupdriverapi.ClonePhaseCompleted
+		r.Status.Message = "Clone completed"
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.WithField("pvc", pvc.Name).Info("Clone completed")
+	return nil
+}
+
+// pvcFromMetadata returns the PVC to create, taken from Spec.Metadata if it is set or else from the Snapshot that
+// produced Spec.SnapshotID.
+func (c *cloneFromSnapshotController) pvcFromMetadata(req *backupdriverapi.CloneFromSnapshot) (*core_v1.PersistentVolumeClaim, error) {
+	metadata := req.Spec.Metadata
+	if len(metadata) == 0 {
+		snapshots, err := c.snapshotLister.Snapshots(req.Namespace).List(labels.Everything())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list Snapshots")
+		}
+		for _, snapshot := range snapshots {
+			if snapshot.Status.SnapshotID == req.Spec.SnapshotID && len(snapshot.Status.Metadata) > 0 {
+				metadata = snapshot.Status.Metadata
+				break
+			}
+		}
+	}
+
+	if len(metadata) == 0 {
+		return nil, errors.New("no metadata is specified and no Snapshot in the namespace recorded any")
+	}
+
+	pvc := &core_v1.PersistentVolumeClaim{}
+	if err := json.Unmarshal(metadata, pvc); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal PVC from metadata")
+	}
+	if pvc.Name == "" {
+		return nil, errors.New("the PVC in the metadata has no name")
+	}
+
+	return pvc, nil
+}
+
+// volumeIDForClone returns the ID of the volume backing the clone, creating it from the snapshot unless a previous
+// attempt already did. The volume is placed for the StorageClass of the PVC. The ID of a new volume is recorded on the
+// status of the clone before the PVC and the PV are created, so that a retry reuses it.
+func (c *cloneFromSnapshotController) volumeIDForClone(req *backupdriverapi.CloneFromSnapshot, pvName string, peID astrolabe.ProtectedEntityID, repository backuprepository.Reference, storageClass string) (*backupdriverapi.CloneFromSnapshot, string, error) {
+	if req.Status.VolumeID != "" {
+		return req, req.Status.VolumeID, nil
+	}
+	// Clones started before the volume ID was recorded only find their volume through the PV.
+	pv, err := c.kubeClient.CoreV1().PersistentVolumes().Get(pvName, metav1.GetOptions{})
+	if err == nil && pv.Spec.CSI != nil {
+		return req, pv.Spec.CSI.VolumeHandle, nil
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return req, "", err
+	}
+
+	newPeID, err := c.snapMgr.CreateVolumeFromSnapshot(peID, repository, storageClass)
+	if err != nil {
+		return req, "", err
+	}
+	volumeID := newPeID.GetID()
+	patched, err := c.patchClone(req, func(r *backupdriverapi.CloneFromSnapshot) {
+		r.Status.VolumeID = volumeID
+	})
+	if err != nil {
+		// A volume that is not recorded would be created again by the retry.
+		if deleteErr := c.snapMgr.DeleteVolume(volumeID); deleteErr != nil {
+			loggerForClone(c.logger, req).WithError(deleteErr).Errorf("Failed to delete volume %s that is not recorded", volumeID)
+		}
+		return req, "", err
+	}
+	return patched, volumeID, nil
+}
+
+// createPVCAndPV creates the PVC from the metadata pre-bound to a new PV for volumeID. The PV is created after the
+// PVC with a claimRef carrying the PVC's UID and a Delete reclaim policy, so deleting the PVC also removes the volume.
+func (c *cloneFromSnapshotController) createPVCAndPV(req *backupdriverapi.CloneFromSnapshot, metadataPVC *core_v1.PersistentVolumeClaim, pvName string, volumeID string) error {
+	pvc, err := c.kubeClient.CoreV1().PersistentVolumeClaims(req.Namespace).Create(newClonedPVC(req.Namespace, metadataPVC, pvName))
+	if apierrors.IsAlreadyExists(err) {
+		pvc, err = c.kubeClient.CoreV1().PersistentVolumeClaims(req.Namespace).Get(metadataPVC.Name, metav1.GetOptions{})
+		if err == nil && pvc.Spec.VolumeName != pvName {
+			return errors.Errorf("PVC %s/%s already exists", req.Namespace, metadataPVC.Name)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = c.kubeClient.CoreV1().PersistentVolumes().Create(newClonedPV(pvc, pvName, volumeID))
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (c *cloneFromSnapshotController) cancelClone(req *backupdriverapi.CloneFromSnapshot) error {
+	log := loggerForClone(c.logger, req)
+	var err error
+
+	if req.Status.Phase != backupdriverapi.ClonePhaseCanceling {
+		req, err = c.patchClone(req, func(r *backupdriverapi.CloneFromSnapshot) {
+			r.Status.Phase = backupdriverapi.ClonePhaseCanceling
+			r.Status.Message = "Canceling the clone"
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if pvcName := req.Status.ResourceHandle.Name; pvcName != "" {
+		// The PV of the clone has the Delete reclaim policy, so the volume is removed along with the PVC.
+		log.Infof("Deleting the PVC %s/%s created for the clone", req.Namespace, pvcName)
+		err = c.kubeClient.CoreV1().PersistentVolumeClaims(req.Namespace).Delete(pvcName, &metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete PVC %s/%s", req.Namespace, pvcName)
+		}
+	} else if err = c.deleteClonedVolume(req); err != nil {
+		return err
+	}
+
+	_, err = c.patchClone(req, func(r *backupdriverapi.CloneFromSnapshot) {
+		r.Status.Phase = backupdriverapi.ClonePhaseCanceled
+		r.Status.ResourceHandle = core_v1.TypedLocalObjectReference{}
+		r.Status.VolumeID = ""
+		r.Status.Message = "The clone was canceled"
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.Info("Clone cancellation complete")
+	return nil
+}
+
+// deleteClonedVolume deletes the volume recorded on the status of a clone whose PVC is not recorded, along with the PVC
+// and the PV that may have been created for it.
+func (c *cloneFromSnapshotController) deleteClonedVolume(req *backupdriverapi.CloneFromSnapshot) error {
+	volumeID := req.Status.VolumeID
+	if volumeID == "" {
+		return nil
+	}
+	log := loggerForClone(c.logger, req)
+	pvName := clonedPVName(req)
+	if pvc, err := c.pvcFromMetadata(req); err == nil {
+		existing, err := c.kubeClient.CoreV1().PersistentVolumeClaims(req.Namespace).Get(pvc.Name, metav1.GetOptions{})
+		if err == nil && existing.Spec.VolumeName == pvName {
+			log.Infof("Deleting the PVC %s/%s created for the clone", req.Namespace, pvc.Name)
+			err = c.kubeClient.CoreV1().PersistentVolumeClaims(req.Namespace).Delete(pvc.Name, &metav1.DeleteOptions{})
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete PVC %s/%s", req.Namespace, pvc.Name)
+		}
+	}
+	// Deleting the PV does not delete its volume, so the volume is deleted along with it.
+	err := c.kubeClient.CoreV1().PersistentVolumes().Delete(pvName, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete PV %s", pvName)
+	}
+	log.Infof("Deleting volume %s created for the clone", volumeID)
+	if err := c.snapMgr.DeleteVolume(volumeID); err != nil {
+		return errors.Wrapf(err, "failed to delete volume %s", volumeID)
+	}
+	return nil
+}
+
+func (c *cloneFromSnapshotController) failClone(req *backupdriverapi.CloneFromSnapshot, errMsg string) {
+	log := loggerForClone(c.logger, req)
+	log.Error(errMsg)
+	if req.Status.VolumeID != "" && req.Status.ResourceHandle.Name == "" {
+		if err := c.deleteClonedVolume(req); err != nil {
+			log.WithError(err).Error("Failed to delete the volume of the failed clone")
+		}
+	}
+	_, err := c.patchClone(req, func(r *backupdriverapi.CloneFromSnapshot) {
+		r.Status.Phase = backupdriverapi.ClonePhaseFailed
+		r.Status.Message = errMsg
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to patch CloneFromSnapshot to Failed")
+	}
+}
+
+func (c *cloneFromSnapshotController) patchClone(req *backupdriverapi.CloneFromSnapshot, mutate func(*backupdriverapi.CloneFromSnapshot)) (*backupdriverapi.CloneFromSnapshot, error) {
+	// Record original json
+	oldData, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshall original CloneFromSnapshot")
+	}
+
+	// Mutate
+	mutate(req)
+
+	// Record new json
+	newData, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshall updated CloneFromSnapshot")
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create json merge patch for CloneFromSnapshot")
+	}
+
+	// CloneFromSnapshot has the status subresource enabled, so status changes have to go through it.
+	req, err = c.cloneClient.CloneFromSnapshots(req.Namespace).Patch(req.Name, types.MergePatchType, patchBytes, "status")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to patch CloneFromSnapshot")
+	}
+
+	return req, nil
+}
+
+func (c *cloneFromSnapshotController) reEnqueueHandler(key string) error {
+	log := c.logger.WithField("key", key)
+	log.Infof("Re-adding failed clone %s to the queue", key)
+	c.queue.AddRateLimited(key)
+	return nil
+}
+
+// clonedPVName returns the name of the PV created for a clone. It is derived from the UID of the clone so that a
+// retried clone finds the volume created by a previous attempt.
+func clonedPVName(req *backupdriverapi.CloneFromSnapshot) string {
+	return "clone-" + string(req.UID)
+}
+
+// newClonedPVC builds the PVC to create from the PVC in the metadata, pre-bound to the PV named pvName.
+func newClonedPVC(ns string, metadataPVC *core_v1.PersistentVolumeClaim, pvName string) *core_v1.PersistentVolumeClaim {
+	annotations := make(map[string]string)
+	for key, value := range metadataPVC.Annotations {
+		switch key {
+		case "pv.kubernetes.io/bind-completed", "pv.kubernetes.io/bound-by-controller",
+			"volume.beta.kubernetes.io/storage-provisioner", "kubectl.kubernetes.io/last-applied-configuration":
+			// Binding state of the original PVC does not apply to the clone
+		default:
+			annotations[key] = value
+		}
+	}
+
+	spec := *metadataPVC.Spec.DeepCopy()
+	spec.VolumeName = pvName
+	spec.DataSource = nil
+
+	return &core_v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        metadataPVC.Name,
+			Namespace:   ns,
+			Labels:      metadataPVC.Labels,
+			Annotations: annotations,
+		},
+		Spec: spec,
+	}
+}
+
+// newClonedPV builds a PV for the vSphere CSI volume volumeID that is bound to pvc.
+func newClonedPV(pvc *core_v1.PersistentVolumeClaim, pvName string, volumeID string) *core_v1.PersistentVolume {
+	pv := &core_v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: pvName,
+			Annotations: map[string]string{
+				"pv.kubernetes.io/provisioned-by": utils.VSphereCSIDriverName,
+			},
+		},
+		Spec: core_v1.PersistentVolumeSpec{
+			Capacity: core_v1.ResourceList{
+				core_v1.ResourceStorage: pvc.Spec.Resources.Requests[core_v1.ResourceStorage],
+			},
+			AccessModes: pvc.Spec.AccessModes,
+			ClaimRef: &core_v1.ObjectReference{
+				Kind:       "PersistentVolumeClaim",
+				APIVersion: "v1",
+				Namespace:  pvc.Namespace,
+				Name:       pvc.Name,
+				UID:        pvc.UID,
+			},
+			PersistentVolumeReclaimPolicy: core_v1.PersistentVolumeReclaimDelete,
+			VolumeMode:                    pvc.Spec.VolumeMode,
+			PersistentVolumeSource: core_v1.PersistentVolumeSource{
+				CSI: &core_v1.CSIPersistentVolumeSource{
+					Driver:       utils.VSphereCSIDriverName,
+					VolumeHandle: volumeID,
+				},
+			},
+		},
+	}
+	if pvc.Spec.StorageClassName != nil {
+		pv.Spec.StorageClassName = *pvc.Spec.StorageClassName
+	}
+	return pv
+}
+
+func isClonePhaseTerminal(phase backupdriverapi.ClonePhase) bool {
+	switch phase {
+	case backupdriverapi.ClonePhaseCompleted, backupdriverapi.ClonePhaseFailed, backupdriverapi.ClonePhaseCanceled:
+		return true
+	}
+	return false
+}
+
+func loggerForClone(baseLogger logrus.FieldLogger, req *backupdriverapi.CloneFromSnapshot) logrus.FieldLogger {
+	return baseLogger.WithFields(logrus.Fields{
+		"namespace":  req.Namespace,
+		"name":       req.Name,
+		"snapshotID": req.Spec.SnapshotID,
+		"phase":      req.Status.Phase,
+		"generation": req.Generation,
+	})
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"github.com/agiledragon/gomonkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	backupdriverapi "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/backupdriver/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/builder"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/fake"
+	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/snapshotmgr"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	core_v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"reflect"
+	"testing"
+)
+
+func TestProcessCloneNonProcessedItems(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		clone *backupdriverapi.CloneFromSnapshot
+	}{
+		{
+			name: "missing clone returns nil",
+			key:  "foo/bar",
+		},
+		{
+			name:  "Completed clone is not processed",
+			key:   "app/clone-1",
+			clone: builder.ForCloneFromSnapshot("app", "clone-1").Phase(backupdriverapi.ClonePhaseCompleted).Result(),
+		},
+		{
+			name:  "Failed clone is not processed",
+			key:   "app/clone-1",
+			clone: builder.ForCloneFromSnapshot("app", "clone-1").Phase(backupdriverapi.ClonePhaseFailed).Result(),
+		},
+		{
+			name:  "Canceled clone is not processed",
+			key:   "app/clone-1",
+			clone: builder.ForCloneFromSnapshot("app", "clone-1").Phase(backupdriverapi.ClonePhaseCanceled).Result(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				sharedInformers = informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+				logger          = veleroplugintest.NewLogger()
+			)
+
+			c := &cloneFromSnapshotController{
+				genericController: newGenericController("clone-test", logger),
+				cloneLister:       sharedInformers.Backupdriver().V1().CloneFromSnapshots().Lister(),
+			}
+
+			if test.clone != nil {
+				require.NoError(t, sharedInformers.Backupdriver().V1().CloneFromSnapshots().Informer().GetStore().Add(test.clone))
+			}
+
+			err := c.processCloneItem(test.key)
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestPVCFromMetadata(t *testing.T) {
+	snapshotPVC, _ := json.Marshal(&core_v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "from-snapshot"}})
+	overridePVC, _ := json.Marshal(&core_v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "from-spec"}})
+
+	snapshot := builder.ForSnapshot("app", "snapshot-1").SnapshotID("ivd:1234:5678").Result()
+	snapshot.Status.Metadata = snapshotPVC
+
+	tests := []struct {
+		name         string
+		clone        *backupdriverapi.CloneFromSnapshot
+		expectedName string
+		expectErr    bool
+	}{
+		{
+			name:         "Metadata in the spec overrides the snapshot metadata",
+			clone:        builder.ForCloneFromSnapshot("app", "clone-1").SnapshotID("ivd:1234:5678").Metadata(overridePVC).Result(),
+			expectedName: "from-spec",
+		},
+		{
+			name:         "Metadata is taken from the Snapshot with the same snapshot ID",
+			clone:        builder.ForCloneFromSnapshot("app", "clone-1").SnapshotID("ivd:1234:5678").Result(),
+			expectedName: "from-snapshot",
+		},
+		{
+			name:      "No metadata fails",
+			clone:     builder.ForCloneFromSnapshot("app", "clone-1").SnapshotID("ivd:1234:0000").Result(),
+			expectErr: true,
+		},
+		{
+			name:      "Snapshots in other namespaces are not used",
+			clone:     builder.ForCloneFromSnapshot("other", "clone-1").SnapshotID("ivd:1234:5678").Result(),
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sharedInformers := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+			require.NoError(t, sharedInformers.Backupdriver().V1().Snapshots().Informer().GetStore().Add(snapshot))
+
+			c := &cloneFromSnapshotController{
+				genericController: newGenericController("clone-test", veleroplugintest.NewLogger()),
+				snapshotLister:    sharedInformers.Backupdriver().V1().Snapshots().Lister(),
+			}
+
+			pvc, err := c.pvcFromMetadata(test.clone)
+			assert.Equal(t, test.expectErr, err != nil)
+			if !test.expectErr {
+				assert.Equal(t, test.expectedName, pvc.Name)
+			}
+		})
+	}
+}
+
+func TestNewClonedPVCAndPV(t *testing.T) {
+	storageClass := "gold"
+	metadataPVC := &core_v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "source",
+			Name:      "data",
+			UID:       "old-uid",
+			Annotations: map[string]string{
+				"pv.kubernetes.io/bind-completed": "yes",
+				"app":                             "demo",
+			},
+		},
+		Spec: core_v1.PersistentVolumeClaimSpec{
+			AccessModes:      []core_v1.PersistentVolumeAccessMode{core_v1.ReadWriteOnce},
+			StorageClassName: &storageClass,
+			VolumeName:       "pvc-old",
+			Resources: core_v1.ResourceRequirements{
+				Requests: core_v1.ResourceList{core_v1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		},
+	}
+
+	pvc := newClonedPVC("app", metadataPVC, "clone-1234")
+	assert.Equal(t, "app", pvc.Namespace)
+	assert.Equal(t, "data", pvc.Name)
+	assert.Equal(t, "clone-1234", pvc.Spec.VolumeName)
+	assert.Equal(t, "", string(pvc.UID))
+	assert.Equal(t, map[string]string{"app": "demo"}, pvc.Annotations)
+	assert.Equal(t, "pvc-old", metadataPVC.Spec.VolumeName)
+
+	pvc.UID = "new-uid"
+	pv := newClonedPV(pvc, "clone-1234", "fcd-1")
+	assert.Equal(t, "clone-1234", pv.Name)
+	assert.Equal(t, utils.VSphereCSIDriverName, pv.Spec.CSI.Driver)
+	assert.Equal(t, "fcd-1", pv.Spec.CSI.VolumeHandle)
+	assert.Equal(t, "gold", pv.Spec.StorageClassName)
+	assert.Equal(t, core_v1.PersistentVolumeReclaimDelete, pv.Spec.PersistentVolumeReclaimPolicy)
+	assert.Equal(t, "new-uid", string(pv.Spec.ClaimRef.UID))
+	assert.True(t, resource.MustParse("1Gi").Equal(pv.Spec.Capacity[core_v1.ResourceStorage]))
+}
+
+func TestCloneVolumeIsRecorded(t *testing.T) {
+	peID := astrolabe.NewProtectedEntityIDWithSnapshotID(utils.CnsBlockVolumeType, "fcd-1234", astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+	metadataPVC := &core_v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "data"}}
+	metadata, err := json.Marshal(metadataPVC)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		clone           *backupdriverapi.CloneFromSnapshot
+		expectedCreates int
+		expectedID      string
+	}{
+		{
+			name:            "Volume of a new clone is created and recorded",
+			clone:           builder.ForCloneFromSnapshot("app", "clone-1").Phase(backupdriverapi.ClonePhaseInProgress).Result(),
+			expectedCreates: 1,
+			expectedID:      "fcd-5678",
+		},
+		{
+			name:       "Volume recorded by a previous attempt is reused",
+			clone:      builder.ForCloneFromSnapshot("app", "clone-1").Phase(backupdriverapi.ClonePhaseRetry).VolumeID("fcd-4321").Result(),
+			expectedID: "fcd-4321",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.clone)
+			c := &cloneFromSnapshotController{
+				genericController: newGenericController("clone-test", veleroplugintest.NewLogger()),
+				kubeClient:        kubefake.NewSimpleClientset(),
+				cloneClient:       client.BackupdriverV1(),
+				snapMgr:           &snapshotmgr.SnapshotManager{},
+			}
+			creates := 0
+			patches := gomonkey.ApplyMethod(reflect.TypeOf(c.snapMgr), "CreateVolumeFromSnapshot", func(_ *snapshotmgr.SnapshotManager, _ astrolabe.ProtectedEntityID, _ backuprepository.Reference, _ string) (astrolabe.ProtectedEntityID, error) {
+				creates++
+				return astrolabe.NewProtectedEntityID(utils.CnsBlockVolumeType, "fcd-5678"), nil
+			})
+			defer patches.Reset()
+
+			_, volumeID, err := c.volumeIDForClone(test.clone.DeepCopy(), clonedPVName(test.clone), peID, backuprepository.Reference{}, "")
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedID, volumeID)
+			assert.Equal(t, test.expectedCreates, creates)
+			res, err := client.BackupdriverV1().CloneFromSnapshots("app").Get("clone-1", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedID, res.Status.VolumeID)
+		})
+	}
+
+	t.Run("Volume of a canceled clone is deleted", func(t *testing.T) {
+		clone := builder.ForCloneFromSnapshot("app", "clone-1").Metadata(metadata).CloneCancel(true).
+			Phase(backupdriverapi.ClonePhaseRetry).VolumeID("fcd-5678").Result()
+		pvName := clonedPVName(clone)
+		pvc := &core_v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "data"},
+			Spec:       core_v1.PersistentVolumeClaimSpec{VolumeName: pvName},
+		}
+		client := fake.NewSimpleClientset(clone)
+		kubeClient := kubefake.NewSimpleClientset(pvc)
+		c := &cloneFromSnapshotController{
+			genericController: newGenericController("clone-test", veleroplugintest.NewLogger()),
+			kubeClient:        kubeClient,
+			cloneClient:       client.BackupdriverV1(),
+			snapMgr:           &snapshotmgr.SnapshotManager{},
+		}
+		var deleted []string
+		patches := gomonkey.ApplyMethod(reflect.TypeOf(c.snapMgr), "DeleteVolume", func(_ *snapshotmgr.SnapshotManager, volumeID string) error {
+			deleted = append(deleted, volumeID)
+			return nil
+		})
+		defer patches.Reset()
+
+		require.NoError(t, c.cancelClone(clone.DeepCopy()))
+		assert.Equal(t, []string{"fcd-5678"}, deleted)
+		_, err := kubeClient.CoreV1().PersistentVolumeClaims("app").Get("data", metav1.GetOptions{})
+		assert.True(t, apierrors.IsNotFound(err))
+		res, err := client.BackupdriverV1().CloneFromSnapshots("app").Get("clone-1", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, backupdriverapi.ClonePhaseCanceled, res.Status.Phase)
+		assert.Empty(t, res.Status.VolumeID)
+	})
+}
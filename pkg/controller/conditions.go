@@ -0,0 +1,52 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+// setCondition sets the condition of the given type in conditions and returns them. The LastTransitionTime of the
+// condition only changes when its status does.
+func setCondition(conditions []pluginv1api.Condition, condition pluginv1api.Condition, now time.Time) []pluginv1api.Condition {
+	for i := range conditions {
+		if conditions[i].Type != condition.Type {
+			continue
+		}
+		if conditions[i].Status == condition.Status {
+			condition.LastTransitionTime = conditions[i].LastTransitionTime
+		} else {
+			condition.LastTransitionTime = metav1.Time{Time: now}
+		}
+		conditions[i] = condition
+		return conditions
+	}
+	condition.LastTransitionTime = metav1.Time{Time: now}
+	return append(conditions, condition)
+}
+
+// findCondition returns the condition of the given type in conditions, or nil if there is none.
+func findCondition(conditions []pluginv1api.Condition, conditionType string) *pluginv1api.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/stretchr/testify/assert"
+	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"testing"
+	"time"
+)
+
+func TestSetCondition(t *testing.T) {
+	start := time.Now()
+	var conditions []pluginv1api.Condition
+
+	conditions = setCondition(conditions, pluginv1api.Condition{
+		Type:   pluginv1api.ConditionTransferring,
+		Status: pluginv1api.ConditionTrue,
+		Reason: "Uploading",
+	}, start)
+	assert.Len(t, conditions, 1)
+	assert.Equal(t, start, conditions[0].LastTransitionTime.Time)
+
+	// The transition time is kept while the status does not change.
+	conditions = setCondition(conditions, pluginv1api.Condition{
+		Type:    pluginv1api.ConditionTransferring,
+		Status:  pluginv1api.ConditionTrue,
+		Reason:  "Uploading",
+		Message: "Still uploading",
+	}, start.Add(time.Minute))
+	assert.Len(t, conditions, 1)
+	assert.Equal(t, start, conditions[0].LastTransitionTime.Time)
+	assert.Equal(t, "Still uploading", conditions[0].Message)
+
+	conditions = setCondition(conditions, pluginv1api.Condition{
+		Type:   pluginv1api.ConditionTransferring,
+		Status: pluginv1api.ConditionFalse,
+		Reason: "UploadCompleted",
+	}, start.Add(2*time.Minute))
+	assert.Equal(t, start.Add(2*time.Minute), conditions[0].LastTransitionTime.Time)
+
+	assert.Nil(t, findCondition(conditions, pluginv1api.ConditionRetryScheduled))
+	conditions = setCondition(conditions, pluginv1api.Condition{
+		Type:   pluginv1api.ConditionRetryScheduled,
+		Status: pluginv1api.ConditionFalse,
+	}, start.Add(3*time.Minute))
+	assert.Len(t, conditions, 2)
+	assert.Equal(t, pluginv1api.ConditionFalse, findCondition(conditions, pluginv1api.ConditionRetryScheduled).Status)
+}
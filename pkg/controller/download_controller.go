@@ -25,11 +25,18 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
 	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/dataMover"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/encryption"
 	pluginv1client "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/typed/veleroplugin/v1"
 	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions/veleroplugin/v1"
 	listers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/listers/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/metrics"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/migration"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/placement"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/transferpolicy"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -37,6 +44,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/clock"
 	"time"
 )
@@ -49,8 +57,15 @@ type downloadController struct {
 	downloadLister		listers.DownloadLister
 	nodeName			string
 	dataMover			*dataMover.DataMover
+	transferStreams		int
+	transferPolicy		*transferpolicy.Source
+	restoreMapping		*migration.Source
+	transferSlots		*TransferSlots
+	metrics				*metrics.ServerMetrics
+	events				*transferEventRecorder
 	clock				clock.Clock
 	processDownloadFunc func(*pluginv1api.Download) error
+	placeVolumeFunc		func(placement.Request) (placement.Datastore, error)
 }
 
 func NewDownloadController(
@@ -60,6 +75,12 @@ func NewDownloadController(
 	kubeClient			kubernetes.Interface,
 	dataMover				*dataMover.DataMover,
 	nodeName			string,
+	transferStreams		int,
+	transferPolicy		*transferpolicy.Source,
+	restoreMapping		*migration.Source,
+	transferSlots		*TransferSlots,
+	serverMetrics		*metrics.ServerMetrics,
+	eventRecorder		record.EventRecorder,
 ) Interface {
 	c := &downloadController{
 		genericController:	newGenericController("download", logger),
@@ -68,7 +89,14 @@ func NewDownloadController(
 		downloadLister:		downloadInformer.Lister(),
 		nodeName:			nodeName,
 		dataMover:			dataMover,
+		transferStreams:	transferStreams,
+		transferPolicy:		transferPolicy,
+		restoreMapping:		restoreMapping,
+		transferSlots:		transferSlots,
+		metrics:			serverMetrics,
+		events:				newTransferEventRecorder(eventRecorder, kubeClient, logger),
 		clock:				&clock.RealClock{},
+		placeVolumeFunc:	dataMover.PlaceVolume,
 	}
 
 	c.syncHandler = c.processDownloadItem
@@ -145,6 +173,15 @@ func (c *downloadController) processDownloadItem(key string) error {
 		return nil
 	}
 
+	// The worker is not held while the Download waits for a transfer slot, so that the other Downloads are processed.
+	slotKey := "download/" + key
+	if !c.transferSlots.tryAcquire(slotKey) {
+		log.Infof("All the transfer slots of node %s are taken, the download waits for one", c.nodeName)
+		c.queue.AddAfter(key, utils.TransferSlotRetryPeriod)
+		return nil
+	}
+	defer c.transferSlots.release(slotKey)
+
 	leaseLockName := "download-lease." + name
 	// Acquire lease for processing Download.
 	lock := &resourcelock.LeaseLock{
@@ -236,10 +273,46 @@ func (c *downloadController) processDownload(req *pluginv1api.Download) error {
 		return errors.New(errMsg)
 	}
 
-	returnPeId, err := c.dataMover.CopyFromRepo(peID)
+	req, datastore, err := c.placeVolume(req)
 	if err != nil {
+		errMsg := fmt.Sprintf("Failed to place the volume of snapshot %v for StorageClass %s. %v", peID.String(), req.Spec.StorageClass, err)
+		// Retrying does not help if no datastore is compatible with the StorageClass, or if the strict restore mapping
+		// does not map it
+		newPhase := pluginv1api.DownLoadPhaseRetry
+		if errors.Is(err, placement.ErrNoCompatibleDatastore) || errors.Is(err, migration.ErrUnmapped) {
+			newPhase = pluginv1api.DownloadPhaseFailed
+		}
+		_, err = c.patchDownloadByStatus(req, newPhase, errMsg)
+		if err != nil {
+			errMsg = fmt.Sprintf("%v. %v", errMsg, errors.WithStack(err))
+		}
+		log.Error(errMsg)
+		return errors.New(errMsg)
+	}
+
+	// Downloads are not held back by the transfer windows, as restores are not deferred, but share the bandwidth.
+	applyBandwidthLimits(c.transferPolicy, c.dataMover, log)
+	progress := c.newDownloadProgressReporter(req)
+	progress.start()
+	streams := c.transferStreams
+	if req.Spec.TransferStreams > 0 {
+		streams = int(req.Spec.TransferStreams)
+	}
+	repository := backuprepository.NewReference(req.Spec.BackupRepository, req.Spec.BackupStorageLocation, req.Spec.RepositoryParameters)
+	c.metrics.RegisterTransferAttempt(metrics.Download, c.nodeName, repository.String())
+	startTime := c.clock.Now()
+	returnPeId, err := c.dataMover.CopyFromRepo(peID, repository, streams, datastore, progress.update)
+	progress.stop()
+	if err != nil {
+		c.metrics.RegisterTransferFailure(metrics.Download, c.nodeName, repository.String(), progress.bytesTransferred())
 		errMsg := fmt.Sprintf("Failed to download snapshot, %v, from durable object storage. %v", peID.String(), errors.WithStack(err))
-		_, err = c.patchDownloadByStatus(req, pluginv1api.DownLoadPhaseRetry, errMsg)
+		// Retrying does not help if the key the snapshot is encrypted with is missing, or if the strict restore mapping
+		// does not map its datastore
+		newPhase := pluginv1api.DownLoadPhaseRetry
+		if errors.Is(err, encryption.ErrKeyNotFound) || errors.Is(err, migration.ErrUnmapped) {
+			newPhase = pluginv1api.DownloadPhaseFailed
+		}
+		_, err = c.patchDownloadByStatus(req, newPhase, errMsg)
 		if err != nil {
 			errMsg = fmt.Sprintf("%v. %v", errMsg, errors.WithStack(err))
 		}
@@ -247,6 +320,7 @@ func (c *downloadController) processDownload(req *pluginv1api.Download) error {
 		return errors.New(errMsg)
 	}
 
+	c.metrics.RegisterTransferSuccess(metrics.Download, c.nodeName, repository.String(), progress.bytesTransferred(), c.clock.Since(startTime))
 	log.Debugf("A new volume %s was just created from the call to CopyFromRepo", returnPeId.String())
 
 	// update status to Completed with path & snapshot id
@@ -263,6 +337,68 @@ func (c *downloadController) processDownload(req *pluginv1api.Download) error {
 	return nil
 }
 
+// placeVolume returns the ID of the datastore the volume of the Download is placed on for its StorageClass, or "" if
+// the Download has no StorageClass or the StorageClass does not restrict the placement. The placement is recorded in
+// the status of the Download, and kept by its retries.
+func (c *downloadController) placeVolume(req *pluginv1api.Download) (*pluginv1api.Download, string, error) {
+	if req.Spec.StorageClass == "" {
+		return req, "", nil
+	}
+	if placed := req.Status.Placement; placed != nil && placed.StorageClass == req.Spec.StorageClass {
+		return req, placed.Datastore, nil
+	}
+
+	storageClass, err := c.kubeClient.StorageV1().StorageClasses().Get(req.Spec.StorageClass, metav1.GetOptions{})
+	if err != nil {
+		return req, "", errors.Wrapf(err, "Failed to get StorageClass %s", req.Spec.StorageClass)
+	}
+	request := placement.RequestFromStorageClass(storageClass)
+	if request, err = c.mapRequest(request); err != nil {
+		return req, "", err
+	}
+	var datastore placement.Datastore
+	if !request.Empty() {
+		if datastore, err = c.placeVolumeFunc(request); err != nil {
+			return req, "", err
+		}
+	}
+
+	req, err = c.patchDownload(req, func(r *pluginv1api.Download) {
+		r.Status.Placement = &pluginv1api.VolumePlacement{
+			StorageClass:      request.StorageClass,
+			StoragePolicyName: request.StoragePolicyName,
+			DatastoreURL:      request.DatastoreURL,
+			Datastore:         datastore.ID,
+			DatastoreName:     datastore.Name,
+		}
+	})
+	if err != nil {
+		return req, "", err
+	}
+	loggerForDownload(c.logger, req).WithField("datastore", datastore.Name).Infof("The volume is placed for StorageClass %s", request.StorageClass)
+	return req, datastore.ID, nil
+}
+
+// mapRequest maps the storage policy and the datastore URL of the Request with the restore mapping, for the
+// StorageClasses that were copied from the cluster the snapshot was taken on.
+func (c *downloadController) mapRequest(request placement.Request) (placement.Request, error) {
+	mapping, err := c.restoreMapping.Get()
+	if err != nil {
+		return request, err
+	}
+	if request.StoragePolicyName != "" {
+		if request.StoragePolicyName, err = mapping.StoragePolicy(request.StoragePolicyName); err != nil {
+			return request, err
+		}
+	}
+	if request.DatastoreURL != "" {
+		if request.DatastoreURL, err = mapping.DatastoreURL(request.DatastoreURL); err != nil {
+			return request, err
+		}
+	}
+	return request, nil
+}
+
 func (c *downloadController) patchDownload(req *pluginv1api.Download, mutate func(*pluginv1api.Download)) (*pluginv1api.Download, error) {
 	log := loggerForDownload(c.logger, req)
 	// Record original json
@@ -297,6 +433,19 @@ func (c *downloadController) patchDownload(req *pluginv1api.Download, mutate fun
 	return req, nil
 }
 
+// newDownloadProgressReporter returns a progressReporter that patches the progress of the Download.
+func (c *downloadController) newDownloadProgressReporter(req *pluginv1api.Download) *progressReporter {
+	// The progress is patched concurrently with the processing of the Download, so it is patched from its own copy.
+	reqCopy := req.DeepCopy()
+	return newProgressReporter(c.clock, utils.ProgressUpdateInterval, func(bytesDone int64, totalBytes int64) error {
+		_, err := c.patchDownload(reqCopy, func(r *pluginv1api.Download) {
+			r.Status.Progress.BytesDone = bytesDone
+			r.Status.Progress.TotalBytes = totalBytes
+		})
+		return err
+	}, loggerForDownload(c.logger, req))
+}
+
 func (c *downloadController) patchDownloadByStatus(req *pluginv1api.Download, newPhase pluginv1api.DownloadPhase, msg string) (*pluginv1api.Download, error) {
 	// update status to Failed
 	log := loggerForDownload(c.logger, req)
@@ -312,6 +461,8 @@ func (c *downloadController) patchDownloadByStatus(req *pluginv1api.Download, ne
 			r.Status.CompletionTimestamp = &metav1.Time{Time: c.clock.Now()}
 			r.Status.Message = "Download completed"
 			r.Status.VolumeID = msg
+			c.setDownloadCondition(r, pluginv1api.ConditionTransferring, pluginv1api.ConditionFalse, "DownloadCompleted", "The snapshot is downloaded")
+			c.releaseDownloadConditions(r, "DownloadCompleted")
 		})
 	case pluginv1api.DownLoadPhaseRetry:
 		if req.Status.RetryCount > utils.DOWNLOAD_MAX_RETRY {
@@ -320,6 +471,8 @@ func (c *downloadController) patchDownloadByStatus(req *pluginv1api.Download, ne
 				r.Status.Phase = pluginv1api.DownloadPhaseFailed
 				r.Status.CompletionTimestamp = &metav1.Time{Time: c.clock.Now()}
 				r.Status.Message = msg
+				c.setDownloadCondition(r, pluginv1api.ConditionTransferring, pluginv1api.ConditionFalse, "DownloadFailed", msg)
+				c.releaseDownloadConditions(r, "RetriesExhausted")
 			})
 		} else {
 			req, err = c.patchDownload(req, func (r *pluginv1api.Download){
@@ -327,8 +480,24 @@ func (c *downloadController) patchDownloadByStatus(req *pluginv1api.Download, ne
 				r.Status.NextRetryTimestamp = &metav1.Time{Time: c.clock.Now().Add(utils.DOWNLOAD_BACKOFF * time.Minute)}
 				r.Status.RetryCount = r.Status.RetryCount + 1
 				r.Status.Message = msg
+				c.setDownloadCondition(r, pluginv1api.ConditionTransferring, pluginv1api.ConditionFalse, "DownloadFailed", msg)
+				c.setDownloadCondition(r, pluginv1api.ConditionLeaseAcquired, pluginv1api.ConditionFalse, "DownloadFailed", fmt.Sprintf("Node %s stopped processing the Download", c.nodeName))
+				c.setDownloadCondition(r, pluginv1api.ConditionRetryScheduled, pluginv1api.ConditionTrue, "BackOff",
+					fmt.Sprintf("The download is retried after %s, at %s", utils.DOWNLOAD_BACKOFF * time.Minute, r.Status.NextRetryTimestamp.Format(time.RFC3339)))
 			})
+			if err == nil {
+				repository := backuprepository.NewReference(req.Spec.BackupRepository, req.Spec.BackupStorageLocation, req.Spec.RepositoryParameters)
+				c.metrics.RegisterTransferRetry(metrics.Download, c.nodeName, repository.String(), utils.DOWNLOAD_BACKOFF * time.Minute)
+			}
 		}
+	case pluginv1api.DownloadPhaseFailed:
+		req, err = c.patchDownload(req, func (r *pluginv1api.Download){
+			r.Status.Phase = newPhase
+			r.Status.CompletionTimestamp = &metav1.Time{Time: c.clock.Now()}
+			r.Status.Message = msg
+			c.setDownloadCondition(r, pluginv1api.ConditionTransferring, pluginv1api.ConditionFalse, "DownloadFailed", msg)
+			c.releaseDownloadConditions(r, "DownloadFailed")
+		})
 	case pluginv1api.DownloadPhaseInProgress:
 		req, err = c.patchDownload(req, func (r *pluginv1api.Download){
 			if r.Status.Phase == pluginv1api.DownloadPhaseNew {
@@ -337,6 +506,11 @@ func (c *downloadController) patchDownloadByStatus(req *pluginv1api.Download, ne
 			}
 			r.Status.Phase = newPhase
 			r.Status.ProcessingNode = c.nodeName
+			c.setDownloadCondition(r, pluginv1api.ConditionLeaseAcquired, pluginv1api.ConditionTrue, "LeaseAcquired", fmt.Sprintf("Node %s holds the lease of the Download", c.nodeName))
+			c.setDownloadCondition(r, pluginv1api.ConditionTransferring, pluginv1api.ConditionTrue, "Downloading", fmt.Sprintf("Node %s is downloading the snapshot", c.nodeName))
+			if findCondition(r.Status.Conditions, pluginv1api.ConditionRetryScheduled) != nil {
+				c.setDownloadCondition(r, pluginv1api.ConditionRetryScheduled, pluginv1api.ConditionFalse, "Retrying", "The download is being retried")
+			}
 		})
 	default:
 		err = errors.New("Unexpected download phase")
@@ -346,11 +520,48 @@ func (c *downloadController) patchDownloadByStatus(req *pluginv1api.Download, ne
 		log.WithError(err).Errorf("Failed to patch Download from %v to %v", oldPhase, newPhase)
 	} else {
 		log.Infof("Download status updated from %v to %v", oldPhase, newPhase)
+		c.recordDownloadEvent(req, msg)
 	}
 
 	return req, err
 }
 
+// setDownloadCondition sets the condition of the given type on the Download.
+func (c *downloadController) setDownloadCondition(r *pluginv1api.Download, conditionType string, status pluginv1api.ConditionStatus, reason string, message string) {
+	r.Status.Conditions = setCondition(r.Status.Conditions, pluginv1api.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: r.Generation,
+		Reason:             reason,
+		Message:            message,
+	}, c.clock.Now())
+}
+
+// releaseDownloadConditions clears the conditions of a Download that is done being processed.
+func (c *downloadController) releaseDownloadConditions(r *pluginv1api.Download, reason string) {
+	c.setDownloadCondition(r, pluginv1api.ConditionLeaseAcquired, pluginv1api.ConditionFalse, reason, fmt.Sprintf("Node %s is done processing the Download", c.nodeName))
+	if findCondition(r.Status.Conditions, pluginv1api.ConditionRetryScheduled) != nil {
+		c.setDownloadCondition(r, pluginv1api.ConditionRetryScheduled, pluginv1api.ConditionFalse, reason, "The download is not retried anymore")
+	}
+}
+
+// recordDownloadEvent emits the Event of the phase the Download was moved to. The Events of a Download are also emitted
+// on the claim of the volume it restores, if the volume still exists.
+func (c *downloadController) recordDownloadEvent(req *pluginv1api.Download, msg string) {
+	volumeID := volumeIDOfSnapshot(req.Spec.SnapshotID)
+	switch req.Status.Phase {
+	case pluginv1api.DownloadPhaseInProgress:
+		c.events.event(req, volumeID, corev1.EventTypeNormal, "DownloadStarted", "Downloading snapshot %s on node %s", req.Spec.SnapshotID, c.nodeName)
+	case pluginv1api.DownLoadPhaseRetry:
+		c.events.event(req, volumeID, corev1.EventTypeWarning, "DownloadFailed", "Download of snapshot %s failed on node %s, it will be retried at %s: %s",
+			req.Spec.SnapshotID, c.nodeName, req.Status.NextRetryTimestamp.Format(time.RFC3339), msg)
+	case pluginv1api.DownloadPhaseFailed:
+		c.events.event(req, volumeID, corev1.EventTypeWarning, "DownloadFailed", "Download of snapshot %s failed on node %s: %s", req.Spec.SnapshotID, c.nodeName, msg)
+	case pluginv1api.DownloadPhaseCompleted:
+		c.events.event(req, volumeID, corev1.EventTypeNormal, "DownloadCompleted", "Downloaded snapshot %s to volume %s on node %s", req.Spec.SnapshotID, req.Status.VolumeID, c.nodeName)
+	}
+}
+
 func loggerForDownload(baseLogger logrus.FieldLogger, req *pluginv1api.Download) logrus.FieldLogger {
 	log := baseLogger.WithFields(logrus.Fields{
 		"namespace": req.Namespace,
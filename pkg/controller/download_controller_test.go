@@ -19,6 +19,7 @@ package controller
 import (
 	"errors"
 	"encoding/json"
+	"fmt"
 	"github.com/agiledragon/gomonkey"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,12 +28,17 @@ import (
 	core "k8s.io/client-go/testing"
 	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
 	v1 "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/builder"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/dataMover"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/fake"
 	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/migration"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/placement"
 	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
 	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/clock"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
@@ -260,12 +266,20 @@ func TestPatchDownloadByStatus(t *testing.T) {
 }
 
 func TestProcessedDownloadItem(t *testing.T) {
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gold"},
+		Parameters: map[string]string{"storagePolicyName": "Gold Policy"},
+	}
 	tests := []struct {
-		name          string
-		key           string
-		download      *v1.Download
-		expectedPhase v1.DownloadPhase
-		expectedErr   error
+		name              string
+		key               string
+		download          *v1.Download
+		restoreMapping    map[string]string
+		placeErr          error
+		expectedPhase     v1.DownloadPhase
+		expectedErr       error
+		expectedPolicy    string
+		expectedDatastore string
 	}{
 		{
 			name:          "New download proccessed to be completed",
@@ -281,6 +295,38 @@ func TestProcessedDownloadItem(t *testing.T) {
 			expectedPhase: v1.DownLoadPhaseRetry,
 			expectedErr:   errors.New("Failed to get PEID from SnapshotID, ivd:invalid"),
 		},
+		{
+			name:              "Download placed for its StorageClass",
+			key:               "velero/download-1",
+			download:          defaultDownload().Phase(v1.DownloadPhaseNew).SnapshotID("ivd:1234:1234").StorageClass("gold").Result(),
+			expectedPhase:     v1.DownloadPhaseCompleted,
+			expectedDatastore: "datastore-1034",
+		},
+		{
+			name:          "Download fail when no datastore is compatible with its StorageClass",
+			key:           "velero/download-1",
+			download:      defaultDownload().Phase(v1.DownloadPhaseNew).SnapshotID("ivd:1234:1234").StorageClass("gold").Result(),
+			placeErr:      fmt.Errorf("no datastore is compatible with storage policy Gold Policy: %w", placement.ErrNoCompatibleDatastore),
+			expectedPhase: v1.DownloadPhaseFailed,
+			expectedErr:   errors.New("Failed to place the volume of snapshot ivd:1234:1234 for StorageClass gold."),
+		},
+		{
+			name:              "Download placed for the storage policy its StorageClass is mapped to",
+			key:               "velero/download-1",
+			download:          defaultDownload().Phase(v1.DownloadPhaseNew).SnapshotID("ivd:1234:1234").StorageClass("gold").Result(),
+			restoreMapping:    map[string]string{migration.ConfigMapStoragePoliciesKey: "Gold Policy: vSAN Gold"},
+			expectedPhase:     v1.DownloadPhaseCompleted,
+			expectedPolicy:    "vSAN Gold",
+			expectedDatastore: "datastore-1034",
+		},
+		{
+			name:           "Download fail when the strict restore mapping does not map the storage policy of its StorageClass",
+			key:            "velero/download-1",
+			download:       defaultDownload().Phase(v1.DownloadPhaseNew).SnapshotID("ivd:1234:1234").StorageClass("gold").Result(),
+			restoreMapping: map[string]string{migration.ConfigMapStoragePoliciesKey: "Silver Policy: vSAN Silver", migration.ConfigMapStrictKey: "true"},
+			expectedPhase:  v1.DownloadPhaseFailed,
+			expectedErr:    errors.New("Failed to place the volume of snapshot ivd:1234:1234 for StorageClass gold."),
+		},
 		{
 			name:          "Download fail when copying from remote repository",
 			key:           "velero/download-1",
@@ -295,8 +341,19 @@ func TestProcessedDownloadItem(t *testing.T) {
 				clientset       = fake.NewSimpleClientset(test.download)
 				sharedInformers = informers.NewSharedInformerFactory(clientset, 0)
 				logger          = veleroplugintest.NewLogger()
-				kubeClient      = kubefake.NewSimpleClientset()
+				kubeClient      = kubefake.NewSimpleClientset(storageClass)
 			)
+			if test.restoreMapping != nil {
+				_, err := kubeClient.CoreV1().ConfigMaps(utils.DefaultNamespace).Create(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: utils.DefaultNamespace, Name: migration.DefaultConfigMapName},
+					Data:       test.restoreMapping,
+				})
+				require.NoError(t, err)
+			}
+			expectedPolicy := test.expectedPolicy
+			if expectedPolicy == "" {
+				expectedPolicy = "Gold Policy"
+			}
 
 			c := &downloadController{
 				genericController: newGenericController("download-test", logger),
@@ -306,10 +363,16 @@ func TestProcessedDownloadItem(t *testing.T) {
 				nodeName:          "download-test",
 				clock:             &clock.RealClock{},
 				dataMover:         &dataMover.DataMover{},
+				restoreMapping:    migration.NewSource(kubeClient, utils.DefaultNamespace, migration.DefaultConfigMapName),
+				placeVolumeFunc: func(request placement.Request) (placement.Datastore, error) {
+					assert.Equal(t, expectedPolicy, request.StoragePolicyName)
+					return placement.Datastore{ID: "datastore-1034", Name: "vsanDatastore"}, test.placeErr
+				},
 			}
 			require.NoError(t, sharedInformers.Veleroplugin().V1().Downloads().Informer().GetStore().Add(test.download))
 
-			patches := gomonkey.ApplyMethod(reflect.TypeOf(c.dataMover), "CopyFromRepo", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID) (astrolabe.ProtectedEntityID, error) {
+			patches := gomonkey.ApplyMethod(reflect.TypeOf(c.dataMover), "CopyFromRepo", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID, _ backuprepository.Reference, _ int, datastore string, _ dataMover.ProgressFunc) (astrolabe.ProtectedEntityID, error) {
+				assert.Equal(t, test.expectedDatastore, datastore)
 				return astrolabe.ProtectedEntityID{}, test.expectedErr
 			})
 			defer patches.Reset()
@@ -320,6 +383,11 @@ func TestProcessedDownloadItem(t *testing.T) {
 			res, err := c.downloadClient.Downloads(test.download.Namespace).Get(test.download.Name, metav1.GetOptions{})
 			require.Nil(t, err)
 			require.Equal(t, test.expectedPhase, res.Status.Phase)
+			if test.expectedDatastore != "" {
+				require.NotNil(t, res.Status.Placement)
+				assert.Equal(t, test.expectedDatastore, res.Status.Placement.Datastore)
+				assert.Equal(t, "gold", res.Status.Placement.StorageClass)
+			}
 		})
 	}
 }
\ No newline at end of file
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+)
+
+// transferEventRecorder emits the Events of Uploads and Downloads on them, and on the PersistentVolumeClaim of the
+// volume they transfer, so that kubectl describe pvc shows them. A nil transferEventRecorder emits nothing.
+type transferEventRecorder struct {
+	recorder   record.EventRecorder
+	kubeClient kubernetes.Interface
+	logger     logrus.FieldLogger
+}
+
+func newTransferEventRecorder(recorder record.EventRecorder, kubeClient kubernetes.Interface, logger logrus.FieldLogger) *transferEventRecorder {
+	if recorder == nil {
+		return nil
+	}
+	return &transferEventRecorder{
+		recorder:   recorder,
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// event emits an Event on object, and on the PersistentVolumeClaim bound to the volume with the given ID, if any.
+func (r *transferEventRecorder) event(object runtime.Object, volumeID string, eventType string, reason string, messageFmt string, args ...interface{}) {
+	if r == nil {
+		return
+	}
+	message := fmt.Sprintf(messageFmt, args...)
+	r.recorder.Event(object, eventType, reason, message)
+
+	claim, err := r.claimOfVolume(volumeID)
+	if err != nil {
+		r.logger.WithError(err).Debugf("Failed to find the PersistentVolumeClaim of volume %s for the event %s", volumeID, reason)
+		return
+	}
+	if claim != nil {
+		r.recorder.Event(claim, eventType, reason, message)
+	}
+}
+
+// claimOfVolume returns a reference to the PersistentVolumeClaim bound to the PersistentVolume of the vSphere volume
+// with the given ID, or nil if there is none, e.g. because the volume was deleted.
+func (r *transferEventRecorder) claimOfVolume(volumeID string) (*corev1.ObjectReference, error) {
+	if volumeID == "" {
+		return nil, nil
+	}
+	pvList, err := r.kubeClient.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, pv := range pvList.Items {
+		if pv.Spec.CSI == nil || pv.Spec.CSI.VolumeHandle != volumeID || pv.Spec.ClaimRef == nil {
+			continue
+		}
+		claimRef := *pv.Spec.ClaimRef
+		claimRef.Kind = "PersistentVolumeClaim"
+		claimRef.APIVersion = "v1"
+		return &claimRef, nil
+	}
+	return nil, nil
+}
+
+// volumeIDOfSnapshot returns the ID of the volume of the snapshot with the given protected entity ID, or "" if it is
+// not a valid one.
+func volumeIDOfSnapshot(snapshotID string) string {
+	peID, err := astrolabe.NewProtectedEntityIDFromString(snapshotID)
+	if err != nil {
+		return ""
+	}
+	return peID.GetID()
+}
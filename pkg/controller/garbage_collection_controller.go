@@ -0,0 +1,300 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	pluginv1client "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/typed/veleroplugin/v1"
+	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions/veleroplugin/v1"
+	listers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/listers/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	velerov1api "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velero_clientset "github.com/vmware-tanzu/velero/pkg/generated/clientset/versioned"
+	"github.com/vmware-tanzu/velero/pkg/label"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	"strings"
+	"time"
+)
+
+// garbageCollectionLease is held by the node that collects the garbage, so that the data managers on the other nodes
+// do not delete the same records at the same time.
+const garbageCollectionLease = "garbage-collection-lease"
+
+// The prefixes of the names of the Leases taken to process Uploads and Downloads, followed by the name of the record.
+const (
+	uploadLeasePrefix   = "upload-lease."
+	downloadLeasePrefix = "download-lease."
+)
+
+// The prefixes of the names of the Leases taken to process Snapshots, CloneFromSnapshots and BackupRepositoryClaims,
+// see objectLeaseName.
+const (
+	snapshotLeasePrefix = "snapshot-lease."
+	cloneLeasePrefix    = "clone-lease."
+	claimLeasePrefix    = "claim-lease."
+)
+
+// garbageCollectionController periodically deletes the Uploads and Downloads that are done for longer than the TTL,
+// the Leases of the Uploads and Downloads that do not exist anymore, and the Leases of the Snapshots,
+// CloneFromSnapshots and BackupRepositoryClaims that are not held. The Uploads of the Velero backups that exist
+// are kept, as the backups are deleted through them. It reports the Uploads of the Velero backups that were deleted,
+// until they expire.
+type garbageCollectionController struct {
+	*genericController
+
+	kubeClient     kubernetes.Interface
+	uploadClient   pluginv1client.UploadsGetter
+	downloadClient pluginv1client.DownloadsGetter
+	uploadLister   listers.UploadLister
+	downloadLister listers.DownloadLister
+	namespace      string
+	nodeName       string
+	ttl            time.Duration
+	backupNames    func() (map[string]bool, error)
+	events         *transferEventRecorder
+	clock          clock.Clock
+}
+
+func NewGarbageCollectionController(
+	logger logrus.FieldLogger,
+	uploadInformer informers.UploadInformer,
+	downloadInformer informers.DownloadInformer,
+	pluginClient pluginv1client.VeleropluginV1Interface,
+	kubeClient kubernetes.Interface,
+	veleroClient velero_clientset.Interface,
+	namespace string,
+	nodeName string,
+	frequency time.Duration,
+	ttl time.Duration,
+	eventRecorder record.EventRecorder,
+) Interface {
+	c := &garbageCollectionController{
+		genericController: newGenericController("garbage-collection", logger),
+		kubeClient:        kubeClient,
+		uploadClient:      pluginClient,
+		downloadClient:    pluginClient,
+		uploadLister:      uploadInformer.Lister(),
+		downloadLister:    downloadInformer.Lister(),
+		namespace:         namespace,
+		nodeName:          nodeName,
+		ttl:               ttl,
+		backupNames:       veleroBackupNames(veleroClient, namespace),
+		events:            newTransferEventRecorder(eventRecorder, kubeClient, logger),
+		clock:             &clock.RealClock{},
+	}
+
+	c.resyncFunc = c.run
+	c.resyncPeriod = frequency
+	c.cacheSyncWaiters = append(
+		c.cacheSyncWaiters,
+		uploadInformer.Informer().HasSynced,
+		downloadInformer.Informer().HasSynced,
+	)
+
+	return c
+}
+
+// veleroBackupNames returns a function that lists the names of the Velero backups in the namespace, as the Uploads of
+// the backups are labeled with.
+func veleroBackupNames(veleroClient velero_clientset.Interface, namespace string) func() (map[string]bool, error) {
+	return func() (map[string]bool, error) {
+		backups, err := veleroClient.VeleroV1().Backups(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to list the Velero backups")
+		}
+		names := make(map[string]bool, len(backups.Items))
+		for _, backup := range backups.Items {
+			names[label.GetValidName(backup.Name)] = true
+		}
+		return names, nil
+	}
+}
+
+func (c *garbageCollectionController) run() {
+	err := runWithLease(c.kubeClient, c.namespace, garbageCollectionLease, c.nodeName, c.logger, c.collectGarbage)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to collect the garbage Uploads, Downloads and Leases")
+	}
+}
+
+func (c *garbageCollectionController) collectGarbage() error {
+	uploads, err := c.uploadLister.Uploads(c.namespace).List(labels.Everything())
+	if err != nil {
+		return errors.Wrap(err, "Failed to list Uploads")
+	}
+	downloads, err := c.downloadLister.Downloads(c.namespace).List(labels.Everything())
+	if err != nil {
+		return errors.Wrap(err, "Failed to list Downloads")
+	}
+
+	// Without the Velero backups, none of the Uploads of a backup is deleted
+	backupNames, err := c.backupNames()
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to list the Velero backups, the Uploads of backups are kept")
+		backupNames = nil
+	}
+
+	var failed int
+	remaining := make(map[string]bool)
+	expiredUploads := c.expiredUploads(uploads, backupNames)
+	for _, upload := range uploads {
+		if !expiredUploads[upload.Name] {
+			remaining[uploadLeasePrefix+upload.Name] = true
+			continue
+		}
+		c.logger.WithField("upload", upload.Name).Infof("Deleting Upload in phase %s", upload.Status.Phase)
+		if err := c.uploadClient.Uploads(c.namespace).Delete(upload.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			c.logger.WithError(err).Errorf("Failed to delete Upload %s", upload.Name)
+			remaining[uploadLeasePrefix+upload.Name] = true
+			failed++
+		}
+	}
+	for _, download := range downloads {
+		if !c.isDownloadExpired(download) {
+			remaining[downloadLeasePrefix+download.Name] = true
+			continue
+		}
+		c.logger.WithField("download", download.Name).Infof("Deleting Download in phase %s", download.Status.Phase)
+		if err := c.downloadClient.Downloads(c.namespace).Delete(download.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			c.logger.WithError(err).Errorf("Failed to delete Download %s", download.Name)
+			remaining[downloadLeasePrefix+download.Name] = true
+			failed++
+		}
+	}
+
+	failed += c.deleteOrphanedLeases(remaining)
+	if backupNames != nil {
+		c.reportOrphanedUploads(uploads, expiredUploads, backupNames)
+	}
+
+	if failed > 0 {
+		return errors.Errorf("Failed to delete %d garbage records", failed)
+	}
+	return nil
+}
+
+// expiredUploads returns the names of the Uploads that are done for longer than the TTL. The last completed Upload of
+// each repository is kept, as the repositories that are maintained are the ones of the completed Uploads. The Uploads
+// whose local snapshot failed to be deleted are kept too, as they record the local snapshot that is left behind, and so
+// are the Uploads of the Velero backups in backupNames, as the snapshots are deleted with the backups through them. A nil
+// backupNames keeps all the Uploads of backups.
+func (c *garbageCollectionController) expiredUploads(uploads []*pluginv1api.Upload, backupNames map[string]bool) map[string]bool {
+	lastCompleted := make(map[string]*pluginv1api.Upload)
+	for _, upload := range uploads {
+		if upload.Status.Phase != pluginv1api.UploadPhaseCompleted {
+			continue
+		}
+		repository := backuprepository.NewReference(upload.Spec.BackupRepository, upload.Spec.BackupStorageLocation, upload.Spec.RepositoryParameters).String()
+		if last, ok := lastCompleted[repository]; !ok || completionTime(last.Status.CompletionTimestamp, last.ObjectMeta).Before(completionTime(upload.Status.CompletionTimestamp, upload.ObjectMeta)) {
+			lastCompleted[repository] = upload
+		}
+	}
+	keep := make(map[string]bool, len(lastCompleted))
+	for _, upload := range lastCompleted {
+		keep[upload.Name] = true
+	}
+
+	expired := make(map[string]bool)
+	for _, upload := range uploads {
+		if keep[upload.Name] || !isUploadPhaseTerminal(upload.Status.Phase) || upload.Status.Phase == pluginv1api.UploadPhaseCleanupFailed {
+			continue
+		}
+		if backupName, ok := upload.Labels[velerov1api.BackupNameLabel]; ok && (backupNames == nil || backupNames[backupName]) {
+			continue
+		}
+		if c.isExpired(completionTime(upload.Status.CompletionTimestamp, upload.ObjectMeta)) {
+			expired[upload.Name] = true
+		}
+	}
+	return expired
+}
+
+func (c *garbageCollectionController) isDownloadExpired(download *pluginv1api.Download) bool {
+	if download.Status.Phase != pluginv1api.DownloadPhaseCompleted && download.Status.Phase != pluginv1api.DownloadPhaseFailed {
+		return false
+	}
+	return c.isExpired(completionTime(download.Status.CompletionTimestamp, download.ObjectMeta))
+}
+
+func (c *garbageCollectionController) isExpired(doneTime time.Time) bool {
+	return c.clock.Now().Sub(doneTime) > c.ttl
+}
+
+// completionTime returns the time a record was done, its creation time if it was not recorded.
+func completionTime(completionTimestamp *metav1.Time, meta metav1.ObjectMeta) time.Time {
+	if completionTimestamp != nil {
+		return completionTimestamp.Time
+	}
+	return meta.CreationTimestamp.Time
+}
+
+// deleteOrphanedLeases deletes the Leases of the Uploads and Downloads that are not in remaining, and the Leases of the
+// Snapshots, CloneFromSnapshots and BackupRepositoryClaims, which are taken again whenever they are processed, unless
+// they were renewed lately. It returns the number of Leases it failed to delete.
+func (c *garbageCollectionController) deleteOrphanedLeases(remaining map[string]bool) int {
+	leases, err := c.kubeClient.CoordinationV1().Leases(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to list Leases")
+		return 1
+	}
+
+	var failed int
+	for _, lease := range leases.Items {
+		switch {
+		case strings.HasPrefix(lease.Name, uploadLeasePrefix), strings.HasPrefix(lease.Name, downloadLeasePrefix):
+			if remaining[lease.Name] {
+				continue
+			}
+		case strings.HasPrefix(lease.Name, snapshotLeasePrefix), strings.HasPrefix(lease.Name, cloneLeasePrefix),
+			strings.HasPrefix(lease.Name, claimLeasePrefix):
+		default:
+			continue
+		}
+		if lease.Spec.RenewTime != nil && c.clock.Now().Sub(lease.Spec.RenewTime.Time) < utils.LeaseDuration {
+			continue
+		}
+		c.logger.WithField("lease", lease.Name).Info("Deleting Lease that is not held")
+		if err := c.kubeClient.CoordinationV1().Leases(c.namespace).Delete(lease.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			c.logger.WithError(err).Errorf("Failed to delete Lease %s", lease.Name)
+			failed++
+		}
+	}
+	return failed
+}
+
+// reportOrphanedUploads reports the Uploads of the Velero backups that do not exist anymore. They are not deleted, as
+// the backup may be synced again from its storage location.
+func (c *garbageCollectionController) reportOrphanedUploads(uploads []*pluginv1api.Upload, deleted map[string]bool, backupNames map[string]bool) {
+	for _, upload := range uploads {
+		backupName, ok := upload.Labels[velerov1api.BackupNameLabel]
+		if !ok || deleted[upload.Name] || backupNames[backupName] {
+			continue
+		}
+		c.logger.WithField("upload", upload.Name).Warnf("The Upload in phase %s belongs to backup %s, which does not exist anymore", upload.Status.Phase, backupName)
+		c.events.event(upload, volumeIDOfSnapshot(upload.Spec.SnapshotID), corev1.EventTypeWarning, "BackupDeleted",
+			"The Upload of snapshot %s belongs to backup %s, which does not exist anymore", upload.Spec.SnapshotID, backupName)
+	}
+}
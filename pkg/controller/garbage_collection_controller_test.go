@@ -0,0 +1,200 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/fake"
+	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	velerov1api "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	now := time.Now()
+	longAgo := &metav1.Time{Time: now.Add(-48 * time.Hour)}
+	lately := &metav1.Time{Time: now.Add(-time.Hour)}
+
+	uploads := []*pluginv1api.Upload{
+		// The last completed Upload of the repository is kept.
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-last"},
+			Spec:       pluginv1api.UploadSpec{BackupStorageLocation: "default"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCompleted, CompletionTimestamp: &metav1.Time{Time: now.Add(-30 * time.Hour)}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-completed"},
+			Spec:       pluginv1api.UploadSpec{BackupStorageLocation: "default"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCompleted, CompletionTimestamp: longAgo},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-canceled"},
+			Spec:       pluginv1api.UploadSpec{BackupStorageLocation: "default"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCanceled, CompletionTimestamp: longAgo},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-recent"},
+			Spec:       pluginv1api.UploadSpec{BackupStorageLocation: "default"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCleanupFailed, CompletionTimestamp: lately},
+		},
+		// The Upload is the record of the local snapshot that is left behind.
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-cleanup-failed"},
+			Spec:       pluginv1api.UploadSpec{BackupStorageLocation: "default"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCleanupFailed, CompletionTimestamp: longAgo},
+		},
+		// The Upload of a backup that exists is kept, as the backup is deleted through it.
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-backup",
+				Labels: map[string]string{velerov1api.BackupNameLabel: "backup-1"}},
+			Spec:   pluginv1api.UploadSpec{BackupStorageLocation: "default"},
+			Status: pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCompleted, CompletionTimestamp: longAgo},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-deleted-backup",
+				Labels: map[string]string{velerov1api.BackupNameLabel: "deleted-backup"}},
+			Spec:   pluginv1api.UploadSpec{BackupStorageLocation: "default"},
+			Status: pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCompleted, CompletionTimestamp: longAgo},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-retrying", CreationTimestamp: *longAgo,
+				Labels: map[string]string{velerov1api.BackupNameLabel: "deleted-backup"}},
+			Spec:   pluginv1api.UploadSpec{BackupStorageLocation: "default"},
+			Status: pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseUploadError},
+		},
+	}
+	downloads := []*pluginv1api.Download{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "download-completed"},
+			Status:     pluginv1api.DownloadStatus{Phase: pluginv1api.DownloadPhaseCompleted, CompletionTimestamp: longAgo},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "download-in-progress", CreationTimestamp: *longAgo},
+			Status:     pluginv1api.DownloadStatus{Phase: pluginv1api.DownloadPhaseInProgress},
+		},
+	}
+	leases := []*coordinationv1.Lease{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-lease.upload-completed"},
+			Spec:       coordinationv1.LeaseSpec{RenewTime: &metav1.MicroTime{Time: longAgo.Time}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-lease.upload-gone"},
+			Spec:       coordinationv1.LeaseSpec{RenewTime: &metav1.MicroTime{Time: longAgo.Time}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-lease.upload-retrying"},
+			Spec:       coordinationv1.LeaseSpec{RenewTime: &metav1.MicroTime{Time: longAgo.Time}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "download-lease.download-renewed"},
+			Spec:       coordinationv1.LeaseSpec{RenewTime: &metav1.MicroTime{Time: now}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: objectLeaseName(snapshotLeasePrefix, "app", "snapshot-done")},
+			Spec:       coordinationv1.LeaseSpec{RenewTime: &metav1.MicroTime{Time: longAgo.Time}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: objectLeaseName(cloneLeasePrefix, "app", "clone-running")},
+			Spec:       coordinationv1.LeaseSpec{RenewTime: &metav1.MicroTime{Time: now}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "repository-maintenance-lease"},
+			Spec:       coordinationv1.LeaseSpec{RenewTime: &metav1.MicroTime{Time: longAgo.Time}},
+		},
+	}
+
+	pluginClient := fake.NewSimpleClientset()
+	kubeClient := kubefake.NewSimpleClientset()
+	sharedInformers := informers.NewSharedInformerFactory(pluginClient, 0)
+	uploadInformer := sharedInformers.Veleroplugin().V1().Uploads()
+	downloadInformer := sharedInformers.Veleroplugin().V1().Downloads()
+	for _, upload := range uploads {
+		_, err := pluginClient.VeleropluginV1().Uploads(upload.Namespace).Create(upload)
+		require.NoError(t, err)
+		require.NoError(t, uploadInformer.Informer().GetStore().Add(upload))
+	}
+	for _, download := range downloads {
+		_, err := pluginClient.VeleropluginV1().Downloads(download.Namespace).Create(download)
+		require.NoError(t, err)
+		require.NoError(t, downloadInformer.Informer().GetStore().Add(download))
+	}
+	for _, lease := range leases {
+		_, err := kubeClient.CoordinationV1().Leases(lease.Namespace).Create(lease)
+		require.NoError(t, err)
+	}
+
+	c := &garbageCollectionController{
+		genericController: newGenericController("garbage-collection-test", veleroplugintest.NewLogger()),
+		kubeClient:        kubeClient,
+		uploadClient:      pluginClient.VeleropluginV1(),
+		downloadClient:    pluginClient.VeleropluginV1(),
+		uploadLister:      uploadInformer.Lister(),
+		downloadLister:    downloadInformer.Lister(),
+		namespace:         "velero",
+		ttl:               24 * time.Hour,
+		backupNames: func() (map[string]bool, error) {
+			return map[string]bool{"backup-1": true}, nil
+		},
+		clock: &steppedClock{now: now},
+	}
+	require.NoError(t, c.collectGarbage())
+
+	for name, kept := range map[string]bool{
+		"upload-last":           true,
+		"upload-completed":      false,
+		"upload-canceled":       false,
+		"upload-recent":         true,
+		"upload-cleanup-failed": true,
+		"upload-backup":         true,
+		"upload-deleted-backup": false,
+		"upload-retrying":       true,
+	} {
+		_, err := pluginClient.VeleropluginV1().Uploads("velero").Get(name, metav1.GetOptions{})
+		assert.Equal(t, kept, err == nil, name)
+		if !kept {
+			assert.True(t, apierrors.IsNotFound(err), name)
+		}
+	}
+	for name, kept := range map[string]bool{
+		"download-completed":   false,
+		"download-in-progress": true,
+	} {
+		_, err := pluginClient.VeleropluginV1().Downloads("velero").Get(name, metav1.GetOptions{})
+		assert.Equal(t, kept, err == nil, name)
+	}
+	for name, kept := range map[string]bool{
+		"upload-lease.upload-completed":    false,
+		"upload-lease.upload-gone":         false,
+		"upload-lease.upload-retrying":     true,
+		"download-lease.download-renewed":  true,
+		"snapshot-lease.app.snapshot-done": false,
+		"clone-lease.app.clone-running":    true,
+		"repository-maintenance-lease":     true,
+	} {
+		_, err := kubeClient.CoordinationV1().Leases("velero").Get(name, metav1.GetOptions{})
+		assert.Equal(t, kept, err == nil, name)
+	}
+}
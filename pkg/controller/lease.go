@@ -0,0 +1,84 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	"github.com/vmware-tanzu/velero/pkg/label"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// runWithLease acquires the Lease named leaseLockName in namespace ns on behalf of nodeName and calls process
+// while holding it. If the Lease is held by another node, it returns without calling process.
+func runWithLease(kubeClient kubernetes.Interface, ns string, leaseLockName string, nodeName string, log logrus.FieldLogger, process func() error) error {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      leaseLockName,
+			Namespace: ns,
+		},
+		Client: kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: nodeName,
+		},
+	}
+
+	// use a Go context so we can tell the leaderelection code when we
+	// want to step down
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var processErr error
+
+	// start the leader election code loop
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: false,
+		LeaseDuration:   utils.LeaseDuration,
+		RenewDeadline:   utils.RenewDeadline,
+		RetryPeriod:     utils.RetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				// Current node got the lease process request.
+				processErr = process()
+				cancel()
+			},
+			OnStoppedLeading: func() {
+				log.Infof("Released lease %s/%s", ns, leaseLockName)
+			},
+			OnNewLeader: func(identity string) {
+				if identity == nodeName {
+					// Same node is trying to acquire or renew the lease, ignore.
+					return
+				}
+				log.Infof("Lock %s/%s is acquired by another node %s. Current node - %s need not process it.", ns, leaseLockName, identity, nodeName)
+				cancel()
+			},
+		},
+	})
+
+	return processErr
+}
+
+// objectLeaseName returns the name of the Lease taken in the namespace of the data manager to process the object ns/name
+// of another namespace, which is prefix followed by the namespace and name of the object, hashed if they are too long.
+func objectLeaseName(prefix, ns, name string) string {
+	return prefix + label.GetValidName(ns+"."+name)
+}
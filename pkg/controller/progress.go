@@ -0,0 +1,107 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/sirupsen/logrus"
+	"k8s.io/utils/clock"
+	"sync"
+	"time"
+)
+
+// progressReporter records the progress of a copy of the data mover and patches it to the API server at most once
+// per interval, so that the data mover does not wait for the API server and a long copy does not flood it.
+type progressReporter struct {
+	clock    clock.Clock
+	interval time.Duration
+	patch    func(bytesDone int64, totalBytes int64) error
+	logger   logrus.FieldLogger
+
+	mutex      sync.Mutex
+	bytesDone  int64
+	totalBytes int64
+	changed    bool
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// newProgressReporter returns a progressReporter that calls patch with the latest progress every interval, if the
+// progress changed. start has to be called to begin patching.
+func newProgressReporter(clock clock.Clock, interval time.Duration, patch func(bytesDone int64, totalBytes int64) error, logger logrus.FieldLogger) *progressReporter {
+	return &progressReporter{
+		clock:    clock,
+		interval: interval,
+		patch:    patch,
+		logger:   logger,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+// update records the progress. It is a dataMover.ProgressFunc.
+func (r *progressReporter) update(bytesDone int64, totalBytes int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.bytesDone = bytesDone
+	r.totalBytes = totalBytes
+	r.changed = true
+}
+
+// bytesTransferred returns the number of bytes of the data done so far.
+func (r *progressReporter) bytesTransferred() int64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.bytesDone
+}
+
+func (r *progressReporter) start() {
+	go func() {
+		defer close(r.doneCh)
+		for {
+			select {
+			case <-r.stopCh:
+				return
+			case <-r.clock.After(r.interval):
+				r.flush()
+			}
+		}
+	}()
+}
+
+// stop stops patching periodically and patches the progress recorded since the last patch, if any.
+func (r *progressReporter) stop() {
+	close(r.stopCh)
+	<-r.doneCh
+	r.flush()
+}
+
+func (r *progressReporter) flush() {
+	r.mutex.Lock()
+	if !r.changed {
+		r.mutex.Unlock()
+		return
+	}
+	bytesDone, totalBytes := r.bytesDone, r.totalBytes
+	r.changed = false
+	r.mutex.Unlock()
+
+	// A failure to report the progress does not fail the copy, the next patch reports the progress again.
+	if err := r.patch(bytesDone, totalBytes); err != nil {
+		r.logger.WithError(err).Warnf("Failed to update the progress to %d of %d bytes", bytesDone, totalBytes)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/stretchr/testify/assert"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	"k8s.io/utils/clock"
+	"testing"
+	"time"
+)
+
+func TestProgressReporter(t *testing.T) {
+	tests := []struct {
+		name     string
+		updates  [][2]int64
+		expected [][2]int64
+	}{
+		{
+			name:     "No progress is not patched",
+			updates:  nil,
+			expected: nil,
+		},
+		{
+			name:     "Only the latest progress is patched on stop",
+			updates:  [][2]int64{{0, 100}, {10, 100}, {60, 100}},
+			expected: [][2]int64{{60, 100}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var patched [][2]int64
+			r := newProgressReporter(&clock.RealClock{}, time.Hour, func(bytesDone int64, totalBytes int64) error {
+				patched = append(patched, [2]int64{bytesDone, totalBytes})
+				return nil
+			}, veleroplugintest.NewLogger())
+			r.start()
+			for _, update := range test.updates {
+				r.update(update[0], update[1])
+			}
+			r.stop()
+			assert.Equal(t, test.expected, patched)
+		})
+	}
+}
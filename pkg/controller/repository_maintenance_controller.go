@@ -0,0 +1,139 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/dataMover"
+	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions/veleroplugin/v1"
+	listers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/listers/veleroplugin/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"sort"
+	"time"
+)
+
+// repositoryMaintenanceLease is held by the node that maintains the remote repositories, so that the data managers on
+// the other nodes do not maintain them at the same time.
+const repositoryMaintenanceLease = "repository-maintenance-lease"
+
+// repositoryMaintenanceController periodically merges the long chains of incremental snapshots in the remote
+// repositories that snapshots were uploaded to, and purges the deleted snapshots that no snapshot depends on anymore.
+type repositoryMaintenanceController struct {
+	*genericController
+
+	kubeClient             kubernetes.Interface
+	uploadLister           listers.UploadLister
+	namespace              string
+	nodeName               string
+	maxChainDepth          int
+	maintainRepositoryFunc func(backuprepository.Reference, int) error
+}
+
+func NewRepositoryMaintenanceController(
+	logger logrus.FieldLogger,
+	uploadInformer informers.UploadInformer,
+	kubeClient kubernetes.Interface,
+	dataMover *dataMover.DataMover,
+	namespace string,
+	nodeName string,
+	frequency time.Duration,
+	maxChainDepth int,
+) Interface {
+	c := &repositoryMaintenanceController{
+		genericController:      newGenericController("repository-maintenance", logger),
+		kubeClient:             kubeClient,
+		uploadLister:           uploadInformer.Lister(),
+		namespace:              namespace,
+		nodeName:               nodeName,
+		maxChainDepth:          maxChainDepth,
+		maintainRepositoryFunc: dataMover.MaintainRepository,
+	}
+
+	c.resyncFunc = c.run
+	c.resyncPeriod = frequency
+	c.cacheSyncWaiters = append(
+		c.cacheSyncWaiters,
+		uploadInformer.Informer().HasSynced,
+	)
+
+	return c
+}
+
+func (c *repositoryMaintenanceController) run() {
+	repositories, err := c.repositoriesToMaintain()
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to list the repositories to maintain")
+		return
+	}
+	if len(repositories) == 0 {
+		return
+	}
+
+	err = runWithLease(c.kubeClient, c.namespace, repositoryMaintenanceLease, c.nodeName, c.logger, func() error {
+		var failed int
+		for _, repository := range repositories {
+			if err := c.maintainRepositoryFunc(repository, c.maxChainDepth); err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return errors.Errorf("Failed to maintain %d of %d repositories", failed, len(repositories))
+		}
+		return nil
+	})
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to maintain the remote repositories")
+	}
+}
+
+// repositoriesToMaintain returns the repositories that snapshots were uploaded to, in the order of their names.
+func (c *repositoryMaintenanceController) repositoriesToMaintain() ([]backuprepository.Reference, error) {
+	return repositoriesOfUploads(c.uploadLister, c.namespace)
+}
+
+// repositoriesOfUploads returns the repositories of the completed Uploads in the namespace, in the order of their
+// names.
+func repositoriesOfUploads(uploadLister listers.UploadLister, namespace string) ([]backuprepository.Reference, error) {
+	uploads, err := uploadLister.Uploads(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list Uploads")
+	}
+
+	references := make(map[string]backuprepository.Reference)
+	for _, upload := range uploads {
+		if upload.Status.Phase != pluginv1api.UploadPhaseCompleted {
+			continue
+		}
+		repository := backuprepository.NewReference(upload.Spec.BackupRepository, upload.Spec.BackupStorageLocation, upload.Spec.RepositoryParameters)
+		references[repository.String()] = repository
+	}
+
+	names := make([]string, 0, len(references))
+	for name := range references {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	repositories := make([]backuprepository.Reference, 0, len(names))
+	for _, name := range names {
+		repositories = append(repositories, references[name])
+	}
+	return repositories, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/fake"
+	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestRepositoriesToMaintain(t *testing.T) {
+	sharedInformers := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	uploadInformer := sharedInformers.Veleroplugin().V1().Uploads()
+	uploads := []*pluginv1api.Upload{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-1"},
+			Spec:       pluginv1api.UploadSpec{BackupStorageLocation: "default"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCompleted},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-2"},
+			Spec:       pluginv1api.UploadSpec{BackupStorageLocation: "default"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCompleted},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-3"},
+			Spec:       pluginv1api.UploadSpec{BackupRepository: "br-1234", BackupStorageLocation: "default"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCompleted},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-4"},
+			Spec:       pluginv1api.UploadSpec{BackupStorageLocation: "secondary"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseInProgress},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "upload-5"},
+			Spec:       pluginv1api.UploadSpec{BackupStorageLocation: "other"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCompleted},
+		},
+	}
+	for _, upload := range uploads {
+		require.NoError(t, uploadInformer.Informer().GetStore().Add(upload))
+	}
+
+	c := &repositoryMaintenanceController{
+		genericController: newGenericController("repository-maintenance-test", veleroplugintest.NewLogger()),
+		uploadLister:      uploadInformer.Lister(),
+		namespace:         "velero",
+	}
+	repositories, err := c.repositoriesToMaintain()
+	require.NoError(t, err)
+	assert.Equal(t, []backuprepository.Reference{
+		backuprepository.NewReference("br-1234", "", nil),
+		backuprepository.NewReference("", "default", nil),
+	}, repositories)
+}
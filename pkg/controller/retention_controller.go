@@ -0,0 +1,178 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/dataMover"
+	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions/veleroplugin/v1"
+	listers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/listers/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/retention"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/snapshotmgr"
+	velero_clientset "github.com/vmware-tanzu/velero/pkg/generated/clientset/versioned"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/clock"
+	"time"
+)
+
+// retentionLease is held by the node that applies the retention policies, so that the data managers on the other nodes
+// do not delete the same snapshots at the same time.
+const retentionLease = "retention-lease"
+
+// retentionController periodically indexes the snapshots in the remote repositories that snapshots were uploaded to,
+// and deletes the snapshots that the retention policies expire or whose Velero backup does not exist anymore, see
+// retention.Plan. In dry-run mode the snapshots that would be deleted are only reported.
+type retentionController struct {
+	*genericController
+
+	kubeClient          kubernetes.Interface
+	uploadLister        listers.UploadLister
+	namespace           string
+	nodeName            string
+	config              *retention.Source
+	backupNames         func() (map[string]bool, error)
+	indexRepositoryFunc func(backuprepository.Reference) ([]objectstore.IndexedSnapshot, error)
+	deleteSnapshotFunc  func(astrolabe.ProtectedEntityID, backuprepository.Reference) error
+	clock               clock.Clock
+}
+
+func NewRetentionController(
+	logger logrus.FieldLogger,
+	uploadInformer informers.UploadInformer,
+	kubeClient kubernetes.Interface,
+	veleroClient velero_clientset.Interface,
+	dataMover *dataMover.DataMover,
+	snapManager *snapshotmgr.SnapshotManager,
+	namespace string,
+	nodeName string,
+	frequency time.Duration,
+	config *retention.Source,
+) Interface {
+	c := &retentionController{
+		genericController:   newGenericController("retention", logger),
+		kubeClient:          kubeClient,
+		uploadLister:        uploadInformer.Lister(),
+		namespace:           namespace,
+		nodeName:            nodeName,
+		config:              config,
+		backupNames:         veleroBackupNames(veleroClient, namespace),
+		indexRepositoryFunc: dataMover.IndexRepository,
+		deleteSnapshotFunc:  snapManager.DeleteRemoteSnapshot,
+		clock:               &clock.RealClock{},
+	}
+
+	c.resyncFunc = c.run
+	c.resyncPeriod = frequency
+	c.cacheSyncWaiters = append(
+		c.cacheSyncWaiters,
+		uploadInformer.Informer().HasSynced,
+	)
+
+	return c
+}
+
+func (c *retentionController) run() {
+	repositories, err := repositoriesOfUploads(c.uploadLister, c.namespace)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to list the repositories to apply the retention policies to")
+		return
+	}
+	if len(repositories) == 0 {
+		return
+	}
+
+	err = runWithLease(c.kubeClient, c.namespace, retentionLease, c.nodeName, c.logger, func() error {
+		return c.applyRetention(repositories)
+	})
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to apply the retention policies")
+	}
+}
+
+func (c *retentionController) applyRetention(repositories []backuprepository.Reference) error {
+	config, err := c.config.Get()
+	if err != nil {
+		return err
+	}
+	// The snapshots are not deleted as orphans if the backups cannot be listed.
+	backups, err := c.backupNames()
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, repository := range repositories {
+		if err := c.applyRepositoryRetention(repository, config, backups); err != nil {
+			c.logger.WithError(err).WithField("repository", repository.String()).Error("Failed to apply the retention policy")
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("Failed to apply the retention policies of %d of %d repositories", failed, len(repositories))
+	}
+	return nil
+}
+
+func (c *retentionController) applyRepositoryRetention(repository backuprepository.Reference, config retention.Config, backups map[string]bool) error {
+	policy := config.PolicyFor(repository.String())
+	log := c.logger.WithFields(logrus.Fields{
+		"repository": repository.String(),
+		"policy":     policy.String(),
+		"dryRun":     config.DryRun,
+	})
+
+	snapshots, err := c.indexRepositoryFunc(repository)
+	if err != nil {
+		return errors.Wrap(err, "Failed to index the snapshots of the repository")
+	}
+
+	var kept, deleted, failed int
+	for _, decision := range retention.Plan(snapshots, policy, config.DeleteOrphans, backups, c.clock.Now()) {
+		snapshotLog := log.WithField("peID", decision.Snapshot.ID.String())
+		if !decision.Delete {
+			snapshotLog.Debugf("Keeping the snapshot, %s", decision.Reason)
+			kept++
+			continue
+		}
+		if config.DryRun {
+			snapshotLog.Infof("The snapshot would be deleted, %s", decision.Reason)
+			deleted++
+			continue
+		}
+		snapshotLog.Infof("Deleting the snapshot, %s", decision.Reason)
+		if err := c.deleteSnapshotFunc(decision.Snapshot.ID, repository); err != nil && !objectstore.IsNotFound(err) {
+			snapshotLog.WithError(err).Error("Failed to delete the snapshot")
+			failed++
+			continue
+		}
+		deleted++
+	}
+
+	if config.DryRun {
+		log.Infof("Retention report: %d snapshots are kept, %d would be deleted", kept, deleted)
+	} else {
+		log.Infof("Retention report: %d snapshots are kept, %d are deleted", kept, deleted)
+	}
+	if failed > 0 {
+		return errors.Errorf("Failed to delete %d snapshots", failed)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/retention"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	"testing"
+	"time"
+)
+
+func TestApplyRepositoryRetention(t *testing.T) {
+	now := time.Now()
+	snapshotID := func(snapshot string) astrolabe.ProtectedEntityID {
+		return astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID(snapshot))
+	}
+	snapshots := []objectstore.IndexedSnapshot{
+		{ID: snapshotID("snap-new"), Origin: &objectstore.SnapshotOrigin{Timestamp: now.Add(-time.Hour)}},
+		{ID: snapshotID("snap-old"), Origin: &objectstore.SnapshotOrigin{Timestamp: now.Add(-90 * 24 * time.Hour)}},
+		{ID: snapshotID("snap-backup"), Origin: &objectstore.SnapshotOrigin{Timestamp: now.Add(-90 * 24 * time.Hour), Backup: "backup-1"}},
+		{ID: snapshotID("snap-orphan"), Origin: &objectstore.SnapshotOrigin{Timestamp: now.Add(-time.Hour), Backup: "deleted-backup"}},
+	}
+	repository := backuprepository.NewReference("", "default", nil)
+
+	for _, dryRun := range []bool{true, false} {
+		var deleted []string
+		c := &retentionController{
+			genericController: newGenericController("retention-test", veleroplugintest.NewLogger()),
+			indexRepositoryFunc: func(r backuprepository.Reference) ([]objectstore.IndexedSnapshot, error) {
+				assert.Equal(t, repository, r)
+				return snapshots, nil
+			},
+			deleteSnapshotFunc: func(id astrolabe.ProtectedEntityID, r backuprepository.Reference) error {
+				assert.Equal(t, repository, r)
+				deleted = append(deleted, id.GetSnapshotID().String())
+				return nil
+			},
+			clock: &steppedClock{now: now},
+		}
+		config := retention.Config{
+			Repositories:  map[string]retention.Policy{"BackupStorageLocation/default": {MaxAge: 30 * 24 * time.Hour}},
+			DeleteOrphans: true,
+			DryRun:        dryRun,
+		}
+		require.NoError(t, c.applyRepositoryRetention(repository, config, map[string]bool{"backup-1": true}))
+		if dryRun {
+			assert.Empty(t, deleted)
+		} else {
+			assert.Equal(t, []string{"snap-old", "snap-orphan"}, deleted)
+		}
+	}
+}
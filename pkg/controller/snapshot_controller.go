@@ -0,0 +1,528 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	backupdriverapi "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/backupdriver/v1"
+	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	backupdriverclient "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/typed/backupdriver/v1"
+	backupdriverinformers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions/backupdriver/v1"
+	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions/veleroplugin/v1"
+	backupdriverlisters "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/listers/backupdriver/v1"
+	listers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/listers/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/snapshotmgr"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	core_v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+	"time"
+)
+
+type snapshotController struct {
+	*genericController
+
+	kubeClient          kubernetes.Interface
+	snapshotClient      backupdriverclient.SnapshotsGetter
+	snapshotLister      backupdriverlisters.SnapshotLister
+	repositoryLister    backupdriverlisters.BackupRepositoryLister
+	uploadLister        listers.UploadLister
+	namespace           string
+	nodeName            string
+	snapMgr             *snapshotmgr.SnapshotManager
+	clock               clock.Clock
+	processSnapshotFunc func(*backupdriverapi.Snapshot) error
+}
+
+func NewSnapshotController(
+	logger logrus.FieldLogger,
+	snapshotInformer backupdriverinformers.SnapshotInformer,
+	snapshotClient backupdriverclient.SnapshotsGetter,
+	repositoryInformer backupdriverinformers.BackupRepositoryInformer,
+	uploadInformer informers.UploadInformer,
+	kubeClient kubernetes.Interface,
+	snapMgr *snapshotmgr.SnapshotManager,
+	namespace string,
+	nodeName string,
+) Interface {
+	c := &snapshotController{
+		genericController: newGenericController("snapshot", logger),
+		kubeClient:        kubeClient,
+		snapshotClient:    snapshotClient,
+		snapshotLister:    snapshotInformer.Lister(),
+		repositoryLister:  repositoryInformer.Lister(),
+		uploadLister:      uploadInformer.Lister(),
+		namespace:         namespace,
+		nodeName:          nodeName,
+		snapMgr:           snapMgr,
+		clock:             &clock.RealClock{},
+	}
+
+	c.syncHandler = c.processSnapshotItem
+	c.retryHandler = c.reEnqueueHandler
+	c.cacheSyncWaiters = append(
+		c.cacheSyncWaiters,
+		snapshotInformer.Informer().HasSynced,
+		repositoryInformer.Informer().HasSynced,
+		uploadInformer.Informer().HasSynced,
+	)
+	c.processSnapshotFunc = c.processSnapshot
+
+	snapshotInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.enqueueSnapshotItem,
+			UpdateFunc: func(_, obj interface{}) { c.enqueueSnapshotItem(obj) },
+		},
+	)
+
+	// The phase of a Snapshot follows the phase of the Upload created for it, so
+	// re-enqueue the Snapshots that refer to an Upload whenever the Upload changes.
+	uploadInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.enqueueSnapshotsForUpload,
+			UpdateFunc: func(_, obj interface{}) { c.enqueueSnapshotsForUpload(obj) },
+		},
+	)
+
+	return c
+}
+
+func (c *snapshotController) enqueueSnapshotItem(obj interface{}) {
+	req := obj.(*backupdriverapi.Snapshot)
+
+	log := loggerForSnapshot(c.logger, req)
+
+	if isSnapshotPhaseTerminal(req.Status.Phase) {
+		log.Debug("Snapshot CR is in a terminal phase, skipping")
+		return
+	}
+
+	log.Infof("Enqueueing snapshot")
+	c.enqueue(obj)
+}
+
+func (c *snapshotController) enqueueSnapshotsForUpload(obj interface{}) {
+	upload := obj.(*pluginv1api.Upload)
+
+	snapshots, err := c.snapshotLister.List(labels.Everything())
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to list Snapshots")
+		return
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.Status.SnapshotID == "" || snapshot.Status.SnapshotID != upload.Spec.SnapshotID {
+			continue
+		}
+		c.enqueueSnapshotItem(snapshot)
+	}
+}
+
+func (c *snapshotController) processSnapshotItem(key string) error {
+	log := c.logger.WithField("key", key)
+	log.Info("Running processSnapshotItem")
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		log.WithError(err).Error("Failed to split the key of queue item")
+		return nil
+	}
+
+	req, err := c.snapshotLister.Snapshots(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		log.Error("Snapshot is not found")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to get Snapshot")
+	}
+
+	if isSnapshotPhaseTerminal(req.Status.Phase) {
+		return nil
+	}
+
+	// Every data manager node watches Snapshots, the lease makes sure that only one of them acts on it at a time.
+	return runWithLease(c.kubeClient, c.namespace, objectLeaseName(snapshotLeasePrefix, ns, name), c.nodeName, log, func() error {
+		// Don't mutate the shared cache
+		return c.processSnapshotFunc(req.DeepCopy())
+	})
+}
+
+func (c *snapshotController) processSnapshot(req *backupdriverapi.Snapshot) error {
+	log := loggerForSnapshot(c.logger, req)
+	var err error
+
+	// retrieve snapshot request for its updated status from k8s api server and filter out terminated one
+	req, err = c.snapshotClient.Snapshots(req.Namespace).Get(req.Name, metav1.GetOptions{})
+	if err != nil {
+		log.WithError(err).Error("Failed to retrieve snapshot CR from kubernetes API server")
+		return errors.WithStack(err)
+	}
+
+	if isSnapshotPhaseTerminal(req.Status.Phase) {
+		log.WithField("phase", req.Status.Phase).Info("The snapshot CR in kubernetes API server is in a terminal phase. Skipping it")
+		return nil
+	}
+
+	if req.Spec.SnapshotCancel || req.Status.Phase == backupdriverapi.SnapshotPhaseCanceling {
+		return c.cancelSnapshot(req)
+	}
+
+	switch req.Status.Phase {
+	case "", backupdriverapi.SnapshotPhaseNew, backupdriverapi.SnapshotPhaseInProgress:
+		// A Snapshot left in InProgress by a data manager that went away is taken again from the beginning.
+		return c.createSnapshot(req)
+	case backupdriverapi.SnapshotPhaseSnapshotted, backupdriverapi.SnapshotPhaseUploading:
+		return c.syncSnapshotWithUpload(req)
+	}
+
+	return nil
+}
+
+func (c *snapshotController) createSnapshot(req *backupdriverapi.Snapshot) error {
+	log := loggerForSnapshot(c.logger, req)
+	log.Info("Snapshot starting")
+	var err error
+
+	if err := checkBackupRepositoryAccess(c.repositoryLister, req.Spec.BackupRepository, req.Namespace); err != nil {
+		c.failSnapshot(req, backupdriverapi.SnapshotPhaseSnapshotFailed, fmt.Sprintf("Failed to snapshot %s/%s. %v", req.Namespace, req.Spec.Name, err))
+		return nil
+	}
+
+	req, err = c.patchSnapshot(req, func(r *backupdriverapi.Snapshot) {
+		r.Status.Phase = backupdriverapi.SnapshotPhaseInProgress
+		r.Status.Message = ""
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	pvc, volumeID, err := c.resolveResourceHandle(req)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to resolve resource handle %s/%s. %v", req.Namespace, req.Spec.Name, err)
+		c.failSnapshot(req, backupdriverapi.SnapshotPhaseSnapshotFailed, errMsg)
+		return nil
+	}
+
+	metadata, err := json.Marshal(pvc)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to marshal PVC %s/%s. %v", pvc.Namespace, pvc.Name, err)
+		c.failSnapshot(req, backupdriverapi.SnapshotPhaseSnapshotFailed, errMsg)
+		return nil
+	}
+
+	tags := map[string]string{
+		utils.SnapshotTagSnapshotName: fmt.Sprintf("%s/%s", req.Namespace, req.Name),
+	}
+	if req.Spec.BackupRepository != "" {
+		tags[utils.SnapshotTagBackupRepository] = req.Spec.BackupRepository
+	}
+	peID := astrolabe.NewProtectedEntityID(utils.CnsBlockVolumeType, volumeID)
+	snapshotPeID, err := c.snapMgr.CreateSnapshot(peID, tags)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to snapshot volume %s of PVC %s/%s. %v", volumeID, pvc.Namespace, pvc.Name, err)
+		c.failSnapshot(req, backupdriverapi.SnapshotPhaseSnapshotFailed, errMsg)
+		return nil
+	}
+
+	repository := backuprepository.NewReference(req.Spec.BackupRepository, "", nil)
+	req, err = c.recordSnapshotID(req, snapshotPeID, metadata)
+	if err != nil {
+		// The Snapshot is left InProgress, so the retry takes a new snapshot from the beginning. As the Upload created
+		// for the snapshot that is not recorded is not finished, DeleteSnapshot only requests its cancellation, and
+		// leaves the local snapshot in place.
+		log.WithError(err).Errorf("Failed to record snapshot %s, deleting it", snapshotPeID.String())
+		if deleteErr := c.snapMgr.DeleteSnapshot(snapshotPeID, repository); deleteErr != nil {
+			log.WithError(deleteErr).Errorf("Failed to delete the snapshot %s that is not recorded", snapshotPeID.String())
+		}
+		return errors.WithStack(err)
+	}
+
+	log.WithField("snapshotID", req.Status.SnapshotID).Info("Snapshot completed")
+	return nil
+}
+
+// recordSnapshotIDBackoff is how often the ID of a snapshot that was taken is recorded on its Snapshot before giving up.
+var recordSnapshotIDBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Steps:    5,
+}
+
+// recordSnapshotID moves the Snapshot to Snapshotted with the ID of the snapshot taken for it. The patch is retried, as
+// the snapshot is only known by this call once it is taken.
+func (c *snapshotController) recordSnapshotID(req *backupdriverapi.Snapshot, snapshotPeID astrolabe.ProtectedEntityID, metadata []byte) (*backupdriverapi.Snapshot, error) {
+	log := loggerForSnapshot(c.logger, req)
+	var patched *backupdriverapi.Snapshot
+	var lastErr error
+	err := wait.ExponentialBackoff(recordSnapshotIDBackoff, func() (bool, error) {
+		patched, lastErr = c.patchSnapshot(req.DeepCopy(), func(r *backupdriverapi.Snapshot) {
+			r.Status.Phase = backupdriverapi.SnapshotPhaseSnapshotted
+			r.Status.SnapshotID = snapshotPeID.String()
+			r.Status.Metadata = metadata
+			r.Status.Message = "Local snapshot completed"
+		})
+		if lastErr != nil {
+			log.WithError(lastErr).Warnf("Failed to record snapshot %s, retrying", snapshotPeID.String())
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, err
+	}
+	return patched, nil
+}
+
+// resolveResourceHandle returns the PVC referred to by the Snapshot along with the CSI volume handle of its PV.
+func (c *snapshotController) resolveResourceHandle(req *backupdriverapi.Snapshot) (*core_v1.PersistentVolumeClaim, string, error) {
+	handle := req.Spec.TypedLocalObjectReference
+	if handle.Kind != "PersistentVolumeClaim" || (handle.APIGroup != nil && *handle.APIGroup != "") {
+		return nil, "", errors.Errorf("unsupported resource kind %s", handle.Kind)
+	}
+
+	pvc, err := c.kubeClient.CoreV1().PersistentVolumeClaims(req.Namespace).Get(handle.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to get PVC %s/%s", req.Namespace, handle.Name)
+	}
+
+	if pvc.Spec.VolumeName == "" || pvc.Status.Phase != core_v1.ClaimBound {
+		return nil, "", errors.Errorf("PVC %s/%s is not bound to a PV", pvc.Namespace, pvc.Name)
+	}
+
+	pv, err := c.kubeClient.CoreV1().PersistentVolumes().Get(pvc.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to get PV %s", pvc.Spec.VolumeName)
+	}
+
+	if pv.Spec.CSI == nil || pv.Spec.CSI.VolumeHandle == "" {
+		return nil, "", errors.Errorf("PV %s is not a CSI volume", pv.Name)
+	}
+
+	return pvc, pv.Spec.CSI.VolumeHandle, nil
+}
+
+// syncSnapshotWithUpload moves a Snapshot through the upload phases by following the Upload created for it.
+func (c *snapshotController) syncSnapshotWithUpload(req *backupdriverapi.Snapshot) error {
+	log := loggerForSnapshot(c.logger, req)
+
+	upload, err := c.uploadForSnapshot(req)
+	if err != nil {
+		return err
+	}
+	if upload == nil {
+		log.Debug("No Upload has been observed for the snapshot yet")
+		return nil
+	}
+
+	newPhase, msg := snapshotPhaseForUpload(req.Status.Phase, upload)
+	if newPhase == req.Status.Phase && req.Status.Progress.BytesDone == upload.Status.Progress.BytesDone &&
+		req.Status.Progress.TotalBytes == upload.Status.Progress.TotalBytes {
+		return nil
+	}
+
+	_, err = c.patchSnapshot(req, func(r *backupdriverapi.Snapshot) {
+		r.Status.Phase = newPhase
+		r.Status.Progress.TotalBytes = upload.Status.Progress.TotalBytes
+		r.Status.Progress.BytesDone = upload.Status.Progress.BytesDone
+		if msg != "" {
+			r.Status.Message = msg
+		}
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.Infof("Snapshot status updated from %v to %v", req.Status.Phase, newPhase)
+	return nil
+}
+
+// snapshotPhaseForUpload maps the phase of an Upload onto the phase of the Snapshot it was created for.
+func snapshotPhaseForUpload(current backupdriverapi.SnapshotPhase, upload *pluginv1api.Upload) (backupdriverapi.SnapshotPhase, string) {
+	switch upload.Status.Phase {
+	case pluginv1api.UploadPhaseInProgress, pluginv1api.UploadPhaseUploadError:
+		// UploadError is retried by the upload controller, so the snapshot is still being uploaded.
+		return backupdriverapi.SnapshotPhaseUploading, upload.Status.Message
+	case pluginv1api.UploadPhaseCompleted, pluginv1api.UploadPhaseCleanupFailed:
+		// The data is in the durable repository even if the local snapshot could not be cleaned up.
+		return backupdriverapi.SnapshotPhaseUploaded, "Upload completed"
+	case pluginv1api.UploadPhaseCanceling, pluginv1api.UploadPhaseCanceled:
+		return backupdriverapi.SnapshotPhaseUploadFailed, "The upload was canceled"
+	default:
+		return current, ""
+	}
+}
+
+func (c *snapshotController) cancelSnapshot(req *backupdriverapi.Snapshot) error {
+	log := loggerForSnapshot(c.logger, req)
+	var err error
+
+	if req.Status.SnapshotID == "" {
+		// Nothing has been snapshotted yet.
+		_, err = c.patchSnapshot(req, func(r *backupdriverapi.Snapshot) {
+			r.Status.Phase = backupdriverapi.SnapshotPhaseCanceled
+			r.Status.Message = "The snapshot was canceled"
+		})
+		return err
+	}
+
+	peID, err := astrolabe.NewProtectedEntityIDFromString(req.Status.SnapshotID)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to get PEID from SnapshotID, %v. %v", req.Status.SnapshotID, err)
+		c.failSnapshot(req, backupdriverapi.SnapshotPhaseCanceled, errMsg)
+		return nil
+	}
+
+	upload, err := c.uploadForSnapshot(req)
+	if err != nil {
+		return err
+	}
+
+	if req.Status.Phase != backupdriverapi.SnapshotPhaseCanceling {
+		req, err = c.patchSnapshot(req, func(r *backupdriverapi.Snapshot) {
+			r.Status.Phase = backupdriverapi.SnapshotPhaseCanceling
+			r.Status.Message = "Canceling the snapshot"
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		// DeleteSnapshot cancels the Upload if it is still running, otherwise it removes the local and durable snapshots.
+		log.Infof("Deleting snapshot %s", peID.String())
+		if err = c.snapMgr.DeleteSnapshot(peID, backuprepository.NewReference(req.Spec.BackupRepository, "", nil)); err != nil {
+			return errors.Wrapf(err, "failed to delete snapshot %s", peID.String())
+		}
+	}
+
+	if upload != nil && !isUploadPhaseTerminal(upload.Status.Phase) {
+		log.Info("Waiting for the upload of the snapshot to be canceled")
+		return nil
+	}
+
+	_, err = c.patchSnapshot(req, func(r *backupdriverapi.Snapshot) {
+		r.Status.Phase = backupdriverapi.SnapshotPhaseCanceled
+		r.Status.SnapshotID = ""
+		r.Status.Message = "The snapshot was canceled"
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.Info("Snapshot cancellation complete")
+	return nil
+}
+
+// uploadForSnapshot returns the Upload created for the snapshot ID of the Snapshot, or nil if none has been observed.
+func (c *snapshotController) uploadForSnapshot(req *backupdriverapi.Snapshot) (*pluginv1api.Upload, error) {
+	uploads, err := c.uploadLister.List(labels.Everything())
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list Uploads")
+	}
+
+	for _, upload := range uploads {
+		if upload.Spec.SnapshotID == req.Status.SnapshotID {
+			return upload, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *snapshotController) failSnapshot(req *backupdriverapi.Snapshot, phase backupdriverapi.SnapshotPhase, errMsg string) {
+	log := loggerForSnapshot(c.logger, req)
+	log.Error(errMsg)
+	_, err := c.patchSnapshot(req, func(r *backupdriverapi.Snapshot) {
+		r.Status.Phase = phase
+		r.Status.Message = errMsg
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Failed to patch Snapshot to %v", phase)
+	}
+}
+
+func (c *snapshotController) patchSnapshot(req *backupdriverapi.Snapshot, mutate func(*backupdriverapi.Snapshot)) (*backupdriverapi.Snapshot, error) {
+	// Record original json
+	oldData, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshall original Snapshot")
+	}
+
+	// Mutate
+	mutate(req)
+
+	// Record new json
+	newData, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshall updated Snapshot")
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create json merge patch for Snapshot")
+	}
+
+	// Snapshot has the status subresource enabled, so status changes have to go through it.
+	req, err = c.snapshotClient.Snapshots(req.Namespace).Patch(req.Name, types.MergePatchType, patchBytes, "status")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to patch Snapshot")
+	}
+
+	return req, nil
+}
+
+func (c *snapshotController) reEnqueueHandler(key string) error {
+	log := c.logger.WithField("key", key)
+	log.Infof("Re-adding failed snapshot %s to the queue", key)
+	c.queue.AddRateLimited(key)
+	return nil
+}
+
+func isSnapshotPhaseTerminal(phase backupdriverapi.SnapshotPhase) bool {
+	switch phase {
+	case backupdriverapi.SnapshotPhaseSnapshotFailed, backupdriverapi.SnapshotPhaseUploaded,
+		backupdriverapi.SnapshotPhaseUploadFailed, backupdriverapi.SnapshotPhaseCanceled:
+		return true
+	}
+	return false
+}
+
+func isUploadPhaseTerminal(phase pluginv1api.UploadPhase) bool {
+	return phase == pluginv1api.UploadPhaseCompleted || phase == pluginv1api.UploadPhaseCleanupFailed || phase == pluginv1api.UploadPhaseCanceled
+}
+
+func loggerForSnapshot(baseLogger logrus.FieldLogger, req *backupdriverapi.Snapshot) logrus.FieldLogger {
+	return baseLogger.WithFields(logrus.Fields{
+		"namespace":  req.Namespace,
+		"name":       req.Name,
+		"phase":      req.Status.Phase,
+		"generation": req.Generation,
+	})
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"github.com/agiledragon/gomonkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	backupdriverapi "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/backupdriver/v1"
+	v1 "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/builder"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/fake"
+	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/snapshotmgr"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessSnapshotNonProcessedItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		snapshot *backupdriverapi.Snapshot
+	}{
+		{
+			name: "missing snapshot returns nil",
+			key:  "foo/bar",
+		},
+		{
+			name:     "Uploaded snapshot is not processed",
+			key:      "app/snapshot-1",
+			snapshot: builder.ForSnapshot("app", "snapshot-1").Phase(backupdriverapi.SnapshotPhaseUploaded).Result(),
+		},
+		{
+			name:     "SnapshotFailed snapshot is not processed",
+			key:      "app/snapshot-1",
+			snapshot: builder.ForSnapshot("app", "snapshot-1").Phase(backupdriverapi.SnapshotPhaseSnapshotFailed).Result(),
+		},
+		{
+			name:     "Canceled snapshot is not processed",
+			key:      "app/snapshot-1",
+			snapshot: builder.ForSnapshot("app", "snapshot-1").Phase(backupdriverapi.SnapshotPhaseCanceled).Result(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				sharedInformers = informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+				logger          = veleroplugintest.NewLogger()
+			)
+
+			c := &snapshotController{
+				genericController: newGenericController("snapshot-test", logger),
+				snapshotLister:    sharedInformers.Backupdriver().V1().Snapshots().Lister(),
+			}
+
+			if test.snapshot != nil {
+				require.NoError(t, sharedInformers.Backupdriver().V1().Snapshots().Informer().GetStore().Add(test.snapshot))
+			}
+
+			err := c.processSnapshotItem(test.key)
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestSnapshotPhaseForUpload(t *testing.T) {
+	tests := []struct {
+		name          string
+		current       backupdriverapi.SnapshotPhase
+		uploadPhase   v1.UploadPhase
+		expectedPhase backupdriverapi.SnapshotPhase
+	}{
+		{
+			name:          "New upload keeps the snapshot Snapshotted",
+			current:       backupdriverapi.SnapshotPhaseSnapshotted,
+			uploadPhase:   v1.UploadPhaseNew,
+			expectedPhase: backupdriverapi.SnapshotPhaseSnapshotted,
+		},
+		{
+			name:          "InProgress upload moves the snapshot to Uploading",
+			current:       backupdriverapi.SnapshotPhaseSnapshotted,
+			uploadPhase:   v1.UploadPhaseInProgress,
+			expectedPhase: backupdriverapi.SnapshotPhaseUploading,
+		},
+		{
+			name:          "UploadError upload keeps the snapshot Uploading",
+			current:       backupdriverapi.SnapshotPhaseUploading,
+			uploadPhase:   v1.UploadPhaseUploadError,
+			expectedPhase: backupdriverapi.SnapshotPhaseUploading,
+		},
+		{
+			name:          "Completed upload moves the snapshot to Uploaded",
+			current:       backupdriverapi.SnapshotPhaseUploading,
+			uploadPhase:   v1.UploadPhaseCompleted,
+			expectedPhase: backupdriverapi.SnapshotPhaseUploaded,
+		},
+		{
+			name:          "CleanupFailed upload moves the snapshot to Uploaded",
+			current:       backupdriverapi.SnapshotPhaseUploading,
+			uploadPhase:   v1.UploadPhaseCleanupFailed,
+			expectedPhase: backupdriverapi.SnapshotPhaseUploaded,
+		},
+		{
+			name:          "Canceled upload moves the snapshot to UploadFailed",
+			current:       backupdriverapi.SnapshotPhaseUploading,
+			uploadPhase:   v1.UploadPhaseCanceled,
+			expectedPhase: backupdriverapi.SnapshotPhaseUploadFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			upload := defaultUpload().Phase(test.uploadPhase).Result()
+			phase, _ := snapshotPhaseForUpload(test.current, upload)
+			assert.Equal(t, test.expectedPhase, phase)
+		})
+	}
+}
+
+func TestResolveResourceHandle(t *testing.T) {
+	boundPVC := &core_v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "data"},
+		Spec:       core_v1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+		Status:     core_v1.PersistentVolumeClaimStatus{Phase: core_v1.ClaimBound},
+	}
+	pendingPVC := &core_v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "pending"},
+		Status:     core_v1.PersistentVolumeClaimStatus{Phase: core_v1.ClaimPending},
+	}
+	pv := &core_v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec: core_v1.PersistentVolumeSpec{
+			PersistentVolumeSource: core_v1.PersistentVolumeSource{
+				CSI: &core_v1.CSIPersistentVolumeSource{
+					Driver:       "csi.vsphere.vmware.com",
+					VolumeHandle: "fcd-1234",
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		snapshot         *backupdriverapi.Snapshot
+		expectedVolumeID string
+		expectErr        bool
+	}{
+		{
+			name:             "Bound PVC resolves to the CSI volume handle",
+			snapshot:         builder.ForSnapshot("app", "snapshot-1").PVC("data").Result(),
+			expectedVolumeID: "fcd-1234",
+		},
+		{
+			name:      "Unbound PVC fails",
+			snapshot:  builder.ForSnapshot("app", "snapshot-1").PVC("pending").Result(),
+			expectErr: true,
+		},
+		{
+			name:      "Missing PVC fails",
+			snapshot:  builder.ForSnapshot("app", "snapshot-1").PVC("missing").Result(),
+			expectErr: true,
+		},
+		{
+			name: "Resource other than PVC fails",
+			snapshot: func() *backupdriverapi.Snapshot {
+				s := builder.ForSnapshot("app", "snapshot-1").Result()
+				s.Spec.TypedLocalObjectReference = core_v1.TypedLocalObjectReference{Kind: "Pod", Name: "data"}
+				return s
+			}(),
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &snapshotController{
+				genericController: newGenericController("snapshot-test", veleroplugintest.NewLogger()),
+				kubeClient:        kubefake.NewSimpleClientset([]runtime.Object{boundPVC, pendingPVC, pv}...),
+			}
+
+			_, volumeID, err := c.resolveResourceHandle(test.snapshot)
+			assert.Equal(t, test.expectErr, err != nil)
+			assert.Equal(t, test.expectedVolumeID, volumeID)
+		})
+	}
+}
+
+func TestCreateSnapshotRecordsSnapshotID(t *testing.T) {
+	pvc := &core_v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "data"},
+		Spec:       core_v1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+		Status:     core_v1.PersistentVolumeClaimStatus{Phase: core_v1.ClaimBound},
+	}
+	pv := &core_v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec: core_v1.PersistentVolumeSpec{
+			PersistentVolumeSource: core_v1.PersistentVolumeSource{
+				CSI: &core_v1.CSIPersistentVolumeSource{Driver: "csi.vsphere.vmware.com", VolumeHandle: "fcd-1234"},
+			},
+		},
+	}
+	snapshotPeID := astrolabe.NewProtectedEntityIDWithSnapshotID(utils.CnsBlockVolumeType, "fcd-1234", astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+
+	defer func(backoff wait.Backoff) { recordSnapshotIDBackoff = backoff }(recordSnapshotIDBackoff)
+	recordSnapshotIDBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+
+	tests := []struct {
+		name            string
+		failedPatches   int
+		expectedPhase   backupdriverapi.SnapshotPhase
+		expectedID      string
+		expectErr       bool
+		expectedDeletes int
+	}{
+		{
+			name:          "Snapshot ID is recorded after failed patches",
+			failedPatches: 2,
+			expectedPhase: backupdriverapi.SnapshotPhaseSnapshotted,
+			expectedID:    snapshotPeID.String(),
+		},
+		{
+			name:            "Snapshot that cannot be recorded is deleted",
+			failedPatches:   3,
+			expectedPhase:   backupdriverapi.SnapshotPhaseInProgress,
+			expectErr:       true,
+			expectedDeletes: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snapshot := builder.ForSnapshot("app", "snapshot-1").PVC("data").Phase(backupdriverapi.SnapshotPhaseNew).Result()
+			client := fake.NewSimpleClientset(snapshot)
+			sharedInformers := informers.NewSharedInformerFactory(client, 0)
+			failedPatches := test.failedPatches
+			client.PrependReactor("patch", "snapshots", func(action core.Action) (bool, runtime.Object, error) {
+				if strings.Contains(string(action.(core.PatchAction).GetPatch()), "snapshotID") && failedPatches > 0 {
+					failedPatches--
+					return true, nil, errors.New("etcdserver: request timed out")
+				}
+				return false, nil, nil
+			})
+
+			c := &snapshotController{
+				genericController: newGenericController("snapshot-test", veleroplugintest.NewLogger()),
+				kubeClient:        kubefake.NewSimpleClientset(pvc, pv),
+				snapshotClient:    client.BackupdriverV1(),
+				repositoryLister:  sharedInformers.Backupdriver().V1().BackupRepositories().Lister(),
+				snapMgr:           &snapshotmgr.SnapshotManager{},
+			}
+
+			deletes := 0
+			patches := gomonkey.ApplyMethod(reflect.TypeOf(c.snapMgr), "CreateSnapshot", func(_ *snapshotmgr.SnapshotManager, _ astrolabe.ProtectedEntityID, _ map[string]string) (astrolabe.ProtectedEntityID, error) {
+				return snapshotPeID, nil
+			})
+			patches.ApplyMethod(reflect.TypeOf(c.snapMgr), "DeleteSnapshot", func(_ *snapshotmgr.SnapshotManager, peID astrolabe.ProtectedEntityID, _ backuprepository.Reference) error {
+				assert.Equal(t, snapshotPeID.String(), peID.String())
+				deletes++
+				return nil
+			})
+			defer patches.Reset()
+
+			err := c.createSnapshot(snapshot.DeepCopy())
+			assert.Equal(t, test.expectErr, err != nil)
+			assert.Equal(t, test.expectedDeletes, deletes)
+			res, err := client.BackupdriverV1().Snapshots("app").Get("snapshot-1", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedPhase, res.Status.Phase)
+			assert.Equal(t, test.expectedID, res.Status.SnapshotID)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/dataMover"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/transferpolicy"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	"time"
+)
+
+// applyBandwidthLimits sets the bandwidth limits of the transfer policy on the data mover before a transfer starts.
+// The limits are left unchanged if the policy cannot be read.
+func applyBandwidthLimits(source *transferpolicy.Source, dataMover *dataMover.DataMover, log logrus.FieldLogger) {
+	policy, err := source.Get()
+	if err != nil {
+		log.WithError(err).Warn("Failed to get the transfer policy, the bandwidth limits are left unchanged")
+		return
+	}
+	dataMover.SetBandwidthLimits(policy.BandwidthLimit, policy.RepositoryBandwidthLimits)
+}
+
+// timeToTransferWindow returns how long an upload waits for the next transfer window of the policy, or 0 if a window is
+// open. The upload waits if the policy cannot be read, so that a policy that is being fixed is not bypassed.
+func timeToTransferWindow(source *transferpolicy.Source, now time.Time, log logrus.FieldLogger) time.Duration {
+	policy, err := source.Get()
+	if err != nil {
+		log.WithError(err).Error("Failed to get the transfer policy")
+		return utils.ResyncPeriod
+	}
+	return policy.Windows.NextOpen(now).Sub(now)
+}
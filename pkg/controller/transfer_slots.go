@@ -0,0 +1,108 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	"k8s.io/utils/clock"
+	"sync"
+	"time"
+)
+
+// TransferSlots caps the number of uploads and downloads a data manager runs at the same time, across the upload and
+// download controllers. A transfer that finds no free slot does not block the worker that processes it, it is
+// requeued instead, and the slots are handed out in the order the transfers first asked for one, so that a transfer
+// is not starved by those that come after it. A nil TransferSlots does not cap the transfers.
+type TransferSlots struct {
+	clock clock.Clock
+	// waiters that have not asked for a slot for this long, because their CR was deleted or is processed by another
+	// node, give up their place in the line
+	waiterExpiration time.Duration
+
+	mutex   sync.Mutex
+	slots   int
+	holders map[string]bool
+	waiters []transferSlotWaiter
+}
+
+type transferSlotWaiter struct {
+	key      string
+	lastSeen time.Time
+}
+
+// NewTransferSlots returns TransferSlots that run at most slots transfers at the same time, or nil if slots is not
+// positive.
+func NewTransferSlots(slots int) *TransferSlots {
+	if slots <= 0 {
+		return nil
+	}
+	return &TransferSlots{
+		clock:            &clock.RealClock{},
+		waiterExpiration: 3 * utils.TransferSlotRetryPeriod,
+		slots:            slots,
+		holders:          make(map[string]bool),
+	}
+}
+
+// tryAcquire takes a slot for the transfer with the given key if one is free and no transfer that asked before it
+// still waits. Otherwise the transfer is put in line, and is expected to ask again.
+func (this *TransferSlots) tryAcquire(key string) bool {
+	if this == nil {
+		return true
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.holders[key] {
+		return true
+	}
+
+	now := this.clock.Now()
+	position := -1
+	waiters := this.waiters[:0]
+	for _, waiter := range this.waiters {
+		if waiter.key == key {
+			waiter.lastSeen = now
+			position = len(waiters)
+		} else if now.Sub(waiter.lastSeen) > this.waiterExpiration {
+			continue
+		}
+		waiters = append(waiters, waiter)
+	}
+	if position < 0 {
+		position = len(waiters)
+		waiters = append(waiters, transferSlotWaiter{key: key, lastSeen: now})
+	}
+	this.waiters = waiters
+
+	if position >= this.slots-len(this.holders) {
+		return false
+	}
+	this.waiters = append(this.waiters[:position], this.waiters[position+1:]...)
+	this.holders[key] = true
+	return true
+}
+
+// release frees the slot of the transfer with the given key.
+func (this *TransferSlots) release(key string) {
+	if this == nil {
+		return
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	delete(this.holders, key)
+}
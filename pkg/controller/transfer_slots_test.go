@@ -0,0 +1,72 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/clock"
+	"testing"
+	"time"
+)
+
+// steppedClock is a clock whose time only changes when it is stepped.
+type steppedClock struct {
+	clock.RealClock
+	now time.Time
+}
+
+func (c *steppedClock) Now() time.Time {
+	return c.now
+}
+
+func (c *steppedClock) Step(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestTransferSlots(t *testing.T) {
+	fakeClock := &steppedClock{now: time.Now()}
+	slots := NewTransferSlots(2)
+	slots.clock = fakeClock
+
+	assert.True(t, slots.tryAcquire("upload/velero/upload-1"))
+	assert.True(t, slots.tryAcquire("download/velero/download-1"))
+	// A transfer that holds a slot keeps it.
+	assert.True(t, slots.tryAcquire("upload/velero/upload-1"))
+
+	// The transfers that find no free slot wait in line.
+	assert.False(t, slots.tryAcquire("upload/velero/upload-2"))
+	assert.False(t, slots.tryAcquire("download/velero/download-2"))
+
+	// The slot that is freed goes to the first in line, even if the second one asks first.
+	slots.release("upload/velero/upload-1")
+	assert.False(t, slots.tryAcquire("download/velero/download-2"))
+	assert.True(t, slots.tryAcquire("upload/velero/upload-2"))
+
+	// A waiter that stops asking gives up its place in line.
+	assert.False(t, slots.tryAcquire("upload/velero/upload-3"))
+	fakeClock.Step(slots.waiterExpiration / 2)
+	assert.False(t, slots.tryAcquire("upload/velero/upload-3"))
+	fakeClock.Step(slots.waiterExpiration)
+	slots.release("upload/velero/upload-2")
+	assert.True(t, slots.tryAcquire("upload/velero/upload-3"))
+
+	// A nil TransferSlots does not cap the transfers.
+	assert.Nil(t, NewTransferSlots(0))
+	var unlimited *TransferSlots
+	assert.True(t, unlimited.tryAcquire("upload/velero/upload-1"))
+	unlimited.release("upload/velero/upload-1")
+}
@@ -23,18 +23,25 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
 	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/dataMover"
 	pluginv1client "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/typed/veleroplugin/v1"
 	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions/veleroplugin/v1"
 	listers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/listers/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/metrics"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/snapshotmgr"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/transferpolicy"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	velerov1api "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/clock"
 	"math"
 	"time"
@@ -48,6 +55,11 @@ type uploadController struct {
 	uploadLister      listers.UploadLister
 	nodeName          string
 	dataMover         *dataMover.DataMover
+	transferStreams   int
+	transferPolicy    *transferpolicy.Source
+	transferSlots     *TransferSlots
+	metrics           *metrics.ServerMetrics
+	events            *transferEventRecorder
 	snapMgr           *snapshotmgr.SnapshotManager
 	clock             clock.Clock
 	processUploadFunc func(*pluginv1api.Upload) error
@@ -61,6 +73,11 @@ func NewUploadController(
 	dataMover *dataMover.DataMover,
 	snapMgr *snapshotmgr.SnapshotManager,
 	nodeName string,
+	transferStreams int,
+	transferPolicy *transferpolicy.Source,
+	transferSlots *TransferSlots,
+	serverMetrics *metrics.ServerMetrics,
+	eventRecorder record.EventRecorder,
 ) Interface {
 	c := &uploadController{
 		genericController: newGenericController("upload", logger),
@@ -69,6 +86,11 @@ func NewUploadController(
 		uploadLister:      uploadInformer.Lister(),
 		nodeName:          nodeName,
 		dataMover:         dataMover,
+		transferStreams:   transferStreams,
+		transferPolicy:    transferPolicy,
+		transferSlots:     transferSlots,
+		metrics:           serverMetrics,
+		events:            newTransferEventRecorder(eventRecorder, kubeClient, logger),
 		snapMgr:           snapMgr,
 		clock:             &clock.RealClock{},
 	}
@@ -200,6 +222,22 @@ func (c *uploadController) processUploadItem(key string) error {
 		return nil
 	}
 
+	// Uploads are only started in the transfer windows, outside of them the Upload waits in its current phase.
+	if wait := timeToTransferWindow(c.transferPolicy, c.clock.Now(), log); wait > 0 {
+		c.waitForTransferWindow(req, wait)
+		c.queue.AddAfter(key, wait)
+		return nil
+	}
+
+	// The worker is not held while the Upload waits for a transfer slot, so that the other Uploads are processed.
+	slotKey := "upload/" + key
+	if !c.transferSlots.tryAcquire(slotKey) {
+		log.Infof("All the transfer slots of node %s are taken, the upload waits for one", c.nodeName)
+		c.queue.AddAfter(key, utils.TransferSlotRetryPeriod)
+		return nil
+	}
+	defer c.transferSlots.release(slotKey)
+
 	leaseLockName := "upload-lease." + name
 	// Acquire lease for processing Upload.
 	lock := &resourcelock.LeaseLock{
@@ -304,11 +342,64 @@ func (c *uploadController) processUpload(req *pluginv1api.Upload) error {
 		return errors.New(errMsg)
 	}
 
-	_, err = c.dataMover.CopyToRepo(peID)
+	// Record the encryption key before uploading, so that a retry encrypts the snapshot with the same key
+	if req.Status.EncryptionKeyID == "" {
+		encryptionKeyID, err := c.dataMover.CurrentEncryptionKeyID()
+		if err != nil {
+			errMsg := fmt.Sprintf("Failed to get the encryption key for snapshot, %v. %v", peID.String(), errors.WithStack(err))
+			_, err = c.patchUploadByStatus(req, pluginv1api.UploadPhaseUploadError, errMsg)
+			if err != nil {
+				errMsg = fmt.Sprintf("%v. %v", errMsg, errors.WithStack(err))
+			}
+			log.Error(errMsg)
+			return errors.New(errMsg)
+		}
+		if encryptionKeyID != "" {
+			req, err = c.patchUpload(req, func(r *pluginv1api.Upload) {
+				r.Status.EncryptionKeyID = encryptionKeyID
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			log.Infof("The snapshot will be encrypted with key %s", encryptionKeyID)
+		}
+	}
+
+	if req.Status.Checkpoint != nil {
+		log.Infof("The upload was interrupted after %d bytes were committed, resuming it", req.Status.Checkpoint.CommittedBytes)
+	}
+	// The checkpoints are recorded as the upload goes, so that the node that picks up the Upload if this one dies
+	// knows where it resumes from.
+	checkpointed := func(committedBytes int64) {
+		updated, err := c.patchUpload(req, func(r *pluginv1api.Upload) {
+			r.Status.Checkpoint = &pluginv1api.UploadCheckpoint{
+				CommittedBytes: committedBytes,
+				Timestamp:      &metav1.Time{Time: c.clock.Now()},
+			}
+		})
+		if err != nil {
+			log.WithError(err).Warnf("Failed to record the checkpoint of the upload at %d bytes", committedBytes)
+			return
+		}
+		req = updated
+	}
+	streams := c.transferStreams
+	if req.Spec.TransferStreams > 0 {
+		streams = int(req.Spec.TransferStreams)
+	}
+	applyBandwidthLimits(c.transferPolicy, c.dataMover, log)
+	repository := backuprepository.NewReference(req.Spec.BackupRepository, req.Spec.BackupStorageLocation, req.Spec.RepositoryParameters)
+	progress := c.newUploadProgressReporter(req)
+	progress.start()
+	c.metrics.RegisterTransferAttempt(metrics.Upload, c.nodeName, repository.String())
+	startTime := c.clock.Now()
+	_, err = c.dataMover.CopyToRepo(peID, repository, snapshotOriginOfUpload(req), req.Status.EncryptionKeyID, streams, progress.update, checkpointed)
+	progress.stop()
 	if err != nil {
 		log.Infof("CopyToRepo Error Received: %v", err.Error())
 		// Check if the request was canceled.
 		if errors.Is(err, context.Canceled) {
+			c.metrics.RegisterTransferCancel(metrics.Upload, c.nodeName, repository.String(), progress.bytesTransferred())
 			log.Infof("The upload of PE %v upload was canceled.", peID.String())
 			_, err = c.patchUploadByStatus(req, pluginv1api.UploadPhaseCanceled, "The upload was canceled.")
 			if err != nil {
@@ -317,6 +408,7 @@ func (c *uploadController) processUpload(req *pluginv1api.Upload) error {
 			log.Infof("Upload Cancellation complete.")
 			return nil
 		} else {
+			c.metrics.RegisterTransferFailure(metrics.Upload, c.nodeName, repository.String(), progress.bytesTransferred())
 			errMsg := fmt.Sprintf("Failed to upload snapshot, %v, to durable object storage. %v", peID.String(), errors.WithStack(err))
 			_, err = c.patchUploadByStatus(req, pluginv1api.UploadPhaseUploadError, errMsg)
 			if err != nil {
@@ -327,6 +419,23 @@ func (c *uploadController) processUpload(req *pluginv1api.Upload) error {
 		}
 	}
 
+	c.metrics.RegisterTransferSuccess(metrics.Upload, c.nodeName, repository.String(), progress.bytesTransferred(), c.clock.Since(startTime))
+
+	// The checksum is recorded so that the verifications of the snapshot can be traced back to the upload.
+	checksum, err := c.dataMover.SnapshotChecksum(peID, repository)
+	if err != nil {
+		log.WithError(err).Warn("Failed to get the checksum of the snapshot in the remote repository")
+	} else if checksum != "" {
+		updated, err := c.patchUpload(req, func(r *pluginv1api.Upload) {
+			r.Status.Checksum = checksum
+		})
+		if err != nil {
+			log.WithError(err).Warnf("Failed to record checksum %s of the snapshot", checksum)
+		} else {
+			req = updated
+		}
+	}
+
 	// Unregister on-going upload
 	c.dataMover.UnregisterOngoingUpload(peID)
 
@@ -362,6 +471,35 @@ func (c *uploadController) patchUpload(req *pluginv1api.Upload, mutate func(*plu
 	return utils.PatchUpload(req, mutate, c.uploadClient.Uploads(req.Namespace), log)
 }
 
+// waitForTransferWindow records on the Upload that it waits for the next transfer window.
+func (c *uploadController) waitForTransferWindow(req *pluginv1api.Upload, wait time.Duration) {
+	log := loggerForUpload(c.logger, req)
+	msg := fmt.Sprintf("Waiting for the next transfer window, which opens at %s", c.clock.Now().Add(wait).Format(time.RFC3339))
+	log.Infof("Outside of the transfer windows. %s", msg)
+	if req.Status.Message == msg {
+		return
+	}
+	_, err := c.patchUpload(req, func(r *pluginv1api.Upload) {
+		r.Status.Message = msg
+	})
+	if err != nil {
+		log.WithError(err).Warn("Failed to record on the Upload that it waits for the next transfer window")
+	}
+}
+
+// newUploadProgressReporter returns a progressReporter that patches the progress of the Upload.
+func (c *uploadController) newUploadProgressReporter(req *pluginv1api.Upload) *progressReporter {
+	// The progress is patched concurrently with the processing of the Upload, so it is patched from its own copy.
+	reqCopy := req.DeepCopy()
+	return newProgressReporter(c.clock, utils.ProgressUpdateInterval, func(bytesDone int64, totalBytes int64) error {
+		_, err := c.patchUpload(reqCopy, func(r *pluginv1api.Upload) {
+			r.Status.Progress.BytesDone = bytesDone
+			r.Status.Progress.TotalBytes = totalBytes
+		})
+		return err
+	}, loggerForUpload(c.logger, req))
+}
+
 func (c *uploadController) patchUploadByStatus(req *pluginv1api.Upload, newPhase pluginv1api.UploadPhase, msg string) (*pluginv1api.Upload, error) {
 	// update status to Failed
 	log := loggerForUpload(c.logger, req)
@@ -375,6 +513,10 @@ func (c *uploadController) patchUploadByStatus(req *pluginv1api.Upload, newPhase
 			r.Status.Phase = newPhase
 			r.Status.CompletionTimestamp = &metav1.Time{Time: c.clock.Now()}
 			r.Status.Message = msg
+			r.Status.Checkpoint = nil
+			c.setUploadCondition(r, pluginv1api.ConditionTransferring, pluginv1api.ConditionFalse, "UploadCompleted", "The snapshot is uploaded")
+			c.setUploadCondition(r, pluginv1api.ConditionLocalSnapshotCleanedUp, pluginv1api.ConditionTrue, "LocalSnapshotDeleted", "The local snapshot is deleted")
+			c.releaseUploadConditions(r, "UploadCompleted")
 		})
 	case pluginv1api.UploadPhaseUploadError:
 		var retry int32
@@ -390,7 +532,15 @@ func (c *uploadController) patchUploadByStatus(req *pluginv1api.Upload, newPhase
 			}
 			r.Status.CurrentBackOff = int32(currentBackOff)
 			r.Status.NextRetryTimestamp = &metav1.Time{Time: c.clock.Now().Add(time.Duration(currentBackOff) * time.Minute)}
+			c.setUploadCondition(r, pluginv1api.ConditionTransferring, pluginv1api.ConditionFalse, "UploadFailed", msg)
+			c.setUploadCondition(r, pluginv1api.ConditionLeaseAcquired, pluginv1api.ConditionFalse, "UploadFailed", fmt.Sprintf("Node %s stopped processing the Upload", c.nodeName))
+			c.setUploadCondition(r, pluginv1api.ConditionRetryScheduled, pluginv1api.ConditionTrue, "BackOff",
+				fmt.Sprintf("The upload is retried after %s, at %s", time.Duration(currentBackOff)*time.Minute, r.Status.NextRetryTimestamp.Format(time.RFC3339)))
 		})
+		if err == nil {
+			repository := backuprepository.NewReference(req.Spec.BackupRepository, req.Spec.BackupStorageLocation, req.Spec.RepositoryParameters)
+			c.metrics.RegisterTransferRetry(metrics.Upload, c.nodeName, repository.String(), time.Duration(req.Status.CurrentBackOff)*time.Minute)
+		}
 		if retry > utils.RETRY_WARNING_COUNT {
 			errMsg := fmt.Sprintf("Please fix the network issue on the work node, %s", c.nodeName)
 			log.Warningf(errMsg)
@@ -400,6 +550,9 @@ func (c *uploadController) patchUploadByStatus(req *pluginv1api.Upload, newPhase
 			r.Status.Phase = newPhase
 			r.Status.CompletionTimestamp = &metav1.Time{Time: c.clock.Now()}
 			r.Status.Message = msg
+			c.setUploadCondition(r, pluginv1api.ConditionTransferring, pluginv1api.ConditionFalse, "UploadCompleted", "The snapshot is uploaded")
+			c.setUploadCondition(r, pluginv1api.ConditionLocalSnapshotCleanedUp, pluginv1api.ConditionFalse, "LocalSnapshotCleanupFailed", msg)
+			c.releaseUploadConditions(r, "LocalSnapshotCleanupFailed")
 		})
 	case pluginv1api.UploadPhaseInProgress:
 		req, err = c.patchUpload(req, func(r *pluginv1api.Upload) {
@@ -409,12 +562,19 @@ func (c *uploadController) patchUploadByStatus(req *pluginv1api.Upload, newPhase
 			}
 			r.Status.Phase = newPhase
 			r.Status.ProcessingNode = c.nodeName
+			c.setUploadCondition(r, pluginv1api.ConditionLeaseAcquired, pluginv1api.ConditionTrue, "LeaseAcquired", fmt.Sprintf("Node %s holds the lease of the Upload", c.nodeName))
+			c.setUploadCondition(r, pluginv1api.ConditionTransferring, pluginv1api.ConditionTrue, "Uploading", fmt.Sprintf("Node %s is uploading the snapshot", c.nodeName))
+			if findCondition(r.Status.Conditions, pluginv1api.ConditionRetryScheduled) != nil {
+				c.setUploadCondition(r, pluginv1api.ConditionRetryScheduled, pluginv1api.ConditionFalse, "Retrying", "The upload is being retried")
+			}
 		})
 	case pluginv1api.UploadPhaseCanceled:
 		req, err = c.patchUpload(req, func(r *pluginv1api.Upload) {
 			r.Status.Phase = newPhase
 			r.Status.CompletionTimestamp = &metav1.Time{Time: c.clock.Now()}
 			r.Status.Message = msg
+			c.setUploadCondition(r, pluginv1api.ConditionTransferring, pluginv1api.ConditionFalse, "UploadCanceled", msg)
+			c.releaseUploadConditions(r, "UploadCanceled")
 		})
 	case pluginv1api.UploadPhaseCanceling:
 		req, err = c.patchUpload(req, func(r *pluginv1api.Upload) {
@@ -430,11 +590,65 @@ func (c *uploadController) patchUploadByStatus(req *pluginv1api.Upload, newPhase
 		log.WithError(err).Errorf("Failed to patch Upload from %v to %v", oldPhase, newPhase)
 	} else {
 		log.Infof("Upload status updated from %v to %v", oldPhase, newPhase)
+		c.recordUploadEvent(req, newPhase, msg)
 	}
 
 	return req, err
 }
 
+// snapshotOriginOfUpload returns the origin the snapshot of the Upload is indexed with in the remote repository.
+func snapshotOriginOfUpload(req *pluginv1api.Upload) objectstore.SnapshotOrigin {
+	origin := objectstore.SnapshotOrigin{
+		Backup: req.Labels[velerov1api.BackupNameLabel],
+	}
+	if req.Spec.BackupTimestamp != nil {
+		origin.Timestamp = req.Spec.BackupTimestamp.Time
+	}
+	return origin
+}
+
+// setUploadCondition sets the condition of the given type on the Upload.
+func (c *uploadController) setUploadCondition(r *pluginv1api.Upload, conditionType string, status pluginv1api.ConditionStatus, reason string, message string) {
+	r.Status.Conditions = setCondition(r.Status.Conditions, pluginv1api.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: r.Generation,
+		Reason:             reason,
+		Message:            message,
+	}, c.clock.Now())
+}
+
+// releaseUploadConditions clears the conditions of an Upload that is done being processed.
+func (c *uploadController) releaseUploadConditions(r *pluginv1api.Upload, reason string) {
+	c.setUploadCondition(r, pluginv1api.ConditionLeaseAcquired, pluginv1api.ConditionFalse, reason, fmt.Sprintf("Node %s is done processing the Upload", c.nodeName))
+	if findCondition(r.Status.Conditions, pluginv1api.ConditionRetryScheduled) != nil {
+		c.setUploadCondition(r, pluginv1api.ConditionRetryScheduled, pluginv1api.ConditionFalse, reason, "The upload is not retried anymore")
+	}
+}
+
+// recordUploadEvent emits the Event of the transition of the Upload to newPhase.
+func (c *uploadController) recordUploadEvent(req *pluginv1api.Upload, newPhase pluginv1api.UploadPhase, msg string) {
+	volumeID := volumeIDOfSnapshot(req.Spec.SnapshotID)
+	switch newPhase {
+	case pluginv1api.UploadPhaseInProgress:
+		c.events.event(req, volumeID, corev1.EventTypeNormal, "UploadStarted", "Uploading snapshot %s on node %s", req.Spec.SnapshotID, c.nodeName)
+	case pluginv1api.UploadPhaseUploadError:
+		retryTime := ""
+		if req.Status.NextRetryTimestamp != nil {
+			retryTime = req.Status.NextRetryTimestamp.Format(time.RFC3339)
+		}
+		c.events.event(req, volumeID, corev1.EventTypeWarning, "UploadFailed", "Upload of snapshot %s failed on node %s, it will be retried at %s: %s", req.Spec.SnapshotID, c.nodeName, retryTime, msg)
+	case pluginv1api.UploadPhaseCompleted:
+		c.events.event(req, volumeID, corev1.EventTypeNormal, "UploadCompleted", "Uploaded snapshot %s on node %s", req.Spec.SnapshotID, c.nodeName)
+	case pluginv1api.UploadPhaseCleanupFailed:
+		c.events.event(req, volumeID, corev1.EventTypeWarning, "LocalSnapshotCleanupFailed", "Uploaded snapshot %s on node %s, but failed to delete the local snapshot: %s", req.Spec.SnapshotID, c.nodeName, msg)
+	case pluginv1api.UploadPhaseCanceling:
+		c.events.event(req, volumeID, corev1.EventTypeNormal, "UploadCanceling", "Canceling the upload of snapshot %s on node %s", req.Spec.SnapshotID, c.nodeName)
+	case pluginv1api.UploadPhaseCanceled:
+		c.events.event(req, volumeID, corev1.EventTypeNormal, "UploadCanceled", "The upload of snapshot %s was canceled on node %s", req.Spec.SnapshotID, c.nodeName)
+	}
+}
+
 func loggerForUpload(baseLogger logrus.FieldLogger, req *pluginv1api.Upload) logrus.FieldLogger {
 	log := baseLogger.WithFields(logrus.Fields{
 		"namespace":  req.Namespace,
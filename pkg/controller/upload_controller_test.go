@@ -24,10 +24,12 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
 	v1 "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/builder"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/dataMover"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/fake"
 	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/snapshotmgr"
 	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
@@ -154,22 +156,28 @@ func TestProcessedUploadItem(t *testing.T) {
 			}
 			require.NoError(t, sharedInformers.Veleroplugin().V1().Uploads().Informer().GetStore().Add(test.upload))
 			if test.cleanupFail {
-				patches := gomonkey.ApplyMethod(reflect.TypeOf(c.dataMover), "CopyToRepo", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID) (astrolabe.ProtectedEntityID, error) {
+				patches := gomonkey.ApplyMethod(reflect.TypeOf(c.dataMover), "CopyToRepo", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID, _ backuprepository.Reference, _ objectstore.SnapshotOrigin, _ string, _ int, _ dataMover.ProgressFunc, _ dataMover.CheckpointFunc) (astrolabe.ProtectedEntityID, error) {
 					return astrolabe.ProtectedEntityID{}, nil
 				})
 				defer patches.Reset()
 				patches.ApplyMethod(reflect.TypeOf(c.dataMover), "UnregisterOngoingUpload", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID) () {
 				})
+				patches.ApplyMethod(reflect.TypeOf(c.dataMover), "SnapshotChecksum", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID, _ backuprepository.Reference) (string, error) {
+					return "sha256:checksum", nil
+				})
 				defer patches.Reset()
 				patches.ApplyMethod(reflect.TypeOf(c.snapMgr), "DeleteLocalSnapshot", func(_ *snapshotmgr.SnapshotManager, _ astrolabe.ProtectedEntityID) error {
 					return test.expectedErr
 				})
 			} else {
-				patches := gomonkey.ApplyMethod(reflect.TypeOf(c.dataMover), "CopyToRepo", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID) (astrolabe.ProtectedEntityID, error) {
+				patches := gomonkey.ApplyMethod(reflect.TypeOf(c.dataMover), "CopyToRepo", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID, _ backuprepository.Reference, _ objectstore.SnapshotOrigin, _ string, _ int, _ dataMover.ProgressFunc, _ dataMover.CheckpointFunc) (astrolabe.ProtectedEntityID, error) {
 					return astrolabe.ProtectedEntityID{}, test.expectedErr
 				})
 				patches.ApplyMethod(reflect.TypeOf(c.dataMover), "UnregisterOngoingUpload", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID) () {
 				})
+				patches.ApplyMethod(reflect.TypeOf(c.dataMover), "SnapshotChecksum", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID, _ backuprepository.Reference) (string, error) {
+					return "sha256:checksum", nil
+				})
 				defer patches.Reset()
 				// UploadPhaseCompleted case
 				if test.expectedErr == nil {
@@ -420,7 +428,7 @@ func TestUploadErrorRetry(t *testing.T) {
 
 			// First time set Inprogress to UploadError
 			require.NoError(t, sharedInformers.Veleroplugin().V1().Uploads().Informer().GetStore().Add(test.upload))
-			patches := gomonkey.ApplyMethod(reflect.TypeOf(c.dataMover), "CopyToRepo", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID) (astrolabe.ProtectedEntityID, error) {
+			patches := gomonkey.ApplyMethod(reflect.TypeOf(c.dataMover), "CopyToRepo", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID, _ backuprepository.Reference, _ objectstore.SnapshotOrigin, _ string, _ int, _ dataMover.ProgressFunc, _ dataMover.CheckpointFunc) (astrolabe.ProtectedEntityID, error) {
 				return astrolabe.ProtectedEntityID{}, errors.New("Failed at copying to remote repository")
 			})
 			defer patches.Reset()
@@ -430,12 +438,15 @@ func TestUploadErrorRetry(t *testing.T) {
 
 			// Retry for second time, set to completed at this time
 			require.NoError(t, sharedInformers.Veleroplugin().V1().Uploads().Informer().GetStore().Add(test.upload))
-			patches.ApplyMethod(reflect.TypeOf(c.dataMover), "CopyToRepo", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID) (astrolabe.ProtectedEntityID, error) {
+			patches.ApplyMethod(reflect.TypeOf(c.dataMover), "CopyToRepo", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID, _ backuprepository.Reference, _ objectstore.SnapshotOrigin, _ string, _ int, _ dataMover.ProgressFunc, _ dataMover.CheckpointFunc) (astrolabe.ProtectedEntityID, error) {
 				return astrolabe.ProtectedEntityID{}, nil
 			})
 
 			patches.ApplyMethod(reflect.TypeOf(c.dataMover), "UnregisterOngoingUpload", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID) () {
 			})
+			patches.ApplyMethod(reflect.TypeOf(c.dataMover), "SnapshotChecksum", func(_ *dataMover.DataMover, _ astrolabe.ProtectedEntityID, _ backuprepository.Reference) (string, error) {
+				return "sha256:checksum", nil
+			})
 
 			patches.ApplyMethod(reflect.TypeOf(c.snapMgr), "DeleteLocalSnapshot", func(_ *snapshotmgr.SnapshotManager, _ astrolabe.ProtectedEntityID) error {
 				return nil
@@ -446,6 +457,7 @@ func TestUploadErrorRetry(t *testing.T) {
 			res, err := c.uploadClient.Uploads(test.upload.Namespace).Get(test.upload.Name, metav1.GetOptions{})
 			require.Nil(t, err)
 			require.Equal(t, test.expectedPhase, res.Status.Phase)
+			require.Equal(t, "sha256:checksum", res.Status.Checksum)
 			require.Equal(t, test.retry + 1, res.Status.RetryCount)
 		})
 	}
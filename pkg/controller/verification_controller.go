@@ -0,0 +1,198 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/dataMover"
+	pluginv1client "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/typed/veleroplugin/v1"
+	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions/veleroplugin/v1"
+	listers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/listers/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	"time"
+)
+
+// verificationLease is held by the node that verifies the remote repositories, so that the data managers on the other
+// nodes do not read the same snapshots back at the same time.
+const verificationLease = "verification-lease"
+
+// verificationController periodically reads the snapshots in the remote repositories that snapshots were uploaded to
+// back, and compares them against the checksums recorded when they were uploaded. The result is recorded in the
+// Verified condition of the Uploads of the snapshots, and the snapshots that fail the verification are reported with
+// Events.
+type verificationController struct {
+	*genericController
+
+	kubeClient           kubernetes.Interface
+	uploadClient         pluginv1client.UploadsGetter
+	uploadLister         listers.UploadLister
+	namespace            string
+	nodeName             string
+	transferStreams      int
+	verifyRepositoryFunc func(backuprepository.Reference, int) ([]objectstore.SnapshotVerification, error)
+	events               *transferEventRecorder
+	clock                clock.Clock
+}
+
+func NewVerificationController(
+	logger logrus.FieldLogger,
+	uploadInformer informers.UploadInformer,
+	pluginClient pluginv1client.VeleropluginV1Interface,
+	kubeClient kubernetes.Interface,
+	dataMover *dataMover.DataMover,
+	namespace string,
+	nodeName string,
+	frequency time.Duration,
+	transferStreams int,
+	eventRecorder record.EventRecorder,
+) Interface {
+	c := &verificationController{
+		genericController:    newGenericController("verification", logger),
+		kubeClient:           kubeClient,
+		uploadClient:         pluginClient,
+		uploadLister:         uploadInformer.Lister(),
+		namespace:            namespace,
+		nodeName:             nodeName,
+		transferStreams:      transferStreams,
+		verifyRepositoryFunc: dataMover.VerifyRepository,
+		events:               newTransferEventRecorder(eventRecorder, kubeClient, logger),
+		clock:                &clock.RealClock{},
+	}
+
+	c.resyncFunc = c.run
+	c.resyncPeriod = frequency
+	c.cacheSyncWaiters = append(
+		c.cacheSyncWaiters,
+		uploadInformer.Informer().HasSynced,
+	)
+
+	return c
+}
+
+func (c *verificationController) run() {
+	repositories, err := repositoriesOfUploads(c.uploadLister, c.namespace)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to list the repositories to verify")
+		return
+	}
+	if len(repositories) == 0 {
+		return
+	}
+
+	err = runWithLease(c.kubeClient, c.namespace, verificationLease, c.nodeName, c.logger, func() error {
+		return c.verify(repositories)
+	})
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to verify the remote repositories")
+	}
+}
+
+func (c *verificationController) verify(repositories []backuprepository.Reference) error {
+	var failed int
+	for _, repository := range repositories {
+		if err := c.verifyRepository(repository); err != nil {
+			c.logger.WithError(err).WithField("repository", repository.String()).Error("Failed to verify the repository")
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("Failed to verify %d of %d repositories", failed, len(repositories))
+	}
+	return nil
+}
+
+func (c *verificationController) verifyRepository(repository backuprepository.Reference) error {
+	log := c.logger.WithField("repository", repository.String())
+	verifications, err := c.verifyRepositoryFunc(repository, c.transferStreams)
+	if err != nil {
+		return err
+	}
+
+	uploads, err := c.uploadLister.Uploads(c.namespace).List(labels.Everything())
+	if err != nil {
+		return errors.Wrap(err, "Failed to list Uploads")
+	}
+	uploadsOfSnapshots := make(map[string][]*pluginv1api.Upload)
+	for _, upload := range uploads {
+		if upload.Status.Phase != pluginv1api.UploadPhaseCompleted && upload.Status.Phase != pluginv1api.UploadPhaseCleanupFailed {
+			continue
+		}
+		if backuprepository.NewReference(upload.Spec.BackupRepository, upload.Spec.BackupStorageLocation, upload.Spec.RepositoryParameters).String() != repository.String() {
+			continue
+		}
+		uploadsOfSnapshots[upload.Spec.SnapshotID] = append(uploadsOfSnapshots[upload.Spec.SnapshotID], upload)
+	}
+
+	var failed int
+	for _, verification := range verifications {
+		if !verification.Verified() {
+			failed++
+		}
+		for _, upload := range uploadsOfSnapshots[verification.ID.String()] {
+			c.recordVerification(upload, verification)
+		}
+	}
+	log.Infof("Verification report: %d snapshots are verified, %d failed the verification", len(verifications)-failed, failed)
+	return nil
+}
+
+// recordVerification records the result of the verification of the snapshot of the Upload in its status, and emits an
+// Event if the snapshot failed the verification or passes it again.
+func (c *verificationController) recordVerification(upload *pluginv1api.Upload, verification objectstore.SnapshotVerification) {
+	log := loggerForUpload(c.logger, upload)
+	previous := findCondition(upload.Status.Conditions, pluginv1api.ConditionVerified)
+	_, err := utils.PatchUpload(upload, func(r *pluginv1api.Upload) {
+		now := c.clock.Now()
+		r.Status.VerificationTimestamp = &metav1.Time{Time: now}
+		condition := pluginv1api.Condition{
+			Type:               pluginv1api.ConditionVerified,
+			Status:             pluginv1api.ConditionTrue,
+			ObservedGeneration: r.Generation,
+			Reason:             "ChecksumsMatched",
+			Message:            "The snapshot is read back from the remote repository and matches its checksums",
+		}
+		if !verification.Verified() {
+			condition.Status = pluginv1api.ConditionFalse
+			condition.Reason = "VerificationFailed"
+			condition.Message = verification.Err.Error()
+		}
+		r.Status.Conditions = setCondition(r.Status.Conditions, condition, now)
+	}, c.uploadClient.Uploads(upload.Namespace), log)
+	if err != nil {
+		log.WithError(err).Error("Failed to record the verification of the snapshot")
+	}
+
+	volumeID := volumeIDOfSnapshot(upload.Spec.SnapshotID)
+	switch {
+	case !verification.Verified():
+		c.events.event(upload, volumeID, corev1.EventTypeWarning, "VerificationFailed",
+			"Snapshot %s failed the verification: %v", upload.Spec.SnapshotID, verification.Err)
+	case previous != nil && previous.Status == pluginv1api.ConditionFalse:
+		c.events.event(upload, volumeID, corev1.EventTypeNormal, "Verified",
+			"Snapshot %s matches its checksums again", upload.Spec.SnapshotID)
+	}
+}
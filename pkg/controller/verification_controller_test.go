@@ -0,0 +1,112 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/fake"
+	informers "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/informers/externalversions"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"testing"
+	"time"
+)
+
+func TestVerifyRepository(t *testing.T) {
+	now := time.Now()
+	snapshotID := func(volume string) astrolabe.ProtectedEntityID {
+		return astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", volume, astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+	}
+	uploads := []*pluginv1api.Upload{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-verified"},
+			Spec:       pluginv1api.UploadSpec{SnapshotID: snapshotID("vol-1").String(), BackupStorageLocation: "default"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCompleted},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-corrupted"},
+			Spec:       pluginv1api.UploadSpec{SnapshotID: snapshotID("vol-2").String(), BackupStorageLocation: "default"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCompleted},
+		},
+		// The snapshot of an Upload to another repository is not the one verified.
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "upload-other-repository"},
+			Spec:       pluginv1api.UploadSpec{SnapshotID: snapshotID("vol-1").String(), BackupStorageLocation: "secondary"},
+			Status:     pluginv1api.UploadStatus{Phase: pluginv1api.UploadPhaseCompleted},
+		},
+	}
+
+	pluginClient := fake.NewSimpleClientset()
+	sharedInformers := informers.NewSharedInformerFactory(pluginClient, 0)
+	uploadInformer := sharedInformers.Veleroplugin().V1().Uploads()
+	for _, upload := range uploads {
+		_, err := pluginClient.VeleropluginV1().Uploads(upload.Namespace).Create(upload)
+		require.NoError(t, err)
+		require.NoError(t, uploadInformer.Informer().GetStore().Add(upload))
+	}
+	repository := backuprepository.NewReference("", "default", nil)
+	recorder := record.NewFakeRecorder(10)
+	logger := veleroplugintest.NewLogger()
+
+	c := &verificationController{
+		genericController: newGenericController("verification-test", logger),
+		uploadClient:      pluginClient.VeleropluginV1(),
+		uploadLister:      uploadInformer.Lister(),
+		namespace:         "velero",
+		transferStreams:   2,
+		verifyRepositoryFunc: func(r backuprepository.Reference, streams int) ([]objectstore.SnapshotVerification, error) {
+			assert.Equal(t, repository, r)
+			assert.Equal(t, 2, streams)
+			return []objectstore.SnapshotVerification{
+				{ID: snapshotID("vol-1"), Checksum: "sha256:1234", Size: 4096},
+				{ID: snapshotID("vol-2"), Err: errors.New("Chunk 5678 of ProtectedEntity ivd:vol-2:snap-1 is corrupted")},
+			}, nil
+		},
+		events: newTransferEventRecorder(recorder, kubefake.NewSimpleClientset(), logger),
+		clock:  &steppedClock{now: now},
+	}
+	require.NoError(t, c.verifyRepository(repository))
+
+	for name, status := range map[string]pluginv1api.ConditionStatus{
+		"upload-verified":         pluginv1api.ConditionTrue,
+		"upload-corrupted":        pluginv1api.ConditionFalse,
+		"upload-other-repository": "",
+	} {
+		upload, err := pluginClient.VeleropluginV1().Uploads("velero").Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		condition := findCondition(upload.Status.Conditions, pluginv1api.ConditionVerified)
+		if status == "" {
+			assert.Nil(t, condition, name)
+			assert.Nil(t, upload.Status.VerificationTimestamp, name)
+			continue
+		}
+		require.NotNil(t, condition, name)
+		assert.Equal(t, status, condition.Status, name)
+		require.NotNil(t, upload.Status.VerificationTimestamp, name)
+	}
+
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Warning VerificationFailed")
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataMover
+
+import (
+	"sync"
+
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"golang.org/x/time/rate"
+)
+
+// bandwidthBurst is the number of bytes a limited transfer may send at once. It does not change with the limits, so
+// that the limits can be changed while transfers are waiting on them.
+const bandwidthBurst = 256 * 1024
+
+// bandwidthLimiters are the token buckets the transfers of the DataMover share. The zero value is unlimited.
+type bandwidthLimiters struct {
+	mutex        sync.Mutex
+	global       *rate.Limiter
+	repositories map[string]*rate.Limiter
+}
+
+func newBandwidthLimiter(bytesPerSecond int64) *rate.Limiter {
+	return rate.NewLimiter(bandwidthLimit(bytesPerSecond), bandwidthBurst)
+}
+
+func bandwidthLimit(bytesPerSecond int64) rate.Limit {
+	if bytesPerSecond <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(bytesPerSecond)
+}
+
+// SetBandwidthLimits limits the bandwidth all the transfers of the DataMover share to global bytes per second, and
+// that the transfers to and from each repository, by its name as printed by backuprepository.Reference, share to the
+// bytes per second of the repository. A limit that is not positive is unlimited. The transfers in progress are
+// throttled by the new limits as well.
+func (this *DataMover) SetBandwidthLimits(global int64, repositories map[string]int64) {
+	this.bandwidth.mutex.Lock()
+	defer this.bandwidth.mutex.Unlock()
+
+	if this.bandwidth.global == nil {
+		this.bandwidth.global = newBandwidthLimiter(global)
+	} else {
+		this.bandwidth.global.SetLimit(bandwidthLimit(global))
+	}
+
+	if this.bandwidth.repositories == nil {
+		this.bandwidth.repositories = make(map[string]*rate.Limiter)
+	}
+	for name, limiter := range this.bandwidth.repositories {
+		// The limiter of a repository whose limit was removed is kept unlimited, as transfers may be using it.
+		limiter.SetLimit(bandwidthLimit(repositories[name]))
+	}
+	for name, bytesPerSecond := range repositories {
+		if _, ok := this.bandwidth.repositories[name]; !ok {
+			this.bandwidth.repositories[name] = newBandwidthLimiter(bytesPerSecond)
+		}
+	}
+}
+
+// bandwidthLimitersFor returns the limiters the transfers to and from the repository are throttled by.
+func (this *DataMover) bandwidthLimitersFor(repository backuprepository.Reference) []*rate.Limiter {
+	this.bandwidth.mutex.Lock()
+	defer this.bandwidth.mutex.Unlock()
+
+	var limiters []*rate.Limiter
+	if this.bandwidth.global != nil {
+		limiters = append(limiters, this.bandwidth.global)
+	}
+	if limiter, ok := this.bandwidth.repositories[repository.String()]; ok {
+		limiters = append(limiters, limiter)
+	}
+	return limiters
+}
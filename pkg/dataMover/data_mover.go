@@ -22,7 +22,12 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
 	"github.com/vmware-tanzu/astrolabe/pkg/ivd"
-	"github.com/vmware-tanzu/astrolabe/pkg/s3repository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/encryption"
+	pluginivd "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/ivd"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/migration"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/placement"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
 	"sync"
 )
@@ -30,11 +35,21 @@ import (
 type DataMover struct {
 	logrus.FieldLogger
 	ivdPETM             *ivd.IVDProtectedEntityTypeManager
-	s3PETM              *s3repository.ProtectedEntityTypeManager
+	repositories        *backuprepository.PETMCache
 	inProgressCancelMap *sync.Map
+	// keyProvider supplies the keys snapshots are encrypted with, snapshots are not encrypted if it is nil
+	keyProvider encryption.KeyProvider
+	// bandwidth throttles the transfers to and from the remote repositories
+	bandwidth bandwidthLimiters
+	// restoreMapping maps the datastores of the snapshots taken on another vCenter to those of this one
+	restoreMapping *migration.Source
+	// placer chooses the datastores of the restored volumes of StorageClasses
+	placer *placement.Placer
+	// vcParams are the parameters of the vCenter the changes of the IVDs are tracked with
+	vcParams map[string]interface{}
 }
 
-func NewDataMoverFromCluster(params map[string]interface{}, logger logrus.FieldLogger) (*DataMover, error) {
+func NewDataMoverFromCluster(params map[string]interface{}, keyProvider encryption.KeyProvider, logger logrus.FieldLogger) (*DataMover, error) {
 	// Retrieve VC configuration from the cluster only of it has not been passed by the caller
 	if _, ok := params[ivd.HostVcParamKey]; !ok {
 		err := utils.RetrieveVcConfigSecret(params, logger)
@@ -46,21 +61,10 @@ func NewDataMoverFromCluster(params map[string]interface{}, logger logrus.FieldL
 		logger.Infof("DataMover: vSphere VC credential is retrieved")
 	}
 
-	err := utils.RetrieveVSLFromVeleroBSLs(params, logger)
-	if err != nil {
-		logger.WithError(err).Errorf("Could not retrieve velero default backup location.")
-		return nil, err
-	}
-	logger.Infof("DataMover: Velero Backup Storage Location is retrieved, region=%v, bucket=%v",
-		params["region"], params["bucket"])
-
-	s3PETM, err := utils.GetS3PETMFromParamsMap(params, logger)
+	repositories, err := newRepositoriesFromCluster(params, logger)
 	if err != nil {
-		logger.WithError(err).Errorf("Failed to get s3PETM from params map, region=%v, bucket=%v",
-			params["region"], params["bucket"])
 		return nil, err
 	}
-	logger.Infof("DataMover: Get s3PETM from the params map")
 
 	ivdPETM, err := utils.GetIVDPETMFromParamsMap(params, logger)
 	if err != nil {
@@ -78,49 +82,193 @@ func NewDataMoverFromCluster(params map[string]interface{}, logger logrus.FieldL
 	dataMover := DataMover{
 		FieldLogger:         logger,
 		ivdPETM:             ivdPETM,
-		s3PETM:              s3PETM,
+		repositories:        repositories,
 		inProgressCancelMap: &syncMap,
+		keyProvider:         keyProvider,
+		placer:              placement.NewPlacer(params, logger),
+		vcParams:            params,
 	}
 
 	logger.Infof("DataMover is initialized")
 	return &dataMover, nil
 }
 
-func (this *DataMover) CopyToRepo(peID astrolabe.ProtectedEntityID) (astrolabe.ProtectedEntityID, error) {
-	log := this.WithField("Local PEID", peID.String())
+// NewRepositoryDataMoverFromCluster returns a DataMover that only accesses the remote repositories, e.g. to verify
+// them, without connecting to vSphere. It cannot copy snapshots to or from local volumes. The encrypted snapshots are
+// decrypted with the keys of keyProvider, which may be nil.
+func NewRepositoryDataMoverFromCluster(params map[string]interface{}, keyProvider encryption.KeyProvider, logger logrus.FieldLogger) (*DataMover, error) {
+	repositories, err := newRepositoriesFromCluster(params, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &DataMover{
+		FieldLogger:         logger,
+		repositories:        repositories,
+		inProgressCancelMap: &sync.Map{},
+		keyProvider:         keyProvider,
+	}, nil
+}
+
+// newRepositoriesFromCluster returns the cache of the remote repositories, whose default is the repository of the
+// default Velero Backup Storage Location.
+func newRepositoriesFromCluster(params map[string]interface{}, logger logrus.FieldLogger) (*backuprepository.PETMCache, error) {
+	err := utils.RetrieveVSLFromVeleroBSLs(params, logger)
+	if err != nil {
+		logger.WithError(err).Errorf("Could not retrieve velero default backup location.")
+		return nil, err
+	}
+	logger.Infof("DataMover: Velero Backup Storage Location is retrieved, provider=%v, bucket=%v",
+		utils.GetProviderFromParamsMap(params), params["bucket"])
+
+	err = utils.RetrieveCredentialsFromSecret(params, logger)
+	if err != nil {
+		logger.WithError(err).Errorf("Could not retrieve the credentials of the backup storage location.")
+		return nil, err
+	}
+
+	repositoryPETM, err := utils.GetRepositoryPETMFromParamsMap(params, logger)
+	if err != nil {
+		logger.WithError(err).Errorf("Failed to get the remote repository PETM from params map, provider=%v, bucket=%v",
+			utils.GetProviderFromParamsMap(params), params["bucket"])
+		return nil, err
+	}
+	logger.Infof("DataMover: Get the remote repository PETM from the params map")
+	return backuprepository.NewPETMCache(repositoryPETM, logger), nil
+}
+
+// CurrentEncryptionKeyID returns the ID of the key new snapshots are encrypted with, or "" if encryption is not
+// enabled.
+func (this *DataMover) CurrentEncryptionKeyID() (string, error) {
+	if this.keyProvider == nil {
+		return "", nil
+	}
+	return this.keyProvider.CurrentKeyID()
+}
+
+// CopyToRepo copies the local snapshot to the remote repository, where it is indexed with its origin. The snapshot is
+// encrypted with the key with the given ID unless the key ID is empty. The repository only uploads the data that
+// changed since the last snapshot of the volume it holds, and only reads the areas of the IVD that changed since then
+// if changed block tracking is enabled on it. CopyFromRepo rebuilds the full data from the chain of snapshots. The
+// data is uploaded with the given number of concurrent streams, or the default of the repository if it is not
+// positive. progress, if not nil, is called as the data of the local snapshot is read. The upload is throttled by the
+// bandwidth limits of the DataMover. If the repository checkpoints uploads, an upload of the snapshot that was
+// interrupted is resumed from its last checkpoint, and checkpointed, if not nil, is called when the upload is
+// checkpointed or resumed.
+func (this *DataMover) CopyToRepo(peID astrolabe.ProtectedEntityID, repository backuprepository.Reference, origin objectstore.SnapshotOrigin, encryptionKeyID string, streams int, progress ProgressFunc, checkpointed CheckpointFunc) (astrolabe.ProtectedEntityID, error) {
+	log := this.WithField("Local PEID", peID.String()).WithField("repository", repository.String())
 	log.Infof("Copying the snapshot from local to remote repository")
+	repositoryPETM, err := this.repositories.Get(repository)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to get PETM of the remote repository")
+		return astrolabe.ProtectedEntityID{}, err
+	}
+
 	ctx := context.Background()
 	updatedPE, err := this.ivdPETM.GetProtectedEntity(ctx, peID)
 	if err != nil {
 		log.WithError(err).Errorf("Failed to get ProtectedEntity")
 		return astrolabe.ProtectedEntityID{}, err
 	}
+	// The repository only reads the areas of the IVD that changed since the last snapshot of the volume it holds.
+	vsom, logout, err := pluginivd.Login(ctx, this.vcParams, this.FieldLogger)
+	if err != nil {
+		log.WithError(err).Warn("Failed to connect to vCenter to track the changes of the IVD, reading all its data")
+	} else {
+		defer logout()
+		updatedPE = pluginivd.NewChangeTrackingProtectedEntity(updatedPE, vsom)
+	}
+	updatedPE, err = newProgressProtectedEntity(ctx, updatedPE, progress)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to get ProtectedEntity")
+		return astrolabe.ProtectedEntityID{}, err
+	}
+
+	// The repository encrypts the data chunk by chunk, so that encrypted snapshots are still deduplicated, copied
+	// incrementally and resumed.
+	var key []byte
+	if encryptionKeyID != "" {
+		if this.keyProvider == nil {
+			err = errors.Errorf("Encryption key %s is requested, but no encryption key provider is configured", encryptionKeyID)
+			log.WithError(err).Errorf("Failed to encrypt ProtectedEntity")
+			return astrolabe.ProtectedEntityID{}, err
+		}
+		key, err = this.keyProvider.GetKey(encryptionKeyID)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to get encryption key %s", encryptionKeyID)
+			return astrolabe.ProtectedEntityID{}, err
+		}
+		log = log.WithField("encryptionKeyID", encryptionKeyID)
+	}
 
 	log.Infof("Registering a in-progress cancel function.")
+	ctx = objectstore.WithBandwidthLimiters(objectstore.WithTransferStreams(ctx, streams), this.bandwidthLimitersFor(repository)...)
+	ctx = objectstore.WithSnapshotOrigin(objectstore.WithEncryptionKey(ctx, encryptionKeyID, key), origin)
 	ctx, cancelFunc := context.WithCancel(ctx)
 	this.RegisterOngoingUpload(peID, cancelFunc)
 
-	log.Debugf("Ready to call s3 PETM copy API for local PE")
-	s3PE, err := this.s3PETM.Copy(ctx, updatedPE, astrolabe.AllocateNewObject)
-	log.Debugf("Return from the call of s3 PETM copy API for local PE")
+	log.Debugf("Ready to call remote repository PETM copy API for local PE")
+	var remotePE astrolabe.ProtectedEntity
+	if resumable, ok := repositoryPETM.(resumableRepository); ok {
+		remotePE, err = resumable.CopyWithCheckpoints(ctx, updatedPE, astrolabe.AllocateNewObject, objectstore.CheckpointFunc(checkpointed))
+	} else {
+		remotePE, err = repositoryPETM.Copy(ctx, updatedPE, astrolabe.AllocateNewObject)
+	}
+	log.Debugf("Return from the call of remote repository PETM copy API for local PE")
 	if err != nil {
 		log.WithError(err).Errorf("Failed at copying to remote repository")
 		return astrolabe.ProtectedEntityID{}, err
 	}
 
-	log.WithField("Remote s3PEID", s3PE.GetID().String()).Infof("Protected Entity was just copied from local to remote repository.")
-	return s3PE.GetID(), nil
+	log.WithField("Remote PEID", remotePE.GetID().String()).Infof("Protected Entity was just copied from local to remote repository.")
+	return remotePE.GetID(), nil
 }
 
-func (this *DataMover) CopyFromRepo(peID astrolabe.ProtectedEntityID) (astrolabe.ProtectedEntityID, error) {
-	log := this.WithField("Remote PEID", peID.String())
+// CopyFromRepo copies the snapshot in the remote repository to a new local volume. The data is downloaded with the given
+// number of concurrent streams, or the default of the repository if it is not positive, and is throttled by the
+// bandwidth limits of the DataMover. progress, if not nil, is called as the data of the snapshot is read from the
+// repository. The volume is created on the datastore with the given ID if it is not empty, e.g. as chosen by PlaceVolume.
+// Otherwise the snapshot may have been taken on another vCenter, whose datastores are mapped to those of this one by
+// the restore mapping of the DataMover.
+func (this *DataMover) CopyFromRepo(peID astrolabe.ProtectedEntityID, repository backuprepository.Reference, streams int, datastore string, progress ProgressFunc) (astrolabe.ProtectedEntityID, error) {
+	log := this.WithField("Remote PEID", peID.String()).WithField("repository", repository.String())
 	log.Infof("Copying the snapshot from remote repository to local.")
-	ctx := context.Background()
-	pe, err := this.s3PETM.GetProtectedEntity(ctx, peID)
+	repositoryPETM, err := this.repositories.Get(repository)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to get PETM of the remote repository")
+		return astrolabe.ProtectedEntityID{}, err
+	}
+
+	// The key ID is stored with the encrypted data, so an encrypted snapshot is decrypted with the key it was
+	// encrypted with whether or not encryption is still enabled.
+	ctx := objectstore.WithBandwidthLimiters(objectstore.WithTransferStreams(context.Background(), streams), this.bandwidthLimitersFor(repository)...)
+	ctx = objectstore.WithKeyProvider(ctx, this.keyProvider)
+	pe, err := repositoryPETM.GetProtectedEntity(ctx, peID)
 	if err != nil {
 		log.WithError(err).Errorf("Failed to get ProtectedEntity from remote PEID")
 		return astrolabe.ProtectedEntityID{}, err
 	}
+	if encryptionKeyID, err := this.checkEncryptionKey(ctx, pe); err != nil {
+		log.WithError(err).Errorf("Failed to decrypt ProtectedEntity from remote PEID")
+		return astrolabe.ProtectedEntityID{}, err
+	} else if encryptionKeyID != "" {
+		log = log.WithField("encryptionKeyID", encryptionKeyID)
+	}
+	pe, err = newProgressProtectedEntity(ctx, pe, progress)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to get ProtectedEntity from remote PEID")
+		return astrolabe.ProtectedEntityID{}, err
+	}
+	mapping := migration.Mapping{DefaultDatastore: datastore}
+	if datastore == "" {
+		mapping, err = this.restoreMapping.Get()
+		if err != nil {
+			log.WithError(err).Errorf("Failed to get the restore mapping")
+			return astrolabe.ProtectedEntityID{}, err
+		}
+	} else {
+		log = log.WithField("datastore", datastore)
+	}
+	pe = newRelocatingProtectedEntity(pe, mapping, log)
 
 	log.Debugf("Ready to call ivd PETM copy API for remote PE.")
 	ivdPE, err := this.ivdPETM.Copy(ctx, pe, astrolabe.AllocateNewObject)
@@ -134,6 +282,170 @@ func (this *DataMover) CopyFromRepo(peID astrolabe.ProtectedEntityID) (astrolabe
 	return ivdPE.GetID(), nil
 }
 
+// encryptedProtectedEntity is a snapshot in a remote repository that may be encrypted.
+type encryptedProtectedEntity interface {
+	GetEncryptionKeyID(ctx context.Context) (string, error)
+}
+
+// checkEncryptionKey returns the ID of the key the snapshot in the remote repository is encrypted with, or "" if it is
+// not encrypted. It fails with an error wrapping encryption.ErrKeyNotFound if the key is not available, so that
+// nothing is restored.
+func (this *DataMover) checkEncryptionKey(ctx context.Context, pe astrolabe.ProtectedEntity) (string, error) {
+	encrypted, ok := pe.(encryptedProtectedEntity)
+	if !ok {
+		return "", nil
+	}
+	keyID, err := encrypted.GetEncryptionKeyID(ctx)
+	if err != nil || keyID == "" {
+		return "", err
+	}
+	if this.keyProvider == nil {
+		return keyID, errors.Wrapf(encryption.ErrKeyNotFound, "%s is encrypted with key %s, but no encryption key provider is configured", pe.GetID().String(), keyID)
+	}
+	if _, err := this.keyProvider.GetKey(keyID); err != nil {
+		return keyID, errors.Wrapf(err, "Failed to get key %s that %s is encrypted with", keyID, pe.GetID().String())
+	}
+	return keyID, nil
+}
+
+// PlaceVolume returns the datastore of this vCenter that a volume restored for the StorageClass of the request is
+// placed on. The error wraps placement.ErrNoCompatibleDatastore if no datastore is compatible with the StorageClass.
+func (this *DataMover) PlaceVolume(request placement.Request) (placement.Datastore, error) {
+	if this.placer == nil {
+		return placement.Datastore{}, errors.New("The DataMover does not place volumes")
+	}
+	datastore, err := this.placer.Place(context.Background(), request)
+	if err != nil {
+		this.WithError(err).Errorf("Failed to place the volume of StorageClass %s", request.StorageClass)
+		return placement.Datastore{}, err
+	}
+	this.WithField("datastore", datastore.Name).Infof("The volume of StorageClass %s is placed on datastore %s", request.StorageClass, datastore.ID)
+	return datastore, nil
+}
+
+// CheckpointFunc is called when an upload is checkpointed or resumed, with the number of bytes of the data of the
+// snapshot committed to the remote repository.
+type CheckpointFunc func(committedBytes int64)
+
+// resumableRepository is a remote repository that checkpoints uploads, so that an interrupted upload is resumed.
+type resumableRepository interface {
+	CopyWithCheckpoints(ctx context.Context, pe astrolabe.ProtectedEntity, options astrolabe.CopyCreateOptions, checkpointed objectstore.CheckpointFunc) (astrolabe.ProtectedEntity, error)
+}
+
+// indexedRepository is a remote repository that indexes the snapshots copied to it.
+type indexedRepository interface {
+	IndexSnapshots(ctx context.Context) ([]objectstore.IndexedSnapshot, error)
+}
+
+// IndexRepository returns the snapshots kept in the remote repository, with their origin. It returns an error if the
+// repository does not index its snapshots.
+func (this *DataMover) IndexRepository(repository backuprepository.Reference) ([]objectstore.IndexedSnapshot, error) {
+	repositoryPETM, err := this.repositories.Get(repository)
+	if err != nil {
+		this.WithError(err).Errorf("Failed to get PETM of the remote repository %s", repository.String())
+		return nil, err
+	}
+	indexed, ok := repositoryPETM.(indexedRepository)
+	if !ok {
+		return nil, errors.Errorf("The remote repository %s does not index its snapshots", repository.String())
+	}
+	return indexed.IndexSnapshots(context.Background())
+}
+
+// verifiableRepository is a remote repository that records the checksums of the snapshots copied to it, and verifies
+// them.
+type verifiableRepository interface {
+	Checksum(ctx context.Context, id astrolabe.ProtectedEntityID) (string, error)
+	VerifySnapshot(ctx context.Context, id astrolabe.ProtectedEntityID) objectstore.SnapshotVerification
+	VerifySnapshots(ctx context.Context) ([]objectstore.SnapshotVerification, error)
+}
+
+func (this *DataMover) verifiableRepository(repository backuprepository.Reference) (verifiableRepository, error) {
+	repositoryPETM, err := this.repositories.Get(repository)
+	if err != nil {
+		this.WithError(err).Errorf("Failed to get PETM of the remote repository %s", repository.String())
+		return nil, err
+	}
+	verifiable, ok := repositoryPETM.(verifiableRepository)
+	if !ok {
+		return nil, errors.Errorf("The remote repository %s does not record the checksums of its snapshots", repository.String())
+	}
+	return verifiable, nil
+}
+
+// SnapshotChecksum returns the checksum of the data of the snapshot recorded when it was copied to the remote
+// repository, or "" if the repository does not record checksums.
+func (this *DataMover) SnapshotChecksum(peID astrolabe.ProtectedEntityID, repository backuprepository.Reference) (string, error) {
+	repositoryPETM, err := this.repositories.Get(repository)
+	if err != nil {
+		return "", err
+	}
+	verifiable, ok := repositoryPETM.(verifiableRepository)
+	if !ok {
+		return "", nil
+	}
+	return verifiable.Checksum(context.Background(), peID)
+}
+
+// VerifySnapshot reads the snapshot back from the remote repository and compares it against the checksums recorded
+// when it was copied. The data is read with the given number of concurrent streams, or the default of the repository
+// if it is not positive, and is throttled by the bandwidth limits of the DataMover. Encrypted snapshots are decrypted
+// with the keys of the DataMover.
+func (this *DataMover) VerifySnapshot(peID astrolabe.ProtectedEntityID, repository backuprepository.Reference, streams int) (objectstore.SnapshotVerification, error) {
+	verifiable, err := this.verifiableRepository(repository)
+	if err != nil {
+		return objectstore.SnapshotVerification{ID: peID}, err
+	}
+	ctx := objectstore.WithBandwidthLimiters(objectstore.WithTransferStreams(context.Background(), streams), this.bandwidthLimitersFor(repository)...)
+	return verifiable.VerifySnapshot(objectstore.WithKeyProvider(ctx, this.keyProvider), peID), nil
+}
+
+// VerifyRepository verifies all the snapshots kept in the remote repository, as VerifySnapshot does.
+func (this *DataMover) VerifyRepository(repository backuprepository.Reference, streams int) ([]objectstore.SnapshotVerification, error) {
+	verifiable, err := this.verifiableRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+	log := this.WithField("repository", repository.String())
+	log.Info("Verifying the snapshots of the remote repository")
+	ctx := objectstore.WithBandwidthLimiters(objectstore.WithTransferStreams(context.Background(), streams), this.bandwidthLimitersFor(repository)...)
+	verifications, err := verifiable.VerifySnapshots(objectstore.WithKeyProvider(ctx, this.keyProvider))
+	if err != nil {
+		log.WithError(err).Error("Failed to verify the snapshots of the remote repository")
+		return verifications, err
+	}
+	return verifications, nil
+}
+
+// maintainableRepository is a remote repository whose chains of incremental snapshots can be merged.
+type maintainableRepository interface {
+	Maintain(ctx context.Context, maxChainDepth int) error
+}
+
+// MaintainRepository merges the chains of incremental snapshots in the remote repository that are longer than
+// maxChainDepth, and purges the deleted snapshots that no snapshot depends on anymore.
+func (this *DataMover) MaintainRepository(repository backuprepository.Reference, maxChainDepth int) error {
+	log := this.WithField("repository", repository.String())
+	repositoryPETM, err := this.repositories.Get(repository)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to get PETM of the remote repository")
+		return err
+	}
+	maintainable, ok := repositoryPETM.(maintainableRepository)
+	if !ok {
+		log.Debug("The remote repository does not need maintenance")
+		return nil
+	}
+
+	log.Info("Maintaining the remote repository")
+	if err = maintainable.Maintain(context.Background(), maxChainDepth); err != nil {
+		log.WithError(err).Error("Failed to maintain the remote repository")
+		return err
+	}
+	log.Info("The remote repository is maintained")
+	return nil
+}
+
 func (this *DataMover) IsUploading(peID astrolabe.ProtectedEntityID) bool {
 	log := this.WithField("PEID", peID.String())
 	log.Infof("Checking if the node is uploading")
@@ -0,0 +1,146 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataMover
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	"io"
+	"sync"
+)
+
+// ProgressFunc is called as the data of a snapshot is copied, with the number of bytes of the data copied so far and
+// the total number of bytes of the data. It is called on every read of the data, so it should return quickly.
+type ProgressFunc func(bytesDone int64, totalBytes int64)
+
+// progressProtectedEntity is a ProtectedEntity that reports the progress of the reads of its data.
+type progressProtectedEntity struct {
+	astrolabe.ProtectedEntity
+	totalBytes int64
+	progress   ProgressFunc
+}
+
+// changeTrackingProgressProtectedEntity is a progressProtectedEntity that still tracks the areas of its data that
+// change between snapshots, so that the repository only reads those.
+type changeTrackingProgressProtectedEntity struct {
+	*progressProtectedEntity
+	tracker objectstore.ChangeTrackingProtectedEntity
+}
+
+// newProgressProtectedEntity returns a ProtectedEntity that reports the progress of the reads of the data of pe, and
+// reports that nothing is read yet. pe is returned as is if progress is nil. The total number of bytes is 0 unless pe
+// is an objectstore.SizedProtectedEntity.
+func newProgressProtectedEntity(ctx context.Context, pe astrolabe.ProtectedEntity, progress ProgressFunc) (astrolabe.ProtectedEntity, error) {
+	if progress == nil {
+		return pe, nil
+	}
+	var totalBytes int64
+	if sized, ok := pe.(objectstore.SizedProtectedEntity); ok {
+		size, err := sized.GetSize(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get the size of ProtectedEntity %s", pe.GetID().String())
+		}
+		totalBytes = size
+	}
+	progress(0, totalBytes)
+
+	progressPE := &progressProtectedEntity{
+		ProtectedEntity: pe,
+		totalBytes:      totalBytes,
+		progress:        progress,
+	}
+	if tracker, ok := pe.(objectstore.ChangeTrackingProtectedEntity); ok {
+		return &changeTrackingProgressProtectedEntity{
+			progressProtectedEntity: progressPE,
+			tracker:                 tracker,
+		}, nil
+	}
+	return progressPE, nil
+}
+
+// GetSize returns the size of the data of the ProtectedEntity, or 0 if it is not known, which the repository also takes
+// as unknown.
+func (this *progressProtectedEntity) GetSize(ctx context.Context) (int64, error) {
+	return this.totalBytes, nil
+}
+
+func (this *progressProtectedEntity) GetDataReader(ctx context.Context) (io.ReadCloser, error) {
+	reader, err := this.ProtectedEntity.GetDataReader(ctx)
+	if err != nil || reader == nil {
+		return reader, err
+	}
+	progressReader := &progressReader{
+		ReadCloser: reader,
+		totalBytes: this.totalBytes,
+		progress:   this.progress,
+	}
+	if readerAt, ok := reader.(io.ReaderAt); ok {
+		return &progressReaderAt{
+			progressReader: progressReader,
+			readerAt:       readerAt,
+		}, nil
+	}
+	return progressReader, nil
+}
+
+func (this *changeTrackingProgressProtectedEntity) GetChangeID(ctx context.Context) (string, error) {
+	return this.tracker.GetChangeID(ctx)
+}
+
+func (this *changeTrackingProgressProtectedEntity) GetChangedAreas(ctx context.Context, changeID string) ([]objectstore.Extent, error) {
+	return this.tracker.GetChangedAreas(ctx, changeID)
+}
+
+// progressReader reports the number of bytes read.
+type progressReader struct {
+	io.ReadCloser
+	totalBytes int64
+	progress   ProgressFunc
+
+	mutex     sync.Mutex
+	bytesDone int64
+}
+
+func (this *progressReader) Read(p []byte) (int, error) {
+	n, err := this.ReadCloser.Read(p)
+	this.advance(this.bytesDone + int64(n))
+	return n, err
+}
+
+func (this *progressReader) advance(bytesDone int64) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if bytesDone > this.bytesDone {
+		this.bytesDone = bytesDone
+		this.progress(this.bytesDone, this.totalBytes)
+	}
+}
+
+// progressReaderAt reports the offset up to which the data is read, so that the areas of the data that are skipped,
+// as they have not changed since the previous snapshot, are accounted for.
+type progressReaderAt struct {
+	*progressReader
+	readerAt io.ReaderAt
+}
+
+func (this *progressReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	n, err := this.readerAt.ReadAt(p, offset)
+	this.advance(offset + int64(n))
+	return n, err
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataMover
+
+import (
+	"bytes"
+	"context"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/migration"
+	"io"
+	"io/ioutil"
+)
+
+// SetRestoreMapping sets the Source of the mapping of the datastores of the snapshots to those of this vCenter, which
+// is read on every copy from a remote repository.
+func (this *DataMover) SetRestoreMapping(source *migration.Source) {
+	this.restoreMapping = source
+}
+
+// relocatingProtectedEntity is a ProtectedEntity of another vCenter whose metadata is rewritten to place the volume
+// restored from it on the datastores of this vCenter.
+type relocatingProtectedEntity struct {
+	astrolabe.ProtectedEntity
+	mapping migration.Mapping
+	logger  logrus.FieldLogger
+}
+
+// newRelocatingProtectedEntity returns a ProtectedEntity whose metadata is relocated with the mapping, or pe as is if
+// the mapping is empty.
+func newRelocatingProtectedEntity(pe astrolabe.ProtectedEntity, mapping migration.Mapping, logger logrus.FieldLogger) astrolabe.ProtectedEntity {
+	if mapping.Empty() {
+		return pe
+	}
+	return &relocatingProtectedEntity{
+		ProtectedEntity: pe,
+		mapping:         mapping,
+		logger:          logger,
+	}
+}
+
+func (this *relocatingProtectedEntity) GetMetadataReader(ctx context.Context) (io.ReadCloser, error) {
+	reader, err := this.ProtectedEntity.GetMetadataReader(ctx)
+	if err != nil || reader == nil {
+		return reader, err
+	}
+	defer reader.Close()
+	metadata, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read the metadata of ProtectedEntity %s", this.GetID().String())
+	}
+	metadata, relocated, err := this.mapping.RelocateMetadata(metadata)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to relocate ProtectedEntity %s", this.GetID().String())
+	}
+	if relocated {
+		this.logger.Infof("The datastore of ProtectedEntity %s is mapped to a datastore of this vCenter", this.GetID().String())
+	}
+	return ioutil.NopCloser(bytes.NewReader(metadata)), nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"github.com/pkg/errors"
+)
+
+// The data of a snapshot kept in chunks is encrypted chunk by chunk, after it is split, so that the chunks are still
+// deduplicated, copied incrementally and uploaded from a checkpoint.
+//
+// A chunk is named by its hash, the HMAC-SHA256 of its plaintext with a key derived from the encryption key, so that
+// the names do not tell anything about the content of the chunks without the key, and the chunks encrypted with
+// different keys do not share names. The nonce of a chunk is derived from its hash and the key ID, so a nonce is only
+// used again to seal the same plaintext with the same key, which gives the same sealed chunk.
+//
+// A sealed chunk is the chunk magic, the length of the key ID as a big endian uint16, the key ID and the plaintext
+// sealed with AES-256-GCM. The header and the hash are the additional authenticated data, so that a chunk cannot be
+// passed for another one.
+const chunkMagic = "VSPHEREPLUGINCHK1"
+
+// The context of the derivation of the key chunks are hashed with from the encryption key.
+const chunkHashKeyContext = "velero-plugin-for-vsphere chunk hash"
+
+// ChunkCipher hashes, seals and opens the chunks of the data of snapshots with a key.
+type ChunkCipher struct {
+	keyID   string
+	aead    cipher.AEAD
+	hashKey []byte
+	header  []byte
+}
+
+func NewChunkCipher(keyID string, key []byte) (*ChunkCipher, error) {
+	if keyID == "" || len(keyID) > maxKeyIDLength {
+		return nil, errors.Errorf("invalid encryption key ID %q", keyID)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(chunkHashKeyContext))
+
+	var header bytes.Buffer
+	header.WriteString(chunkMagic)
+	binary.Write(&header, binary.BigEndian, uint16(len(keyID)))
+	header.WriteString(keyID)
+	return &ChunkCipher{
+		keyID:   keyID,
+		aead:    aead,
+		hashKey: mac.Sum(nil),
+		header:  header.Bytes(),
+	}, nil
+}
+
+// KeyID returns the ID of the key of the cipher.
+func (this *ChunkCipher) KeyID() string {
+	return this.keyID
+}
+
+// Hash returns the hex encoded hash the chunk is named by.
+func (this *ChunkCipher) Hash(chunk []byte) string {
+	mac := hmac.New(sha256.New, this.hashKey)
+	mac.Write(chunk)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (this *ChunkCipher) nonce(hash string) []byte {
+	sum := sha256.New()
+	binary.Write(sum, binary.BigEndian, uint16(len(this.keyID)))
+	sum.Write([]byte(this.keyID))
+	sum.Write([]byte(hash))
+	return sum.Sum(nil)[:this.aead.NonceSize()]
+}
+
+func (this *ChunkCipher) additionalData(hash string) []byte {
+	return append(append([]byte{}, this.header...), hash...)
+}
+
+// Seal returns the chunk with the given hash sealed, appended to dst.
+func (this *ChunkCipher) Seal(dst []byte, hash string, chunk []byte) []byte {
+	dst = append(dst, this.header...)
+	return this.aead.Seal(dst, this.nonce(hash), chunk, this.additionalData(hash))
+}
+
+// Open returns the plaintext of the sealed chunk with the given hash. It fails if the chunk is not sealed with the key
+// of the cipher, or if it is corrupted.
+func (this *ChunkCipher) Open(hash string, sealed []byte) ([]byte, error) {
+	keyID, err := ChunkKeyID(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if keyID != this.keyID {
+		return nil, errors.Errorf("chunk is encrypted with key %s instead of %s", keyID, this.keyID)
+	}
+	chunk, err := this.aead.Open(nil, this.nonce(hash), sealed[len(this.header):], this.additionalData(hash))
+	if err != nil {
+		return nil, errors.New("Failed to authenticate encrypted chunk, it is corrupted")
+	}
+	return chunk, nil
+}
+
+// ChunkKeyID returns the ID of the key the sealed chunk is encrypted with.
+func ChunkKeyID(sealed []byte) (string, error) {
+	if len(sealed) < len(chunkMagic)+2 || string(sealed[:len(chunkMagic)]) != chunkMagic {
+		return "", errors.New("chunk is not encrypted")
+	}
+	keyIDLength := int(binary.BigEndian.Uint16(sealed[len(chunkMagic):]))
+	if len(sealed) < len(chunkMagic)+2+keyIDLength {
+		return "", errors.New("encrypted chunk is truncated")
+	}
+	return string(sealed[len(chunkMagic)+2 : len(chunkMagic)+2+keyIDLength]), nil
+}
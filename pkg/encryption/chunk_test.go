@@ -0,0 +1,85 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSealOpenChunk(t *testing.T) {
+	key := newKey(t)
+	chunkCipher, err := NewChunkCipher("key-1", key)
+	require.NoError(t, err)
+	chunk := []byte("aaaabbbb")
+
+	// The same chunk is named and sealed the same way every time, so that it is deduplicated
+	hash := chunkCipher.Hash(chunk)
+	sealed := chunkCipher.Seal(nil, hash, chunk)
+	assert.Equal(t, hash, chunkCipher.Hash(chunk))
+	assert.Equal(t, sealed, chunkCipher.Seal(nil, hash, chunk))
+	assert.False(t, bytes.Contains(sealed, chunk))
+	keyID, err := ChunkKeyID(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", keyID)
+
+	opened, err := chunkCipher.Open(hash, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, chunk, opened)
+
+	// The name does not tell the content without the key
+	sum := sha256.Sum256(chunk)
+	assert.NotEqual(t, hex.EncodeToString(sum[:]), hash)
+	otherCipher, err := NewChunkCipher("key-2", newKey(t))
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, otherCipher.Hash(chunk))
+}
+
+func TestOpenTamperedChunk(t *testing.T) {
+	key := newKey(t)
+	chunkCipher, err := NewChunkCipher("key-1", key)
+	require.NoError(t, err)
+	hash := chunkCipher.Hash([]byte("aaaa"))
+	sealed := chunkCipher.Seal(nil, hash, []byte("aaaa"))
+
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = chunkCipher.Open(hash, tampered)
+	assert.Error(t, err)
+
+	// A chunk cannot be passed for another one
+	_, err = chunkCipher.Open(chunkCipher.Hash([]byte("bbbb")), sealed)
+	assert.Error(t, err)
+
+	_, err = chunkCipher.Open(hash, sealed[:len(chunkMagic)+3])
+	assert.Error(t, err)
+
+	// A key with the same ID but different content fails authentication
+	otherCipher, err := NewChunkCipher("key-1", newKey(t))
+	require.NoError(t, err)
+	_, err = otherCipher.Open(hash, sealed)
+	assert.Error(t, err)
+
+	otherCipher, err = NewChunkCipher("key-2", key)
+	require.NoError(t, err)
+	_, err = otherCipher.Open(hash, sealed)
+	assert.EqualError(t, err, "chunk is encrypted with key key-1 instead of key-2")
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// KeySize is the size of the AES-256 keys returned by a KeyProvider.
+	KeySize = 32
+
+	// The entry of the key Secret that holds the ID of the key new snapshots are encrypted with. Every other entry
+	// of the Secret is a key, named by its key ID.
+	SecretCurrentKeyIDKey = "currentKeyID"
+)
+
+// ErrKeyNotFound is returned when the key a snapshot is encrypted with is not available.
+var ErrKeyNotFound = errors.New("encryption key not found")
+
+// KeyProvider supplies the keys snapshots are encrypted with. Keys are identified by a key ID that is stored with
+// the encrypted data, so that keys can be rotated while older snapshots can still be restored. It can be
+// implemented on top of a key management service.
+type KeyProvider interface {
+	// CurrentKeyID returns the ID of the key new snapshots are encrypted with.
+	CurrentKeyID() (string, error)
+	// GetKey returns the KeySize bytes of the key with the given ID, or an error wrapping ErrKeyNotFound.
+	GetKey(keyID string) ([]byte, error)
+}
+
+// SecretKeyProvider is a KeyProvider that reads the keys from a Kubernetes Secret. The Secret is read on every call,
+// so that keys added to it are picked up without a restart.
+type SecretKeyProvider struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	name       string
+}
+
+func NewSecretKeyProvider(kubeClient kubernetes.Interface, namespace string, name string) *SecretKeyProvider {
+	return &SecretKeyProvider{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+	}
+}
+
+func (this *SecretKeyProvider) getSecretData() (map[string][]byte, error) {
+	secret, err := this.kubeClient.CoreV1().Secrets(this.namespace).Get(this.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(ErrKeyNotFound, "encryption key secret %s/%s does not exist", this.namespace, this.name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get encryption key secret %s/%s", this.namespace, this.name)
+	}
+	return secret.Data, nil
+}
+
+func (this *SecretKeyProvider) CurrentKeyID() (string, error) {
+	data, err := this.getSecretData()
+	if err != nil {
+		return "", err
+	}
+	keyID := string(data[SecretCurrentKeyIDKey])
+	if keyID == "" {
+		return "", errors.Errorf("%s is not set in encryption key secret %s/%s", SecretCurrentKeyIDKey, this.namespace, this.name)
+	}
+	return keyID, nil
+}
+
+func (this *SecretKeyProvider) GetKey(keyID string) ([]byte, error) {
+	data, err := this.getSecretData()
+	if err != nil {
+		return nil, err
+	}
+	key, ok := data[keyID]
+	if !ok || keyID == SecretCurrentKeyIDKey {
+		return nil, errors.Wrapf(ErrKeyNotFound, "key %s is not in encryption key secret %s/%s", keyID, this.namespace, this.name)
+	}
+	if len(key) != KeySize {
+		return nil, errors.Errorf("key %s in encryption key secret %s/%s is %d bytes, expected %d", keyID, this.namespace, this.name, len(key), KeySize)
+	}
+	return key, nil
+}
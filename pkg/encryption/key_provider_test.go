@@ -0,0 +1,63 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"testing"
+)
+
+func TestSecretKeyProvider(t *testing.T) {
+	key := make([]byte, KeySize)
+	kubeClient := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "velero",
+			Name:      "encryption-keys",
+		},
+		Data: map[string][]byte{
+			SecretCurrentKeyIDKey: []byte("key-2"),
+			"key-1":               key,
+			"key-2":               key,
+			"short":               []byte("key"),
+		},
+	})
+
+	keyProvider := NewSecretKeyProvider(kubeClient, "velero", "encryption-keys")
+	keyID, err := keyProvider.CurrentKeyID()
+	require.NoError(t, err)
+	assert.Equal(t, "key-2", keyID)
+
+	got, err := keyProvider.GetKey("key-1")
+	require.NoError(t, err)
+	assert.Equal(t, key, got)
+
+	_, err = keyProvider.GetKey("key-3")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	_, err = keyProvider.GetKey(SecretCurrentKeyIDKey)
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	_, err = keyProvider.GetKey("short")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrKeyNotFound))
+
+	_, err = NewSecretKeyProvider(kubeClient, "velero", "missing").GetKey("key-1")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+	"math"
+)
+
+// Encrypted data is a header followed by chunks sealed with AES-256-GCM.
+//
+// The header is the magic, the length of the key ID as a big endian uint16, the key ID and a random nonce prefix.
+// Each chunk holds chunkSize bytes of plaintext, except the last one, which may be shorter. The nonce of a chunk is
+// the nonce prefix, the index of the chunk as a big endian uint32 and a byte that is 1 for the last chunk and 0
+// otherwise, so that reordered, dropped or truncated chunks fail authentication. The header is the additional
+// authenticated data of every chunk.
+const (
+	magic           = "VSPHEREPLUGINENC1"
+	chunkSize       = 64 * 1024
+	noncePrefixSize = 7
+	maxKeyIDLength  = math.MaxUint16
+)
+
+// IsEncrypted reports whether the data read by the reader starts with the header of encrypted data. It does not
+// consume any data.
+func IsEncrypted(reader *bufio.Reader) bool {
+	header, err := reader.Peek(len(magic))
+	return err == nil && string(header) == magic
+}
+
+// The size of a GCM tag.
+const tagSize = 16
+
+func headerSize(keyID string) int64 {
+	return int64(len(magic) + 2 + len(keyID) + noncePrefixSize)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, errors.Errorf("encryption key is %d bytes, expected %d", len(key), KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+type chunkCipher struct {
+	aead    cipher.AEAD
+	header  []byte
+	nonce   []byte
+	counter uint32
+}
+
+func newChunkCipher(key []byte, header []byte, noncePrefix []byte) (*chunkCipher, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, noncePrefix)
+	return &chunkCipher{
+		aead:   aead,
+		header: header,
+		nonce:  nonce,
+	}, nil
+}
+
+func (this *chunkCipher) nextNonce(last bool) ([]byte, error) {
+	if this.counter == math.MaxUint32 {
+		return nil, errors.New("too many chunks of encrypted data")
+	}
+	binary.BigEndian.PutUint32(this.nonce[noncePrefixSize:], this.counter)
+	this.nonce[len(this.nonce)-1] = 0
+	if last {
+		this.nonce[len(this.nonce)-1] = 1
+	}
+	this.counter++
+	return this.nonce, nil
+}
+
+type encryptingReader struct {
+	source *bufio.Reader
+	cipher *chunkCipher
+	plain  []byte
+	sealed []byte
+	output []byte
+	done   bool
+	err    error
+}
+
+// NewEncryptingReader returns a reader of the data read from source encrypted with the key with the given ID.
+func NewEncryptingReader(source io.Reader, keyID string, key []byte) (io.Reader, error) {
+	if keyID == "" || len(keyID) > maxKeyIDLength {
+		return nil, errors.Errorf("invalid encryption key ID %q", keyID)
+	}
+	noncePrefix := make([]byte, noncePrefixSize)
+	if _, err := io.ReadFull(rand.Reader, noncePrefix); err != nil {
+		return nil, errors.Wrap(err, "Failed to generate nonce")
+	}
+
+	var header bytes.Buffer
+	header.WriteString(magic)
+	binary.Write(&header, binary.BigEndian, uint16(len(keyID)))
+	header.WriteString(keyID)
+	header.Write(noncePrefix)
+
+	chunkCipher, err := newChunkCipher(key, header.Bytes(), noncePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptingReader{
+		source: bufio.NewReaderSize(source, chunkSize),
+		cipher: chunkCipher,
+		plain:  make([]byte, chunkSize),
+		sealed: make([]byte, 0, chunkSize+tagSize),
+		output: header.Bytes(),
+	}, nil
+}
+
+func (this *encryptingReader) Read(p []byte) (int, error) {
+	for len(this.output) == 0 {
+		if this.err != nil {
+			return 0, this.err
+		}
+		if this.done {
+			return 0, io.EOF
+		}
+		this.err = this.sealChunk()
+	}
+	n := copy(p, this.output)
+	this.output = this.output[n:]
+	return n, nil
+}
+
+func (this *encryptingReader) sealChunk() error {
+	n, err := io.ReadFull(this.source, this.plain)
+	last := false
+	switch err {
+	case nil:
+		// A full chunk is the last one if there is no more data after it.
+		if _, err := this.source.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+
+	nonce, err := this.cipher.nextNonce(last)
+	if err != nil {
+		return err
+	}
+	this.output = this.cipher.aead.Seal(this.sealed[:0], nonce, this.plain[:n], this.cipher.header)
+	this.done = last
+	return nil
+}
+
+type decryptingReader struct {
+	source *bufio.Reader
+	cipher *chunkCipher
+	sealed []byte
+	output []byte
+	done   bool
+	err    error
+}
+
+// readHeader reads the header of encrypted data from the reader and returns the key ID, the whole header and the
+// nonce prefix in it.
+func readHeader(reader io.Reader) (string, []byte, []byte, error) {
+	header := make([]byte, len(magic)+2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return "", nil, nil, errors.Wrap(err, "Failed to read the header of encrypted data")
+	}
+	if string(header[:len(magic)]) != magic {
+		return "", nil, nil, errors.New("data is not encrypted")
+	}
+	rest := make([]byte, int(binary.BigEndian.Uint16(header[len(magic):]))+noncePrefixSize)
+	if _, err := io.ReadFull(reader, rest); err != nil {
+		return "", nil, nil, errors.Wrap(err, "Failed to read the header of encrypted data")
+	}
+	keyID := string(rest[:len(rest)-noncePrefixSize])
+	return keyID, append(header, rest...), rest[len(rest)-noncePrefixSize:], nil
+}
+
+// GetKeyID returns the ID of the key the data read by the reader is encrypted with, or "" if the data is not
+// encrypted.
+func GetKeyID(reader *bufio.Reader) (string, error) {
+	if !IsEncrypted(reader) {
+		return "", nil
+	}
+	keyID, _, _, err := readHeader(reader)
+	return keyID, err
+}
+
+// NewDecryptingReader returns a reader of the data read from source decrypted with the key from the key provider it
+// was encrypted with. It fails with an error wrapping ErrKeyNotFound if the key is not available.
+func NewDecryptingReader(source io.Reader, keyProvider KeyProvider) (io.Reader, error) {
+	bufferedSource := bufio.NewReaderSize(source, chunkSize+tagSize)
+	keyID, header, noncePrefix, err := readHeader(bufferedSource)
+	if err != nil {
+		return nil, err
+	}
+	if keyProvider == nil {
+		return nil, errors.Wrapf(ErrKeyNotFound, "data is encrypted with key %s, but no encryption key provider is configured", keyID)
+	}
+	key, err := keyProvider.GetKey(keyID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get key %s the data is encrypted with", keyID)
+	}
+
+	chunkCipher, err := newChunkCipher(key, header, noncePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptingReader{
+		source: bufferedSource,
+		cipher: chunkCipher,
+		sealed: make([]byte, chunkSize+tagSize),
+	}, nil
+}
+
+func (this *decryptingReader) Read(p []byte) (int, error) {
+	for len(this.output) == 0 {
+		if this.err != nil {
+			return 0, this.err
+		}
+		if this.done {
+			return 0, io.EOF
+		}
+		this.err = this.openChunk()
+	}
+	n := copy(p, this.output)
+	this.output = this.output[n:]
+	return n, nil
+}
+
+func (this *decryptingReader) openChunk() error {
+	n, err := io.ReadFull(this.source, this.sealed)
+	last := false
+	switch err {
+	case nil:
+		if _, err := this.source.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	case io.ErrUnexpectedEOF:
+		last = true
+	case io.EOF:
+		return errors.New("encrypted data is truncated")
+	default:
+		return err
+	}
+
+	nonce, err := this.cipher.nextNonce(last)
+	if err != nil {
+		return err
+	}
+	this.output, err = this.cipher.aead.Open(this.sealed[:0], nonce, this.sealed[:n], this.cipher.header)
+	if err != nil {
+		return errors.New("Failed to authenticate encrypted data, it is corrupted or truncated")
+	}
+	this.done = last
+	return nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// mapKeyProvider is a KeyProvider with the keys in a map.
+type mapKeyProvider map[string][]byte
+
+func (this mapKeyProvider) CurrentKeyID() (string, error) {
+	return "key-1", nil
+}
+
+func (this mapKeyProvider) GetKey(keyID string) ([]byte, error) {
+	key, ok := this[keyID]
+	if !ok {
+		return nil, errors.Wrapf(ErrKeyNotFound, "key %s", keyID)
+	}
+	return key, nil
+}
+
+func newKey(t *testing.T) []byte {
+	key := make([]byte, KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func encrypt(t *testing.T, plain []byte, keyID string, key []byte) []byte {
+	reader, err := NewEncryptingReader(bytes.NewReader(plain), keyID, key)
+	require.NoError(t, err)
+	encrypted, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	return encrypted
+}
+
+func decrypt(encrypted []byte, keyProvider KeyProvider) ([]byte, error) {
+	reader, err := NewDecryptingReader(bytes.NewReader(encrypted), keyProvider)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(reader)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	keys := mapKeyProvider{"key-1": newKey(t)}
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3 * chunkSize, 3*chunkSize + 100} {
+		plain := make([]byte, size)
+		_, err := rand.Read(plain)
+		require.NoError(t, err)
+
+		encrypted := encrypt(t, plain, "key-1", keys["key-1"])
+		chunks := (size + chunkSize - 1) / chunkSize
+		if chunks == 0 {
+			chunks = 1
+		}
+		assert.Equal(t, headerSize("key-1")+int64(size+chunks*tagSize), int64(len(encrypted)), "size %d", size)
+
+		keyID, err := GetKeyID(bufio.NewReader(bytes.NewReader(encrypted)))
+		require.NoError(t, err)
+		assert.Equal(t, "key-1", keyID)
+
+		decrypted, err := decrypt(encrypted, keys)
+		require.NoError(t, err, "size %d", size)
+		assert.True(t, bytes.Equal(plain, decrypted), "size %d", size)
+	}
+}
+
+func TestDecryptTamperedData(t *testing.T) {
+	keys := mapKeyProvider{"key-1": newKey(t)}
+	plain := make([]byte, 2*chunkSize+10)
+	encrypted := encrypt(t, plain, "key-1", keys["key-1"])
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)/2] ^= 1
+	_, err := decrypt(tampered, keys)
+	assert.Error(t, err)
+
+	// Truncated at a chunk boundary
+	_, err = decrypt(encrypted[:headerSize("key-1")+chunkSize+tagSize], keys)
+	assert.Error(t, err)
+
+	_, err = decrypt(encrypted[:len(encrypted)-1], keys)
+	assert.Error(t, err)
+}
+
+func TestDecryptWithMissingKey(t *testing.T) {
+	encrypted := encrypt(t, []byte("data"), "key-1", newKey(t))
+
+	_, err := decrypt(encrypted, mapKeyProvider{"key-2": newKey(t)})
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	_, err = decrypt(encrypted, nil)
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	// A key with the same ID but different content fails authentication
+	_, err = decrypt(encrypted, mapKeyProvider{"key-1": newKey(t)})
+	assert.Error(t, err)
+}
+
+func TestGetKeyIDOfUnencryptedData(t *testing.T) {
+	reader := bufio.NewReader(bytes.NewReader([]byte("data")))
+	keyID, err := GetKeyID(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "", keyID)
+
+	// Nothing is consumed
+	data, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+}
+
+func TestEncryptingReaderSourceError(t *testing.T) {
+	reader, err := NewEncryptingReader(io.MultiReader(bytes.NewReader([]byte("data")), &errorReader{}), "key-1", newKey(t))
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(reader)
+	assert.EqualError(t, err, "read failed")
+}
+
+type errorReader struct{}
+
+func (this *errorReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
@@ -29,12 +29,12 @@ import (
 )
 
 var rawCRDs = [][]byte{
-	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xb4V\xcbn+7\f\xdd\xcfW\x10\xb7\x8b\xb4@<F\xd0M\xe1ݭ\xd3\x02A_A\x12dStAK\xb4\xcdF#\xa9\"\xe5\xdb\xf4\xeb\vi^\x9e\xc4EV\xf5\xc6\x10I\x1d>\x0eIM\xb3Z\xad\x1a\x8c\xfcLI8\xf8\r`d\xfa[ɗ\x93\xb4/\xdfI\xcba}\xbaّ\xe2M\xf3\xc2\xden`\x9bEC\xf7@\x12r2tK{\xf6\xac\x1c|ӑ\xa2E\xc5M\x03\x80\xde\a\xc5\"\x96r\x040\xc1k\n\xceQZ\x1dȷ/yG\xbb\xcc\xceR\xaa\x1eF\xff_[:\x91\xfb\xa6\x010\x89\xea\xfd'\xeeH\x14\xbb\xb8\x01\x9f\x9dk\x00<v\xb4\x81\x1d\x9a\x97\x1c\x13\xc5 \xac!1Iۋl\xe2SEm$\x92)\xde\x0f)\xe48ޘ\xd5=\xd2\x10_\x9f\xdb\xf7\xd5\xe4a\x04}\xad*Ǣ?]T\xff̢\xd5$\xba\x9c\xd0]\n\xaa\xaa\x85\xfd!;L\xef\f\x8a\x031!\xd2\x06\xb6.\x8bRj\x00N\xe8\xd8\xd6\xdc\xfb\xd0B$\xff\xf9\xfe\xee\xf9\xdbGs\xa4\x0e{!\x80%1\x89c\xb5\x83\xabw\xb1\x01\v \x98\x1euU\x9dXH\x03k-\xc0\x9d\x16\x8b\x89\x16;\xa0\x02\xec^A\x8f4\xe0\xc1m-\x17\xa0/\x97\xf7\x94\xc8\x1b\xb2\xc5\x06\x1e=F9\x06\xbd\x86\xad\v\x9e~L\xa1\x1bE\xd5\xfc\x96\x1ci\xf1\xf4t\xa4\t|\x8cR\xa60ǐj(\xc8^\xaaw\x93ȒWF\a\xfb\x90\x00\x87\xc2\xc1\\\xb97\xc0s\xe2CĶ4&\xf5h=\xe7\xa0GT\xf8\xc2\xce\xc1\x8e \vY\xd0\x00\x8a\xee\xa5\xfe\x1f\xe9\f\x1d\xe07\xef^'\xf4)WR\xd3\xc2\xf6A`\x9fB\xd7\xf7ODSݠ\x02&\xaa\xddB\x16\xd8\xc3g\xe7\xc2\x17\xb2\xbf\xceF\xc5\xf7\\g\x024\xc54\xf8k\xe0}\r`\x02,܀\x0fz\x19\xa7\x98\x86H\xa96\xc9\x12u\x8f\xecګ\xe1\x18S\xb1R\x1e\x9b\xbc\xfc\xf0-ڬ\x02`\xa5n!\x00\xd0\xd7ҝ\xa2\x89\xfd\xa1y+Ɣp\xae\xd2\xd9\"9\xb3\\\xf6ii\xe4\xdef\xc1а\x00Ȃ\xd4&\x87P\n\xc2R(I$\xe4\xfbe\xb2\x88,\xec\x01=\x84ݟd\xb4\x85GJ\x05\x04\xe4\x18\xb2\xb3\xa5\x9bN\x94\x14\x12\x99p\xf0\xfcτ,#\xd9\x0e\x95\x86\xf1\x9d\xd2\xf7Jɣ+#\x98麶q\x87\xa5G\x8b\x0f\xc8\xfe\f\xad\x9aH\v\xbf\x84D\xc0~\x1f6pT\x8d\xb2Y\xaf\x0f\xac\xe3\xea4\xa1\xeb\xb2g}]\xd7I\xe3]\u0590d]\xb7\xdcZ\xf8\xb0\xc2d\x8e\xacd4'Zc\xe4U\r\xdc\xd7\xcd\xd9v\xf6\xabq<\xe4\xea]\xf1\xdfp\xb2{\xb3\x00\xb6\x0e\xb9\xdb|t\xab\xee\xbd\xffd\xab\xac\xbd~\x91\xf4\xd7\xfa\xacgR\x8a\xa8\xd4\xf2\xe1\x87ǧy\x92\vqK\xa6*G\xf35\x99\xe9*\xe5e\xbf\xaf\xd3\xc9\xc3X\x15D\xf26\x06\xf6\xdao\x03\xc7\xe4\x97TI\xdeu\xac\xa5?\xfe\xca$ZXma[\x9f\x9d:\xddѢ\x92m\xe1\xce\xc3\x16;r[\x14\xfa\xdf\xc9*\x15\x96U)\xe9\xc7t\x9d\xbf\x96KþZ\x93\xb8,\xa5\xd8Sz\x8f\t;RJ\x8b\x19Ek\xeb\x03\x8c\xee\xfe¼\u007f8\xc3\x17ܝ/\xd2\x0f:\xa8ԟ\x13M=\xb4z\xbf`&\xcd\xc5\x0e\x9d\xb4\x17\xf3\\h\xcf\xc3j.\xa60}G\x9cn\xe6S-\xc6j\xf8l\xa8\n\x00)\xedg7\xa0)\xf7o\x88hHx\xa0A\"\x8a\x9a\xeb=4\x86\xa2\x0e\xe9\x9c\u007f*|\xfa\xb4x\xf9\xeb\xd1\x04\xdfS!\x1b\xf8\xfd\x8f\xa6G%\xfb<\xc6Q\x84\xff\x06\x00\x00\xff\xff\x83@:@s\t\x00\x00"),
-	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xb4UKo+7\x0f\xddϯ \xee\xb7\xc8W\xe0z\x8c\xa0\x9bbv\xa9o\x17\x17} \xb8\t\xb2)\xba\xa0G\xb4\xcdF#\xa9$\xe54\xfd\xf5\x854\xe3\xf1#\x0e\xb2\xeaR\x14ux\xc4\xc3G\xb3X,\x1aL\xfcD\xa2\x1cC\a\x98\x98\xfe6\n\xe5\xa4\xed\xf3\x0f\xdar\\\xeeo\xd7dx\xdb<sp\x1d\xac\xb2Z\x1c\xbe\x91\xc6,=}\xa1\r\a6\x8e\xa1\x19\xc8Сa\xd7\x00`\bѰ\x98\xb5\x1c\x01\xfa\x18L\xa2\xf7$\x8b-\x85\xf69\xafi\x9d\xd9;\x92\x1a\xe1\x10\xff\xff\x8e\xf6\xe4\xbfk\x00z\xa1\xfa\xfe\x91\aR\xc3!u\x10\xb2\xf7\r@\xc0\x81:Xc\xff\x9c\x93P\x8a\xca\x16\xe5\xb5\xf7ȃ\xb6\xa3\xd9\t\xef+r\xa3\x89\xfa\xc2`+1\xa7ë\xe3\xf5\x886q\x1c\xff\xf7cu\xf96\x03\xaf\np\xbd\xf7\xac\xf6\xf3\xfb>\xbf\xb0Z\xf5K>\v\xfa\xf7(V\x17\xe5\xb0\xcd\x1e\xe5\x1d\xa7\x06@\xfb\x98\xa8\x83\xdf\n\xbd\x84=\xb9\x06`\x8f\x9e]\xcd\xcaH8&\nw\xf7_\x9f\xbe\u007f\xe8w4\xe0h\x04p\xa4\xbdp\xaa~ps\x9d,\xb0BVr`\x11\\ѐ\x96\xd8\xf7\xa4\n\xf8\xe6A\vp7A\x03\x04zy\xe3\x00/\xec=\xaci\x14\x8d\x1c\xc0\v\xdb\x0elGpt\xfaR\x93\xfe\x19VB\x8e\x821\xfa\x19\x13\x83\x83;\xef\xe3\v\xb9\xf9\xbf:\x82\x12ێ\xa4`\x17\xb4p\xb8\x05ۡU\xd3%\x97\x87D=\xc0\v\xea\x8c~ \xc5\x01\xa2\xd47oc\x952\xe1\r\x8f^\xef\xc1\xb6\x00\x8f;\x9aq\xdfda\xc3\xe4\xddH\xbb\x10\xce\xc9ոs.\n{\x88\x9b\xab\xf8\x97lۛɒ$&\x12\xe3C\x91\xd6|]\xf2?^\x01\xb0\xd1pf\x00\xb0\xd7RJj\xc2a\xdb\\\x9aQ\x04\x8f\xe1O\x86\xc1\x89\xe7yE\x95\x92\x1b}\xa6\xd2\xd1\xfa\xa5\xa9\x89Ɂ\xd6r\x1c\xbf\xca\nBIH)\x8c\x03\xe1\x8cY\xdc\x00\x06\x88\xeb?\xa9\xb7\x16\x1eH\n\b\xe8.f\xef\xca\xccؓ\x18\b\xf5q\x1b\xf8\x9f\x19YKՖ\x90\x1e\x8d\xa6\xa6\x9b\xbf\x1f\x8c$\xa0/͒\xe9s\xad\xad\x01_A\xa8Ā\x1cNЪ\x8b\xb6\xf0k\x14\x02\x0e\x9b\xd8\xc1\xce,i\xb7\\n\xd9\x0e㯏Ð\x03\xdb\xeb\xb2\x0e1^g\x8b\xa2\xcb:\xa9\x96\xca\xdb\x05J\xbfc\xa3\u07b2\xd0\x12\x13/*\xf1P\xa7_;\xb8\xff\xc94+\xf5\xe6M\xf2/4Y_TE\xf7у:\xb2\xde\x15\xaa\f\xab\xd2\xe88=\x1b?|ԣ\x98j\x93\xfe\xf4\xf0\b\a\x96U\xb3s\x91\xaa<\xc7gzT\xaad\x96ÆdTz#q\xa8\x88\x14\\\x8a\x1c\xc6\x16\xed=S8WI\xf3z`+\xa5\xf1W&\xb5\"h\v\xab\xba5NZ\xa7\x85\xaf\x01V8\x90_\xa1\xd2\u007f\xaeSɰ.JJ?V\xeatٝ;\x8eٚ\xcde\xb2\xa7Q\xcd{\x14\x1c\xc8H\xce\xda\x13\x9d\xab\xfb\x13\xfd\xfd\x95V\xff\xb0}\xaf\x84;\x9d\xb7\x1fTP\xc9?\v\xcd5\xb4\xb8\xce\xf7\xec\xf6\x14\xbe\xb9Je^\xe7\xfb\xdb\xe3\xa9~j1m\xefz\x01\xa0\xa5\x8c\\\a&y\x9c\xabjQpK\x93E\r-\xd7we7%\x9bF\xde\xe9\xb6\xfe\xf4\xe9l\xe5\xd6c\x1fØR\xed\xe0\xf7?\x9a\x11\x95\xdcӁG1\xfe\x1b\x00\x00\xff\xff\xc0J\x05\xd1\xfa\b\x00\x00"),
-	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xb4XM\x8f\x1b7\x12\xbd\xebW<x\x0fc\x03\xa3\x16\x8c\xbd,t3\xe4\xf5\xae\xb0\xebفǘ\xcb\"\a6YR3\xc3&;$[c%\xc8\u007f\x0f\x8a\xec\x0f\xb5\xd4\x1aO\x90D7\x16\xc9WU\xaf\xbe\xa8^,\x97˅h\xf4#\xf9\xa0\x9d]C4\x9a\xbeE\xb2\xbc\n\xc5\xd3?B\xa1\xdd\xea\xf0\xbe\xa4(\xde/\x9e\xb4Ukl\xda\x10]\xfd\x85\x82k\xbd\xa4\x8f\xb4\xd3VG\xed좦(\x94\x88b\xbd\x00\x84\xb5.\n\x16\a^\x02\xd2\xd9\xe8\x9d1\xe4\x97{\xb2\xc5S[R\xd9j\xa3\xc8'\r\xbd\xfe\xb7\x8a\x0ed\xde-\x00\xe9)\xdd\xff\xaak\nQ\xd4\xcd\x1a\xb65f\x01XQ\xd3\x1a\xd28K;\xef\xea`E\x13*\x17CQ\n\xf9\xd46\xca\xebCB]\x84\x86$k\xdf{\xd76k\x9cog\xa4ξ\xce7\x06\xfd\xe4]\xfdЁ\xa6=\xa3C\xfc\xcf\xfc\xfe\u007fu\xc8g\x1a\xd3za\xe6\xccJ\xdbA\xdb}k\x84\x9f9\xb0\x00\x82t\r\xadq\xc7\xe64B\x92bY[\xfa\x8e\xe3\xce\xc4\x10El\xc3\x1a\xbf\xfc\xba\x00\x0e\xc2h\x95\bʛ\xae!\xfb\xe1~\xfb\xf8\xf7\aYQ-\xb2\x10P\x14\xa4\xd7M:\x87\x9bK\xfb\xa1\x03\xda@\n\xd1e\xc6\t\x02\x96\x9e\xd1\xeb\xc6\xdbxl\xb4\x14\xc6\x1c;H@\xe0\xfeq\xf3\x0e\xec\x04\x04z?\n\xe0\u007fV\x12bE\xe8\xe1on\x02\xee+\x11\b\x95\b@\xed\x0eYU\xbf\x1f\x93\xab\xf9\xa7\x931ɯ\xeb\xd6$\x9d\xac\xa1\u05ca\xedǛ\x0e\xa2\xf1\xae!\x1fu\xcfW2u\xcc\xedAv\xce\nӖ\xcf@q6SH\x1a\xba\x9c$\x85\x90(\x85\xdb!V:\xc0S\xe3)\x90\xcd\xf9}\x02\v>\",\\\xf9#\xc9X\xe0\x81<\x83 T\xae5\x8aK\xe0@>\u0093t{\xab\u007f\x1e\x90\x03\xfb\xcb*\x8d\x88\xd4\xe5\xd3\xc0\x8a\x8d\xe4\xad0LLK\xb7\x10V\xa1\x16Gxb\x1dh\xed\tZ:\x12\n|v\x9e\xa0\xedέQ\xc5\u0604\xf5j\xb5ױ\xaff\xe9꺵:\x1eW\xa9&u\xd9F\xe7\xc3*\x15\xde*\xe8\xfdRxY\xe9H2\xb6\x9eV\xa2\xd1\xcbd\xb8M\xc5\\\xd4\xeaoCZޜX\x1a\x8f\x9c\xc1!zm\xf7\x838\x15\xd5U\u07b9\xa48䢻\x96\xed\x1f\xe9e\x11\xb3\xf2\xe5\x9f\x0f_\xc7\f\xe0\x10L9Ol\x8f\xd7\xc2H<\x13\xa5\xed\x8e|\x0eܐ<dU㴍i!\x8d&;%=\xb4e\xad#G\xfa\xa7\x96B\xe4\xf8\x14ؤ\x9e\x86\x92\xd06JDR\x05\xb6\x16\x1bQ\x93و@\u007f9\xed\xccpX2\xa5\xdf'\xfe\xb4\x15O\x0ff\xb6\x06q\xdf\"g#t\xd1-\x1e\x1a\x92\xe9\x8a\xdei\nc\xaas\xfe\x96\x94[\x9bJ4O\xe8\x14\xa7\xd5Z\x00_+\xc2\xe7\xce\xc2\x14\xa3\x92\xe0\x0e\xe4\xbdV\x8a\xecm\x8a\xca\xce\xf9Z\xc4\\s4\xf83-\x8dЫ\xefL\x92\x05\xf0\xe1~\xfb/n\xf7\xa9PR\x86\xe5\xcdc\xc2a\x0e\x18s4;\xb7\x99\xe2\x04x\xae\x8dt\xad$!O\xa5g\x94\r\xea;Ç\xb4-\x89\xd39kSg\b\xb3!\xe4\x1fO\xac\xe6\v5.\xe8\xe8\xfc\xf1E\xcdLj\x9ep\\\n\xdd\r\xf6\xd0S\xf4\x9a\x0e4m\x9b\x1c\xa4\x1c\x8a3\xd0n*\xf2\x18\x9a\xb4\xf2\xd5\xfd\xe3\x06F\x1f(@[\xd4m\x88\xa8ā \xa4\xa40t\xb0Q\xf5k}LI\xb3\x11V\x92yѿގ|\x14\xda*-\xb9]\xf6E\x9a\x82\x99\xf7\x9c\xdd;f\xfbd2Mo_\xb8,\x85\xe5$\f\x14!\"\x84=F]s\xccv\\\xd2\x13\xde<\tYq\xee#\x92\xaf5w\xe6\xa6J\xe5\x8f\xed\xee\x02wr\x95G`\xbe\xae.\xaeϲU:gHL'\xccyK\xbd\xe0\xa9盛\xe9\xfeǲp\xae\x99\xf0/\x97\xe8\x1a\xe51\xce\xdb?\x83Փ\xb1\xfdx\x8ev\xe5\nGW{\x9a\xf8\xbc\x1cjq\"<\xab\x96\xc9\xdeI\x96M\xe4L\xe7D0\x1a\xf8\xdd֙\x9fc\xaf\xe8\x1c5\x85 \xf6\xf4J\x8f\x913\xe2\xc5 \xdf 7\xe7\xfc\xb6\x1aG_~E\xe8\x1dɣ4\x94\x81ң$\x1f/\x00\xdc\xd1\xf3E\x9a.q\xe7\xf0\xec\xfc\x13\x8e\x14oa\xe9[\xec\xeeꀭ\xbd\xf7n\xef\xb9\xc8q\xba\x18\xb9\xcaiu\x99\xfc\xe2\x89,\x80\x8d\xab\x1bC\x91\x14\x96\xe91\xd75_.\x87\x92\xc8\xf6\xe9\b\xe0\x93\xd0&\x1d#\xee\xdbQD\xba\xbd,Uv\x04\xbbt\xf2\x16֝B>\x8bp\x82\x96\xc3\xcd\t\xbf\xc4sE6\x91\x93x\xb8\x00\xdd\x19\xb1g_\x03\xbb\xafw\xe3\xc9d%\x8f}a<\tu잰\xdaFwZ\xc3Wle\x98\xe9/'\r\x9e\xb51\t\x8a\xbb\xd6`g7\x1a\xfb\xe1T\x89x\x81\xf8\x9cH;)\xe2\fUr\x12d\xd3:\xb8D\xe3\xe8Fb\xa6۸\xb4\xf3*\x8b7\xafMپ\xc3\xfc[Xe^\xce]v\xb1J\xc7.\x86$\xbb\x9cՏ/\x89I\x0f=ýVpxa\\_\xd83\x8c\xec\xaei\xa6\u007f\x8b\xdc\xdc\xe6\x06\xb8\xa7\x1dy\xb2\x92T1\x83\xcb3`\x82Ǚӿ\x97T~\xd8\f\xcbܩ\xd3 -\xf9\xddȻ\xb3\x98\x92\x87Ї\xfbm\xb6\xac\xc0'\xe7yD\xc1\xc5*\xbfl\xbdZ6\xc2\xc7c\nO\xb8\x9dX\xd0\xf7\xcf9s\xafF\x13W\x06\xcd\x05w\xaf\x186#c\xbfׂ\xf4?\xff{\x16\xf0\u007f\xe6\xde\x02\xbe\xf0'Z07z075\xb2\x90\xb5ϖ\xcb\xd9ܸ6Ӻ)1\x91\x9d\xbf\rf\x00\xcf\xc1\x96)\xc3\xc6Ej8\x8b\xd9\xeb\xc3W\x97\xc3\xfbq\x95Ji\xd9}dI\x1b@\xe0\xffSj\x8d\xe8[\xea>E8\xcf\x13-K\xc6Iȏ\xc1&\x92\xba;\xff\xb0\xf2\xe6\xcd\xe4+IZJg\x95Ο\x88\xf0\xff\x1f\x16\x19\x95\xd4co\a\v\u007f\v\x00\x00\xff\xff\xcfw\x89\x10\xa1\x12\x00\x00"),
-	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xb4XMo\x1b7\x13\xbe\xebW\f\xfc\x1e\xfc\x16\xb0$\x04-\x8aB7W\ue1d0&0,ח\xa2\a.9Ҳ\xe6\x92[r\xa8D-\xfaߋ!\xf7C\xbbZ\xd9\t\x90\xea\xb6\xe4|>3\xf3\x90\xd4l>\x9f\xcfD\xad\x9f\xd0\a\xed\xec\nD\xad\xf1#\xa1寰x\xfe.,\xb4[\x1e\xde\x14H\xe2\xcd\xecY[\xb5\x82u\f\xe4\xaa\a\f.z\x89w\xb8\xd3V\x93vvV!\t%H\xacf\x00\xc2ZG\x82\x97\x03\u007f\x02Hg\xc9;c\xd0\xcf\xf7h\x17ϱ\xc0\"j\xa3\xd0'\x0f\xad\xff\xff+<\xa0\xf9j\x06 =&\xfdG]a Q\xd5+\xb0ј\x19\x80\x15\x15\xae XQ\x87\xd2QX\x14B>\xc7Zy}H\xc6f\xa1F\xc9N\xf7\xde\xc5z\x05\xe3\xedl\xa0\t+\xa7\xb4ml\xa5%\xa3\x03\xbd\x1d,\xff\xa2CުM\xf4\u009c\xf8N\xabA\xdb}4\xc2\xf7\xeb3\x80 ]\x8d+xϮj!Q\xf1Z,|\x03[\xe3>\x90\xa0\x18V\xf0\xf7?3\x80\x830Z\xa5\x9c\xf3\xa6\xab\xd1\xde\xdeo\x9e\xbe\xde\xca\x12+\x91\x17\x01\x14\x06\xe9u\x9d\xe4\xe0\xba\v\x12t\x80\x18P\x019\xf0\xf8g\xc4@@\xa5 \x10]X,B\xe2\x19\xed\x02`\xc3_\x8dE\x00\xeb\xa8S\xae\x84\x15{\x04*\x11\xb4=\xa0%\xe7\x8f\xe0v}\xd2 \xac\x02\xe50$5\xb0\x98\xf5\xf0c\v\x13\xff\xb4\x05\xe7\x15zޑ\xc6\xd9l\xb0M\x1fv\xdeU'\x91]7z\xb5w5z\xd2->\xfc;i\xcfnm\x8c\x02Ôe@qCbH\ue6b6B\x05!A\xc8iP\xa9\x03x\xac=\x06\xb4\xb9EO\xcc\x02\x8b\b\v\xae\xf8\x03%-`\x8b\x9e\x8d@(]4\x8a\xbb\xf8\x80\x9e\xc0\xa3t{\xab\xff\xea,\aΓ]\x1aAx\x02C\x86\x82\xd0[a\xb8\xc0\x11o\x12|\x958\x82G\xf6\x01ўXK\"a\x01\xef\x9cg\xf8wn\x05%Q\x1dV\xcb\xe5^S;\x90\xd2UU\xb4\x9a\x8e\xcb4V\xba\x88\xe4|X\xa6\xd9Y\x06\xbd\x9f\v/KM()z\\\x8aZ\xcfS\xe06\xcd\xe3\xa2R\xff\xeb\xda\xf0\xfa$R:r\xc7\x06\xf2\xda\xee\xbb\xe54 \x17q\xe79\xe1\xae\x12\x8dZ\x8e\xbf\x87\x97\x97\x18\x95\x87\x1f\xb6\x8f}\xf1\xb9\x04C\xcc\x13ڽZ\xe8\x81g\xa0\xb4\xddq#q\xe1R߰E\xb4\xaav\xdaR\xfa\x90F\xa3\x1d\x82\x1ebQi\n\xed(p}\x16\xb0N\xb4\x04\x05B\xac\x95 T\v\xd8XX\x8b\n\xcdZ\x04\xfc\xcfag\x84Ü!}\x1d\xf8S6\x1d\nf\xb4\xba\xe5\x96\xee&+\xb4\xadQ\xa6\xb1g\x94\x12q\xf7e`\xc5\x13\xbd\xa9\xd9\xe3_\xe6\xcf\a\xac]\xd0\xcc\x05\xc3ݑ\xbf\xc7\x12\x1b\x05\xaef\xa3\xc1\xb3\xd1s\x90\xe5J$Aے\xe3\xc8\"\xa4p[b[\xde?\xad\xc1\xe8\x03\x06&\x95*\x06\x82R\x1c\x10\x84\x94\x18\xba\xb9뽍\x8cM\x82˿\x16\x87\x9f\x85U\x06_\xcc\xeaa \n\x1ewܚ\xe4@\xc0\xdbX\xa0\xb7H\x18:\x837 \xa3\xf7hɌc\x01\x10\xc0\xd9\x14\x91r;sw\x17\b\xe9\xc8U\xa88ANf\x17\xb9\x83Fʗ\xea\x03\x99#\u007fJ\xa7\xdd\xd9\xce(\x93\xdb\xfbM\x12l{\"\x9d\x91\xb0s~H\xcf\x05\xf2\xe4\xa6<\xd1JT\x8b\t\xbb\x00\x9b\xdd\xc0\x1e\x8f\x16\xf7\x94\xdeiT7\xc9`\xf7\t\x89)R\xf1\nlҜ\xb4)y\x02o\xef79\xb2\x05\xfc\xe8<\b{\x04Ge\xe6\x00\xaf\xe6\xb5\xf0tL\x85\r7\x83\bxص\x9f\x0e\xf7b\x1f\xc0\x04\xcbMbג\x1d'\xc6\xd6\xf8\xa8\xb8\x88\xd8\xe7F\x90.5\xafE\xc0\xb7\x896\x02V\xf8\x82\x11\xb4Ѝc\x98'l\xce\x16\xd9\xfb䠍ȉ\u007f\xed诅\x95h^\x1c\xb4\xed@\x14\xb4UZ\x8a<[͍Ɓ\xcc{\xce\xee\x1d'\xdcZ_\xc0H\xfb,\u007f),7_@\x02\xbe\x16\xd9#\xe9\x8aa\xdbq˥nm9ʣ\x90%\xf2\xb1F\xe8+\xcdgw]\xa6\x03\x026\xbbI\xb2\xeaTK\x11\x1auu\xa6>\tX\xe1\x9cAqz\a\x99*\xc5\xfc\x8c\x86\a\x9bC.{\xf5\xc4ȷ\xceKg\xc6:\xb3W#\x96\xefL'\x192\v\x8d\xafM\x97\xb8\xa9\xc2\x10\xc4\xfeer}\x97e\xf25\xa2Q\x00Q\xb8H\x03\xbfס\th<\xdc\x17\x9bz\xea\x04\x9d\xf0\x9e\x85:\x0el\xfd\x11\xaa\xa9f\x06\x16\xac\x04\xad\xa08\xd2tE'BI\xe5\u007f1\x8e{\x96\xe8O\xea\xbe\x02\xd8\x16\xe0\xd7\xda8qFm\x97]z\xb7\xf7\x18\xce\x0e\x8b\xa1\xd7F\xa8\xcb>&'\x9fq\xf20\n\xe1\xce\xd9I\xf2j\xa1Җ\xbe\xfd\xe6\"\x1f\xf1\xfdx\x8f\xfe|\xae\x1c\t\xf3=\xdb\xffҶ_\xa5\xaa\xcd\xdd'\xd1\x14l\xee\xf2\x1b\x8b\xa7\xbe@\xb4\xdd\xf3\xea\x91O\xf7\x0f\xda\x18f\x9c\x9d6&\x1d\xeega~(1\x9f\xf8\xa9A`Ϗ*rp\xb5\xed\x9b\xf0\xea\xd3\n>\x91ҘG\xe6\xa77\xbe\x91|\xf7\x00?\xbc\xe9\xbf\x12\xee\xf3潝6\x00\x02\xdf\xcb\xd5\n\xc8Gl\x9e\xb0\xce\xf3\x88畞Z\xf8zV\x13\xaa\xf7\xe3\xc7\xf6\xd5\xd5\xe0-\x9d>\xa5\xb3J\xe7\u007f\v\xe0\xb7\xdfg\xd9*\xaa\xa76\x0e^\xfc7\x00\x00\xff\xff~\x94[,\xac\x10\x00\x00"),
-	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xbcXAo\xe3\xb6\x12\xbe\xfbW\f\xf6\x1d\xf2\x1e\xf0\xe4`\xbbEQ\xf8\xd6&-\x10\xb4\x1b,\xe2m.E\x0f#ql\xb1\xa1H-g\xe8l\xfa닡$K\x96\x1d'h\xbb\xd5%\xd1p83\xfc\xbe\x8fCZ\x8b\xa2(\x16\xd8\xda{\x8al\x83_\x01\xb6\x96>\vy}\xe3\xe5÷\xbc\xb4\xe1r\xf7\xb6$\xc1\xb7\x8b\a\xeb\xcd\n\xae\x12Kh\xee\x88C\x8a\x15]\xd3\xc6z+6\xf8EC\x82\x06\x05W\v\x00\xf4>\b\xaa\x99\xf5\x15\xa0\n^bp\x8eb\xb1%\xbf|H%\x95\xc9:C1g\x18\xf2\xff\xd7Ў\xdc\xff\x16\x00U\xa4<\xff\xa3m\x88\x05\x9bv\x05>9\xb7\x00\xf0\xd8\xd0\nLx\xf4.\xa0\xe1\xe5\x8e\x1c\xc5к\xb4\xb5~iÂ[\xaa4\xe96\x86Ԯ`>\xdc\x05\xe8\xcb\xea\x96t\xdd\xc7\xca&gY~:0\xfflY\xf2P\xebRD7ɝ\xadl\xfd69\x8c\xa3}\x01\xc0Uhi\x05\xb7\x9a\xaaŊԶCgM^T\x97<\xb4\xe4\xbf\xfbps\xffn]\xd5\xd4`g\x040\xc4U\xb4m\xf6\xdb\xd7\xd0[K\x02\xecWTtK\x82H,!R?\xb9\x8d\xa1\xa5(vX\xa0>\x13\x82\xf7\xb6Y\x9a\v\xad\xa3\xf3\x01\xa3\x94\x12\x83\xd4\x04=1d\x80s\x8d\x106 \xb5e\x88\xd4Fb\xf2\x1dɓ\xb0\xa0.\xe8!\x94\xbfS%KXS\xd4 \xc0uHΨ\x0ev\x14\x05\"Ua\xeb\xed\x1f\xfb\xc8\f\x12rJ\x87B=\xde\xc3c\xbdP\xf4\xe8\x14\xc1D\xff\a\xf4\x06\x1a|\x82H\x9a\x03\x92\x9fD\xcb.\xbc\x84\xf7!\x12X\xbf\t+\xa8EZ^]^n\xad\f\x92\xaeB\xd3$o\xe5\xe92\vӖIB\xe4ˬ\xbeK\xb6\xdb\x02cU[\xa1JR\xa4Klm\x91\v\xf7Y\xd1\xcb\xc6\xfc'\xf6\xfa\xe7\x8bI\xa5\U000a4733D\xeb\xb7{s\x96س\xb8\xab\xd2\xc02`?\xad\xab\u007f\x84WM\x8a\xca\xdd\x0f\xeb\x8f0$\xcd\x14\x1cb\x9e\xd1\x1e\xa7\xf1\b\xbc\x02e\xfd\x86bG\xdc&\x86&G$o\xda`\xbd\xe4\x97\xcaY\xf2\x87\xa0s*\x1b+\xca\xf4\xa7D,\xca\xcf\x12\xae\xf2Ɔ\x92 \xb5\x06\x85\xcc\x12n<\\aC\xee\n\x99\xbe8\xec\x8a0\x17\n\xe9\xcb\xc0O\xfbѡc\x87\xd6\xde<4\x8c\x93\f\xad[\xaa\x94\xa0\x8cRn}#\r:q2\xef\xd4\xdeӧߡc';\x18\x9d廛9\xe7\x9d\x12MW\x80؆\xf2?}HxD\x86\n\x9d#\xb3\x9c\x05\x05\xf8X\x13p\x16\xc1\x05w3-Cb2\xb0\t\x11\xd6\x1e[\xae\x83\xec\x13\xcd\xe6oBlPV\xa0,\x17:{6~\x12\xf2\x8cf\x1f\xf8\xe6\xfa\xec:\xd7{\xb7\x01]k\x94荥\x98\vT\xd3\x10\xabk;\x04\xbb\xe0RC\xf3\xa5>S\xcai\xae\x05%\xf1\xb3l\x0f\xedv\x9d\xddF\xdec$/\xfd\xe4\xdc\xe0\xf6\x9e\xcbW\b\xa0\nM\xeb\xe8\xf04;\x87\xcdձ\xff\xb1\f\xd0\xefϛN\x06ݤSJ\x18\xe3\xeduЅ#\x03\xb4#\x0f\xc1\xc3\x06\xad#39V\x0f\xf4s\x14\xf2HO'j\x9e\xcfzIRz\xbec\xe9h\x05\x12ӫ\xf5\xd6\x103n\xe9,\xa0\xef;\x9f\xae\xd1\xf6\x13\x00ː\xba\xee7,\xfa\x82{\x8a_\xa90\x00O\x9f\xe5\x8e$>\xbd\x8e\xd8\xdb#\xf7\xe1\\,i\xcflg\x97\x1a\x05\xac7\xb6B\x99c\x01\xd9WsC\xd4h\x99\x80\x19\x81pu\xb7\x84_\x94\x1d\t\xb0\xb1N(\xc2|\xbdGa\xfb^\x0f\x8f\xb5\xadjU\x141X\x0f%m\xb4\xd9L\x12j\x9ds\x90\xbe\f\xbdm\x8d|\x9e\xdc\x0f\xeaqj\xab\xd2\xd06N\xedU}ȧf\x1e\xba\x80[z<\xb2\xdd\xf8\x0f1l#\xf1\\\xd3\xc5 |\x9a\xa3Y@f\xfa\xc8\xfac\xe6\xe9\xd5ˏ\xa1\"\xd6+\xe6m0\xe7q\xd0\xfdz\x8d\x82\xef\xd1\xe3\x96\"\xf8`\xa8\x93Q\x8d\f\xad\xad\x1e\xc8@j\x0f\x10QҎT0\xe6\xd4&`\x19\x1e\xads\x933\x1f\x90\x81C\xf0\xfa\xf7 \x98\x1d\xd2\x1c\x85Lm\xd6\xe84\xf2M\xcfͤ\xe2J\xb7\x82\xbf\x90\xc1\xef|\x99\x1c\x1a=\b\x91\x83\a+\xfb\"ǅ\x96O\x80>H\xddc\xf1\xeaM\xdd\xf6T\x9fW]\xef\x04upC[\x0e\x82\x0e|jJ\xddk\x1b(\x9f\x84\xf8\xf0\xe8:Z\x84^e\xa7\xba\x1dg\x0fG|^\x88\x10\xf7\\T\xe83\x15\xfd\xce6\x96[\x87s\x95\x8dk\xc8w1ݗ\xda\xfcǎ7\x04\xd7\xf3*\x8f\xcd\xc1y\xee(\xd3'\x97s\x1d\xfc\x91\x1ca\xd2\x05\xac\x97o\xbe>1ޡ\xae7\xfa-\x1ds\x9a!\xfc^\xe3\xffӱO\xde\x06 \xf7<\x89OW!yy\xe1^6\xb8\x1d\x1c\xc5S\xbe\x86\xc6\xc8Y\xeb\x91\n4f\xae-}p\xe8\xd5\xd7c\xaf\xee[\xee\xd0ē\xaa\t<\xc9c\x88\x0f`\x99\x13A\x89ՃZ?%JGb\x86\xae\xd7k\xe2\xc4z\u05cfX=\xe8\x8f\a\x15\x98\xa12m\xb7\xba\xeb\x9ei\xd9\xd6˻\xafN\x02v\nL\x16\x8c\xf2\xba\x03o}\xe0\xfa\xf2%&\x87\xfe\v\x97ك4\xffν\xa3\xdb\xd0/\xdcr\xef{\xa73w\xdc~+\x9a\xbf}\xb9\xd5\xc3\xdbF\xda\xff\xd8,\xa6\xbfOf\xfe\xfb\x0f.\xbb\xb7\xe3[\xdesE\xff}%\x0f@\x87\xb9\x99 \xa3\xc5\xeau\xab\xb3\x8c\xd7i\xac*j\x85\xcc\xed\xfc\xe3ʛ7\a\xdfN\xf2k\x15\xbc\xb1\xdd\xd7!\xf8\xf5\xb7E\x17\x95\xcc\xfdP\x87\x1a\xff\f\x00\x00\xff\xff\xdb\xc6XŜ\x12\x00\x00"),
-	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xbcXя\xdb6\x0f\u007f\xcf_A\xf4{\xb8\xef\x03\xbe\xe4\xd0u\x18\x86\xbc\xad\xb9\x0e8l\xbd\x15\x97\xb6/\xc3\x1eh\x8b\x8e\xb5\x93%W\x94r\xcd\xfe\xfa\x81\x92\xed\xd8q.\xbd\r\xdd\xf2rg\x8a\"\xa9\x1f\u007f$e/\x96\xcb\xe5\x02[\xfd\x91<kg׀\xad\xa6ρ\xac<\xf1\xea\xe1{^iw\xbd\u007fYP\xc0\x97\x8b\am\xd5\x1a6\x91\x83k\xee\x89]\xf4%\xddP\xa5\xad\x0e\xda\xd9EC\x01\x15\x06\\/\x00\xd0Z\x17P\xc4,\x8f\x00\xa5\xb3\xc1;c\xc8/wdW\x0f\xb1\xa0\"j\xa3\xc8'\x0f\xbd\xff\xff*ړ\xf9\xdf\x02\xa0\xf4\x94\xf6\xbf\xd7\rq\xc0\xa6]\x83\x8d\xc6,\x00,6\xb4\x86\xd8\x1a\x87\x8aW{2\xe4]k\xe2Nەv\vn\xa9\x14\x97;\xefb\xbb\x86\xd3弽\v*\x1f\xe8C\xb2\x94\x04Fs\xf8i$\xfcYsH\v\xad\x89\x1e\xcd\xe05\xc9X\xdb]4\xe8{\xe9\x02\x80K\xd7\xd2\x1a\xee\xc4E\x8b%\x89l\x8fF\xabt\x94\xecԵd\u007fxw\xfb\xf1ն\xac\xa9\xc1,\x04Pĥ\xd7m\xd2\xeb\xbcw\xb2\x82\x00\xbbs,\xf3A\xa0\xc0\xf2!\xb6\xdd\xceֻ\x96|\xd0\xfd\xa9\xe47\xca\xe9 ;\xf1q%Ad\x1dP\x92Eb\b5A\x97\vR\xc0)@p\x15\x84Z3xj=1ٜבY\x10\x15\xb4\xe0\x8aߩ\f+ؒ\x17#\xc0\xb5\x8bFI\xea\xf7\xe4\x03x*\xdd\xce\xea?\x06\xcb\f\xc1%\x97\x06\x03u@\xf7?m\x03y\x8bF\xe0\x8b\xf4\u007f@\xab\xa0\xc1\x03x\x12\x1f\x10\xed\xc8ZR\xe1\x15\xbcu\x9e@\xdbʭ\xa1\x0e\xa1\xe5\xf5\xf5\xf5N\x87\x9eťk\x9ahu8\\'.\xea\"\x06\xe7\xf9:\x11\xee\x9a\xf5n\x89\xbe\xacu\xa02DO\xd7\xd8\xeae\n\xdc&\x12\xaf\x1a\xf5\x1f\xdfQ\x9e\xafF\x91\x86\x83$\x9c\x83\xd7v7\x88\x13\xaf\x9e\xc4]\b\x06\x9a\x01\xbbm9\xfe#\xbc\"\x12T\xee\xdfl\xdfC\xef4\xa5`\x8ayB\xfb\xb8\x8d\x8f\xc0\vP\xdaV\xe4s\xe2*\xef\x9ad\x91\xacj\x9d\xb6!=\x94F\x93\x9d\x82αht\x90L\u007f\x8a\xc4A\xf2\xb3\x82M\xaae(\bb\xab0\x90Z\xc1\xad\x85\r6d6\xc8\xf4\x8f\xc3.\b\xf3R \xfd2\xf0\xe3\x164U\xcch\r\xe2\xbeK\x9c\xcdж\xa5R\x12\x94PJ\xdd\xee\x98\x06\xd98\xdaw\xae\xf6\xe4\x97\v\xf4ػ&\x8b'\xee^OuS\x9dx\x95\xdd\a\xddP\xfa'\x1b\x84Gd(\xd1\x18R\xab\x13\x93\x00\xefk\x02N\f\xb8\xe2\xbcQ3D&\x05\x95\xf3\xb0\xb5\xd8r\xed\xc2\xe0\xe7d\u007f\xe5|\x83a\r\x92\xe2\xa5\xec>Y?\x8bw\x82\xb23|{s\xf1\x94\xdbA\xad\x87V+\xc9r\xa5ɧ\x00E\xd4\xdb\xca=\x87`\xefLl\xe8\xf4\xa8O\x86\x92\xfb\xf0\x06mI\xe6b0\x1fF\x8a\xa0\xadҥt\xa0\x9e\xf7Җʼ\xe6\xec\xceI=f\xcb\xe7\x03)\x9c3\x84\xf6\x8b\x94\v\x18\"?I\xba\x1c\xd26)\x1d\xc9\xe7=\xd9\xd0mM]\xb6\xd3[=\x83\x83\xa5kZC\xd3\x19z\t\x94\xcd\\\u007f\xceE\xb4\x1d\x16\x99\x8ay\xcb96\x1e\xad\r\\\xcc\xc6H\x01\xedɂ\xb3P\xa16\xa4\x86Q>g\xf0\xcc\xea\x98\xd1g\xe2\xe5\xbfHj\xb9Q`ah\r\xc1\xc7g3\xbeK\x8b\xd4\xed/Uu\x19Ӊ\xea\x04N\xa9hWU\x82\x83\xa7\xe0\x0f\xe9L\x19\x91ٱ;\xfa\xc1}RtC\x0e\xba\t\xeb\n:\x00}n\x9d\x95\x8aB3\xd8n\xa8\xac\xd1jnN\xf3\xd3\x03\xa3mx\xf5\xcd\xd9s\xcb\x00ޑ\x9f\xac5Č;\xbax\xe2\xb7Y'\x0f\xb8n\x03`\xe1b\x9e:9\xf0+\xee8\xfd\xecҶ\xf49\xa4\xd3?\x8f\xcbw3\xf5\x1e\xab\x82\x062gy\xa81\f=`\x86\xbc\xe8\x8a\xefY\x8e\xfa\x14l\xeeW\xf0A(\x19\x1cT\xda\x04\xf20=\xeb\xccd\xdfe\x1ek]\xd6RB\xc4 w:\xaad\x90\x8e\x9cI\x8cO%\xee\xeb2\xba\xad\x91/\xa7\xf5\x9dh\x9c\xebK\xd4\xf7\xeayc\x92\x1f\xd9\u061c\x1a^\xc2\x1d=\xced\xb7\xf6\x9dw;O|Z\xc4˾\xd2g\x85\xb1윾\xf1\xde\xf9\xf9.\xe9ʱ\xfd\xf1\\I-!7\xff'\x17N\x11\xba\x04\x9ew%\xb1\xbc\r\xdc9u\x19Eip7\x18\xf0-Zܑ\a\xeb\x14e\x02\xd6\xc8\xd0\xea\xf2!\x11k\x84\xa7$|Ơ\xa3G陚\xe1Q\x1b3\xba\xa1\x012\xb0sV\xfe\x8eL鑋\x99Ma\xf6\xd8\xeem\xce\xea8\xdaR\n\xc8^\x85^o\x14\"\xb0;ӭ=!;\v:\f\x01\x1e\x8fX\x1c\x00\xad\vu\x87³\x1bAۑ\xe42[;%\xa8\x9d駗\vh\xc0Ʀ\x90\n\xad\xa08\xc8ğ\xdc3f\xf1\xcbKǘ\xef\xa3\xddx<G \xee\xd2P\xa2MY\xe8ځ\xd2\xdc\x1a<\x9cK_\x8eN.\xcdR\xce2$\x8f-\xb2\xbb\xea\xc9POK\xa7\xd0<5\xef嗢\xb9qvFC\x986\xfd\xef\xbe=\xb3\xfet\xe3O\xab\x02\xe0k\xb1\xff\xb5m\x9f\xbd.A\xe2O\xf0\x87\x8d\x8b6\\\xcc\xf6\xfd\xa06\x19\xb0\xc7l\x1d\xdb)'\xb2\xa2R\xf3\xfa\x06\xc0\xbe\xb5w\xbc\xce\r\xba\x93\xa9(\x1c\x02K\xe1\xd1\xf9\a\xd0\xcc1\xa7J\xa4\x9f\"E\xcas\xe0laE\x96w1\x8f\xe5\x83\\&\x85V\x8a\x8a\xb8\xdbI\xa5}\x95\xc9\xcc\x01}x\xdeh\xdcNT\xbft\xc3K\x86\xff\xc6\xdb\xc6\xc4ɿq-;C\"\x19\xb4\xda\xd3\xf0*\xbe\x1c\xbf\xbd\x9d\xe8\x0f_\xa0\xf6/\x8fO\x89\xe9\xcb\xee\x83SZ\x80|f5\x8a\x8c\x83\xf3r)ʒ\xe3-\x1f˒\xda@\xea\xee\xf4{Ӌ\x17\x93OJ\xe9\xb1tV\xe9\xfc\xb9\f~\xfdm\x91\xad\x92\xfa\xd8\xc7!\xc2?\x03\x00\x00\xff\xff\xdcb4\x1a\xad\x13\x00\x00"),
+	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xb4VOo\xe3\xc6\x0f\xbd\xebS\x10\xfb;\xec\xaf\xc0ZF\xd0K\xa1\xdb\xd6i\x81\xa0\xff\x82$\xd8\xcbb\x0f\xd4\fm\xb3\x19ͨ$\xe5\xad\xfb\xe9\x8b\x19ɲ\x9cxwOM\x0eƐ\x9cG\x0e\xdf\x1b\x8e\xaa\xd5jUa\xcf\x1fH\x94Sl\x00{\xa6\xbf\x8db^i\xfd\xfc\x83֜և\x9b\x96\fo\xaag\x8e\xbe\x81͠\x96\xba\a\xd24\x88\xa3[\xdard\xe3\x14\xab\x8e\f=\x1a6\x15\x00Ƙ\f\xb3Y\xf3\x12\xc0\xa5h\x92B Y\xed(\xd6\xcfCK\xed\xc0\xc1\x93\x94\f\xa7\xfc\xff\xf7t\xa0\xf0]\x05\xe0\x84\xca\xfe'\xeeH\r\xbb\xbe\x818\x84P\x01D쨁\x16\xdd\xf3\xd0\v\xf5Iْ0i=\x9a\xbc\xf0\xa1\xa0Vړ\xcb\xd9w\x92\x86\xbe\x81\x97\xee\x11i\xaao<ۏ\x05\xe1\xe1\x04z,\xae\xc0j\xbf\\u\xff\xcaj%\xa4\x0f\x83`\xb8VTq+\xc7\xdd\x10P^\x05\x1c+\x00u\xa9\xa7\x066aP#\xa9\x00\x0e\x18ؗ\xb3\x8f\xa5\xa5\x9e\xe2\xfb\xfb\xbb\x0f\xdf?\xba=u\xa5\xbd\xd9\xecI\x9dp_\xe2\xe0\xed\xabڀ\x15\x10܈\xba*I<\xc8\xc4Z\rpg9b\xa6\xc5O\xa8\x00\xed\x11lO\x13\x1eܖn\x02ƼyKBё\xcf1\xf0\x18\xb1\xd7}\xb2w\xb0\t)\xd2ϒ\xba\x93\xa9\x84\xdfR \xa3\x1a\xe0iO3\xf8\xa9J\x9d\xcb<\x95T\x14\x82\x1c\xb5dwB\x9e\xa21\x06\xd8&\x01\x9c\x1a\a\xe7ν\x00>\x1f|\xaa\xd8ga҈6r\x0e\xb6G\x83\xcf\x1c\x02\xb4\x04\x83\x92\aK`\x18\x9e\xcb\xef\x9e\x16\xe8\x00\x7f\xc4p\x9c\xd1糒\xb9\x1a6\x0f\n[Iݨ\x9f\x1e]I\x83\x06(T\xd4B\x1e8\xc2\xfb\x10\xd2g\U000bf7c3r\xee\x19\xb3%@g\xe4!\xc5w\xc0\xdbR\xe8\f\x98\xb9\x81\x98\xec:N\x0eM=I\x11\xc9%\xea\x169\xd4o\xa7e/9\xca\xf8$\xf2\xfc\x8f/\xd1\xce.\x006\xea.\f\x00v\xcc\xeaT\x13\x8e\xbb\x85c4\xa3\b\x9e\xbb\xb4\x18$\x8b\xc8K\x9df!\x8f1\x17\f\x1dF\x1by\xd0\"rH\xb9!\xac\x99\x12!\xa58\x0e\x93\x05,\xe4\x10\x8c\x90\xda?\xc9Y\r\x8f$\x19\x04t\x9f\x86\u0cda\x0e$\x06B.\xed\"\xff3#\xeb\x89\xec\x80Fj\x17\x88\x1c\x8d$b\xc8Wp\xa0wE\xc6\x1d\x1eA(\xe7\x80!.\xd0J\x88\xd6\xf0[\x12\x02\x8e\xdb\xd4\xc0ެ\xd7f\xbdޱ\x9dF\xa7K]7D\xb6\xe3:\xcb[\xb8\x1d,\x89\xae˔[+\xefV(n\xcfF\xce\x06\xa15\xf6\xbc*\x85\xc7|X\xad;\xff\xbf\xd3\xf5з\xaf\x9a\xff\x82\x93\xf6\xc5\x00\xd8\x04\xe4\xae\xf9֮2\xf7\xbe\xc8V\x1e{Y\x8a8m\x1b\x1bs&%\x9br/\x1f~z|\x9a\x87K!n\x01\t\x13G\xe7mz\xa6+\xb7\x97\xe3\xb6\xdcN\x9e\xaeUF\xa4\xe8\xfb\xc4\xd1ʵp\x81)^R\xa5C۱e}\xfc5\x90Zf\xb5\x86Myv\xca\xed\xee=\x1a\xf9\x1a\xee\"l\xb0\xa3\xb0A\xa5\xff\x9c\xac\xdca]\xe5\x96~\x9b\xae\xe5ky\xfa\xcb\xfb\x9b\xa9[\xb39\x0f\xa5~\xa4\xf4\x1e\x05;2\x92\x8b;\x8aޗ\a\x18\xc3\xfd\x95\xfb\xfe\xc5\x02\xbe\x92n9H\x9b\xea\xab8\xb9\xff,4kh\xf5z\xc0̞\xab\n\x9d\xbdW\xcfy\xe1]\x96U]=\xc24F\x1a8ܜW\xa5\x19\xab鳡8\x004O\v߀\xc90>NjIpG\x93E\rm(\xfb\xd09\xeam:\xce\xf2S\xe1͛\x8b\x97\xbf,]\x8a#\x15\xda\xc0\xc7O\xf9m\xb7$䧁\xa7\r|\xfcT\xfd;\x00\x83@:@s\t\x00\x00"),
+	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xb4UMo#7\f\xbdϯ \xb6\x87\xb4\xc0z\x8c\xa0\x97bn\xa9\xb7\x87E?\x10$\xc1^\x16{\xa0%\xdaf\xa3\x91T\x91r\x9a\xfe\xfaB\x9a\xf1x\xecػ\xa7\xb5OC\x91OO\xfcxl\x16\x8bE\x83\x91?Q\x12\x0e\xbe\x03\x8cL\xff*\xf9\xf2%\xed\xf3/\xd2rX\xeeoפx\xdb<\xb3\xb7\x1d\xac\xb2h\xe8\x1fHBN\x86>І=+\a\xdf\xf4\xa4hQ\xb1k\x00\xd0\xfb\xa0X\xccR>\x01L\xf0\x9a\x82s\x94\x16[\xf2\xeds^\xd3:\xb3\xb3\x94\xea\r\x87\xfb\x7f\xb4\xb4'\xf7S\x03`\x12\xd5\xf8'\xeeI\x14\xfb\u0601\xcf\xce5\x00\x1e{\xea`\x8d\xe69\xc7D1\bkH\xaf\xc6!\xf7\xd2\x0ef\x9bx_\x91\x1b\x89d\n\x83m\n9vp~<\xa0\x8d\x1c\x87\xf7\xfdZ\x11\x1e&\xe0U\x01\xae\xe7\x8eE\x7f\xbf\xee\xf3\a\x8bV\xbf\xe8rBw\x8dbu\x11\xf6\xdb\xec0]qj\x00ĄH\x1d\xfc\x85=IDC\xb6\x01أc[\xb32\x10\x0e\x91\xfc\xdd\xfd\xc7O??\x9a\x1d\xf55\xf1\xc5lIL\xe2X\xfd\xe0\xe62Y`\x81,dA\x03\xd8RCZ\xa21$\x02\xf8&\xa0\x05\xb8\x1b\xa1\x01<\xbd\xbcq\x80\x17v\x0e\xd64\x14\x8d,\xc0\v\xeb\x0etGpt\xfaPk\xf2\x1eV\x89,yet\x13&z\vw΅\x17\xb2\xd3{e\x00%\xd6\x1d\xa5\x82]\xd0\xfc\xe1\x14t\x87Z/8\xe7\xf2\x18\xc9\x00\xbc\xa0L\xe8\aR\xec!\xa4\x1a\xf3\xf6\xae\xd2&\xbc\xe1\xc1\xeb\x1al\v\xf0\xb4\xa3\t\xf7\xdc\x056L\xce\x0e\xb4\v\xe1\x1cmMƔ\x8b\xc2\x1e\xc2\xe6\"\xeds\xb6\xed\xcdh\x89)DJʇ&-\x7f<\xe7\x7f<\x02`\xa5\xfe\xc4\x00\xa0\xaf\xa5\x95D\x13\xfb\xed\xec`0cJ\xf8:Ygb0\xf3<\xed\xa8\xd2r\x83\xcf\xd8:R\x9f\xb4\x1fldAj;\x0eOe\x81D1\x91\x90\x1f\x04a\x06\v\xc5\x05=\x84\xf5\xdfd\xb4\x85GJ\x05\x04d\x17\xb2\xb3E3\xf6\x94\x14\x12\x99\xb0\xf5\xfc߄,\xa5k˕\x0e\x95DO\x10\xd9+%\x8f\xae\fK\xa6\xf7\xb5\xb7z|\x85D\xe5\x0e\xc8~\x86V]\xa4\x85?C\"`\xbf\t\x1d\xecT\xa3t\xcb\xe5\x96\xf5 \x7f&\xf4}\xf6\xac\xaf\xcb*b\xbc\xce\x1a\x92,\xabR-\x85\xb7\vLf\xc7JFs\xa2%F^T\xe2\xbe<V\xda\xde\xfe\x90F\xad\x94\x9b7\xc9?\xab\xc9\xfa\xac+\xbao\x05TɺZ\xa8\"Ve\xd0q\f\x1brr\xacG1\x954>\xfc\xf6\xf8\x04\a\x96\xb5f3H\x18\xcbs\f\x93c\xa5Jf\xd9o\xa8\xcc\x15\vlR\xe8k/\x90\xb71\xb0\x1fF\xd48&\x7fZ%\xc9략\xb4\xc6?\x99DKA[Xխ1\x1b\x9d\x16>zXaOn\x85B߽N%ò()\xfdv\xa5\xe6\xcb\xee\xf0+\xf1ݘ\xad\xc9\\\x94=\x0eռǄ=)\xa5\x93\xf1Dk\xeb\xfeDw\x7faԯ\x12\xf8\xcaus\xbd횯\xe2\x94\xfcs\xa2\xa9\x87\x16\x97\xf9\x9e\x9c\xceᛋTF%\xe8`\x7f{\xfc\xaa\x8fZ\x8cۻ\x1e\x00H\x19xہ\xa6<\xe8\xaahH\xb8\xa5\xd1\"\x8a\x9ak\\\xd9MQGɛo\xebw\xefNVn\xfd4\xc1\x0f)\x95\x0e>\x7f)\xbbTC\";j\x96t\xf0\xf9K\xf3\xff\x00\xc0J\x05\xd1\xfa\b\x00\x00"),
+	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xb4XMo\xe4\xb8\x11\xbd\xf7\xafx\xd8\x1c\xbc\vX2\x06\xb9\x04\xba\r\xecL\xd2Hvb\x8c\a\xbe,\xf6@\x91\xd5-\xc6\x14\xa9\x90T{:A\xfe{P\xa4>Z\xean\x8f\xf3\xe5\xf6E\xfcx\xacz\xaczUҦ(\x8a\x8d\xe8\xf43\xf9\xa0\x9d\xad :M\xdf\"Y~\n\xe5\xcb\xefB\xa9\xdd\xdd\xe1CMQ|ؼh\xab*\xdc\xf7!\xba\xf6\v\x05\xd7{I\x0f\xb4\xd3VG\xed즥(\x94\x88\xa2\xda\x00\xc2Z\x17\x05\x0f\a~\x04\xa4\xb3\xd1;c\xc8\x17{\xb2\xe5K_S\xddk\xa3ȧ\x13\xc6\xf3\x7fTt \xf3\xd3\x06\x90\x9e\xd2\xfe\xaf\xba\xa5\x10E\xdbU\xb0\xbd1\x1b\xc0\x8a\x96*H\xe3,\xed\xbck\x83\x15]h\\\fe-\xe4K\xdf)\xaf\x0f\tu\x13:\x92|\xfa\u07bb\xbe\xab\xb0\x9e\xceH\x83}\x83o\f\xfaɻ\xf6i\x00MsF\x87\xf8\xa7\xcb\xf3\x7f\xd6!\xaf\xe9L\uf179dV\x9a\x0e\xda\xee{#\xfc\x85\x05\x1b H\xd7Q\x85Ϣ\xa5\xd0\tI\x8a\xc7\xfa\xda\x0f\x1c\x0f&\x86(b\x1f*\xfc\xe3\x9f\x1b\xe0 \x8cV\x89\xa0<\xe9:\xb2\x1f\x1f\xb7Ͽ}\x92\r\xb5\xe9\x0exXQ\x90^wi\x1dn\xce\xed\x87\x0e\xe8\x03)D\x97\x19'\bXz\xc5x6~\x8c\xc7NKa\xccq\x80\x04\x04\x1e\x9f\xef\x7f\x02\x93\x0f\x81я\x12\xf8\x8b\x95\x84\xd8\x10F\xf8\x9b\x9b\x80\xc7F\x04B#\x02кC>j\x9c\x8f\xc9\xd5\x04\n\x9d\x8cI~]\xb7&\x9d\xc9'\x8c\xa7b\xfbp3@t\xdeu\xe4\xa3\x1e\xaf\x94\x7f'\xb1=\x8d\xadYa\xda\xf2\x1a(\x8ef\nɇC\x1e#\x85\x90(\x85\xdb!6:\xc0S\xe7)\x90\xcd\xf1}\x02\v^\",\\\xfdW\x92\xb1\xc4\x13y\x06Ah\\o\x14\xa7\xc0\x81|\x84'\xe9\xf6V\xff}B\x0e\xec/\x1fiD\xa4\x10\x17\x88\xdaF\xf2V\x18\xbe\xf0\x9en!\xacB+\x8e\xf0\xc4g\xa0\xb7'hiI(\xf1\xb3\xf3\x04mw\xaeB\x13c\x17\xaa\xbb\xbb\xbd\x8ec6K\u05f6\xbd\xd5\xf1x\x97rR\xd7}t>ܥĻ\vz_\b/\x1b\x1dI\xc6\xdeӝ\xe8t\x91\f\xb7\xecl([\xf5\x9b\xf12\xc2H<\xff\xe2\x91#8D\xaf\xed~\x1aNIu\x95wN)\xber1l\xcb.\xce\xf4\xf2\x10\xb3\xf2\xe5\xf7O_\xe7\b\xe0+8\x81\xc4\xc0\xf6\xbc-\xcc\xc43Q\xda\xee\xc8狛\x82\x87\xacꜶ1=H\xa3\xc9.I\x0f}\xdd\xea\xc87\xfd\xb7\x9eB\xe4\xfb)q\x9f4\r5\xa1\uf508\xa4Jl-\xeeEK\xe6^\x04\xfa\xbf\xd3\xce\f\x87\x82)\xfd>\xf1\xa7R<\xfe\xf1\xfej`k\x1a\x1e%\xf2\xe2\r\x9d\xa9\xc5SG2m\xd1;Ma\x0eu\x8eߚ\xb2\xb4\xa9\xa4\v'\x908\xd1\bl\x1fJ\xe0kC\xf8y\xb00\x05sMp\a\xf2^+E\xf66\xdd\xca\xce\xf9VDN(~\x1a\xfdY\xc0\xea0\x1e?\x98$K\xe0\xe3\xe3\xf6\x0f,\xf7)QR\x84\xe5\xc9cBe\x0e\x18s6;\xcbLy\x02|IF\x06)I\xc8\xcb\xd1\x15e\xd3\xf1\x83\xe1S\xd8\xd6\xc4\xe1\x9cO\x9b5\xef\x8d+\xe4\x7f\xaeX\xdd\x17\xea\\\xd0\xd1\xf9\xe3\x9b'3\xa9\xbc\xbe\xef\xe0\xa7\x1d졧\xe85\x1dh)\x9b|I\xf9*V\xa0CU\xec\xc4J\xca\xef\x1e\x9f\xefa\xf4\x81\x02\xb4Eۇ\x88F\x1c\bBJ\n\x93\x82\xcdG\xbf\xd7\xc7\x144\xf7\xc2J2o\xfa7ڑ\x97B[\xa5%\xcb嘤l\x81\xccs\xce\xee\x1d\xb3=:[b\xf2\"\xef^\x9d\x03Ha9\xb1\x03E\x88\ba\x8fQ\xb7\x84\x9avίx\xf3$dñ\x8fH\xbeլ\xcc\x1d\x17\xb8\x12\xd8\xee\xcep\x17[\xb9\x04\xe6\xed\xeal\xfbjg\x8e\x88\xda9CbYa֒z\xc6Ө\xaa\xa7\xe1\xfe\xdfE\xe1%1\xe1_N\xd1\n\xf51\xd2{\xb1F2\xb6\x0f\xd5\xfb\xb6\xf0\xedjO\v\x9f\x8b)\x17\x17\x83\xablY̝D\xd9b\x9c\xe9\\\f\xcc\x06~W:s;\xf6\x0e\xe5h)\x04\xb1\xa7wz\x8c\x1c\x11o^\xf2\rr+\x97{\xab\xb9\xf4\xa5\x1c4zG\xf2(\re \x0e\x00\x91\x97\x97\x00>\xd3\xeb\n\x19(\xf0\xd9\xe1\xd5\xf9\x17\x1c)\xde\xc2ҷ8\xec\xd5\x01[\xfb\xe8\xdd\xdes\x92\xe3\xf4a\xe6*\x87\xd5\x19j\x14/d\x01ܻ\xb63\x14I\xa1H\xcd\xdc \xbe\x9c\x0e5\x91\x1d\xc3\x11\xc0'\xa1MZF\xac\xdbQD\xba=CM7\x89]Zy\v\xebN!_E8A˹\xceBP\xe0\xb5!\x9b\xc8I<\x9c\x81\xee\x8c\xd8s\xd2\x04v_\xef敩o\xe5\xb2/\x8c'\xa1\x8eC\v\xabmt\xa79|\xc5V\x86Y\xfe\xf1\xc2>\xe0U\x1b\x93\xa0X\xb5&;\x87\xd288\x13\x1b\xb1\xecJ\xf8\xc7\x1e.\x928C\xd5\x1c\x04\x8c\xa7F\xb7\x13\x8d\xb3\x1b\xbcO\x0e\x13\xe7v^e\xf1\xe6\xbd!;*\xcc\x1f\x85U\xe6\xed\xd8\xe5Bդecu\x9f\xe4\x89]\xce\xc7ϝ\xc4B~W\xb8\xd7\x12\xee\xadr}\xbdd\x0f\xa2\x99\xde\x16\xb1s~i[f\xddӎ<YI\xaaܜ\xc1\x82k\xc0\x02Ϻ\xa99!\x95\x1b\x9b\xe91+u*\xa45\xb7\xeb<{\x11Sr\x11\xfa\xf8\xb8͖\x95\xf8\xe4<\x97(\xb8\xd8\xe4\xce֫\xa2\x13>\x1e\x93N\x85ۅ\x05\xa3~^2\xf7\xeam^+4\xffI\xb1\x99\x19\xfbw-\xe0>\xe4\xbb\x16\xf0;\xf3h\x01o\xf8\x1fZp\xa9\xf4\\\xac\x1a\xfc_\xa4\xb6i5x\xb1n\xf0\xff\xc1\x99\xbe\xa5\xf3\"\xb8p\x8d\x13e\xfb0&I\xde2\xa5\xfdYjp\x03r\xae\x17)\x02\xa4\xf3\x8a\xd4i;31\xa4\xa7T\xbfE\xe0\x97Pn\x7f\x86\x96Q\xe5\x86\xfe\f\xd3S\x1f\xc6w\xe4dT~+\x15\x83(\xc3\xf9Ij2\x02\x14\xb1\xfe\a\xe8\xb8y\x17\xfb\x97\x98/\xc62\xba\x18[7O\x17\x18_\x83\x15\xe9\rf~H\x8a\xbc\xb9\xb8}\xf8\x04P\xe1\xf0a~JŽ\x18\xbeB\xa5\t \U0001bfaa\x10}O÷\x1a\xe7\xb9\xe4\xe7\x91\xe1\xcb\r\x7f\x17\x93\x92\xbaH\xea\xf3\xfa\xcb\xd3\x0f?,>#\xa5G\xe9\xacJ\x9f\xd6B\x85_~\xe5oB\xd1yR\xc3ǊP\xe1\x97_7\xff\x1a\x00Z!y&\xc2\x13\x00\x00"),
+	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xb4X͎\xdc6\x12\xbe\xeb)\n\xb3\a\xef\x02\xd3j\x18\xbbX,t\xf3\xce\xec&\r\xc7\xc6\xc03\xf1\xc5\xf0\x81\x12\xab[\xccH\xa4\xc2*\xb6\xdd\t\xf2\xeeA\x91\xa2\xba[\xa3\xf91\x92\xb8\xe7\"\xb2~\xbf\xaa\xfaH\xbaX\xadV\x85\x1a\xccG\xf4d\x9c\xad@\r\x06\xbf2Z\xf9\xa2\xf2\xfe?T\x1a\xb7\u07bf\xae\x91\xd5\xeb\xe2\xdeX]\xc1U v\xfd\a$\x17|\x83\u05f85ְq\xb6葕V\xac\xaa\x02@Y\xebX\xc92\xc9'@\xe3,{\xd7u\xe8W;\xb4\xe5}\xa8\xb1\x0e\xa6\xd3裇\xec\xff\xef\x1a\xf7\xd8\xfd\xa3\x00h<F\xfd;\xd3#\xb1\xea\x87\nl\xe8\xba\x02\xc0\xaa\x1e+ \xab\x06j\x1dSY\xab\xe6>\fڛ}4VЀ\x8d8\xddy\x17\x86\n\xe6\xdb\xc9\xc0\x18VJ\xe9v\xb4\x15\x97:C\xfc\xf6l\xf9\aCik\xe8\x82W݉\xef\xb8J\xc6\xeeB\xa7\xfcq\xbd\x00\xa0\xc6\rX\xc1{\xd5#\r\xaaA-k\xa1\xf6#l\xa3{bŁ*\xf8\xf5\xb7\x02`\xaf:\xa3c\xcei\xd3\rh\xdf\xdcl>\xfe\xf3\xb6i\xb1\x8f\xb0ʲFj\xbc\x19\xa2\x1c\xbc\x9a\x82\x04C\x10\b5\xb0\x03\x8f?\a$\x06n\x15\x83\x9a\xc2\x12\x11V\xf7hK\x80\r\x83\xa1\xd1\"\x80u<)\xf7ʪ\x1d\x02\xb7\b\xc6\xeeѲ\xf3\ap\xdb\xc9\n\x81\xb2\x1a\xb4C\x8aj`19ů\x19&\xf9\x19\v\xcek\xf4\xb2\xd3t\xce&\x839}\xd8zןD\xf6j\xd4\x1b\xbc\x1bг\xc9\xe5\x91\xdfI{Nks\x14\x04\xa6$\x03Z\x1a\x12)\xbaۧ5\xd4@\x11BI\x83[C\xe0q\xf0HhS\x8b\x9e\x98\x05\x11Q\x16\\\xfd\x136\\\xc2-z1\x02Ժ\xd0i\xe9\xe2=z\x06\x8f\x8d\xdbY\xf3\xcbd\x99$Oq\xd9)\xc6\x13\x18\xe4\xcfXFoU'\x05\x0ex\x19\xe1\xeb\xd5\x01<\x8a\x0f\b\xf6\xc4Z\x14\xa1\x12\xde9/\xf0o]\x05-\xf3@\xd5z\xbd3\x9c\a\xb2q}\x1f\xac\xe1\xc3:\x8e\x95\xa9\x03;O\xeb8;k2\xbb\x95\xf2Mk\x18\x1b\x0e\x1e\xd7j0\xab\x18\xb8\x95d\xa9\xec\xf5ߦ6\xcc\xc0ˏ\x0fұ\xc4\xde\xd8ݴ\x1c\a\xe4Q\xdceN\xa4\xabԨ\x96R<\xc2+K\x82ʇ\xff\xdd\xde\x1d\x8b/%81\t#\xdaG5:\x02/@\x19\xbb\x95F\x92\xc2ž\x11\x8bh\xf5\xe0\x8c\x95\x1eGh:\x83\xf6\x1ct\nuo\x98\xf2(H}J\xb8\x8a\xb4\x045B\x18\xb4b\xd4%l,\\\xa9\x1e\xbb+E\xf8\x97\xc3.\b\xd3J }\x1e\xf8S6\xcd\xff\x92`BkZ\xcet\xb7X\xa1\xdb\x01\x1b)PD)\x12\xf7\xb1\f\xa2x\xa2\xb74{\xf2K\xfc\xf9\x01\aGF\xb8\xe0|w\xe6\xef\xae\xc5Q\x01\xfc\xa4!\xb3\x91'\x1d\x8c\x95JDA\x9b\xc9qf\x11bQ3\xb1\xado>^Ag\xf6H`,\xf4\x81\x18Z\xb5GPM\x834\xcd\xdd\xd1\xdb\xcc\xd8\"\xb8\xf2\x97q\xf8^Y\xdd\xe1\x93Y\xe5\xc3.\x89\x82ǭ\xb4&;P\xf06\xd4\xe8-2\xd2d\xf0\x12\x9a\xe0=Z\xee\xe6\xb1\x00(\x90l\xea }kRw\xd7\b\xf1\xc8ը%AI}\x1bdpgʏ\xd5g\xe4\xc8\xef\xe2i\xf7`g\x96ɛ\x9bM\x14\xcc=\x11\xcfH\xd8:\x7fN\xcf5\xca\xe4\xc6<\xd16\xa8\xcb\x05\xbb\x00\x9b\xed\x99=\x19-\xe9)\xb35\xa8/\xa3\xc1\xe9\x13\"S\xc4\xe2\xd58\xa6\xb9h\xb3\x11\xe2{s\xb3I\x91\x95\xf0\x7f\xe7A\xd9\x038n\x13\ax\xbd\x1a\x94\xe7C,,]\x9eE \xc3n\xfcr\xb8\x8f\xf6\xc1\x12\xcb-b\x97\xc9N\x12\x13krT<\x8aطF \xb3\xf0l\x04r\x9b\xc8\x11\x88\u009f\x18A\x86n\x1e\xc3*b\xf3`Q\xbc\xcf\x16\x17\xc9I\xfe\xf2\xe8_)\xdb`W\x15O$\x98g>\x89\x82\xb1\xda4r\xa0\x1eo4\x0e\x9a\xb4\xe7\xec\xceI\x93f\xeb%LW\xa1\xa4=\xf3\x03\xa2(\xd4O\xc8 \xd7\"{`\xd3#Ը\x95\x96\x13H\xb3)\xf0\xa8\x9a\x16\xe5Xc\xf4\xbd\x91\xb3{h\xe3\x01\x01\x9b\xed\x03\xbbg\xaa\xad\xa2Q]?P\x9fi&\xc0j\xe7:T\xb6x\xba\x14\xab\a4|\xb6\x99\x9b \x11T\xf1LQ\xc6[g\xf1H\x11\xae\x12{\x8db\xd2cg\x19\n\vͯM\x8fqS\x8fDj\xf74\xb9\xbeK2\xd2\xd7*+\x80\xaa]\xe03\xbf\xafh\f\xa8,^\xd8\xd4K'\xe8\x82\xf7$4q`\xf6Ǩ\x97\x9a\x19D\xb0W\\A}`|i(\xb1\xfcO\xc6q#\x12y\xb6\xc7\xf3#&\x8c\xb9\x00?\x0e\x9dS\xba|\xb1K\xefv\x1e\x89\x9e\xf6:\nMه\xe8\xe4\x1bN\x1eA\x81\xae\x9d]$\xaf\f\x95\xb1\xfc\xef\x7f-\xec'\xbc\xe4~\xbcC\xff`\x9f\x1d\xab\xee\xbf\a^r\xfb\xc7l?KU\x9b\xeb'a\xcbD\x03\x9b\xeb\xf4ƒ\xa9\xaf\x11\xed\xf4\xbc\xba\x93\xcb\xea\x17\xd3u\xc28[\xd3u\xf1p\x9f\xd9\x04\xf8ҊN\x8b\xa9A`'\x8f*vp\x91\x1d0ꋗ\x15|!\xa59\x8f\xacNo|3\xf9\xf1\xa5T\xc1\xfe\xf5\xf1+\x96{5\xbe\xb7\xe3\x06\x00ɃHW\xc0>\xe0\xf8\x84u^F<\xad\x1c\xa9E\xaeg\x03\xa3~?\x7fl_\\\x9c\xbd\xa5\xe3g㬎\xff\x89@\x15|\xfa,Oev\x1e\xf5\xf8\xa6\xa3\n>}.~\x1f\x00~\x94[,\xac\x10\x00\x00"),
+	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xb4Z_oܸ\x11\x7f\xdfO1H\x1f\xd2\x02^\x19\xe9\x15E\xb1owv\xaf0.I\x8d\xb5\x93\x97\xc3=\x8c\xa4Y-k\x8aԑ\x94\x9d\xed\xa7/\x86\xa2\xfeS\xb2\x92\xe6\xb2y\xf0\x92\xc3\xf9\xf3\x9b\xe1p8\xdc\xdd~\xbf\xdfa%>\x93\xb1B\xab\x03`%\xe8\x8b#\xc5\xdfl\xf2\xf4\x0f\x9b\b}\xfd\xfc.%\x87\xefvOB\xe5\a\xb8\xa9\xad\xd3呬\xaeMF\xb7t\x12J8\xa1ծ$\x879:<\xec\x00P)퐇-\x7f\x05ȴrFKIf_\x90J\x9e\xea\x94\xd2ZȜ\x8c\x97\xd0\xca\xffsN\xcf$\xff\xb2\x03\xc8\f\xf9\xf5\x8f\xa2$밬\x0e\xa0j)w\x00\nK:@\xae_\x94Ԙ\xdb\xe4\x99$\x19]ɺ\x10*\x11zg+\xcaXhat]\x1d`:\xdd0\bj5&\xdd\x06^~H\n\xeb~\x19\r\xbf\x17\xd6\xf9\xa9J\xd6\x06\xe5@\xb6\x1f\xb5B\x15\xb5Dӏ\xef\x00l\xa6+:\xc0G,\xc9V\x98Q\xbe\x03xF)roT#\\W\xa4~\xbc\xbf\xfb\xfc\xc3Cv\xa6\xd2\xe3\xc6\xc39\xd9̈\xca\xd3u:\x84є\x00\x83E\xfb\xc6$0d\x9d6\x14\x16WFWd\x9ch\r\xe4\xcf\xc0\xc1\xdd\xd8D\xcc[֣\xa1\x81\x9c]J\x16ܙ\xe0\xb9\x19\xa3\x1c\xac\xd7\x11\xf4\t\xdcYX0T\x19\xb2\xa4\x1a'\x0f\xd8\x02\x93\xa0\x02\x9d\xfe\x872\x97\xc0\x03\x19f\x02\xf6\xack\x99s\x1c<\x93q`(Ӆ\x12\xff\xed8[pڋ\x94\xe8Ⱥ\x11G\xa1\x1c\x19\x85\x92\x11\xac\xe9\nP\xe5P\xe2\x05\f\xb1\f\xa8Հ\x9b'\xb1\t|І@\xa8\x93>\xc0ٹ\xca\x1e\xae\xaf\v\xe1ڐ\xcetY\xd6J\xb8˵\x0fL\x91\xd6N\x1b{\xed\xa3\xefڊb\x8f&;\vG\x99\xab\r]c%\xf6^q\xc5\xc6ڤ\xcc\xffdB\xfc۷\x03M݅}n\x9d\x11\xaa\xe8\x86}\x88-\xe2Α\x06\xc2\x02\x86e\x8d\x89=\xbc<Ĩ\x1c\xff\xf9\xf0\b\xadP\xef\x82\x01K\bh\xf7\xcbl\x0f<\x03%ԉ\x8c_\x05'\xa3K\x8f3\xa9\xbc\xd2B9\xff%\x93\x82\xd4\x18t[\xa7\xa5p\xec\xe9\xdfk\xb2\x8e\xfd\x93\xc0\x8d\xdfؐ\x12\xd4U\x8e\x8e\xf2\x04\xee\x14\xdc`I\xf2\x06-\xfd\xe1\xb03\xc2vϐ\xbe\x0e\xfc0\x1f\xb5\xffx\xfd!\xa0\xd5\r\xb7\t#ꡇ\x8a2v\x90Gɧ\xbe\xde\r\xbcp\xb0.\xb6\xf7\xf8\x93b\xf6TWG\xaa\xb4\x15N\x9b\xcbxv\"\xef\xa7\tq+\x9b\x93\x16o-\xfe{F\xe3\xf4\x84%t\xb9Ȼ\xd7*\xac\xecY;\xef\xfddB\x1b\x05\xaf\xd7\xfb\xc1i\x83\x05\xbd\xd7\xd9 u\xad*?Y\x11\xb3\xe0\xb3Oaq\xfa\x89\x00\xe0İb\r\xdc9\x96 \n\xa5\r\xe5 Nsx\x84\x05Knj7\xc0\xe3\x998\xdba-9!u\xe4AG\x8e\x1d(QaA\x86%Զa\xafH\xb83\x99\x05\xae\x8bh\x86<ݟgk@\x1e'\xc4>_\x9a\xbc\x01҉\x92\xfc\x1f\x81%\xbc\xa0\x85\f\xa5\xa4<n\xa3\xf59\xf8\xadmV\xb6\xa6\x9c\xb4\x81\x87\x00e'h\xb2\xfe\xa4M\x89\xee\x00\xbc\xd7\xf7\xbcz\xbb\xb5-\x9c\xf7h\xb0$Gf\xb2+\x000\xcf}\xe5\x80\xf2~a笊\x98A6\x978\x82-\xad\xb3'rWP\x19:\x89/W`\xa8\x88E\x1b\x1f.\x99\xa1\x9c\xb3\x0eJ\v\x95\xd1'!'{o\x1a\xe2\xe8F\x9e\x991\xed\x8eq\xef*.m澊\xe6&\xfe\xdf\xc6\xfb\xdd\xeda\r\x80֗w\xb7\xed\x8e\x13ވ\x93 \xe3\x9d=\xda;\xc1\x9cg-뒒\xddF\xcc9ް\xa0\x1b\x89֮+3 \x8c%\x80\x00`3\x1f\xc6\xee\xb9ذ\x8e\x94\xfb쵺\x91(ʉ\x10\x80\x94\xf8L\f\xa1\x9f'~\x137V\xb0\x9cJr\xb1\x05Z\x01rТ\xa7\x82L\x97\x15:\x91J\x82\x17\xe1\xce3\x9e|\xce\x05\xd3*-Ev\xf1\xaar$t<j#\xa1\xea\x02k3`Π\xb2'2\x0f\xce\x10\x96\xeb\x98=\x8ei;\xd8\xea2%\xc3\xc0eZe\xb51\xa4\x1co9O\x13\v\xb6\xce\xc7\xc2v\x89\x93roy2\xcay\x01\xf7[t\xf8a\x9c\xe7f<\xc5\t\x84ϳ|\xfcG\xd2^\x9b(\x84r?\xfcu2׀\xc3E\\Af0\x17?\x8c\x1d\xbaz\x84S\xb4\x1e~\xf0d-D=*~\x94+Ў2\xd9pBs|H\x1a_7\xd6<u3\xa7\x9fghT\xfd\xb1\xe5\xb7}\xb3(\x96\xa4{~]\x8an\xd8Q\x0e\xf4L\x8a\xe3\xf9\x84BRޱ\xb4\xc9(\xb5\xcfX\xceR}Dg\xbb\xe0ĥl\xcf\x170L%\x1d\xc0\x99\x9a\xb6n\x81L\xab&\xcf\xdbW0m\xc9\x00\r\r\xae\x02\xa0S\xb6\xd2'\xda.W\xb0\xa79'O8\x82\x9f\x8c\xb9\x9e?\xc2\xd1|\v.\xa9\x11\x86S\xb2\xa0\x15\x01r\xb1\xe7\xa6\xe2\x01\x15|\xaa\xba\xbb\xe3\xf8\xa3M\x1f\x84\\\xa5\x9c\xb4\x94\xfa%Dl_\u05f6<{\x9c\xc2H\x84\xe5/uJF\x91#\v?\xde\xdf\xcd\x03i)\xc0\x9b\x8fD\xeb|\x92\x11m\x18Ĩ&\x80\xbc\x9f-jw\x1d\xb3닑n\xefEY\xc2\xd8D\xc8Ψ\x8a\xd8V\xd8\x12\x87\x1b\xa2\xf1\x95\x98lo\b\xd6b\xb1\x05\x84\x0f\r%[\x8ep\xaeKT`\bs\x16\xdfr\x01Lu\xed:`\xa2<\xc3y\xd0@0u{\xf2-&4{\x83\xf2\x7f\x91\"\x13-\xd0#\xd6\xfc{\xb6\xa8uiя\x04\xf5\xda\xcb\xce&\xafr\x9a\xb3đn\xd6=+\x94\xfb\xfbߢ\x14K\x87E\xfb\xcf\x10\xdaMF\x1e=!\x1b\x86\xfd\x055,\xef\xca!\xf6\xd4\xc0'Q\xae\xf0}<5?\xd8\x16\x14\x0fG\xdbT\xe8\x95OC\xfa\x04\x8f\x86{ ?\xa3\xb4t\x05\x9fԓ\xd2/\v\x1a\x91\xaa˸\xc0=\xbca6o\x96&=\xf7\xa5\xd9 \xf3[@\xf0\xde}\x1d\x82\xc7KE\x11\x00İ\xd5\xf0\xf5⹓!\f\x8d\xba1\xadM\xf3\xd4\x18!j\\\x18\x99`\xb3f\xc3\xd1\xf2f\xf3A\x8a\xc6\xe0e\xb7![-\xe7\xa9ybj\x8b\x87\xb76\x84c\xb2ۈ\x9f\xa2/\xeeH\xce\\\xba\xe2aU\x8f\x8f3\xf2\xb6\x01\x98RW!5\xe3\xee\x8c\x0e\x84\xcaE\x86.\x02♼l0\xcc\xcd\xef\xdbI!\x047\xc7\x04>\xf1\x85\xd6i8\t\xe9\xb8V\x9e\xd8;c\x1b\x9aZ\xf0r\x16ٙ\xaf\adA(H\xe9\xa4\xcdH \xeb\x99\xec\xe2I\xec\xfb\x96I\xd5\x19\xed\xbas\xef\x99\"V\xf2v{e\xa9\xf0\x89%\x82=|\xa4\x97\xd9؝\xba7\xba0d\xa7a\xbeo\x8b\xd4\xd9\xe5`\x0f>0f\xa3?{?m6\x9f/n%)\xb7\x0eAK5*\xb7\xfb\x8b\x9e\x8b_\x06\xaf&<\x01\xc4\x1808#\xef\x98\xe1\xad4\xf9\x1a\xaf\xae\xd5]\x9dv\xf3\xa9\x89u\xb7\x9d\x1d\xc1\xc9M\xf7)\x0fI\x04\xeen[Gw<#,a\t\x85\xa9E\xab\xfe\x18i\xce\xcf\x18۵g\xea؝?\xe6\xa6\bS\xf8NJ\x7f:\xbe߮\xf3\xa7\xe3\xfbV\xe5^M\x1e\x8c\xf4+\xe6\xd1\x14\"\n\xd5\xe5\xab\xd5]\xeb\xa8\xcc\xd4\x1dj\x11Cx4\xbf\x19\xe4\x85jm\x8b\xda\xf7\xbe[\xb2)<\x1e\xa6+b\x06\x04\xb6\xd0tav+\xc5\xd8\xd0ԫo\x03\x7f\U00040b8c\xce\xc8\xf2\xbb\xdeG\x9d\xcf,\x1bY\xf58\xe9\x9f(\x9dsx\xa3\xf3\x19\xa5\x12\xd9\x13\xe5PW\xe3ds\xd2\xf3ʶ\x97\xc9\x17{a\xe1EH9xh\x01.\xad5\xf7\xb5옙h\xc5\xccX֕?/\x87\x9c\xefB\xda\x1bh\x9c\xf1\xbb\x9cz\xebZ\r\xd6մ\xba\xec\x8ah\xe1:%{C\xd3\v\xa0ҾA\xceX$\xbb\x8d\xee\xa8±\xb3\x8av{6\xc1Y˶բ\x1d\xcaA\x8f,\xbd\xf0\xe5X\x9f\xd6\u009f\x1b{\xc33\xb4_\xddvԽ!\x8el\xf0E\x86\\\x1b4ms~\x8d\x10\xb6\x92x\x89\xf9\xd0\xdb\xe0\x1f\xc0\xb8F\xe0{T_}\xb5\xcc\xf9\xa8\xc0\xd8=b\xed\x14\xf1\xea\xdcj\x15\xddh\xaf]\xab\xd6/U\x1e\u009f..&\xf6\xff㽸\xc3|5w\xa3\xebW\x8e\xfbcG6:\xef\x87\xfej\x8b4ˊ\x82\xa1=\xbf*̶:\x00\xb6\r\xb4.\xbco\x8e\xa1\xfck\vʚ\xf8\xa9I\x91{\xd1\xe6\t\x84\xb55\xf970\x1e\xfd\xbd\xa6z\x16\xcc\xd0ԝ,\xb8\xb6\xfc\xc0j0{\xe2\xee4\aXNi]\x14B\x15\xc9n\x11\xd0ͭR\x7f}4n[\xf1\xfd0\"}\xbd1\xe9Y\x7f\xc3\xdb\xd1H\x8c]\xb0\xf2\xfb\x16\xc9\xcdy\xf6\xcaC\xc8\xe7@\xb4\xf2\f\x12\xb6b\x1e\x18&\xdb\xe4G\xc2yz\xa7\xdc\x0f\x1f\x85'\xf4\xe1\xc7\x14\ax~\xd7\x7f\xf3\xfd\xc8}\xf8Q\x8b\x9f\x80\x06\xf3|\x80L8\x16\xc3H\xdfI\xc0,\xa3\xcaQ\xfeq\xfa\x8b\x967oF?X\xf1_\xbb\x8b\xb4=\xc0\xaf\xbf\xf1oT\xb8\xf0\xc9\xc3\xcf>\xec\x01~\xfdm\xf7\xbf\x01\x00\xc1\xc0\xd9V\x11$\x00\x00"),
+	[]byte("\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xbcZݏ۸\x11\x7f\xf7_1H\x1f\xd2\x02k-\xd2+\x8a\xc2o9o\xaeX\xe4\xe3\x16\xbb\x9b\xbc\x1c\xeea$\x8dd\xd6\x12\xa9#\xa9uܿ\xbe\x18\x8aԷd'M\x1b\xefCL\rg8\xbf\xf9\xe4ț\xedv\xbb\xc1J|!m\x84\x92;\xc0J\xd0WK\x92\xbf\x99\xe8\xf8\x0f\x13\tu\xfb\xf2&&\x8bo6G!\xd3\x1d\xeckcU\xf9HF\xd5:\xa1;ʄ\x14V(\xb9)\xc9b\x8a\x16w\x1b\x00\x94RY\xe4e\xc3_\x01\x12%\xadVEAz\x9b\x93\x8c\x8euLq-\x8a\x94\xb4\x93\x10\xe4\xff9\xa5\x17*\xfe\xb2\x01H4\xb9\xfdϢ$c\xb1\xacv \xeb\xa2\xd8\x00H,i\auU(LM\xf4B\x05iU\x15u.d$\xd4\xc6T\x94\xb0\xc8\\\xab\xba\xda\xc1\xf8q\xb3\xdd\x1f\xaaQ\xe8\xb3\xe3\xe4\x16\na\xec\xfb\xde\xe2\aa\xac{P\x15\xb5Ƣ\x95\xea\u058c\x90y]\xa0\x0e\xab\x1b\x00\x93\xa8\x8av\xf0\tK2\x15&\x94n\x00^\xb0\x10\xa9S\xa5\x11\xaa*\x92o\x1f\xee\xbf\xfc\xf4\x94\x1c\xa8th\xf1rJ&Ѣrt^\xba_\x8b\t\xd0\xeb\xb1m\x14\x81\x18\x93c]\xf9\x9d\x95V\x15i+\x82V\xfc\xe9ٴ]\x1b\xc9x͇hh e+\x92\x01{ xi\xd6(\x05\xe3\x0e\b*\x03{\x10\x064U\x9a\f\xc9Ʈ=\xb6\xc0$(A\xc5\xff\xa2\xc4F\xf0D\x9a\x99\x809\xa8\xbaH\xd9\xf4/\xa4-hJT.ſ[\xce\x06\xacr\"\v\xb4d쀣\x90\x96\xb4Ă\xe1\xab\xe9\x06P\xa6P\xe2\x194\xb1\f\xa8e\x8f\x9b#1\x11|T\x9a@\xc8L\xed\xe0`mev\xb7\xb7\xb9\xb0\xc1\x8b\x13U\x96\xb5\x14\xf6|\xeb|QĵU\xda\xdc:\x87\xbb5\"ߢN\x0e\xc2RbkM\xb7X\x89\xad;\xb8deMT\xa6\x7f\xd2\xde\xe5\xcd\xeb\xdeI\xed\x99\rn\xac\x162o\x97\x9d_-\xe2\xce\x0e\x06\xc2\x00\xfam\x8d\x8a\x1d\xbc\xbcĨ<\xbe{z\x86 ԙ\xa0\xc7\x12<\xda\xdd6\xd3\x01\xcf@\t\x99\x91v\xbb Ӫt8\x93L+%\xa4u_\x92B\x90\x1c\x82n\xea\xb8\x14\x96-\xfdGMƲ}\"ػX\x86\x98\xa0\xaeR\xb4\x94Fp/a\x8f%\x15{4\xf4?\x87\x9d\x116[\x86\xf42\xf0\xfd\x14\x14\xfe\xf1\xfe\x9dG\xab]\x0eYb\xd6BO\x15%l \x87\x92\xcbv\x9d\x19xco\xdf\\\xec\xf1\xa7\t\xd0G\xaa\x94\x11V\xe9\xf3\xf0\xe9H\xde\xcf#\xe2 \x9b3\x15\x87\x16\xff\x7fBcՈ%\xf84\xe4\x8ck$V\xe6\xa0,[pD7\v\\w\xe6'\xab4\xe6\xf4A%\xbd\x9c\xb5z\xf0ю\xb9\xd3\x7fq\xd9k\x9e~$\x008),h\x02\xf7\x96\xb9\x8b\\*M)\x88l\n\x8b0`Ȏu\x06x>\x10g9\xac\vND-\xb9?\x1f\xfb\f\x94(1'\xcd\x12jӰ\x97$\xec\x81\xf4\x02\xd7\vHv\x95\xeb2\x86-\xad˒:m \xb4\xa2$\xf7\x9f\xc64pB\x03\t\x16\x05\xa5\xf3\n\x1a\x97x_\x9bfc\xd0#S\x1a\x9e<\x8a\xad\x9c\xd1\xfeL\xe9\x12\xed\x0e8\xc0\xb7\xbc\xfbZU;,\x1fPcI\x96\xf4(\x14\x000M]\x87\x80\xc5\xc3B\xb8\xac\x8a\x18\x01\xf68#q\x80Z\\'G\xb27Pi\xca\xc4\xd7\x1bДϹ\x19W\x94DSʩ\x06\v\x03\x95V\x99(F\x017\xf6m\xb4\x03\xc3L\x98\xfa\xc2\xed\f\xc5\r\xcc\xd4R\xb3\xe9\x88\xff\x82\xa3\xdf\xdf\xed\xd6\xd4\x0f\x96\xbc\xbf\v\x81&\x9c\n\x99 \xedL=\b\x1a\xaf̋*꒢͕\x88[\x8d\xd2d\xa4\x9f\xac&,\xcd\xeay\x9e\x87\xb4m\xf4\xd7eL\x9a\xc1L\x94Lj\xadIZ\xf6\x1fG3\x87\\{dv[\x87\"\xa5p\x12\xf6\x10\rb\xd7+t\x87\x16?N\xe2u\xc2T\xb8|\xc1\xe5k&|\x83\xcf\vi\x7f\xfa\xeb\xe8Y\x03\r7!9\xe9\xc1\xb3&5\xedQ&T\xac\x02\xf3\xb9G\bB\xa6\"\xe1.'\xd4V\xcer\x89c\x02J\xe6\x8ak~\xc3y\xdeH\xb1R\x05a߉\xe7˚E[\x0f\xec5s\xa4'G\x14\f\xd5\xd9ƭr'\a\x9f''Y\xaasɁ\x92\xa3\xeb)V\xb1طd\x83H\xadP\xb7\x16u)Xe\x03\xf7\x1d\xb1\x04\xb0\at\x16\xe5\xa6BXKi\xe8 5\x95\xcaR/\x1bE\xf0Vz=\xda]lN\xad\xeb\xcaRz3aMQ\x1e\x01\x9a\x89wUZ%d\xb8\xd7\aa!\x15d8\xa3\x98\xba$\xdfXq\xc3\xd4\xc10᫤c)Uʩ\x1c-T\"9\x1a\xe6UWc[\xf3\x05\a\xe3\x82v`u=\x0e\x91%\x13\xf0\xa7\xc5\xe3糝{>6ǀ|\x1a\xb21s\x19\x18\xa6\x150\xc3\x1a\x96\x8d0C\u074b\xba\xbf\xffm\xe6\xf9r\xe4\xf1ǆ\xfauQ\xc7\v\x15\xb5\xb3\x98\xab\xaa\x16\x8f4-\x11\xfcQڛ;u\xe6^Si\xa9x^4\xedj2^\b\xf56\xfaL]\xee6+@\xec=Q\x1b\xf0\xe1{߾\xa3\xc0\xf3\x98͘[\xc8yS\xc3\xe9@r\xc8\xe3\x84]&\xbf\x81\xd3A$\x87ٚ\xf9BZd\xc2\x17\xd8\xf1A\x12UV\xa8\xc9\x05\f\xe6(\xa4\xb9\xbe\r\xe3\xbd\x05\r\x87\b\xabHM\xe9\xa7\u0383\xd2+\xe5\xfcƋ\x98k\xc8:nm;\x16P\x05z!\tJB\x86\xa2\xa0\xd434Ѵ\x89\x9bp\xed7u3\xe75\x9bos\xcdU\xb7\\\x01V6\x1d\x9d\xb9\x80g \x03\xd4Ի郊YKg\xf16\xcfp\x01\xe2\xeekđ\x93>\xcdT$\xfe\x13\x96\xa6\xdd\xc9\xd2!\xfcr̙M\x12 \xdf\xe4\xecX8`\xa8\x1a\x13\xae.\x15ܩ\x93\xe4\xa7\xee*\x92\xa9\xa2P'\x1fUݥ5\xf0\xecP\xf2+3,\xdf\xd71iI\x9cn\xdf>\xdcO\x9dh-\xe9\x03\x14h\xac\xeb\xbfDp\x829\xaa\x11 \x1f&\x9bBf`v]\x86l\x1b\x82Y\x960T\x11\x92\x03\xca|.\f\xaeM\x90W\xa4\xc8\x15\x8f\f\xd7\x7fc0\xbf\x06\x84\x8f\r%k\x8ep\xa8K\x94\xa0\tS\x16\x1f\xb8\x00ƪ\xb6-0\xb3<}\xab,\xfa\xa9\xab\xc5$\xfa\x1e\x15\x9aȠ\xf4\x9f$I\xcf\xde\xc0g\xb4\xf9u\xb2)\x984\xefV\xfc\xf1\xc2$\xe3*\xabr\x8a3Ğ\xae\xd7-\xbbT\xcd/\xd5s\xbe@\xa2\xb9J\xc9GGȊa7}\xf2\xdbۋ\x0f[\xaag\x93Y\xae\xf0c,5\xed\xb5\x17\x0e\xee\xfb\xed\xb1\xd0\x1b\x97\x86T\x06Ϛ\a\x9c\xbf`a\xe8\x06>ˣT\xa7\x85\x13\x91\x9c\xd6\xfa泅W\xcc\xe6\xd5\xd2C\xc7}驗\xf9= 8\xeb^\x86\xe0\xf9\\\xd1\f\x00\xa2?G\xfcv\xf1|\x95\x12\x9a\x06\xa3֠\xd345\xce\x105&\x9cy\xc0jM\x96\x17۰\xab\xca(j\x8d\xe7\xc1\x13\x7f\xf3\xe2\x01ЯY\xb6۬\xe0\xb7\x1f\x90\x0e\x9a\x12\x1e\r\xa9,\xe3nB\x93\xd5g\x17\nM_\xb10 \x8c\xe0\xd1\x11\xaa\xb6\x93\xf1\x83z\x15\xd3\x19\xe8k\xa5$\x0f\x14\xb0hy\x97\xc4\xe9]\x982\xda,\x86\xff7\\\xa1I&\xfa\xec4{O\xe7\v\xe3\x8ewCڐ\xd7\xee\xef\x82;\x1d\xe9<\xec\x18Gc\xf2\x9e@?PX\xeca\xc3|\x91\xcaʞA\f;\xd1\tS?XhyG\x9b+]w\xa1J-קiAj\xec\xf6\xda\xf8$t\xb5hI_\xad3~\xdb0\xae\x9e\xe2ӄ<\xbcӉ\xa9툛uw\xb5\rS\x8e\x11ϦW`\xd9\x13\x17\r\x1e\xb8\x7f\x8c\xe03\xf7\xb5VA&\n\xcb\xf7Ё\xae\x13\x96a\x8e\xd2\xdc+\x12\xc5\xf7q~3F\x99\xd2\x03a|\xc6%\xbf\xfd\xb1mqu@\xb3n\xd6\a\xa6\b>\xec\xe3\xbf\xeb=\x97\x1bݹĿ\x85Ot\x9a\xac\xdd\xcb\a\xadrMf\x1c\x05\xdbp]\x98䅭\x17\xfaNk5\xae\xd0[\xd8\xf3ܩ\xae~\x99\xcb([~9\x94\xd0\xf2\x831Bk\xe0\xb5s\x96O*]G\xf1y4\xa2\xe9f+\a4n\xbe\xe2\xeeT=<\xd9\xe3F\x1c\xfb\x12\xf9\xe2%\f\x9cDQ\xf4\xdes\xf14\xc8(%\xc3Tȳ\x12=\x11\x13\x9e\x9c|\xfb|\xef\xa7\xe3ʄs\xad|m\x03]\xef\x88`\xd4L\x9d\xf2-\x8e\xb0\xed\x01;\x15\xe33\xa0T\xee\x1d\x05\xa3\x10}\x03\xda\xceIVq\x0e\x9e\x04\aU\x84+\xb0\xb2X,M\x8a\x9a)\xf3\x88c3h\xef\xfb{o7vzX2\xde\f\tr\x187\xd7\\\xab \x15\xa6*\xf0<g>\xa7\x82{\xe3\xcb\xe1\xccMn\x97\"\xfd+\x13\xbe>\xe1\\\x8f\xb7v\xb3r\xa7\xb9Sr\xe2\x86״\xbc\xeb\r\xaf\x03pqJ\xf7\xdf\xf0^lO\\\xceݫ\xfa\u008c\xf6\xb1%\x1b\xf4\x17\x9d\xb5\xbatj8\x9e\xdck\x9d\x91[\xf1\x1f\x86\xd4\xee\xfd\xbaI\xd0~-\xad\x89\xa7\x85\x92\xecI\xe9#\bc\xea\xc6T\xbc\xfaGM55u`\u0095\x05ֆ\xdfhkL\x8e<\x8de\xb7J)\xae\xf3\\\xc8<\xda,\x02\xf9\r\x8d\x89\xb1\xa8\xedu\xa5\xf1i@ziL\xe4\x18\x7f\xc7[\xbb\x81\x10\xb3\xa0\xe3\x8f-b\xfdi\xdcuP|\x99\xdb1@d4XX\xea\xa8\x18)\xbe\x857\x0e\xd1\xfeja:kd\xd37\xe7\xa44L\x05A\xd81@݀ԏ\xd6x\x90[\xf8W\x01\xfe\xbd\xb4g\xd2^J\xa2\xff\x03\xc83\x91:\xbe\xcal\xfb?4\x18\xd1\xfb\x1f\xe8\xec\xe0\xe5M\xf7ͥ\x93\xad\xffm\x94{\x00\x8dc\xa5\xbd\x93\x99\xe6m\xa6_\xe9.\xb0\x98$\xc4oE>\x8d\x7f\x1a\xf5\xea\xd5\xe0\xd7O\xeek\v\x95\xd9\xc1o\xbf\xf3\x8f\x9e,\xbf\x8d\xf7?%2;\xf8\xed\xf7\xcd\x7f\x06\x00\vΈIX&\x00\x00"),
 }
 
 var CRDs = crds()
@@ -17,14 +17,19 @@ limitations under the License.
 package install
 
 import (
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// the port the data manager exposes its Prometheus metrics on, the default of its --metrics-address flag
+const metricsPort = 8085
+
 type podTemplateOption func(*podTemplateConfig)
 
 type podTemplateConfig struct {
@@ -36,6 +41,10 @@ type podTemplateConfig struct {
 	withSecret                        bool
 	defaultResticMaintenanceFrequency time.Duration
 	plugins                           []string
+	repositoryNFSServer               string
+	repositoryNFSPath                 string
+	repositoryMountPath               string
+	encryptionKeySecret               string
 }
 
 func WithImage(image string) podTemplateOption {
@@ -75,6 +84,22 @@ func WithDefaultResticMaintenanceFrequency(val time.Duration) podTemplateOption
 	}
 }
 
+// WithNFSRepository mounts the NFS share into the data manager at mountPath, to be used as a filesystem repository.
+func WithNFSRepository(server string, path string, mountPath string) podTemplateOption {
+	return func(c *podTemplateConfig) {
+		c.repositoryNFSServer = server
+		c.repositoryNFSPath = path
+		c.repositoryMountPath = mountPath
+	}
+}
+
+// WithEncryptionKeySecret makes the data manager encrypt snapshots with the keys in the secret before uploading them.
+func WithEncryptionKeySecret(name string) podTemplateOption {
+	return func(c *podTemplateConfig) {
+		c.encryptionKeySecret = name
+	}
+}
+
 func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 	c := &podTemplateConfig{
 		image: DefaultImage,
@@ -93,6 +118,16 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 
 	userID := int64(0)
 
+	// The metrics of the data manager are scraped by Prometheus, unless the annotations say otherwise.
+	annotations := map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   strconv.Itoa(metricsPort),
+		"prometheus.io/path":   "/metrics",
+	}
+	for k, v := range c.annotations {
+		annotations[k] = v
+	}
+
 	daemonSet := &appsv1.DaemonSet{
 		ObjectMeta: objectMeta(namespace, "datamgr-for-vsphere-plugin"),
 		TypeMeta: metav1.TypeMeta{
@@ -111,7 +146,7 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 						"name":      "datamgr-for-vsphere-plugin",
 						"component": "velero",
 					},
-					Annotations: c.annotations,
+					Annotations: annotations,
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: "velero",
@@ -143,6 +178,12 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 							Args: []string{
 								"server",
 							},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "metrics",
+									ContainerPort: metricsPort,
+								},
+							},
 
 							VolumeMounts: []corev1.VolumeMount{
 								{
@@ -195,7 +236,7 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 				Name: "cloud-credentials",
 				VolumeSource: corev1.VolumeSource{
 					Secret: &corev1.SecretVolumeSource{
-						SecretName: "cloud-credentials",
+						SecretName: utils.VeleroCredentialsSecretName,
 					},
 				},
 			},
@@ -212,19 +253,49 @@ func DaemonSet(namespace string, opts ...podTemplateOption) *appsv1.DaemonSet {
 		daemonSet.Spec.Template.Spec.Containers[0].Env = append(daemonSet.Spec.Template.Spec.Containers[0].Env, []corev1.EnvVar{
 			{
 				Name:  "GOOGLE_APPLICATION_CREDENTIALS",
-				Value: "/credentials/cloud",
+				Value: "/credentials/" + utils.VeleroCredentialsSecretKey,
 			},
 			{
 				Name:  "AWS_SHARED_CREDENTIALS_FILE",
-				Value: "/credentials/cloud",
+				Value: "/credentials/" + utils.VeleroCredentialsSecretKey,
 			},
 			{
 				Name:  "AZURE_CREDENTIALS_FILE",
-				Value: "/credentials/cloud",
+				Value: "/credentials/" + utils.VeleroCredentialsSecretKey,
 			},
 		}...)
 	}
 
+	if c.repositoryNFSServer != "" {
+		daemonSet.Spec.Template.Spec.Volumes = append(
+			daemonSet.Spec.Template.Spec.Volumes,
+			corev1.Volume{
+				Name: "repository",
+				VolumeSource: corev1.VolumeSource{
+					NFS: &corev1.NFSVolumeSource{
+						Server: c.repositoryNFSServer,
+						Path:   c.repositoryNFSPath,
+					},
+				},
+			},
+		)
+
+		daemonSet.Spec.Template.Spec.Containers[0].VolumeMounts = append(
+			daemonSet.Spec.Template.Spec.Containers[0].VolumeMounts,
+			corev1.VolumeMount{
+				Name:      "repository",
+				MountPath: c.repositoryMountPath,
+			},
+		)
+	}
+
+	if c.encryptionKeySecret != "" {
+		daemonSet.Spec.Template.Spec.Containers[0].Args = append(
+			daemonSet.Spec.Template.Spec.Containers[0].Args,
+			"--encryption-key-secret="+c.encryptionKeySecret,
+		)
+	}
+
 	daemonSet.Spec.Template.Spec.Containers[0].Env = append(daemonSet.Spec.Template.Spec.Containers[0].Env, c.envVars...)
 
 	return daemonSet
@@ -70,6 +70,7 @@ var (
 	DefaultDatamgrPodMemRequest = "0"
 	DefaultDatamgrPodCPULimit   = "0"
 	DefaultDatamgrPodMemLimit   = "0"
+	DefaultRepositoryMountPath  = "/repository"
 )
 
 type DatamgrOptions struct {
@@ -81,6 +82,11 @@ type DatamgrOptions struct {
 	PodAnnotations                    map[string]string
 	DatamgrPodResources               corev1.ResourceRequirements
 	SecretData                        []byte
+	NoSecret                          bool
+	RepositoryNFSServer               string
+	RepositoryNFSPath                 string
+	RepositoryMountPath               string
+	EncryptionKeySecret               string
 }
 
 // Use "latest" if the build process didn't supply a version
@@ -304,14 +310,16 @@ func AllResources(o *DatamgrOptions, withCRDs bool) (*unstructured.UnstructuredL
 		resources = new(unstructured.UnstructuredList)
 	}
 
-	// velero secret will be used
-	secretPresent := true
+	// velero secret will be used, unless velero is installed without it
+	secretPresent := !o.NoSecret
 
 	ds := DaemonSet(o.Namespace,
 		WithAnnotations(o.PodAnnotations),
 		WithImage(o.Image),
 		WithResources(o.DatamgrPodResources),
 		WithSecret(secretPresent),
+		WithNFSRepository(o.RepositoryNFSServer, o.RepositoryNFSPath, o.RepositoryMountPath),
+		WithEncryptionKeySecret(o.EncryptionKeySecret),
 	)
 	appendUnstructured(resources, ds)
 
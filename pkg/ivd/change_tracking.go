@@ -0,0 +1,121 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ivd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	vim "github.com/vmware/govmomi/vim25/types"
+)
+
+// The snapshots of First Class Disks with changed block tracking enabled have a change ID, which the areas of the disk
+// that changed since then are queried with. The repository records the change ID of a snapshot in its manifest, and
+// only reads the areas changed since the change ID of the parent when the next snapshot of the volume is copied to it.
+
+const bytesPerMB = 1024 * 1024
+
+// GlobalObjectManager is the part of the vslm GlobalObjectManager of vCenter that tracks the changes of First Class
+// Disks, which *vslm.GlobalObjectManager implements.
+type GlobalObjectManager interface {
+	Retrieve(ctx context.Context, id vim.ID) (*vim.VStorageObject, error)
+	RetrieveSnapshotDetails(ctx context.Context, id vim.ID, snapshotId vim.ID) (*vim.VStorageObjectSnapshotDetails, error)
+	QueryChangedDiskAreas(ctx context.Context, id vim.ID, snapshotId vim.ID, startOffset int64, changeId string) (*vim.DiskChangeInfo, error)
+}
+
+// ChangeTrackingProtectedEntity is the ProtectedEntity of a snapshot of an IVD, which tracks the areas of its data that
+// change between snapshots through the GlobalObjectManager. It implements objectstore.ChangeTrackingProtectedEntity
+// and objectstore.SizedProtectedEntity.
+type ChangeTrackingProtectedEntity struct {
+	astrolabe.ProtectedEntity
+	vsom GlobalObjectManager
+}
+
+var _ objectstore.ChangeTrackingProtectedEntity = &ChangeTrackingProtectedEntity{}
+var _ objectstore.SizedProtectedEntity = &ChangeTrackingProtectedEntity{}
+
+// NewChangeTrackingProtectedEntity returns the ProtectedEntity of the snapshot of an IVD, which tracks its changes
+// through vsom.
+func NewChangeTrackingProtectedEntity(pe astrolabe.ProtectedEntity, vsom GlobalObjectManager) *ChangeTrackingProtectedEntity {
+	return &ChangeTrackingProtectedEntity{
+		ProtectedEntity: pe,
+		vsom:            vsom,
+	}
+}
+
+func (this *ChangeTrackingProtectedEntity) vimIDs() (vim.ID, vim.ID, error) {
+	id := this.GetID()
+	if !id.HasSnapshot() {
+		return vim.ID{}, vim.ID{}, errors.Errorf("ProtectedEntity %s is not a snapshot", id.String())
+	}
+	return vim.ID{Id: id.GetID()}, vim.ID{Id: id.GetSnapshotID().GetID()}, nil
+}
+
+// GetChangeID returns the change ID of the snapshot. It fails if changed block tracking is not enabled on the IVD.
+func (this *ChangeTrackingProtectedEntity) GetChangeID(ctx context.Context) (string, error) {
+	id, snapshotID, err := this.vimIDs()
+	if err != nil {
+		return "", err
+	}
+	details, err := this.vsom.RetrieveSnapshotDetails(ctx, id, snapshotID)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to retrieve the details of snapshot %s", this.GetID().String())
+	}
+	if details.ChangedBlockTrackingId == "" {
+		return "", errors.Errorf("Changed block tracking is not enabled on IVD %s", id.Id)
+	}
+	return details.ChangedBlockTrackingId, nil
+}
+
+// GetChangedAreas returns the areas of the data of the snapshot that changed since the snapshot with the given change
+// ID, as queried with QueryChangedDiskAreas from the beginning of the disk to its end.
+func (this *ChangeTrackingProtectedEntity) GetChangedAreas(ctx context.Context, changeID string) ([]objectstore.Extent, error) {
+	id, snapshotID, err := this.vimIDs()
+	if err != nil {
+		return nil, err
+	}
+	size, err := this.GetSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var extents []objectstore.Extent
+	for offset := int64(0); offset < size; {
+		info, err := this.vsom.QueryChangedDiskAreas(ctx, id, snapshotID, offset, changeID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to query the areas of snapshot %s changed since %s", this.GetID().String(), changeID)
+		}
+		for _, area := range info.ChangedArea {
+			extents = append(extents, objectstore.Extent{Offset: area.Start, Length: area.Length})
+		}
+		if info.Length <= 0 || info.StartOffset+info.Length <= offset {
+			return nil, errors.Errorf("Invalid changed areas of snapshot %s from offset %d", this.GetID().String(), offset)
+		}
+		offset = info.StartOffset + info.Length
+	}
+	return extents, nil
+}
+
+// GetSize returns the capacity of the IVD.
+func (this *ChangeTrackingProtectedEntity) GetSize(ctx context.Context) (int64, error) {
+	vso, err := this.vsom.Retrieve(ctx, vim.ID{Id: this.GetID().GetID()})
+	if err != nil {
+		return 0, errors.Wrapf(err, "Failed to retrieve IVD %s", this.GetID().GetID())
+	}
+	return vso.Config.CapacityInMB * bytesPerMB, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ivd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	vim "github.com/vmware/govmomi/vim25/types"
+)
+
+// snapshotPE is the ProtectedEntity of a snapshot that only has an ID.
+type snapshotPE struct {
+	astrolabe.ProtectedEntity
+	id astrolabe.ProtectedEntityID
+}
+
+func (this snapshotPE) GetID() astrolabe.ProtectedEntityID {
+	return this.id
+}
+
+// fakeGlobalObjectManager tracks the changes of an IVD, and returns the changed areas a MB at a time.
+type fakeGlobalObjectManager struct {
+	capacityInMB int64
+	changeIDs    map[string]string
+	// changedAreas are the areas changed since each change ID.
+	changedAreas map[string][]vim.DiskChangeExtent
+	queries      []int64
+}
+
+func (this *fakeGlobalObjectManager) Retrieve(ctx context.Context, id vim.ID) (*vim.VStorageObject, error) {
+	return &vim.VStorageObject{Config: vim.VStorageObjectConfigInfo{CapacityInMB: this.capacityInMB}}, nil
+}
+
+func (this *fakeGlobalObjectManager) RetrieveSnapshotDetails(ctx context.Context, id vim.ID, snapshotId vim.ID) (*vim.VStorageObjectSnapshotDetails, error) {
+	return &vim.VStorageObjectSnapshotDetails{ChangedBlockTrackingId: this.changeIDs[snapshotId.Id]}, nil
+}
+
+func (this *fakeGlobalObjectManager) QueryChangedDiskAreas(ctx context.Context, id vim.ID, snapshotId vim.ID, startOffset int64, changeId string) (*vim.DiskChangeInfo, error) {
+	areas, ok := this.changedAreas[changeId]
+	if !ok {
+		return nil, errors.New("FileFault")
+	}
+	this.queries = append(this.queries, startOffset)
+	info := &vim.DiskChangeInfo{StartOffset: startOffset, Length: bytesPerMB}
+	for _, area := range areas {
+		if area.Start >= startOffset && area.Start < startOffset+bytesPerMB {
+			info.ChangedArea = append(info.ChangedArea, area)
+		}
+	}
+	return info, nil
+}
+
+func TestChangeTrackingProtectedEntity(t *testing.T) {
+	ctx := context.Background()
+	vsom := &fakeGlobalObjectManager{
+		capacityInMB: 3,
+		changeIDs:    map[string]string{"snap-1": "52 1a/1", "snap-2": "52 1a/2", "snap-3": ""},
+		changedAreas: map[string][]vim.DiskChangeExtent{
+			"52 1a/1": {{Start: 4096, Length: 512}, {Start: 2*bytesPerMB + 512, Length: 1024}},
+		},
+	}
+	newPE := func(snapshot string) *ChangeTrackingProtectedEntity {
+		id := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "fcd-1", astrolabe.NewProtectedEntitySnapshotID(snapshot))
+		return NewChangeTrackingProtectedEntity(snapshotPE{id: id}, vsom)
+	}
+
+	pe := newPE("snap-2")
+	size, err := pe.GetSize(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3*bytesPerMB), size)
+
+	changeID, err := pe.GetChangeID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "52 1a/2", changeID)
+
+	changedAreas, err := pe.GetChangedAreas(ctx, "52 1a/1")
+	require.NoError(t, err)
+	assert.Equal(t, []objectstore.Extent{{Offset: 4096, Length: 512}, {Offset: 2*bytesPerMB + 512, Length: 1024}}, changedAreas)
+	assert.Equal(t, []int64{0, bytesPerMB, 2 * bytesPerMB}, vsom.queries)
+
+	// The change ID is not valid anymore, e.g. as changed block tracking was reset
+	_, err = pe.GetChangedAreas(ctx, "52 1a/0")
+	assert.Error(t, err)
+
+	// Changed block tracking is not enabled
+	_, err = newPE("snap-3").GetChangeID(ctx)
+	assert.Error(t, err)
+
+	_, err = NewChangeTrackingProtectedEntity(snapshotPE{id: astrolabe.NewProtectedEntityID("ivd", "fcd-1")}, vsom).GetChangeID(ctx)
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ivd
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/astrolabe/pkg/ivd"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vslm"
+)
+
+const keepAliveInterval = 10 * time.Minute
+
+// VcenterURL returns the URL of the SDK of the vCenter of the params, as retrieved by utils.RetrieveVcConfigSecret,
+// and whether its certificate is not verified.
+func VcenterURL(params map[string]interface{}) (*url.URL, bool, error) {
+	host, _ := params[ivd.HostVcParamKey].(string)
+	if host == "" {
+		return nil, false, errors.New("No vCenter is configured")
+	}
+	user, _ := params[ivd.UserVcParamKey].(string)
+	password, _ := params[ivd.PasswordVcParamKey].(string)
+	port, _ := params[ivd.PortVcParamKey].(string)
+	if port == "" {
+		port = "443"
+	}
+	insecure := false
+	if flag, ok := params[ivd.InsecureFlagVcParamKey].(string); ok {
+		insecure, _ = strconv.ParseBool(flag)
+	}
+	return &url.URL{
+		Scheme: "https",
+		User:   url.UserPassword(user, password),
+		Host:   net.JoinHostPort(host, port),
+		Path:   "/sdk",
+	}, insecure, nil
+}
+
+// Login logs in to the vCenter of the params, and returns its GlobalObjectManager along with the func that logs out of
+// it. The session is kept alive until it is logged out of.
+func Login(ctx context.Context, params map[string]interface{}, logger logrus.FieldLogger) (GlobalObjectManager, func(), error) {
+	vcURL, insecure, err := VcenterURL(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	vimClient, err := vim25.NewClient(ctx, soap.NewClient(vcURL, insecure))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Failed to connect to vCenter %s", vcURL.Host)
+	}
+	vimClient.RoundTripper = session.KeepAlive(vimClient.RoundTripper, keepAliveInterval)
+	client := &govmomi.Client{
+		Client:         vimClient,
+		SessionManager: session.NewManager(vimClient),
+	}
+	if err = client.Login(ctx, vcURL.User); err != nil {
+		return nil, nil, errors.Wrapf(err, "Failed to log in to vCenter %s", vcURL.Host)
+	}
+	logout := func() {
+		if err := client.Logout(ctx); err != nil {
+			logger.WithError(err).Warnf("Failed to log out of vCenter %s", vcURL.Host)
+		}
+	}
+	vslmClient, err := vslm.NewClient(ctx, client.Client)
+	if err != nil {
+		logout()
+		return nil, nil, errors.Wrapf(err, "Failed to connect to the storage lifecycle service of vCenter %s", vcURL.Host)
+	}
+	return vslm.NewGlobalObjectManager(vslmClient), logout, nil
+}
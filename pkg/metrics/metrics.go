@@ -0,0 +1,241 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricNamespace = "velero_plugin_for_vsphere"
+
+	nodeLabel       = "node"
+	repositoryLabel = "repository"
+
+	// LocalRepository is the repository label of the metrics of the local snapshots of the volumes.
+	LocalRepository = "local"
+
+	ivdSnapshotCreateDuration = "ivd_snapshot_create_duration_seconds"
+	ivdSnapshotDeleteDuration = "ivd_snapshot_delete_duration_seconds"
+	uploadBackoff             = "upload_backoff_seconds"
+)
+
+// Direction is the direction of a transfer, the metrics of uploads and downloads are named by it.
+type Direction string
+
+const (
+	Upload   Direction = "upload"
+	Download Direction = "download"
+)
+
+func (d Direction) metricName(name string) string {
+	return string(d) + "_" + name
+}
+
+const (
+	attemptTotal    = "attempt_total"
+	successTotal    = "success_total"
+	failureTotal    = "failure_total"
+	cancelTotal     = "cancel_total"
+	retryTotal      = "retry_total"
+	bytesTotal      = "bytes_total"
+	inFlight        = "in_flight"
+	durationSeconds = "duration_seconds"
+	throughput      = "throughput_bytes_per_second"
+)
+
+// ServerMetrics are the Prometheus metrics of a data manager. All its methods may be called on a nil ServerMetrics,
+// which records nothing.
+type ServerMetrics struct {
+	metrics map[string]prometheus.Collector
+}
+
+// NewServerMetrics returns the metrics of a data manager, which are registered with RegisterAllMetrics.
+func NewServerMetrics() *ServerMetrics {
+	m := &ServerMetrics{
+		metrics: make(map[string]prometheus.Collector),
+	}
+
+	labels := []string{nodeLabel, repositoryLabel}
+	for _, direction := range []Direction{Upload, Download} {
+		for name, help := range map[string]string{
+			attemptTotal: "Total number of attempted " + string(direction) + "s",
+			successTotal: "Total number of successful " + string(direction) + "s",
+			failureTotal: "Total number of failed " + string(direction) + "s",
+			cancelTotal:  "Total number of canceled " + string(direction) + "s",
+			retryTotal:   "Total number of " + string(direction) + "s that failed and are retried",
+			bytesTotal:   "Total number of bytes of the volumes transferred by " + string(direction) + "s",
+		} {
+			m.metrics[direction.metricName(name)] = prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: metricNamespace,
+				Name:      direction.metricName(name),
+				Help:      help,
+			}, labels)
+		}
+		m.metrics[direction.metricName(inFlight)] = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Name:      direction.metricName(inFlight),
+			Help:      "Number of " + string(direction) + "s in progress",
+		}, labels)
+		m.metrics[direction.metricName(durationSeconds)] = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricNamespace,
+			Name:      direction.metricName(durationSeconds),
+			Help:      "Time taken by the successful " + string(direction) + "s, in seconds",
+			// 10s to about 11h
+			Buckets: prometheus.ExponentialBuckets(10, 2, 13),
+		}, labels)
+		m.metrics[direction.metricName(throughput)] = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricNamespace,
+			Name:      direction.metricName(throughput),
+			Help:      "Bytes of the volume transferred per second by the successful " + string(direction) + "s",
+			// 1MiB/s to 4GiB/s
+			Buckets: prometheus.ExponentialBuckets(1024*1024, 2, 13),
+		}, labels)
+	}
+	m.metrics[uploadBackoff] = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricNamespace,
+		Name:      uploadBackoff,
+		Help:      "The backoff, in seconds, before the last upload that failed is retried",
+	}, labels)
+	for name, help := range map[string]string{
+		ivdSnapshotCreateDuration: "Time taken to create a local snapshot of a volume, in seconds",
+		ivdSnapshotDeleteDuration: "Time taken to delete a local snapshot of a volume, in seconds",
+	} {
+		m.metrics[name] = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricNamespace,
+			Name:      name,
+			Help:      help,
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+		}, labels)
+	}
+
+	return m
+}
+
+// RegisterAllMetrics registers all the metrics with the default Prometheus registry.
+func (m *ServerMetrics) RegisterAllMetrics() {
+	for _, pm := range m.metrics {
+		prometheus.MustRegister(pm)
+	}
+}
+
+// RegisterPendingUploads registers the metrics of the Uploads that have not been completed yet, which are counted by
+// pending when the metrics are collected. oldestAge is the time since the oldest of them was created, 0 if there is
+// none, so that an alert can fire when the uploads fall behind.
+func (m *ServerMetrics) RegisterPendingUploads(pending func() (count int, oldestAge time.Duration)) {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricNamespace,
+		Name:      "upload_pending",
+		Help:      "Number of uploads that are not completed yet",
+	}, func() float64 {
+		count, _ := pending()
+		return float64(count)
+	}))
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricNamespace,
+		Name:      "upload_oldest_pending_seconds",
+		Help:      "Time since the oldest upload that is not completed yet was created, in seconds",
+	}, func() float64 {
+		_, oldestAge := pending()
+		return oldestAge.Seconds()
+	}))
+}
+
+func (m *ServerMetrics) counter(name string, node string, repository string) prometheus.Counter {
+	return m.metrics[name].(*prometheus.CounterVec).WithLabelValues(node, repository)
+}
+
+func (m *ServerMetrics) gauge(name string, node string, repository string) prometheus.Gauge {
+	return m.metrics[name].(*prometheus.GaugeVec).WithLabelValues(node, repository)
+}
+
+func (m *ServerMetrics) histogram(name string, node string, repository string) prometheus.Observer {
+	return m.metrics[name].(*prometheus.HistogramVec).WithLabelValues(node, repository)
+}
+
+// RegisterTransferAttempt records that a transfer starts, it is in flight until it is recorded as done.
+func (m *ServerMetrics) RegisterTransferAttempt(direction Direction, node string, repository string) {
+	if m == nil {
+		return
+	}
+	m.counter(direction.metricName(attemptTotal), node, repository).Inc()
+	m.gauge(direction.metricName(inFlight), node, repository).Inc()
+}
+
+// RegisterTransferSuccess records that a transfer of the given number of bytes of a volume completed in duration.
+func (m *ServerMetrics) RegisterTransferSuccess(direction Direction, node string, repository string, bytes int64, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.gauge(direction.metricName(inFlight), node, repository).Dec()
+	m.counter(direction.metricName(successTotal), node, repository).Inc()
+	m.counter(direction.metricName(bytesTotal), node, repository).Add(float64(bytes))
+	m.histogram(direction.metricName(durationSeconds), node, repository).Observe(duration.Seconds())
+	if duration > 0 {
+		m.histogram(direction.metricName(throughput), node, repository).Observe(float64(bytes) / duration.Seconds())
+	}
+}
+
+// RegisterTransferFailure records that a transfer failed after transferring the given number of bytes of a volume.
+func (m *ServerMetrics) RegisterTransferFailure(direction Direction, node string, repository string, bytes int64) {
+	if m == nil {
+		return
+	}
+	m.gauge(direction.metricName(inFlight), node, repository).Dec()
+	m.counter(direction.metricName(failureTotal), node, repository).Inc()
+	m.counter(direction.metricName(bytesTotal), node, repository).Add(float64(bytes))
+}
+
+// RegisterTransferCancel records that a transfer was canceled after transferring the given number of bytes of a
+// volume.
+func (m *ServerMetrics) RegisterTransferCancel(direction Direction, node string, repository string, bytes int64) {
+	if m == nil {
+		return
+	}
+	m.gauge(direction.metricName(inFlight), node, repository).Dec()
+	m.counter(direction.metricName(cancelTotal), node, repository).Inc()
+	m.counter(direction.metricName(bytesTotal), node, repository).Add(float64(bytes))
+}
+
+// RegisterTransferRetry records that a transfer that failed is retried, after backoff for uploads.
+func (m *ServerMetrics) RegisterTransferRetry(direction Direction, node string, repository string, backoff time.Duration) {
+	if m == nil {
+		return
+	}
+	m.counter(direction.metricName(retryTotal), node, repository).Inc()
+	if direction == Upload {
+		m.gauge(uploadBackoff, node, repository).Set(backoff.Seconds())
+	}
+}
+
+// ObserveSnapshotCreate records the time taken to create a local snapshot of a volume.
+func (m *ServerMetrics) ObserveSnapshotCreate(node string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.histogram(ivdSnapshotCreateDuration, node, LocalRepository).Observe(duration.Seconds())
+}
+
+// ObserveSnapshotDelete records the time taken to delete a local snapshot of a volume.
+func (m *ServerMetrics) ObserveSnapshotDelete(node string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.histogram(ivdSnapshotDeleteDuration, node, LocalRepository).Observe(duration.Seconds())
+}
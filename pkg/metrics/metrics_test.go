@@ -0,0 +1,57 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferMetrics(t *testing.T) {
+	m := NewServerMetrics()
+	repository := "BackupStorageLocation/default"
+
+	m.RegisterTransferAttempt(Upload, "node-1", repository)
+	m.RegisterTransferAttempt(Upload, "node-1", repository)
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.gauge("upload_in_flight", "node-1", repository)))
+
+	m.RegisterTransferSuccess(Upload, "node-1", repository, 4096, 2*time.Second)
+	m.RegisterTransferFailure(Upload, "node-1", repository, 1024)
+	m.RegisterTransferRetry(Upload, "node-1", repository, 2*time.Minute)
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.gauge("upload_in_flight", "node-1", repository)))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.counter("upload_attempt_total", "node-1", repository)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.counter("upload_success_total", "node-1", repository)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.counter("upload_failure_total", "node-1", repository)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.counter("upload_retry_total", "node-1", repository)))
+	assert.Equal(t, float64(5120), testutil.ToFloat64(m.counter("upload_bytes_total", "node-1", repository)))
+	assert.Equal(t, float64(120), testutil.ToFloat64(m.gauge("upload_backoff_seconds", "node-1", repository)))
+
+	// Downloads are counted apart from uploads.
+	m.RegisterTransferAttempt(Download, "node-1", repository)
+	m.RegisterTransferCancel(Download, "node-1", repository, 0)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.counter("download_cancel_total", "node-1", repository)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.counter("upload_cancel_total", "node-1", repository)))
+
+	// Nil ServerMetrics record nothing.
+	var disabled *ServerMetrics
+	disabled.RegisterTransferAttempt(Upload, "node-1", repository)
+	disabled.RegisterTransferSuccess(Upload, "node-1", repository, 4096, time.Second)
+	disabled.ObserveSnapshotCreate("node-1", time.Second)
+}
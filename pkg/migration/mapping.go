@@ -0,0 +1,322 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultConfigMapName is the name of the restore mapping ConfigMap, in the Velero namespace, that the data
+	// manager and the plugin read if they are not configured with another one.
+	DefaultConfigMapName = "velero-vsphere-restore-mapping"
+
+	// The entries of the restore mapping ConfigMap. Every entry is optional.
+	ConfigMapDatacentersKey      = "datacenters"
+	ConfigMapDatastoresKey       = "datastores"
+	ConfigMapDefaultDatastoreKey = "defaultDatastore"
+	ConfigMapStorageClassesKey   = "storageClasses"
+	ConfigMapStoragePoliciesKey  = "storagePolicies"
+	ConfigMapStrictKey           = "strict"
+)
+
+// ErrUnmapped is returned in strict mode for the datacenters, datastores, StorageClasses and storage policies that
+// are not mapped. Retrying does not help until the Mapping is changed.
+var ErrUnmapped = errors.New("not mapped")
+
+// The topology keys whose values the vSphere CSI driver sets from the region tag of the datacenter of a volume.
+var regionTopologyKeys = map[string]bool{
+	"failure-domain.beta.kubernetes.io/region": true,
+	"topology.kubernetes.io/region":            true,
+}
+
+// Mapping maps the datacenters, datastores, StorageClasses and storage policies of the cluster and the vCenter that
+// the snapshots in a repository were taken on to those of the cluster and the vCenter they are restored to, so that the
+// snapshots of another cluster are restored on this one. The values that are not mapped are kept as they are, unless
+// the Mapping is strict.
+type Mapping struct {
+	// Datacenters are the regions of the target datacenters, by the regions of the source datacenters, as set in the
+	// node affinity of the PersistentVolumes.
+	Datacenters map[string]string
+	// Datastores are the managed object IDs of the target datastores, such as "datastore-1034", by the IDs of the
+	// source datastores, as recorded in the metadata of the snapshots. They may also map the datastore URLs of the
+	// StorageClasses, such as "ds:///vmfs/volumes/vsan:52c1/".
+	Datastores map[string]string
+	// DefaultDatastore is the ID of the datastore the volumes of the source datastores that are not in Datastores are
+	// restored to.
+	DefaultDatastore string
+	// StorageClasses are the names of the target StorageClasses, by the names of the StorageClasses of the backed up
+	// PersistentVolumes, that the restored volumes are placed for.
+	StorageClasses map[string]string
+	// StoragePolicies are the names of the target storage policies, by the names of the storage policies of the
+	// StorageClasses.
+	StoragePolicies map[string]string
+	// Strict rejects the values that are not mapped, instead of keeping them.
+	Strict bool
+}
+
+// Empty returns whether the Mapping leaves the snapshots and the PersistentVolumes as they are.
+func (m Mapping) Empty() bool {
+	return len(m.Datacenters) == 0 && len(m.Datastores) == 0 && m.DefaultDatastore == "" &&
+		len(m.StorageClasses) == 0 && len(m.StoragePolicies) == 0 && !m.Strict
+}
+
+// Datastore returns the ID of the target datastore of the source datastore with the given ID.
+func (m Mapping) Datastore(source string) (string, error) {
+	return m.target("datastore", m.Datastores, source, m.DefaultDatastore)
+}
+
+// DatastoreURL returns the URL of the target datastore of the source datastore with the given URL.
+func (m Mapping) DatastoreURL(source string) (string, error) {
+	return m.target("datastore URL", m.Datastores, source, "")
+}
+
+// Datacenter returns the region of the target datacenter of the source datacenter with the given region.
+func (m Mapping) Datacenter(source string) (string, error) {
+	return m.target("datacenter region", m.Datacenters, source, "")
+}
+
+// StorageClass returns the name of the target StorageClass of the source StorageClass with the given name.
+func (m Mapping) StorageClass(source string) (string, error) {
+	return m.target("StorageClass", m.StorageClasses, source, "")
+}
+
+// StoragePolicy returns the name of the target storage policy of the source storage policy with the given name.
+func (m Mapping) StoragePolicy(source string) (string, error) {
+	return m.target("storage policy", m.StoragePolicies, source, "")
+}
+
+// target returns the target of the source in targets, or else the fallback if it is not empty. A source that is not
+// mapped is kept, as is a source that is a target itself, e.g. the StorageClass of a PersistentVolumeClaim that was
+// already renamed, unless the Mapping is strict.
+func (m Mapping) target(kind string, targets map[string]string, source string, fallback string) (string, error) {
+	if target, ok := targets[source]; ok {
+		return target, nil
+	}
+	if fallback != "" {
+		return fallback, nil
+	}
+	if !m.Strict {
+		return source, nil
+	}
+	for _, target := range targets {
+		if target == source {
+			return source, nil
+		}
+	}
+	return "", errors.Wrapf(ErrUnmapped, "%s %s is not mapped by the strict restore mapping", kind, source)
+}
+
+// RelocateMetadata returns the metadata of a snapshot, as it is stored in the repository, with the datastores, that
+// are the managed object references of type Datastore anywhere in it, replaced by their target datastores. It returns
+// the metadata as is if no datastore is replaced.
+func (m Mapping) RelocateMetadata(metadata []byte) ([]byte, bool, error) {
+	if len(m.Datastores) == 0 && m.DefaultDatastore == "" && !m.Strict {
+		return metadata, false, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(metadata))
+	// The capacity and the other numbers of the metadata are kept exactly as they are.
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, false, errors.Wrap(err, "Failed to parse the metadata of the snapshot")
+	}
+	relocated, err := m.relocateDatastores(document)
+	if err != nil {
+		return nil, false, err
+	}
+	if !relocated {
+		return metadata, false, nil
+	}
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "Failed to encode the relocated metadata of the snapshot")
+	}
+	return encoded, true, nil
+}
+
+func (m Mapping) relocateDatastores(value interface{}) (bool, error) {
+	relocated := false
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if value["Type"] == "Datastore" {
+			if source, ok := value["Value"].(string); ok {
+				target, err := m.Datastore(source)
+				if err != nil {
+					return false, err
+				}
+				if target != source {
+					value["Value"] = target
+					relocated = true
+				}
+			}
+		}
+		for _, field := range value {
+			fieldRelocated, err := m.relocateDatastores(field)
+			if err != nil {
+				return false, err
+			}
+			relocated = relocated || fieldRelocated
+		}
+	case []interface{}:
+		for _, element := range value {
+			elementRelocated, err := m.relocateDatastores(element)
+			if err != nil {
+				return false, err
+			}
+			relocated = relocated || elementRelocated
+		}
+	}
+	return relocated, nil
+}
+
+// RelocatePersistentVolume replaces the regions of the source datacenters in the node affinity of the
+// PersistentVolume by those of their target datacenters. It returns whether the PersistentVolume is changed.
+func (m Mapping) RelocatePersistentVolume(pv *corev1.PersistentVolume) (bool, error) {
+	if (len(m.Datacenters) == 0 && !m.Strict) || pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return false, nil
+	}
+	relocated := false
+	for i := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		term := &pv.Spec.NodeAffinity.Required.NodeSelectorTerms[i]
+		for j := range term.MatchExpressions {
+			expression := &term.MatchExpressions[j]
+			if !regionTopologyKeys[expression.Key] {
+				continue
+			}
+			for k, source := range expression.Values {
+				target, err := m.Datacenter(source)
+				if err != nil {
+					return false, err
+				}
+				if target != source {
+					expression.Values[k] = target
+					relocated = true
+				}
+			}
+		}
+	}
+	return relocated, nil
+}
+
+// ParseMap parses one "<source>: <target>" entry per line, such as "datastore-12: datastore-1034" or
+// "ds:///vmfs/volumes/vsan:52c1/: ds:///vmfs/volumes/vsan:9a7f/".
+func ParseMap(s string) (map[string]string, error) {
+	targets := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// The datastore URLs have colons, so they are separated by a colon and a space.
+		separator, width := strings.Index(line, ": "), 2
+		if separator < 0 {
+			separator, width = strings.LastIndex(line, ":"), 1
+		}
+		if separator < 0 {
+			return nil, errors.Errorf("invalid mapping %q, expected <source>: <target>", line)
+		}
+		source, target := strings.TrimSpace(line[:separator]), strings.TrimSpace(line[separator+width:])
+		if source == "" || target == "" {
+			return nil, errors.Errorf("invalid mapping %q, expected <source>: <target>", line)
+		}
+		if _, ok := targets[source]; ok {
+			return nil, errors.Errorf("invalid mapping %q, %s is mapped more than once", line, source)
+		}
+		targets[source] = target
+	}
+	return targets, nil
+}
+
+// FromConfigMap returns the Mapping of the entries of the ConfigMap.
+func FromConfigMap(configMap *corev1.ConfigMap) (Mapping, error) {
+	var mapping Mapping
+	var err error
+	if value, ok := configMap.Data[ConfigMapDatacentersKey]; ok {
+		if mapping.Datacenters, err = ParseMap(value); err != nil {
+			return Mapping{}, errors.Wrapf(err, "invalid %s", ConfigMapDatacentersKey)
+		}
+	}
+	if value, ok := configMap.Data[ConfigMapDatastoresKey]; ok {
+		if mapping.Datastores, err = ParseMap(value); err != nil {
+			return Mapping{}, errors.Wrapf(err, "invalid %s", ConfigMapDatastoresKey)
+		}
+	}
+	mapping.DefaultDatastore = strings.TrimSpace(configMap.Data[ConfigMapDefaultDatastoreKey])
+	if value, ok := configMap.Data[ConfigMapStorageClassesKey]; ok {
+		if mapping.StorageClasses, err = ParseMap(value); err != nil {
+			return Mapping{}, errors.Wrapf(err, "invalid %s", ConfigMapStorageClassesKey)
+		}
+	}
+	if value, ok := configMap.Data[ConfigMapStoragePoliciesKey]; ok {
+		if mapping.StoragePolicies, err = ParseMap(value); err != nil {
+			return Mapping{}, errors.Wrapf(err, "invalid %s", ConfigMapStoragePoliciesKey)
+		}
+	}
+	if value, ok := configMap.Data[ConfigMapStrictKey]; ok {
+		if mapping.Strict, err = strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return Mapping{}, errors.Wrapf(err, "invalid %s", ConfigMapStrictKey)
+		}
+	}
+	return mapping, nil
+}
+
+// Source supplies the restore Mapping from a ConfigMap, which is read on every call, so that it is set up before a
+// restore without a restart. A nil Source, and a Source whose ConfigMap does not exist, supply an empty Mapping.
+type Source struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	name       string
+}
+
+// NewSource returns a Source that supplies the Mapping of the ConfigMap with the given name in the namespace.
+func NewSource(kubeClient kubernetes.Interface, namespace string, name string) *Source {
+	return &Source{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+	}
+}
+
+func (this *Source) Get() (Mapping, error) {
+	if this == nil || this.name == "" {
+		return Mapping{}, nil
+	}
+	configMap, err := this.kubeClient.CoreV1().ConfigMaps(this.namespace).Get(this.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return Mapping{}, nil
+	}
+	if err != nil {
+		return Mapping{}, errors.Wrapf(err, "Failed to get restore mapping config map %s/%s", this.namespace, this.name)
+	}
+	mapping, err := FromConfigMap(configMap)
+	if err != nil {
+		return Mapping{}, errors.Wrapf(err, "Invalid restore mapping config map %s/%s", this.namespace, this.name)
+	}
+	return mapping, nil
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+const metadata = `{"VirtualStorageObject":{"Config":{"Name":"pvc-1","CapacityInMB":9007199254740993,` +
+	`"Backing":{"Datastore":{"Type":"Datastore","Value":"datastore-12"},"FilePath":"[vsanDatastore] fcd/1.vmdk"}}},` +
+	`"Datastore":{"Type":"Datastore","Value":"datastore-12"},` +
+	`"ExtendedMetadata":[{"Key":"cns.k8s.pv.name","Value":"pvc-1"}]}`
+
+func TestRelocateMetadata(t *testing.T) {
+	// Without datastores to map, the metadata is kept as it is.
+	same, relocated, err := Mapping{}.RelocateMetadata([]byte(metadata))
+	require.NoError(t, err)
+	assert.False(t, relocated)
+	assert.Equal(t, metadata, string(same))
+
+	mapping := Mapping{Datastores: map[string]string{"datastore-12": "datastore-1034"}}
+	moved, relocated, err := mapping.RelocateMetadata([]byte(metadata))
+	require.NoError(t, err)
+	assert.True(t, relocated)
+	var document struct {
+		VirtualStorageObject struct {
+			Config struct {
+				CapacityInMB json.Number
+				Backing      struct{ Datastore struct{ Type, Value string } }
+			}
+		}
+		Datastore        struct{ Type, Value string }
+		ExtendedMetadata []struct{ Key, Value string }
+	}
+	require.NoError(t, json.Unmarshal(moved, &document))
+	assert.Equal(t, "datastore-1034", document.Datastore.Value)
+	assert.Equal(t, "datastore-1034", document.VirtualStorageObject.Config.Backing.Datastore.Value)
+	assert.Equal(t, "9007199254740993", document.VirtualStorageObject.Config.CapacityInMB.String())
+	assert.Equal(t, "pvc-1", document.ExtendedMetadata[0].Value)
+
+	// The datastores that are not mapped are restored to the default datastore if there is one.
+	_, relocated, err = Mapping{Datastores: map[string]string{"datastore-99": "datastore-1034"}}.RelocateMetadata([]byte(metadata))
+	require.NoError(t, err)
+	assert.False(t, relocated)
+	_, relocated, err = Mapping{DefaultDatastore: "datastore-2048"}.RelocateMetadata([]byte(metadata))
+	require.NoError(t, err)
+	assert.True(t, relocated)
+
+	_, _, err = mapping.RelocateMetadata([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestRelocatePersistentVolume(t *testing.T) {
+	pv := &corev1.PersistentVolume{
+		Spec: corev1.PersistentVolumeSpec{
+			NodeAffinity: &corev1.VolumeNodeAffinity{
+				Required: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: "failure-domain.beta.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"zone-a"}},
+							{Key: "failure-domain.beta.kubernetes.io/region", Operator: corev1.NodeSelectorOpIn, Values: []string{"dc-east"}},
+						},
+					}},
+				},
+			},
+		},
+	}
+	mapping := Mapping{Datacenters: map[string]string{"dc-east": "dc-west", "zone-a": "zone-b"}}
+	relocated, err := mapping.RelocatePersistentVolume(pv)
+	require.NoError(t, err)
+	assert.True(t, relocated)
+	expressions := pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions
+	assert.Equal(t, []string{"zone-a"}, expressions[0].Values)
+	assert.Equal(t, []string{"dc-west"}, expressions[1].Values)
+
+	// The regions that are already mapped are accepted in strict mode, the others are rejected.
+	mapping.Strict = true
+	relocated, err = mapping.RelocatePersistentVolume(pv)
+	require.NoError(t, err)
+	assert.False(t, relocated)
+	expressions[1].Values = []string{"dc-north"}
+	_, err = mapping.RelocatePersistentVolume(pv)
+	assert.True(t, errors.Is(err, ErrUnmapped))
+	relocated, err = mapping.RelocatePersistentVolume(&corev1.PersistentVolume{})
+	require.NoError(t, err)
+	assert.False(t, relocated)
+}
+
+func TestStrictMapping(t *testing.T) {
+	mapping := Mapping{
+		Datastores:      map[string]string{"datastore-12": "datastore-1034", "ds:///vmfs/volumes/vsan:52c1/": "ds:///vmfs/volumes/vsan:9a7f/"},
+		StorageClasses:  map[string]string{"gold": "vsan-gold"},
+		StoragePolicies: map[string]string{"Gold Policy": "vSAN Gold"},
+	}
+	for _, strict := range []bool{false, true} {
+		mapping.Strict = strict
+		storageClass, err := mapping.StorageClass("gold")
+		require.NoError(t, err)
+		assert.Equal(t, "vsan-gold", storageClass)
+		storageClass, err = mapping.StorageClass("vsan-gold")
+		require.NoError(t, err)
+		assert.Equal(t, "vsan-gold", storageClass)
+		policy, err := mapping.StoragePolicy("Gold Policy")
+		require.NoError(t, err)
+		assert.Equal(t, "vSAN Gold", policy)
+		url, err := mapping.DatastoreURL("ds:///vmfs/volumes/vsan:52c1/")
+		require.NoError(t, err)
+		assert.Equal(t, "ds:///vmfs/volumes/vsan:9a7f/", url)
+	}
+
+	// The values that are not mapped are kept, unless the Mapping is strict.
+	mapping.Strict = false
+	storageClass, err := mapping.StorageClass("silver")
+	require.NoError(t, err)
+	assert.Equal(t, "silver", storageClass)
+	mapping.Strict = true
+	_, err = mapping.StorageClass("silver")
+	assert.True(t, errors.Is(err, ErrUnmapped))
+	assert.EqualError(t, err, "StorageClass silver is not mapped by the strict restore mapping: not mapped")
+	_, err = mapping.StoragePolicy("Silver Policy")
+	assert.True(t, errors.Is(err, ErrUnmapped))
+	_, _, err = mapping.RelocateMetadata([]byte(strings.Replace(metadata, "datastore-12", "datastore-13", -1)))
+	assert.True(t, errors.Is(err, ErrUnmapped))
+
+	// The default datastore maps all the other datastores.
+	mapping.DefaultDatastore = "datastore-2048"
+	_, relocated, err := mapping.RelocateMetadata([]byte(strings.Replace(metadata, "datastore-12", "datastore-13", -1)))
+	require.NoError(t, err)
+	assert.True(t, relocated)
+}
+
+func TestSource(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	source := NewSource(kubeClient, "velero", DefaultConfigMapName)
+
+	// Without the ConfigMap, nothing is mapped.
+	mapping, err := source.Get()
+	require.NoError(t, err)
+	assert.True(t, mapping.Empty())
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: DefaultConfigMapName},
+		Data: map[string]string{
+			ConfigMapDatacentersKey:      "dc-east: dc-west\n",
+			ConfigMapDatastoresKey:       "# vsanDatastore\ndatastore-12: datastore-1034\ndatastore-13: datastore-1035\n",
+			ConfigMapDefaultDatastoreKey: " datastore-2048 ",
+		},
+	}
+	_, err = kubeClient.CoreV1().ConfigMaps("velero").Create(configMap)
+	require.NoError(t, err)
+	mapping, err = source.Get()
+	require.NoError(t, err)
+	assert.Equal(t, Mapping{
+		Datacenters:      map[string]string{"dc-east": "dc-west"},
+		Datastores:       map[string]string{"datastore-12": "datastore-1034", "datastore-13": "datastore-1035"},
+		DefaultDatastore: "datastore-2048",
+	}, mapping)
+	datastore, err := mapping.Datastore("datastore-13")
+	require.NoError(t, err)
+	assert.Equal(t, "datastore-1035", datastore)
+	datastore, err = mapping.Datastore("datastore-14")
+	require.NoError(t, err)
+	assert.Equal(t, "datastore-2048", datastore)
+
+	configMap.Data[ConfigMapDatastoresKey] = "datastore-12: datastore-1034\ndatastore-12: datastore-1035\n"
+	_, err = kubeClient.CoreV1().ConfigMaps("velero").Update(configMap)
+	require.NoError(t, err)
+	_, err = source.Get()
+	assert.Error(t, err)
+
+	for _, invalid := range []string{"datastore-12", "datastore-12:", ": datastore-1034"} {
+		_, err := ParseMap(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	// The datastore URLs have colons of their own.
+	urls, err := ParseMap("ds:///vmfs/volumes/vsan:52c1/: ds:///vmfs/volumes/vsan:9a7f/")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ds:///vmfs/volumes/vsan:52c1/": "ds:///vmfs/volumes/vsan:9a7f/"}, urls)
+
+	configMap.Data = map[string]string{
+		ConfigMapStorageClassesKey:  "gold: vsan-gold\n",
+		ConfigMapStoragePoliciesKey: "Gold Policy: vSAN Gold\n",
+		ConfigMapStrictKey:          "true",
+	}
+	_, err = kubeClient.CoreV1().ConfigMaps("velero").Update(configMap)
+	require.NoError(t, err)
+	mapping, err = source.Get()
+	require.NoError(t, err)
+	assert.Equal(t, Mapping{
+		StorageClasses:  map[string]string{"gold": "vsan-gold"},
+		StoragePolicies: map[string]string{"Gold Policy": "vSAN Gold"},
+		Strict:          true,
+	}, mapping)
+	configMap.Data[ConfigMapStrictKey] = "sometimes"
+	_, err = kubeClient.CoreV1().ConfigMaps("velero").Update(configMap)
+	require.NoError(t, err)
+	_, err = source.Get()
+	assert.Error(t, err)
+
+	var nilSource *Source
+	mapping, err = nilSource.Get()
+	require.NoError(t, err)
+	assert.True(t, mapping.Empty())
+}
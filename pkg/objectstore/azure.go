@@ -0,0 +1,111 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/pkg/errors"
+	"io"
+	"net/url"
+	"strings"
+)
+
+const (
+	// Size of the blocks a blob is uploaded in, and number of blocks uploaded in parallel.
+	azureBlockSize  = 8 * 1024 * 1024
+	azureMaxBuffers = 4
+
+	// Number of retries of a failed read while downloading a blob.
+	azureMaxRetryRequests = 5
+)
+
+// AzureBlobStore is an ObjectStore in a container of an Azure storage account.
+type AzureBlobStore struct {
+	containerURL azblob.ContainerURL
+}
+
+// NewAzureBlobStore creates an AzureBlobStore for the container of the storage account, authenticated with the shared
+// key of the account. The endpoint defaults to the public Azure cloud, https://<account>.blob.core.windows.net. It may
+// be set to another cloud, or to an emulator, e.g. http://127.0.0.1:10000/devstoreaccount1 for Azurite.
+func NewAzureBlobStore(storageAccount string, storageAccountKey string, container string, endpoint string) (*AzureBlobStore, error) {
+	credential, err := azblob.NewSharedKeyCredential(storageAccount, storageAccountKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid shared key of storage account %s", storageAccount)
+	}
+
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", storageAccount)
+	}
+	containerURL, err := url.Parse(strings.TrimSuffix(endpoint, "/") + "/" + container)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid blob endpoint %s", endpoint)
+	}
+
+	pipeline := azblob.NewPipeline(credential, azblob.PipelineOptions{})
+	return &AzureBlobStore{
+		containerURL: azblob.NewContainerURL(*containerURL, pipeline),
+	}, nil
+}
+
+func (this *AzureBlobStore) PutObject(ctx context.Context, key string, body io.Reader) error {
+	_, err := azblob.UploadStreamToBlockBlob(ctx, body, this.containerURL.NewBlockBlobURL(key), azblob.UploadStreamToBlockBlobOptions{
+		BufferSize: azureBlockSize,
+		MaxBuffers: azureMaxBuffers,
+	})
+	return err
+}
+
+func (this *AzureBlobStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	response, err := this.containerURL.NewBlobURL(key).Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
+	if isAzureNotFound(err) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response.Body(azblob.RetryReaderOptions{MaxRetryRequests: azureMaxRetryRequests}), nil
+}
+
+func (this *AzureBlobStore) DeleteObject(ctx context.Context, key string) error {
+	_, err := this.containerURL.NewBlobURL(key).Delete(ctx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{})
+	if isAzureNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (this *AzureBlobStore) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		response, err := this.containerURL.ListBlobsFlatSegment(ctx, marker, azblob.ListBlobsSegmentOptions{Prefix: prefix})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range response.Segment.BlobItems {
+			keys = append(keys, item.Name)
+		}
+		marker = response.NextMarker
+	}
+	return keys, nil
+}
+
+func isAzureNotFound(err error) bool {
+	storageErr, ok := err.(azblob.StorageError)
+	return ok && storageErr.ServiceCode() == azblob.ServiceCodeBlobNotFound
+}
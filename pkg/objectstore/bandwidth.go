@@ -0,0 +1,83 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// The bandwidth of a copy is limited by the token buckets set in its context with WithBandwidthLimiters. Only the
+// chunks, as they are sent to and received from the object store, are throttled, so the data that is not uploaded
+// because it did not change does not count against the limit. The limiters are shared by the copies that run at the
+// same time, so that they share the bandwidth.
+
+type bandwidthLimitersKey struct{}
+
+// WithBandwidthLimiters returns a context in which the chunks of snapshots are transferred no faster than every one
+// of the given limiters allows, in bytes per second.
+func WithBandwidthLimiters(ctx context.Context, limiters ...*rate.Limiter) context.Context {
+	if len(limiters) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, bandwidthLimitersKey{}, limiters)
+}
+
+func bandwidthLimiters(ctx context.Context) []*rate.Limiter {
+	limiters, _ := ctx.Value(bandwidthLimitersKey{}).([]*rate.Limiter)
+	return limiters
+}
+
+// limitReader returns a reader that reads from reader no faster than the bandwidth limiters of the context allow.
+func limitReader(ctx context.Context, reader io.Reader) io.Reader {
+	limiters := bandwidthLimiters(ctx)
+	if len(limiters) == 0 {
+		return reader
+	}
+	return &limitedReader{
+		ctx:      ctx,
+		reader:   reader,
+		limiters: limiters,
+	}
+}
+
+type limitedReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	limiters []*rate.Limiter
+}
+
+func (this *limitedReader) Read(p []byte) (int, error) {
+	// A limiter does not grant more than its burst at once, even while it is unlimited, as its limit may be set before
+	// it is waited on.
+	for _, limiter := range this.limiters {
+		if len(p) > limiter.Burst() {
+			p = p[:limiter.Burst()]
+		}
+	}
+	n, err := this.reader.Read(p)
+	if n > 0 {
+		for _, limiter := range this.limiters {
+			if waitErr := limiter.WaitN(this.ctx, n); waitErr != nil {
+				return n, waitErr
+			}
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestLimitReader(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 3000)
+
+	// Without limiters, the reader is not wrapped.
+	reader := bytes.NewReader(data)
+	assert.Equal(t, reader, limitReader(context.Background(), reader))
+
+	// The first 1000 bytes are the burst, the other 2000 take a second at 2000 bytes per second.
+	limiter := rate.NewLimiter(2000, 1000)
+	ctx := WithBandwidthLimiters(context.Background(), limiter, rate.NewLimiter(rate.Inf, 1000))
+	start := time.Now()
+	read, err := ioutil.ReadAll(limitReader(ctx, bytes.NewReader(data)))
+	require.NoError(t, err)
+	assert.Equal(t, data, read)
+	assert.True(t, time.Since(start) >= 900*time.Millisecond, "read in %v", time.Since(start))
+
+	// The wait for the limiter is interrupted when the context is canceled.
+	ctx, cancel := context.WithCancel(WithBandwidthLimiters(context.Background(), rate.NewLimiter(1, 1000)))
+	cancel()
+	_, err = ioutil.ReadAll(limitReader(ctx, bytes.NewReader(data)))
+	assert.Error(t, err)
+}
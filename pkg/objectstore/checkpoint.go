@@ -0,0 +1,155 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"io"
+	"strings"
+	"time"
+)
+
+// The upload of the data of a snapshot is checkpointed every checkpointInterval chunks in
+// <prefix>/<type>/checkpoint/<peID>, which lists the chunks uploaded so far. If the upload is interrupted, e.g. as the
+// data manager that runs it dies, the next copy of the snapshot resumes from the checkpoint instead of reading the
+// data from the beginning. The checkpoint is deleted once the snapshot is copied.
+//
+// An upload is only resumed if its data can be read at any offset, as the data of a virtual disk can, so that the data
+// after the checkpoint is the same as when the checkpoint was written, and if it is encrypted with the same key.
+const (
+	DefaultCheckpointInterval = 256
+
+	// A checkpoint that is older than this is considered abandoned and deleted by the maintenance of the repository.
+	checkpointExpiration = 7 * 24 * time.Hour
+)
+
+// CheckpointFunc is called when an upload is checkpointed or resumed, with the number of bytes of the data that are
+// committed to the repository.
+type CheckpointFunc func(committedBytes int64)
+
+// checkpoint is the content of the checkpoint object of an upload.
+type checkpoint struct {
+	Time time.Time `json:"time"`
+	// Parent is the ID of the snapshot the upload is incremental to, or empty if the snapshot is copied in full.
+	Parent string `json:"parent,omitempty"`
+	// Manifest lists all the chunks uploaded so far, its size is the number of bytes committed.
+	Manifest manifest `json:"manifest"`
+}
+
+func (this *ProtectedEntityTypeManager) putCheckpoint(ctx context.Context, id astrolabe.ProtectedEntityID, c checkpoint) error {
+	return this.putCompressedJSON(ctx, this.checkpointPrefix+id.String(), c)
+}
+
+func (this *ProtectedEntityTypeManager) readCheckpoint(ctx context.Context, id astrolabe.ProtectedEntityID) (checkpoint, error) {
+	var c checkpoint
+	if err := this.getCompressedJSON(ctx, this.checkpointPrefix+id.String(), &c); err != nil {
+		return c, err
+	}
+	m := c.Manifest
+	if m.Version != manifestVersion || m.Compression != compressionZstd || m.ChunkSize <= 0 || len(m.Chunks) != chunkCount(m) {
+		return c, errors.Errorf("Unsupported checkpoint of ProtectedEntity %s, version %d, compression %s", id.String(), m.Version, m.Compression)
+	}
+	return c, nil
+}
+
+func (this *ProtectedEntityTypeManager) deleteCheckpoint(ctx context.Context, id astrolabe.ProtectedEntityID) error {
+	return this.store.DeleteObject(ctx, this.checkpointPrefix+id.String())
+}
+
+// findCheckpoint returns the checkpoint to resume the upload of the data of the snapshot from, or nil if the upload
+// starts from the beginning.
+func (this *ProtectedEntityTypeManager) findCheckpoint(ctx context.Context, id astrolabe.ProtectedEntityID, data io.Reader, parent *parentSnapshot) *checkpoint {
+	log := this.logger.WithField("peID", id.String())
+	c, err := this.readCheckpoint(ctx, id)
+	if IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		log.WithError(err).Warn("Failed to read the checkpoint of the upload, uploading the data from the beginning")
+		return nil
+	}
+	if _, ok := data.(io.ReaderAt); !ok {
+		log.Info("The data cannot be read from the checkpoint of the upload, uploading the data from the beginning")
+		return nil
+	}
+	parentID := ""
+	if parent != nil {
+		parentID = parent.id.String()
+	}
+	if c.Parent != parentID || c.Manifest.ChunkSize != this.chunkSize {
+		log.Infof("The checkpoint of the upload is incremental to %q instead of %q, uploading the data from the beginning", c.Parent, parentID)
+		return nil
+	}
+	if c.Manifest.EncryptionKeyID != encryptionKeyID(ctx) {
+		log.Info("The checkpoint of the upload is encrypted with another key, uploading the data from the beginning")
+		return nil
+	}
+	log.Infof("Resuming the upload from the checkpoint of %s at %d bytes", c.Time.Format(time.RFC3339), c.Manifest.Size)
+	return &c
+}
+
+// checkpointedChunks returns the hashes of the chunks listed by the checkpoints of the uploads of the volume, which
+// are referenced by the snapshots the uploads resume.
+func (this *ProtectedEntityTypeManager) checkpointedChunks(ctx context.Context, id astrolabe.ProtectedEntityID) (map[string]bool, error) {
+	keys, err := this.store.ListObjects(ctx, this.checkpointPrefix+volumeID(id).String()+":")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list the checkpoints of volume %s", volumeID(id).String())
+	}
+	chunks := make(map[string]bool)
+	for _, key := range keys {
+		var c checkpoint
+		err := this.getCompressedJSON(ctx, key, &c)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read checkpoint %s", key)
+		}
+		for _, hash := range c.Manifest.Chunks {
+			chunks[hash] = true
+		}
+	}
+	return chunks, nil
+}
+
+// expireCheckpoints deletes the checkpoints of the uploads that were abandoned, and returns the number of checkpoints
+// deleted.
+func (this *ProtectedEntityTypeManager) expireCheckpoints(ctx context.Context) (int, error) {
+	keys, err := this.store.ListObjects(ctx, this.checkpointPrefix)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to list the checkpoints in the repository")
+	}
+	expired := 0
+	for _, key := range keys {
+		var c checkpoint
+		err := this.getCompressedJSON(ctx, key, &c)
+		if IsNotFound(err) {
+			continue
+		}
+		if err == nil && time.Since(c.Time) < checkpointExpiration {
+			continue
+		}
+		if err := this.store.DeleteObject(ctx, key); err != nil {
+			return expired, errors.Wrapf(err, "Failed to delete checkpoint %s", key)
+		}
+		this.logger.Infof("The abandoned checkpoint %s is deleted", strings.TrimPrefix(key, this.checkpointPrefix))
+		expired++
+	}
+	return expired, nil
+}
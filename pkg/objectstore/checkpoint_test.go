@@ -0,0 +1,93 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// failingStore is a memStore that fails to upload chunks once a number of chunks are uploaded.
+type failingStore struct {
+	*memStore
+	mutex           sync.Mutex
+	remainingChunks int
+}
+
+func (this *failingStore) PutObject(ctx context.Context, key string, body io.Reader) error {
+	if strings.HasPrefix(key, "repo/ivd/chunks/") {
+		this.mutex.Lock()
+		failed := this.remainingChunks == 0
+		if !failed {
+			this.remainingChunks--
+		}
+		this.mutex.Unlock()
+		if failed {
+			return errors.New("connection reset by peer")
+		}
+	}
+	return this.memStore.PutObject(ctx, key, body)
+}
+
+func TestResumeUpload(t *testing.T) {
+	ctx := context.Background()
+	store := &failingStore{memStore: newMemStore(), remainingChunks: 3}
+	petm := newChunkedPETM(store)
+	petm.checkpointInterval = 2
+	id := snapshotID("snap-1")
+
+	source := newMemStore()
+	require.NoError(t, source.PutObject(ctx, "source/ivd/peinfo/"+id.String(),
+		strings.NewReader(`{"id":"`+id.String()+`","name":"pvc-1","size":20}`)))
+	sourcePE, err := NewProtectedEntityTypeManager("ivd", source, "source", petm.logger).GetProtectedEntity(ctx, id)
+	require.NoError(t, err)
+	pe := &changeTrackingProtectedEntity{
+		ProtectedEntity: sourcePE,
+		data:            "aaaabbbbccccddddeeee",
+	}
+	var committed []int64
+	checkpointed := func(committedBytes int64) {
+		committed = append(committed, committedBytes)
+	}
+
+	// The upload of dddd fails after the first two chunks are checkpointed
+	_, err = petm.CopyWithCheckpoints(ctx, pe, astrolabe.AllocateNewObject, checkpointed)
+	require.Error(t, err)
+	assert.Equal(t, []int64{8}, committed)
+
+	// The upload resumes from the checkpoint
+	store.mutex.Lock()
+	store.remainingChunks = -1
+	store.mutex.Unlock()
+	committed = nil
+	_, err = petm.CopyWithCheckpoints(ctx, pe, astrolabe.AllocateNewObject, checkpointed)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{8, 16}, committed)
+	require.NotEmpty(t, pe.read)
+	assert.Equal(t, int64(8), pe.read[0].Offset)
+	assert.Equal(t, "aaaabbbbccccddddeeee", readSnapshot(t, petm, id))
+
+	_, err = petm.readCheckpoint(ctx, id)
+	assert.True(t, IsNotFound(err))
+}
@@ -0,0 +1,573 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/encryption"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// The data of a snapshot is split into fixed size chunks, which suits virtual disks, whose blocks do not move. Each
+// chunk is compressed and kept in the object <prefix>/<type>/chunks/<volume>/<hash>, where hash is the SHA-256 of the
+// uncompressed chunk, or its keyed hash if it is encrypted, so that a chunk shared by snapshots of a volume is only uploaded and kept once. Chunks of zeros
+// are not kept at all. The manifest of the snapshot, <prefix>/<type>/manifest/<peID>, lists the chunks of its data.
+//
+// Chunks are only shared by the snapshots of the same volume, so that the chunks no longer referenced once a snapshot
+// is deleted can be found from the manifests of the volume alone.
+const (
+	DefaultChunkSize = 4 * 1024 * 1024
+
+	compressionZstd = "zstd"
+	manifestVersion = 1
+
+	// An upload is recorded in <prefix>/<type>/pending/<peID> while it is in progress, so that the chunks it reuses
+	// are not garbage collected before its manifest is written. A pending upload that is older than this is
+	// considered abandoned.
+	pendingUploadExpiration = 24 * time.Hour
+)
+
+// manifest lists the chunks of the data of a snapshot.
+type manifest struct {
+	Version     int    `json:"version"`
+	Size        int64  `json:"size"`
+	ChunkSize   int    `json:"chunkSize"`
+	Compression string `json:"compression"`
+	// Chunks are the hashes of the chunks in the order of the data. The hash of a chunk of zeros is empty.
+	Chunks []string `json:"chunks,omitempty"`
+	// Parent is the ID of the snapshot that the snapshot is incremental to, see incremental.go. Only the chunks that
+	// differ from the parent are then listed, in Changes.
+	Parent  string        `json:"parent,omitempty"`
+	Changes []chunkChange `json:"changes,omitempty"`
+	// ChangeID is the ID of the state of the data for changed block tracking, if the ProtectedEntity supports it.
+	ChangeID string `json:"changeID,omitempty"`
+	// Checksum is the checksum of the data, see dataChecksum, and MetadataChecksum the SHA-256 of the metadata, which
+	// are recorded when the snapshot is copied so that it can be verified, see verify.go. They are empty for the
+	// snapshots copied by previous versions.
+	Checksum         string `json:"checksum,omitempty"`
+	MetadataChecksum string `json:"metadataChecksum,omitempty"`
+	// EncryptionKeyID is the ID of the key the chunks and the metadata are encrypted with, see encryption.go. It is
+	// empty if the snapshot is not encrypted.
+	EncryptionKeyID string `json:"encryptionKeyID,omitempty"`
+}
+
+func volumeID(id astrolabe.ProtectedEntityID) astrolabe.ProtectedEntityID {
+	return astrolabe.NewProtectedEntityID(id.GetPeType(), id.GetID())
+}
+
+func (this *ProtectedEntityTypeManager) volumeChunkPrefix(id astrolabe.ProtectedEntityID) string {
+	return this.chunkPrefix + volumeID(id).String() + "/"
+}
+
+func isZero(chunk []byte) bool {
+	for _, b := range chunk {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// listChunks returns the hashes of the chunks of the volume in the repository.
+func (this *ProtectedEntityTypeManager) listChunks(ctx context.Context, id astrolabe.ProtectedEntityID) (map[string]bool, error) {
+	prefix := this.volumeChunkPrefix(id)
+	keys, err := this.store.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list the chunks of volume %s", volumeID(id).String())
+	}
+	chunks := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		chunks[strings.TrimPrefix(key, prefix)] = true
+	}
+	return chunks, nil
+}
+
+// putChunks splits the data into chunks, uploads the chunks that are not in the repository yet and returns the
+// manifest of the data. If a parent is given, the manifest only lists the chunks that differ from the parent, and the
+// chunks known to be unchanged are not read. size is the size of the data, which is only needed to skip chunks. The
+// upload is checkpointed as it goes, see checkpoint.go, and resumed from the given checkpoint if it is not nil.
+// checkpointed, if not nil, is called when the upload is checkpointed or resumed.
+func (this *ProtectedEntityTypeManager) putChunks(ctx context.Context, id astrolabe.ProtectedEntityID, data io.Reader, size int64, parent *parentSnapshot, resume *checkpoint, checkpointed CheckpointFunc) (manifest, error) {
+	log := this.logger.WithField("peID", id.String())
+	m := manifest{
+		Version:     manifestVersion,
+		ChunkSize:   this.chunkSize,
+		Compression: compressionZstd,
+	}
+	chunkCipher, err := copyCipher(ctx)
+	if err != nil {
+		return m, err
+	}
+	if chunkCipher != nil {
+		m.EncryptionKeyID = chunkCipher.KeyID()
+	}
+
+	existingChunks, err := this.listChunks(ctx, id)
+	if err != nil {
+		return m, err
+	}
+
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return m, err
+	}
+	defer encoder.Close()
+	uploader := this.newChunkUploader(ctx, id, encoder, chunkCipher)
+	defer uploader.flush()
+
+	source := newChunkSource(data, (parent != nil && parent.changedAreas != nil) || resume != nil)
+	chunk := make([]byte, this.chunkSize)
+	var chunks []string
+	var uploaded, reused, zeros, unchanged int
+	index := 0
+	if resume != nil {
+		if err := source.skip(resume.Manifest.Size); err != nil {
+			return m, errors.Wrapf(err, "Failed to read the data of ProtectedEntity %s", id.String())
+		}
+		m.Size = resume.Manifest.Size
+		chunks = append(chunks, resume.Manifest.Chunks...)
+		index = len(chunks)
+		if checkpointed != nil {
+			checkpointed(m.Size)
+		}
+	}
+	parentID := ""
+	if parent != nil {
+		parentID = parent.id.String()
+	}
+	for ; ; index++ {
+		if index > 0 && index%this.checkpointInterval == 0 && (resume == nil || index > len(resume.Manifest.Chunks)) {
+			// All the chunks read so far are full, and are uploaded once the uploads in progress are done.
+			if err := uploader.flush(); err != nil {
+				return m, err
+			}
+			c := checkpoint{
+				Time:   time.Now().UTC(),
+				Parent: parentID,
+				Manifest: manifest{
+					Version:         manifestVersion,
+					Size:            m.Size,
+					ChunkSize:       this.chunkSize,
+					Compression:     compressionZstd,
+					Chunks:          chunks,
+					EncryptionKeyID: m.EncryptionKeyID,
+				},
+			}
+			if err := this.putCheckpoint(ctx, id, c); err != nil {
+				log.WithError(err).Warn("Failed to checkpoint the upload")
+			} else if checkpointed != nil {
+				checkpointed(m.Size)
+			}
+			// The record of the upload is renewed so that a long upload is not taken for an abandoned one.
+			if err := this.putPendingUpload(ctx, id); err != nil {
+				log.WithError(err).Warn("Failed to renew the record of the upload")
+			}
+		}
+
+		length := int64(this.chunkSize)
+		if remaining := size - source.offset; remaining < length {
+			length = remaining
+		}
+		if length > 0 && parent.unchanged(index, source.offset, length) {
+			if err := source.skip(length); err != nil {
+				return m, errors.Wrapf(err, "Failed to read the data of ProtectedEntity %s", id.String())
+			}
+			m.Size += length
+			chunks = append(chunks, parent.chunks[index])
+			unchanged++
+			continue
+		}
+
+		n, err := source.read(chunk)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return m, errors.Wrapf(err, "Failed to read the data of ProtectedEntity %s", id.String())
+		}
+		m.Size += int64(n)
+
+		hash := ""
+		if !isZero(chunk[:n]) {
+			hash = chunkHash(chunkCipher, chunk[:n])
+		}
+		switch {
+		case parent != nil && index < len(parent.chunks) && parent.chunks[index] == hash:
+			unchanged++
+		case hash == "":
+			zeros++
+		case existingChunks[hash]:
+			reused++
+		default:
+			if err := uploader.upload(hash, chunk[:n]); err != nil {
+				return m, err
+			}
+			existingChunks[hash] = true
+			uploaded++
+		}
+		chunks = append(chunks, hash)
+
+		if n < this.chunkSize {
+			break
+		}
+	}
+	if err := uploader.flush(); err != nil {
+		return m, err
+	}
+
+	m.Checksum = dataChecksum(m.Size, chunks)
+	if parent == nil {
+		m.Chunks = chunks
+		log.Infof("%d chunks are uploaded, %d chunks are already in the repository, %d chunks are zeros", uploaded, reused, zeros)
+		return m, nil
+	}
+	m.Parent = parent.id.String()
+	for index, hash := range chunks {
+		if index >= len(parent.chunks) || parent.chunks[index] != hash {
+			m.Changes = append(m.Changes, chunkChange{Index: index, Hash: hash})
+		}
+	}
+	log.Infof("%d chunks are unchanged since %s, %d chunks are uploaded, %d chunks are already in the repository, %d chunks are zeros",
+		unchanged, m.Parent, uploaded, reused, zeros)
+	return m, nil
+}
+
+func (this *ProtectedEntityTypeManager) putManifest(ctx context.Context, id astrolabe.ProtectedEntityID, m manifest) error {
+	return this.putCompressedJSON(ctx, this.manifestPrefix+id.String(), m)
+}
+
+func (this *ProtectedEntityTypeManager) readManifest(ctx context.Context, id astrolabe.ProtectedEntityID) (manifest, error) {
+	var m manifest
+	if err := this.getCompressedJSON(ctx, this.manifestPrefix+id.String(), &m); err != nil {
+		return m, err
+	}
+	if m.Version != manifestVersion || m.Compression != compressionZstd || m.ChunkSize <= 0 {
+		return m, errors.Errorf("Unsupported manifest of ProtectedEntity %s, version %d, compression %s", id.String(), m.Version, m.Compression)
+	}
+	return m, nil
+}
+
+// putCompressedJSON uploads the value as compressed JSON, as manifests are kept.
+func (this *ProtectedEntityTypeManager) putCompressedJSON(ctx context.Context, key string, v interface{}) error {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to marshal object %s", key)
+	}
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return err
+	}
+	defer encoder.Close()
+	return this.store.PutObject(ctx, key, bytes.NewReader(encoder.EncodeAll(jsonBytes, nil)))
+}
+
+// getCompressedJSON reads the value uploaded by putCompressedJSON. The error is returned as is if the object is not
+// found.
+func (this *ProtectedEntityTypeManager) getCompressedJSON(ctx context.Context, key string, v interface{}) error {
+	reader, err := this.store.GetObject(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	compressed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read object %s", key)
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return err
+	}
+	defer decoder.Close()
+	jsonBytes, err := decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to decompress object %s", key)
+	}
+	if err = json.Unmarshal(jsonBytes, v); err != nil {
+		return errors.Wrapf(err, "Failed to unmarshal object %s", key)
+	}
+	return nil
+}
+
+// chunkReader reads the data of a snapshot from its chunks. The chunks are downloaded ahead of the reads, with a number
+// of concurrent streams.
+type chunkReader struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	petm     *ProtectedEntityTypeManager
+	id       astrolabe.ProtectedEntityID
+	manifest manifest
+	decoder  *zstd.Decoder
+	// cipher decrypts the chunks, it is nil if they are not encrypted
+	cipher  *encryption.ChunkCipher
+	streams int
+	// downloads are the chunks being downloaded, in the order of the data, from chunk next on.
+	downloads []*chunkDownload
+	next      int
+	chunk     []byte
+}
+
+// newChunkReader returns a reader of the data of the snapshot with the given manifest. It fails with an error wrapping
+// encryption.ErrKeyNotFound if the data is encrypted with a key that is not available.
+func (this *ProtectedEntityTypeManager) newChunkReader(ctx context.Context, id astrolabe.ProtectedEntityID, m manifest) (*chunkReader, error) {
+	chunkCipher, err := readCipher(ctx, m.EncryptionKeyID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to decrypt the data of ProtectedEntity %s", id.String())
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &chunkReader{
+		ctx:      ctx,
+		cancel:   cancel,
+		petm:     this,
+		id:       id,
+		manifest: m,
+		decoder:  decoder,
+		cipher:   chunkCipher,
+		streams:  transferStreams(ctx),
+	}, nil
+}
+
+func (this *chunkReader) Read(p []byte) (int, error) {
+	for len(this.chunk) == 0 {
+		if this.next == len(this.manifest.Chunks) {
+			return 0, io.EOF
+		}
+		if err := this.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, this.chunk)
+	this.chunk = this.chunk[n:]
+	return n, nil
+}
+
+func (this *chunkReader) readChunk() error {
+	for len(this.downloads) < this.streams && this.next+len(this.downloads) < len(this.manifest.Chunks) {
+		this.downloads = append(this.downloads, this.download(this.next+len(this.downloads)))
+	}
+	download := this.downloads[0]
+	<-download.done
+	if download.err != nil {
+		return download.err
+	}
+	this.downloads = this.downloads[1:]
+	this.chunk = download.chunk
+	this.next++
+	return nil
+}
+
+// download starts downloading the chunk with the given index.
+func (this *chunkReader) download(index int) *chunkDownload {
+	download := &chunkDownload{done: make(chan struct{})}
+	go func() {
+		defer close(download.done)
+		download.chunk, download.err = this.getChunk(index)
+	}()
+	return download
+}
+
+func (this *chunkReader) getChunk(index int) ([]byte, error) {
+	hash := this.manifest.Chunks[index]
+	size := int64(this.manifest.ChunkSize)
+	if remaining := this.manifest.Size - int64(index)*size; remaining < size {
+		size = remaining
+	}
+	if hash == "" {
+		return make([]byte, size), nil
+	}
+
+	reader, err := this.petm.store.GetObject(this.ctx, this.petm.volumeChunkPrefix(this.id)+hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get chunk %s of ProtectedEntity %s", hash, this.id.String())
+	}
+	compressed, err := ioutil.ReadAll(limitReader(this.ctx, reader))
+	reader.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read chunk %s of ProtectedEntity %s", hash, this.id.String())
+	}
+	if this.cipher != nil {
+		if compressed, err = this.cipher.Open(hash, compressed); err != nil {
+			return nil, errors.Wrapf(err, "Failed to decrypt chunk %s of ProtectedEntity %s", hash, this.id.String())
+		}
+	}
+	chunk, err := this.decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to decompress chunk %s of ProtectedEntity %s", hash, this.id.String())
+	}
+	if chunkHash(this.cipher, chunk) != hash || int64(len(chunk)) != size {
+		return nil, errors.Errorf("Chunk %s of ProtectedEntity %s is corrupted", hash, this.id.String())
+	}
+	return chunk, nil
+}
+
+// Close stops the downloads in progress.
+func (this *chunkReader) Close() error {
+	this.cancel()
+	for _, download := range this.downloads {
+		<-download.done
+	}
+	this.downloads = nil
+	this.decoder.Close()
+	return nil
+}
+
+func (this *ProtectedEntityTypeManager) putPendingUpload(ctx context.Context, id astrolabe.ProtectedEntityID) error {
+	return this.store.PutObject(ctx, this.pendingPrefix+id.String(), strings.NewReader(time.Now().UTC().Format(time.RFC3339)))
+}
+
+// hasPendingUploads returns true if a snapshot of the volume is being uploaded.
+func (this *ProtectedEntityTypeManager) hasPendingUploads(ctx context.Context, id astrolabe.ProtectedEntityID) (bool, error) {
+	keys, err := this.store.ListObjects(ctx, this.pendingPrefix+volumeID(id).String()+":")
+	if err != nil {
+		return false, errors.Wrapf(err, "Failed to list the pending uploads of volume %s", volumeID(id).String())
+	}
+	for _, key := range keys {
+		reader, err := this.store.GetObject(ctx, key)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		content, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return false, err
+		}
+		startTime, err := time.Parse(time.RFC3339, string(content))
+		if err != nil || time.Since(startTime) < pendingUploadExpiration {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CollectGarbage deletes the chunks of the volume of the snapshot that are not referenced by the manifest of any
+// snapshot of the volume or by the checkpoint of any upload, and returns the number of chunks deleted. Nothing is
+// deleted while a snapshot of the volume is being uploaded, as the upload may reuse chunks that are not referenced
+// yet. The garbage is then collected when the next snapshot of the volume is deleted. An upload that starts while the
+// chunks are being deleted may still reuse some of them, which checkChunks detects once its manifest is written.
+func (this *ProtectedEntityTypeManager) CollectGarbage(ctx context.Context, id astrolabe.ProtectedEntityID) (int, error) {
+	log := this.logger.WithField("volume", volumeID(id).String())
+	pending, err := this.hasPendingUploads(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if pending {
+		log.Info("Skipping garbage collection while a snapshot of the volume is being uploaded")
+		return 0, nil
+	}
+
+	manifestPrefix := this.manifestPrefix + volumeID(id).String() + ":"
+	manifestKeys, err := this.store.ListObjects(ctx, manifestPrefix)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Failed to list the manifests of volume %s", volumeID(id).String())
+	}
+	referenced := make(map[string]bool)
+	for _, key := range manifestKeys {
+		snapshotID, err := astrolabe.NewProtectedEntityIDFromString(strings.TrimPrefix(key, this.manifestPrefix))
+		if err != nil {
+			log.WithError(err).Warnf("Skipping object %s that is not named after a ProtectedEntity", key)
+			continue
+		}
+		m, err := this.readManifest(ctx, snapshotID)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		for _, hash := range m.Chunks {
+			referenced[hash] = true
+		}
+		for _, change := range m.Changes {
+			referenced[change.Hash] = true
+		}
+	}
+
+	// The chunks of an interrupted upload are referenced by the snapshot once the upload resumes.
+	checkpointed, err := this.checkpointedChunks(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	for hash := range checkpointed {
+		referenced[hash] = true
+	}
+
+	chunks, err := this.listChunks(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	var garbage []string
+	for hash := range chunks {
+		if !referenced[hash] {
+			garbage = append(garbage, hash)
+		}
+	}
+	if len(garbage) == 0 {
+		return 0, nil
+	}
+
+	// An upload may have started since the manifests were read.
+	pending, err = this.hasPendingUploads(ctx, id)
+	if err != nil || pending {
+		log.Info("Skipping garbage collection while a snapshot of the volume is being uploaded")
+		return 0, err
+	}
+	for i, hash := range garbage {
+		if err := this.store.DeleteObject(ctx, this.volumeChunkPrefix(id)+hash); err != nil {
+			return i, errors.Wrapf(err, "Failed to delete chunk %s of volume %s", hash, volumeID(id).String())
+		}
+	}
+	log.Infof("%d chunks that are no longer referenced are deleted", len(garbage))
+	return len(garbage), nil
+}
+
+// checkChunks fails if chunks of the snapshot are not in the repository, which happens if its upload reused chunks
+// that the garbage collection of the volume deleted before the manifest was written, see CollectGarbage.
+func (this *ProtectedEntityTypeManager) checkChunks(ctx context.Context, id astrolabe.ProtectedEntityID) error {
+	m, err := this.resolveManifest(ctx, id)
+	if err != nil {
+		return err
+	}
+	chunks, err := this.listChunks(ctx, id)
+	if err != nil {
+		return err
+	}
+	var missing int
+	for _, hash := range m.Chunks {
+		if hash != "" && !chunks[hash] {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return errors.Errorf("%d chunks of ProtectedEntity %s were garbage collected while it was uploaded", missing, id.String())
+	}
+	return nil
+}
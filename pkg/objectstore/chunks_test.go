@@ -0,0 +1,201 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// copySnapshot copies a snapshot with the given data to the repository, from a source repository in memory.
+func copySnapshot(t *testing.T, petm *ProtectedEntityTypeManager, id astrolabe.ProtectedEntityID, data string) {
+	require.NoError(t, tryCopySnapshot(t, petm, id, data))
+}
+
+// tryCopySnapshot is copySnapshot, which returns the error of the copy.
+func tryCopySnapshot(t *testing.T, petm *ProtectedEntityTypeManager, id astrolabe.ProtectedEntityID, data string) error {
+	ctx := context.Background()
+	source := newMemStore()
+	require.NoError(t, source.PutObject(ctx, "source/ivd/peinfo/"+id.String(),
+		strings.NewReader(`{"id":"`+id.String()+`","name":"pvc-1","size":`+strconv.Itoa(len(data))+`}`)))
+	require.NoError(t, source.PutObject(ctx, "source/ivd/data/"+id.String(), strings.NewReader(data)))
+	sourcePE, err := NewProtectedEntityTypeManager("ivd", source, "source", petm.logger).GetProtectedEntity(ctx, id)
+	require.NoError(t, err)
+	_, err = petm.Copy(ctx, sourcePE, astrolabe.AllocateNewObject)
+	return err
+}
+
+func readSnapshot(t *testing.T, petm *ProtectedEntityTypeManager, id astrolabe.ProtectedEntityID) string {
+	ctx := context.Background()
+	pe, err := petm.GetProtectedEntity(ctx, id)
+	require.NoError(t, err)
+	reader, err := pe.GetDataReader(ctx)
+	require.NoError(t, err)
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func newChunkedPETM(store ObjectStore) *ProtectedEntityTypeManager {
+	petm := NewProtectedEntityTypeManager("ivd", store, "repo", veleroplugintest.NewLogger())
+	petm.chunkSize = 4
+	return petm
+}
+
+func TestChunkDeduplication(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	petm := newChunkedPETM(store)
+	first := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+	second := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-2"))
+
+	// Chunks aaaa, bbbb, zeros, aaaa and cc
+	firstData := "aaaabbbb\x00\x00\x00\x00aaaacc"
+	copySnapshot(t, petm, first, firstData)
+	chunks, err := petm.listChunks(ctx, first)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 3)
+	assert.Equal(t, firstData, readSnapshot(t, petm, first))
+
+	// Only dddd is uploaded
+	secondData := "aaaabbbbdddd\x00\x00"
+	copySnapshot(t, petm, second, secondData)
+	chunks, err = petm.listChunks(ctx, second)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 4)
+	assert.Equal(t, secondData, readSnapshot(t, petm, second))
+
+	// cc is only referenced by the first snapshot, which the second one is incremental to
+	pe, err := petm.GetProtectedEntity(ctx, first)
+	require.NoError(t, err)
+	_, err = pe.DeleteSnapshot(ctx, first.GetSnapshotID())
+	require.NoError(t, err)
+	chunks, err = petm.listChunks(ctx, second)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 4)
+	assert.Equal(t, secondData, readSnapshot(t, petm, second))
+	require.NoError(t, petm.consolidate(ctx, second))
+	require.NoError(t, petm.Maintain(ctx, DefaultMaxChainDepth))
+	chunks, err = petm.listChunks(ctx, second)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 3)
+	assert.Equal(t, secondData, readSnapshot(t, petm, second))
+
+	_, err = pe.DeleteSnapshot(ctx, second.GetSnapshotID())
+	require.NoError(t, err)
+	keys, err := store.ListObjects(ctx, "repo/")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestGarbageCollectionWithPendingUpload(t *testing.T) {
+	ctx := context.Background()
+	petm := newChunkedPETM(newMemStore())
+	first := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+	second := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-2"))
+	copySnapshot(t, petm, first, "aaaabbbb")
+
+	// The second snapshot may reuse the chunks of the first one while it is uploaded
+	require.NoError(t, petm.putPendingUpload(ctx, second))
+	pe, err := petm.GetProtectedEntity(ctx, first)
+	require.NoError(t, err)
+	_, err = pe.DeleteSnapshot(ctx, first.GetSnapshotID())
+	require.NoError(t, err)
+	chunks, err := petm.listChunks(ctx, first)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 2)
+
+	require.NoError(t, petm.store.DeleteObject(ctx, petm.pendingPrefix+second.String()))
+	deleted, err := petm.CollectGarbage(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+}
+
+// collectingStore is a memStore that deletes the chunks once they are listed, as the garbage collection of the volume
+// does if the upload that lists them starts while it is deleting them.
+type collectingStore struct {
+	*memStore
+	collect bool
+}
+
+func (this *collectingStore) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := this.memStore.ListObjects(ctx, prefix)
+	if err == nil && this.collect && strings.HasPrefix(prefix, "repo/ivd/chunks/") {
+		this.collect = false
+		for _, key := range keys {
+			this.memStore.DeleteObject(ctx, key)
+		}
+	}
+	return keys, err
+}
+
+func TestUploadReusingCollectedChunks(t *testing.T) {
+	ctx := context.Background()
+	store := &collectingStore{memStore: newMemStore()}
+	petm := newChunkedPETM(store)
+	first := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+	second := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-2"))
+	copySnapshot(t, petm, first, "aaaabbbb")
+
+	// The chunks of the purged snapshot are garbage
+	require.NoError(t, store.DeleteObject(ctx, petm.manifestPrefix+first.String()))
+	require.NoError(t, store.DeleteObject(ctx, petm.latestPrefix+volumeID(first).String()))
+
+	store.collect = true
+	err := tryCopySnapshot(t, petm, second, "aaaacccc")
+	assert.Error(t, err)
+	_, err = petm.readManifest(ctx, second)
+	assert.True(t, IsNotFound(err))
+
+	copySnapshot(t, petm, second, "aaaacccc")
+	assert.Equal(t, "aaaacccc", readSnapshot(t, petm, second))
+}
+
+func TestCorruptedChunk(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	petm := newChunkedPETM(store)
+	id := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+	copySnapshot(t, petm, id, "aaaa")
+
+	// Replace the chunk with another chunk that decompresses fine
+	keys, err := store.ListObjects(ctx, petm.volumeChunkPrefix(id))
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer encoder.Close()
+	require.NoError(t, store.PutObject(ctx, keys[0], bytes.NewReader(encoder.EncodeAll([]byte("bbbb"), nil))))
+
+	pe, err := petm.GetProtectedEntity(ctx, id)
+	require.NoError(t, err)
+	reader, err := pe.GetDataReader(ctx)
+	require.NoError(t, err)
+	defer reader.Close()
+	_, err = ioutil.ReadAll(reader)
+	assert.Error(t, err)
+}
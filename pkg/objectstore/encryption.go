@@ -0,0 +1,149 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/encryption"
+	"io"
+)
+
+// The chunks of the data of a snapshot are encrypted one by one, see encryption.ChunkCipher, once they are hashed and
+// compressed, so that encrypted snapshots are deduplicated, copied incrementally and resumed like the others. The ID
+// of the key is recorded in the manifest, and a snapshot is only copied incrementally to, or resumed from, chunks
+// encrypted with the same key. The metadata is encrypted as a whole. The key a copy encrypts with is set in the
+// context of the copy with WithEncryptionKey, and the keys the reads decrypt with with WithKeyProvider, as the
+// ProtectedEntityTypeManager interface has no room for them.
+
+type encryptionKeyKey struct{}
+
+type keyProviderKey struct{}
+
+// encryptionKey is the key the snapshots copied to a repository are encrypted with.
+type encryptionKey struct {
+	id  string
+	key []byte
+}
+
+// WithEncryptionKey returns a context in which the snapshots copied to a repository are encrypted with the key with
+// the given ID. They are not encrypted if the key ID is empty.
+func WithEncryptionKey(ctx context.Context, keyID string, key []byte) context.Context {
+	if keyID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, encryptionKeyKey{}, encryptionKey{id: keyID, key: key})
+}
+
+// WithKeyProvider returns a context in which the encrypted snapshots read from a repository are decrypted with the
+// keys of the key provider.
+func WithKeyProvider(ctx context.Context, keyProvider encryption.KeyProvider) context.Context {
+	if keyProvider == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, keyProviderKey{}, keyProvider)
+}
+
+// encryptionKeyID returns the ID of the key the snapshots copied in the context are encrypted with, or "".
+func encryptionKeyID(ctx context.Context) string {
+	key, _ := ctx.Value(encryptionKeyKey{}).(encryptionKey)
+	return key.id
+}
+
+// copyCipher returns the cipher the chunks of the snapshots copied in the context are encrypted with, or nil if they
+// are not encrypted.
+func copyCipher(ctx context.Context) (*encryption.ChunkCipher, error) {
+	key, ok := ctx.Value(encryptionKeyKey{}).(encryptionKey)
+	if !ok {
+		return nil, nil
+	}
+	return encryption.NewChunkCipher(key.id, key.key)
+}
+
+// getKey returns the key with the given ID from the key provider of the context. It fails with an error wrapping
+// encryption.ErrKeyNotFound if the key is not available.
+func getKey(ctx context.Context, keyID string) ([]byte, error) {
+	keyProvider, ok := ctx.Value(keyProviderKey{}).(encryption.KeyProvider)
+	if !ok {
+		return nil, errors.Wrapf(encryption.ErrKeyNotFound, "data is encrypted with key %s, but no encryption key provider is configured", keyID)
+	}
+	key, err := keyProvider.GetKey(keyID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get key %s the data is encrypted with", keyID)
+	}
+	return key, nil
+}
+
+// readCipher returns the cipher the chunks encrypted with the key with the given ID are decrypted with, or nil if the
+// key ID is empty.
+func readCipher(ctx context.Context, keyID string) (*encryption.ChunkCipher, error) {
+	if keyID == "" {
+		return nil, nil
+	}
+	key, err := getKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	return encryption.NewChunkCipher(keyID, key)
+}
+
+// chunkHash returns the hash a chunk is named by, its SHA-256 if it is not encrypted.
+func chunkHash(chunkCipher *encryption.ChunkCipher, chunk []byte) string {
+	if chunkCipher != nil {
+		return chunkCipher.Hash(chunk)
+	}
+	sum := sha256.Sum256(chunk)
+	return hex.EncodeToString(sum[:])
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// encryptMetadata returns a reader of the metadata encrypted with the key of the copy in the context, if any.
+func encryptMetadata(ctx context.Context, reader io.ReadCloser) (io.ReadCloser, error) {
+	key, ok := ctx.Value(encryptionKeyKey{}).(encryptionKey)
+	if !ok {
+		return reader, nil
+	}
+	encryptingReader, err := encryption.NewEncryptingReader(reader, key.id, key.key)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return readCloser{Reader: encryptingReader, Closer: reader}, nil
+}
+
+// decryptMetadata returns a reader of the metadata decrypted with the key provider of the context, if it is
+// encrypted.
+func decryptMetadata(ctx context.Context, reader io.ReadCloser) (io.ReadCloser, error) {
+	bufferedReader := bufio.NewReader(reader)
+	if !encryption.IsEncrypted(bufferedReader) {
+		return readCloser{Reader: bufferedReader, Closer: reader}, nil
+	}
+	keyProvider, _ := ctx.Value(keyProviderKey{}).(encryption.KeyProvider)
+	decryptingReader, err := encryption.NewDecryptingReader(bufferedReader, keyProvider)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return readCloser{Reader: decryptingReader, Closer: reader}, nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/encryption"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// mapKeyProvider is an encryption.KeyProvider with the keys in a map.
+type mapKeyProvider map[string][]byte
+
+func (this mapKeyProvider) CurrentKeyID() (string, error) {
+	return "key-1", nil
+}
+
+func (this mapKeyProvider) GetKey(keyID string) ([]byte, error) {
+	key, ok := this[keyID]
+	if !ok {
+		return nil, errors.Wrapf(encryption.ErrKeyNotFound, "key %s", keyID)
+	}
+	return key, nil
+}
+
+func newKey(t *testing.T) []byte {
+	key := make([]byte, encryption.KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+// copyEncryptedSnapshot copies a snapshot with the given data and metadata to the repository, encrypted with the key
+// with the given ID.
+func copyEncryptedSnapshot(t *testing.T, petm *ProtectedEntityTypeManager, keys mapKeyProvider, keyID string, id astrolabe.ProtectedEntityID, data string) {
+	ctx := context.Background()
+	source := newMemStore()
+	require.NoError(t, source.PutObject(ctx, "source/ivd/peinfo/"+id.String(),
+		strings.NewReader(`{"id":"`+id.String()+`","name":"pvc-1"}`)))
+	require.NoError(t, source.PutObject(ctx, "source/ivd/md/"+id.String(), strings.NewReader("metadata")))
+	sourcePE, err := NewProtectedEntityTypeManager("ivd", source, "source", petm.logger).GetProtectedEntity(ctx, id)
+	require.NoError(t, err)
+	pe := &changeTrackingProtectedEntity{
+		ProtectedEntity: sourcePE,
+		data:            data,
+	}
+	_, err = petm.Copy(WithEncryptionKey(ctx, keyID, keys[keyID]), pe, astrolabe.AllocateNewObject)
+	require.NoError(t, err)
+}
+
+func readEncryptedSnapshot(t *testing.T, petm *ProtectedEntityTypeManager, keys mapKeyProvider, id astrolabe.ProtectedEntityID) (string, string) {
+	ctx := WithKeyProvider(context.Background(), keys)
+	pe, err := petm.GetProtectedEntity(ctx, id)
+	require.NoError(t, err)
+	reader, err := pe.GetDataReader(ctx)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	reader, err = pe.GetMetadataReader(ctx)
+	require.NoError(t, err)
+	if reader == nil {
+		return string(data), ""
+	}
+	metadata, err := ioutil.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	return string(data), string(metadata)
+}
+
+func TestEncryptedCopy(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	petm := newChunkedPETM(store)
+	keys := mapKeyProvider{"key-1": newKey(t), "key-2": newKey(t)}
+	first, second, third := snapshotID("snap-1"), snapshotID("snap-2"), snapshotID("snap-3")
+
+	copyEncryptedSnapshot(t, petm, keys, "key-1", first, "aaaabbbbaaaa")
+	m, err := petm.readManifest(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", m.EncryptionKeyID)
+	data, metadata := readEncryptedSnapshot(t, petm, keys, first)
+	assert.Equal(t, "aaaabbbbaaaa", data)
+	assert.Equal(t, "metadata", metadata)
+
+	// The chunks are deduplicated, and nothing is kept in the clear
+	chunks, err := petm.listChunks(ctx, first)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 2)
+	for key, content := range store.objects {
+		assert.False(t, bytes.Contains(content, []byte("aaaa")) || bytes.Contains(content, []byte("metadata")), key)
+	}
+
+	// The next snapshot is copied incrementally with the same key
+	copyEncryptedSnapshot(t, petm, keys, "key-1", second, "aaaacccc")
+	m, err = petm.readManifest(ctx, second)
+	require.NoError(t, err)
+	assert.Equal(t, first.String(), m.Parent)
+	assert.Equal(t, []chunkChange{{Index: 1, Hash: m.Changes[0].Hash}}, m.Changes)
+	data, _ = readEncryptedSnapshot(t, petm, keys, second)
+	assert.Equal(t, "aaaacccc", data)
+
+	// and in full with another key
+	copyEncryptedSnapshot(t, petm, keys, "key-2", third, "aaaacccc")
+	m, err = petm.readManifest(ctx, third)
+	require.NoError(t, err)
+	assert.Equal(t, "", m.Parent)
+	assert.Equal(t, "key-2", m.EncryptionKeyID)
+	data, _ = readEncryptedSnapshot(t, petm, keys, third)
+	assert.Equal(t, "aaaacccc", data)
+	chunks, err = petm.listChunks(ctx, first)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 5)
+
+	// The snapshots are verified with the keys
+	verification := petm.VerifySnapshot(WithKeyProvider(ctx, keys), first)
+	assert.NoError(t, verification.Err)
+
+	// The data is not read without the key
+	pe, err := petm.GetProtectedEntity(ctx, first)
+	require.NoError(t, err)
+	_, err = pe.GetDataReader(ctx)
+	assert.True(t, errors.Is(err, encryption.ErrKeyNotFound))
+	_, err = pe.GetDataReader(WithKeyProvider(ctx, mapKeyProvider{"key-2": keys["key-2"]}))
+	assert.True(t, errors.Is(err, encryption.ErrKeyNotFound))
+	keyID, err := pe.(*ProtectedEntity).GetEncryptionKeyID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", keyID)
+}
+
+func TestResumeEncryptedUpload(t *testing.T) {
+	ctx := context.Background()
+	store := &failingStore{memStore: newMemStore(), remainingChunks: 3}
+	petm := newChunkedPETM(store)
+	petm.checkpointInterval = 2
+	keys := mapKeyProvider{"key-1": newKey(t)}
+	id := snapshotID("snap-1")
+
+	source := newMemStore()
+	require.NoError(t, source.PutObject(ctx, "source/ivd/peinfo/"+id.String(),
+		strings.NewReader(`{"id":"`+id.String()+`","name":"pvc-1"}`)))
+	sourcePE, err := NewProtectedEntityTypeManager("ivd", source, "source", petm.logger).GetProtectedEntity(ctx, id)
+	require.NoError(t, err)
+	pe := &changeTrackingProtectedEntity{
+		ProtectedEntity: sourcePE,
+		data:            "aaaabbbbccccddddeeee",
+	}
+	ctx = WithEncryptionKey(ctx, "key-1", keys["key-1"])
+	_, err = petm.CopyWithCheckpoints(ctx, pe, astrolabe.AllocateNewObject, nil)
+	require.Error(t, err)
+
+	store.mutex.Lock()
+	store.remainingChunks = -1
+	store.mutex.Unlock()
+	_, err = petm.CopyWithCheckpoints(ctx, pe, astrolabe.AllocateNewObject, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, pe.read)
+	assert.Equal(t, int64(8), pe.read[0].Offset)
+	data, _ := readEncryptedSnapshot(t, petm, keys, id)
+	assert.Equal(t, "aaaabbbbccccddddeeee", data)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Prefix of the temporary files objects are written to before they are renamed to their key.
+const fileSystemTempPrefix = ".tmp-"
+
+// FileSystemStore is an ObjectStore in a directory, e.g. the mount point of an NFS share. An object is a file whose
+// path relative to the directory is the key of the object.
+type FileSystemStore struct {
+	root string
+}
+
+func NewFileSystemStore(root string) *FileSystemStore {
+	return &FileSystemStore{
+		root: root,
+	}
+}
+
+func (this *FileSystemStore) objectPath(key string) string {
+	return filepath.Join(this.root, filepath.FromSlash(key))
+}
+
+// PutObject writes the object to a temporary file that is renamed once it is complete, so that a partially written
+// object is never read.
+func (this *FileSystemStore) PutObject(ctx context.Context, key string, body io.Reader) error {
+	objectPath := this.objectPath(key)
+	dir := filepath.Dir(objectPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(dir, fileSystemTempPrefix+filepath.Base(objectPath)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, body)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), objectPath)
+}
+
+func (this *FileSystemStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := os.Open(this.objectPath(key))
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (this *FileSystemStore) DeleteObject(ctx context.Context, key string) error {
+	err := os.Remove(this.objectPath(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (this *FileSystemStore) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	// Only the directory the prefix is in needs to be walked.
+	dir := prefix
+	if !strings.HasSuffix(prefix, "/") {
+		dir = path.Dir(prefix)
+	}
+
+	var keys []string
+	err := filepath.Walk(this.objectPath(dir), func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() || strings.HasPrefix(info.Name(), fileSystemTempPrefix) {
+			return nil
+		}
+		relPath, err := filepath.Rel(this.root, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relPath)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFileSystemStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "fsrepository")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	testRepository(t, NewFileSystemStore(root))
+}
+
+func TestFileSystemStoreListObjects(t *testing.T) {
+	root, err := ioutil.TempDir("", "fsrepository")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	ctx := context.Background()
+	store := NewFileSystemStore(root)
+	for _, key := range []string{"repo/ivd/peinfo/ivd:a:1", "repo/ivd/peinfo/ivd:b:1", "repo/ivd/data/ivd:a:1"} {
+		require.NoError(t, store.PutObject(ctx, key, strings.NewReader(key)))
+	}
+
+	keys, err := store.ListObjects(ctx, "repo/ivd/peinfo/ivd:a:")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"repo/ivd/peinfo/ivd:a:1"}, keys)
+
+	keys, err = store.ListObjects(ctx, "missing/")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = store.GetObject(ctx, "repo/ivd/md/ivd:a:1")
+	assert.True(t, IsNotFound(err))
+	assert.NoError(t, store.DeleteObject(ctx, "repo/ivd/md/ivd:a:1"))
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"cloud.google.com/go/storage"
+	"context"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"io"
+)
+
+// GCSStore is an ObjectStore in a Google Cloud Storage bucket.
+type GCSStore struct {
+	bucket *storage.BucketHandle
+}
+
+// NewGCSStore creates a GCSStore for the bucket. The credentials are the JSON key of a service account, and the
+// application default credentials are used if it is empty. The endpoint defaults to the public GCS endpoint. It may be
+// set to an emulator, e.g. http://127.0.0.1:4443/storage/v1/ for fake-gcs-server, in which case no credentials are sent.
+func NewGCSStore(ctx context.Context, bucket string, credentialsJSON string, endpoint string) (*GCSStore, error) {
+	var opts []option.ClientOption
+	if endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint), option.WithoutAuthentication())
+	} else if credentialsJSON != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(credentialsJSON)))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create GCS client for bucket %s", bucket)
+	}
+	return NewGCSStoreFromClient(client, bucket), nil
+}
+
+func NewGCSStoreFromClient(client *storage.Client, bucket string) *GCSStore {
+	return &GCSStore{
+		bucket: client.Bucket(bucket),
+	}
+}
+
+func (this *GCSStore) PutObject(ctx context.Context, key string, body io.Reader) error {
+	// Canceling the context of the writer aborts the upload, so that no partial object is left behind.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	writer := this.bucket.Object(key).NewWriter(ctx)
+	if _, err := io.Copy(writer, body); err != nil {
+		cancel()
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+func (this *GCSStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	reader, err := this.bucket.Object(key).NewReader(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+func (this *GCSStore) DeleteObject(ctx context.Context, key string) error {
+	err := this.bucket.Object(key).Delete(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil
+	}
+	return err
+}
+
+func (this *GCSStore) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	objects := this.bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := objects.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, attrs.Name)
+	}
+	return keys, nil
+}
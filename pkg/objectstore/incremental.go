@@ -0,0 +1,306 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// A snapshot is copied incrementally to the last snapshot of its volume copied to the repository, which is recorded in
+// <prefix>/<type>/latest/<volume>. The manifest of an incremental snapshot references its parent and only lists the
+// chunks that differ from it, so that the data of a snapshot is rebuilt from the chain of manifests up to a full one.
+//
+// If the ProtectedEntity tracks the areas of its data that changed since its parent, only those areas are read.
+// Otherwise, all the data is read and compared to the chunks of the parent, and only the chunks that changed are
+// uploaded either way.
+
+// Extent is an area of the data of a ProtectedEntity.
+type Extent struct {
+	Offset int64
+	Length int64
+}
+
+// ChangeTrackingProtectedEntity is a ProtectedEntity that tracks the areas of its data that change between snapshots,
+// such as an IVD with changed block tracking enabled.
+type ChangeTrackingProtectedEntity interface {
+	astrolabe.ProtectedEntity
+	// GetChangeID returns the ID of the state of the data of the snapshot, to query the areas changed since then.
+	GetChangeID(ctx context.Context) (string, error)
+	// GetChangedAreas returns the areas of the data of the snapshot that changed since the state with the given ID.
+	GetChangedAreas(ctx context.Context, changeID string) ([]Extent, error)
+}
+
+// chunkChange is a chunk of an incremental snapshot that differs from the parent.
+type chunkChange struct {
+	Index int    `json:"index"`
+	Hash  string `json:"hash"`
+}
+
+// parentSnapshot is the snapshot in the repository that a snapshot is copied incrementally to.
+type parentSnapshot struct {
+	id astrolabe.ProtectedEntityID
+	// size, chunkSize and chunks are those of the data of the parent, rebuilt from its chain.
+	size      int64
+	chunkSize int64
+	chunks    []string
+	// changedAreas are the areas of the data that changed since the parent, sorted by offset, or nil if they are not
+	// known.
+	changedAreas []Extent
+	next         int
+}
+
+// unchanged returns true if the chunk of the data at offset is known to be the same as the chunk of the parent, so
+// that it does not need to be read. Chunks must be queried in the order of the data.
+func (this *parentSnapshot) unchanged(index int, offset int64, length int64) bool {
+	if this == nil || this.changedAreas == nil || index >= len(this.chunks) {
+		return false
+	}
+	// The chunk of the parent must span the same area, which it does not if the size of the data changed.
+	parentEnd := offset + this.chunkSize
+	if parentEnd > this.size {
+		parentEnd = this.size
+	}
+	if offset+length != parentEnd {
+		return false
+	}
+	for this.next < len(this.changedAreas) && this.changedAreas[this.next].Offset+this.changedAreas[this.next].Length <= offset {
+		this.next++
+	}
+	return this.next == len(this.changedAreas) || this.changedAreas[this.next].Offset >= offset+length
+}
+
+// chunkSource reads the data of a snapshot chunk by chunk, skipping the chunks that do not need to be read. If the
+// data can be read at any offset, as a virtual disk can, the chunks that are skipped are not read at all. Otherwise,
+// they are read and discarded.
+type chunkSource struct {
+	reader   io.Reader
+	readerAt io.ReaderAt
+	offset   int64
+}
+
+func newChunkSource(data io.Reader, skipping bool) *chunkSource {
+	source := &chunkSource{reader: data}
+	if readerAt, ok := data.(io.ReaderAt); ok && skipping {
+		source.readerAt = readerAt
+	}
+	return source
+}
+
+// read reads the next chunk like io.ReadFull.
+func (this *chunkSource) read(chunk []byte) (int, error) {
+	if this.readerAt == nil {
+		n, err := io.ReadFull(this.reader, chunk)
+		this.offset += int64(n)
+		return n, err
+	}
+	n, err := this.readerAt.ReadAt(chunk, this.offset)
+	this.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = io.ErrUnexpectedEOF
+		if n == len(chunk) {
+			err = nil
+		}
+	}
+	return n, err
+}
+
+func (this *chunkSource) skip(length int64) error {
+	if this.readerAt == nil {
+		if _, err := io.CopyN(ioutil.Discard, this.reader, length); err != nil {
+			return err
+		}
+	}
+	this.offset += length
+	return nil
+}
+
+func chunkCount(m manifest) int {
+	return int((m.Size + int64(m.ChunkSize) - 1) / int64(m.ChunkSize))
+}
+
+// resolveManifest returns the manifest of a snapshot with all its chunks, rebuilt from the chain of manifests of its
+// parents if it is incremental.
+func (this *ProtectedEntityTypeManager) resolveManifest(ctx context.Context, id astrolabe.ProtectedEntityID) (manifest, error) {
+	m, err := this.readManifest(ctx, id)
+	if err != nil || m.Parent == "" {
+		return m, err
+	}
+
+	chain := []manifest{m}
+	visited := map[string]bool{id.String(): true}
+	for parent := m.Parent; parent != ""; parent = chain[len(chain)-1].Parent {
+		if visited[parent] {
+			return m, errors.Errorf("The chain of parents of ProtectedEntity %s has a cycle at %s", id.String(), parent)
+		}
+		visited[parent] = true
+		parentID, err := astrolabe.NewProtectedEntityIDFromString(parent)
+		if err != nil {
+			return m, errors.Wrapf(err, "Invalid parent %s of ProtectedEntity %s", parent, id.String())
+		}
+		parentManifest, err := this.readManifest(ctx, parentID)
+		if err != nil {
+			return m, errors.Wrapf(err, "Failed to read the manifest of parent %s of ProtectedEntity %s", parent, id.String())
+		}
+		if parentManifest.ChunkSize != m.ChunkSize {
+			return m, errors.Errorf("Parent %s of ProtectedEntity %s has chunks of %d bytes instead of %d", parent, id.String(), parentManifest.ChunkSize, m.ChunkSize)
+		}
+		chain = append(chain, parentManifest)
+	}
+
+	chunks := append([]string{}, chain[len(chain)-1].Chunks...)
+	for i := len(chain) - 2; i >= 0; i-- {
+		count := chunkCount(chain[i])
+		for len(chunks) < count {
+			chunks = append(chunks, "")
+		}
+		chunks = chunks[:count]
+		for _, change := range chain[i].Changes {
+			if change.Index < 0 || change.Index >= count {
+				return m, errors.Errorf("Invalid chunk %d in the manifest of ProtectedEntity %s", change.Index, id.String())
+			}
+			chunks[change.Index] = change.Hash
+		}
+	}
+
+	m.Parent = ""
+	m.Changes = nil
+	m.Chunks = chunks
+	return m, nil
+}
+
+// fullManifest returns the manifest of an incremental snapshot with all its chunks, from the chunks of its parent.
+func fullManifest(m manifest, parent *parentSnapshot) manifest {
+	chunks := make([]string, chunkCount(m))
+	copy(chunks, parent.chunks)
+	for _, change := range m.Changes {
+		chunks[change.Index] = change.Hash
+	}
+	m.Parent = ""
+	m.Changes = nil
+	m.Chunks = chunks
+	return m
+}
+
+// findParent returns the snapshot that a snapshot of the ProtectedEntity is copied incrementally to, or nil if it is
+// copied in full.
+func (this *ProtectedEntityTypeManager) findParent(ctx context.Context, pe astrolabe.ProtectedEntity) (*parentSnapshot, error) {
+	id := pe.GetID()
+	log := this.logger.WithField("peID", id.String())
+	reader, err := this.store.GetObject(ctx, this.latestPrefix+volumeID(id).String())
+	if IsNotFound(err) {
+		log.Info("No snapshot of the volume is in the repository, copying the snapshot in full")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get the last snapshot of volume %s", volumeID(id).String())
+	}
+	content, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read the last snapshot of volume %s", volumeID(id).String())
+	}
+	parentID, err := astrolabe.NewProtectedEntityIDFromString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid last snapshot of volume %s", volumeID(id).String())
+	}
+	if parentID.String() == id.String() {
+		return nil, nil
+	}
+
+	parentManifest, err := this.readManifest(ctx, parentID)
+	if IsNotFound(err) {
+		log.Infof("The last snapshot of the volume, %s, is no longer in the repository, copying the snapshot in full", parentID.String())
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if parentManifest.ChunkSize != this.chunkSize {
+		log.Infof("The last snapshot of the volume, %s, has chunks of another size, copying the snapshot in full", parentID.String())
+		return nil, nil
+	}
+	if parentManifest.EncryptionKeyID != encryptionKeyID(ctx) {
+		log.Infof("The last snapshot of the volume, %s, is encrypted with another key, copying the snapshot in full", parentID.String())
+		return nil, nil
+	}
+	resolved, err := this.resolveManifest(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	parent := &parentSnapshot{
+		id:        parentID,
+		size:      resolved.Size,
+		chunkSize: int64(resolved.ChunkSize),
+		chunks:    resolved.Chunks,
+	}
+
+	if tracker, ok := pe.(ChangeTrackingProtectedEntity); ok && parentManifest.ChangeID != "" {
+		changedAreas, err := tracker.GetChangedAreas(ctx, parentManifest.ChangeID)
+		if err != nil {
+			log.WithError(err).Warnf("Failed to get the areas changed since %s, reading all the data", parentID.String())
+		} else {
+			parent.changedAreas = append([]Extent{}, changedAreas...)
+			sort.Slice(parent.changedAreas, func(i, j int) bool {
+				return parent.changedAreas[i].Offset < parent.changedAreas[j].Offset
+			})
+		}
+	}
+	log.Infof("Copying the snapshot incrementally to %s", parentID.String())
+	return parent, nil
+}
+
+// forgetLatest deletes the record of the last snapshot of the volume if it is the given snapshot, so that the next
+// snapshot of the volume is copied in full.
+func (this *ProtectedEntityTypeManager) forgetLatest(ctx context.Context, id astrolabe.ProtectedEntityID) error {
+	key := this.latestPrefix + volumeID(id).String()
+	reader, err := this.store.GetObject(ctx, key)
+	if IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	content, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(content)) != id.String() {
+		return nil
+	}
+	return this.store.DeleteObject(ctx, key)
+}
+
+// consolidate rewrites the manifest of an incremental snapshot with all its chunks, as a synthetic full snapshot that
+// no longer depends on its parents. No data is copied.
+func (this *ProtectedEntityTypeManager) consolidate(ctx context.Context, id astrolabe.ProtectedEntityID) error {
+	resolved, err := this.resolveManifest(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err = this.putManifest(ctx, id, resolved); err != nil {
+		return errors.Wrapf(err, "Failed to rewrite the manifest of ProtectedEntity %s", id.String())
+	}
+	this.logger.WithField("peID", id.String()).Info("The snapshot is consolidated into a synthetic full snapshot")
+	return nil
+}
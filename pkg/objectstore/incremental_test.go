@@ -0,0 +1,196 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"io"
+	"strings"
+	"testing"
+)
+
+func snapshotID(snapshot string) astrolabe.ProtectedEntityID {
+	return astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID(snapshot))
+}
+
+func TestIncrementalCopy(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	petm := newChunkedPETM(store)
+	first, second, third := snapshotID("snap-1"), snapshotID("snap-2"), snapshotID("snap-3")
+
+	copySnapshot(t, petm, first, "aaaabbbbcccc")
+	m, err := petm.readManifest(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, "", m.Parent)
+	assert.Len(t, m.Chunks, 3)
+
+	// bbbb changes and the data grows
+	copySnapshot(t, petm, second, "aaaaddddccccee")
+	m, err = petm.readManifest(ctx, second)
+	require.NoError(t, err)
+	assert.Equal(t, first.String(), m.Parent)
+	assert.Empty(t, m.Chunks)
+	require.Len(t, m.Changes, 2)
+	assert.Equal(t, 1, m.Changes[0].Index)
+	assert.Equal(t, 3, m.Changes[1].Index)
+
+	// The data shrinks back
+	copySnapshot(t, petm, third, "\x00\x00\x00\x00dddd")
+	m, err = petm.readManifest(ctx, third)
+	require.NoError(t, err)
+	assert.Equal(t, second.String(), m.Parent)
+	assert.Equal(t, []chunkChange{{Index: 0, Hash: ""}}, m.Changes)
+
+	assert.Equal(t, "aaaabbbbcccc", readSnapshot(t, petm, first))
+	assert.Equal(t, "aaaaddddccccee", readSnapshot(t, petm, second))
+	assert.Equal(t, "\x00\x00\x00\x00dddd", readSnapshot(t, petm, third))
+
+	// Deleting the middle of the chain keeps its data for its child
+	pe, err := petm.GetProtectedEntity(ctx, second)
+	require.NoError(t, err)
+	_, err = pe.DeleteSnapshot(ctx, second.GetSnapshotID())
+	require.NoError(t, err)
+	_, err = petm.GetProtectedEntity(ctx, second)
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, "aaaabbbbcccc", readSnapshot(t, petm, first))
+	assert.Equal(t, "\x00\x00\x00\x00dddd", readSnapshot(t, petm, third))
+	chunks, err := petm.listChunks(ctx, first)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 5)
+
+	// The chunk of ee is no longer referenced once the child is deleted
+	_, err = pe.DeleteSnapshot(ctx, third.GetSnapshotID())
+	require.NoError(t, err)
+	_, err = petm.readManifest(ctx, second)
+	assert.True(t, IsNotFound(err))
+	chunks, err = petm.listChunks(ctx, first)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 3)
+	assert.Equal(t, "aaaabbbbcccc", readSnapshot(t, petm, first))
+}
+
+func TestIncrementalCopyAfterDeletingTheLastSnapshot(t *testing.T) {
+	ctx := context.Background()
+	petm := newChunkedPETM(newMemStore())
+	first, second := snapshotID("snap-1"), snapshotID("snap-2")
+
+	copySnapshot(t, petm, first, "aaaabbbb")
+	pe, err := petm.GetProtectedEntity(ctx, first)
+	require.NoError(t, err)
+	_, err = pe.DeleteSnapshot(ctx, first.GetSnapshotID())
+	require.NoError(t, err)
+
+	copySnapshot(t, petm, second, "aaaacccc")
+	m, err := petm.readManifest(ctx, second)
+	require.NoError(t, err)
+	assert.Equal(t, "", m.Parent)
+	assert.Equal(t, "aaaacccc", readSnapshot(t, petm, second))
+}
+
+// changeTrackingProtectedEntity is a ProtectedEntity with changed block tracking, which records the areas of its data
+// that are read.
+type changeTrackingProtectedEntity struct {
+	astrolabe.ProtectedEntity
+	data         string
+	changeID     string
+	changedAreas []Extent
+	read         []Extent
+}
+
+func (this *changeTrackingProtectedEntity) GetChangeID(ctx context.Context) (string, error) {
+	return this.changeID, nil
+}
+
+func (this *changeTrackingProtectedEntity) GetChangedAreas(ctx context.Context, changeID string) ([]Extent, error) {
+	return this.changedAreas, nil
+}
+
+func (this *changeTrackingProtectedEntity) GetSize(ctx context.Context) (int64, error) {
+	return int64(len(this.data)), nil
+}
+
+func (this *changeTrackingProtectedEntity) GetDataReader(ctx context.Context) (io.ReadCloser, error) {
+	return &recordingReader{Reader: strings.NewReader(this.data), pe: this}, nil
+}
+
+type recordingReader struct {
+	*strings.Reader
+	pe *changeTrackingProtectedEntity
+}
+
+func (this *recordingReader) ReadAt(p []byte, offset int64) (int, error) {
+	n, err := this.Reader.ReadAt(p, offset)
+	if n > 0 {
+		this.pe.read = append(this.pe.read, Extent{Offset: offset, Length: int64(n)})
+	}
+	return n, err
+}
+
+func (this *recordingReader) Close() error {
+	return nil
+}
+
+func TestIncrementalCopyWithChangedAreas(t *testing.T) {
+	ctx := context.Background()
+	petm := newChunkedPETM(newMemStore())
+	first, second := snapshotID("snap-1"), snapshotID("snap-2")
+	copySnapshot(t, petm, first, "aaaabbbbcccc")
+
+	// Without a change ID for the parent, all the data is read
+	source := newMemStore()
+	require.NoError(t, source.PutObject(ctx, "source/ivd/peinfo/"+second.String(),
+		strings.NewReader(`{"id":"`+second.String()+`","name":"pvc-1","size":12}`)))
+	sourcePE, err := NewProtectedEntityTypeManager("ivd", source, "source", petm.logger).GetProtectedEntity(ctx, second)
+	require.NoError(t, err)
+	pe := &changeTrackingProtectedEntity{
+		ProtectedEntity: sourcePE,
+		data:            "aaaaddddcccc",
+		changeID:        "change-2",
+		changedAreas:    []Extent{{Offset: 5, Length: 2}},
+	}
+	_, err = petm.Copy(ctx, pe, astrolabe.AllocateNewObject)
+	require.NoError(t, err)
+	m, err := petm.readManifest(ctx, second)
+	require.NoError(t, err)
+	assert.Equal(t, "change-2", m.ChangeID)
+	assert.Equal(t, "aaaaddddcccc", readSnapshot(t, petm, second))
+
+	// Only the chunk with the changed area is read, the change of the last chunk is not tracked
+	third := snapshotID("snap-3")
+	require.NoError(t, source.PutObject(ctx, "source/ivd/peinfo/"+third.String(),
+		strings.NewReader(`{"id":"`+third.String()+`","name":"pvc-1","size":12}`)))
+	sourcePE, err = NewProtectedEntityTypeManager("ivd", source, "source", petm.logger).GetProtectedEntity(ctx, third)
+	require.NoError(t, err)
+	pe = &changeTrackingProtectedEntity{
+		ProtectedEntity: sourcePE,
+		data:            "aaaaeeeeffff",
+		changeID:        "change-3",
+		changedAreas:    []Extent{{Offset: 5, Length: 2}},
+	}
+	_, err = petm.Copy(ctx, pe, astrolabe.AllocateNewObject)
+	require.NoError(t, err)
+	assert.Equal(t, []Extent{{Offset: 4, Length: 4}}, pe.read)
+	m, err = petm.readManifest(ctx, third)
+	require.NoError(t, err)
+	assert.Equal(t, second.String(), m.Parent)
+	assert.Len(t, m.Changes, 1)
+	assert.Equal(t, "aaaaeeeecccc", readSnapshot(t, petm, third))
+}
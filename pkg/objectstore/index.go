@@ -0,0 +1,91 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"time"
+)
+
+// Every snapshot copied to the repository is indexed in <prefix>/<type>/index/<peID>, with the time it was taken and
+// the Velero backup it is part of, so that the retention policies can expire the snapshots of the repository without
+// relying on the Uploads, which are garbage collected, or on the Velero backups, which may be lost.
+
+// SnapshotOrigin tells where a snapshot copied to the repository comes from.
+type SnapshotOrigin struct {
+	// Timestamp is the time the snapshot was taken, the time it was copied if it is not known.
+	Timestamp time.Time `json:"timestamp"`
+	// Backup is the name of the Velero backup the snapshot is part of, if any.
+	Backup string `json:"backup,omitempty"`
+}
+
+type snapshotOriginKey struct{}
+
+// WithSnapshotOrigin returns a context in which the snapshots copied to the repository are indexed with the given
+// origin.
+func WithSnapshotOrigin(ctx context.Context, origin SnapshotOrigin) context.Context {
+	return context.WithValue(ctx, snapshotOriginKey{}, origin)
+}
+
+func snapshotOrigin(ctx context.Context) SnapshotOrigin {
+	origin, _ := ctx.Value(snapshotOriginKey{}).(SnapshotOrigin)
+	return origin
+}
+
+func (this *ProtectedEntityTypeManager) putIndexEntry(ctx context.Context, id astrolabe.ProtectedEntityID, origin SnapshotOrigin) error {
+	if origin.Timestamp.IsZero() {
+		origin.Timestamp = time.Now()
+	}
+	origin.Timestamp = origin.Timestamp.UTC()
+	return this.putCompressedJSON(ctx, this.indexPrefix+id.String(), origin)
+}
+
+// IndexedSnapshot is a snapshot kept in the repository.
+type IndexedSnapshot struct {
+	ID astrolabe.ProtectedEntityID
+	// Origin is nil if the snapshot was copied before the snapshots were indexed.
+	Origin *SnapshotOrigin
+}
+
+// IndexSnapshots returns the snapshots kept in the repository, with their origin. The snapshots that were deleted are
+// not returned, even if their data is still kept.
+func (this *ProtectedEntityTypeManager) IndexSnapshots(ctx context.Context) ([]IndexedSnapshot, error) {
+	ids, err := this.GetProtectedEntities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]IndexedSnapshot, 0, len(ids))
+	for _, id := range ids {
+		if !id.HasSnapshot() {
+			continue
+		}
+		snapshot := IndexedSnapshot{ID: id}
+		var origin SnapshotOrigin
+		err := this.getCompressedJSON(ctx, this.indexPrefix+id.String(), &origin)
+		if err != nil && !IsNotFound(err) {
+			return nil, errors.Wrapf(err, "Failed to read the index entry of snapshot %s", id.String())
+		}
+		if err == nil {
+			snapshot.Origin = &origin
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
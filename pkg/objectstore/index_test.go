@@ -0,0 +1,73 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIndexSnapshots(t *testing.T) {
+	ctx := context.Background()
+	logger := veleroplugintest.NewLogger()
+	indexedID := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+	legacyID := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-0"))
+
+	source := newMemStore()
+	require.NoError(t, source.PutObject(ctx, "source/ivd/peinfo/"+indexedID.String(),
+		strings.NewReader(`{"id":"`+indexedID.String()+`","name":"pvc-1","size":4}`)))
+	require.NoError(t, source.PutObject(ctx, "source/ivd/data/"+indexedID.String(), strings.NewReader("data")))
+	sourcePE, err := NewProtectedEntityTypeManager("ivd", source, "source", logger).GetProtectedEntity(ctx, indexedID)
+	require.NoError(t, err)
+
+	store := newMemStore()
+	petm := NewProtectedEntityTypeManager("ivd", store, "repo", logger)
+	// A snapshot copied by a previous version has no index entry.
+	require.NoError(t, store.PutObject(ctx, "repo/ivd/peinfo/"+legacyID.String(),
+		strings.NewReader(`{"id":"`+legacyID.String()+`","name":"pvc-1","size":4}`)))
+
+	taken := time.Date(2020, 6, 1, 22, 0, 0, 0, time.UTC)
+	_, err = petm.Copy(WithSnapshotOrigin(ctx, SnapshotOrigin{Timestamp: taken, Backup: "backup-1"}), sourcePE, astrolabe.AllocateNewObject)
+	require.NoError(t, err)
+
+	snapshots, err := petm.IndexSnapshots(ctx)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, legacyID, snapshots[0].ID)
+	assert.Nil(t, snapshots[0].Origin)
+	assert.Equal(t, indexedID, snapshots[1].ID)
+	require.NotNil(t, snapshots[1].Origin)
+	assert.Equal(t, "backup-1", snapshots[1].Origin.Backup)
+	assert.True(t, taken.Equal(snapshots[1].Origin.Timestamp))
+
+	// A deleted snapshot is no longer indexed.
+	pe, err := petm.GetProtectedEntity(ctx, indexedID)
+	require.NoError(t, err)
+	_, err = pe.DeleteSnapshot(ctx, indexedID.GetSnapshotID())
+	require.NoError(t, err)
+	snapshots, err = petm.IndexSnapshots(ctx)
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	_, err = store.GetObject(ctx, "repo/ivd/index/"+indexedID.String())
+	assert.True(t, IsNotFound(err))
+}
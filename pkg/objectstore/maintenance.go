@@ -0,0 +1,223 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A snapshot that is deleted is marked as expired in <prefix>/<type>/expired/<peID>, and its manifest is only deleted,
+// i.e. the snapshot is purged, once no incremental snapshot depends on it. Maintain merges the chains of incremental
+// snapshots that are longer than a given depth into synthetic full snapshots, which bounds the time to restore a
+// snapshot, and purges the expired snapshots that no snapshot depends on anymore.
+
+// DefaultMaxChainDepth is the default number of incremental snapshots a snapshot may depend on.
+const DefaultMaxChainDepth = 14
+
+func (this *ProtectedEntityTypeManager) putExpired(ctx context.Context, id astrolabe.ProtectedEntityID) error {
+	return this.store.PutObject(ctx, this.expiredPrefix+id.String(), strings.NewReader(time.Now().UTC().Format(time.RFC3339)))
+}
+
+func (this *ProtectedEntityTypeManager) isExpired(ctx context.Context, id astrolabe.ProtectedEntityID) (bool, error) {
+	reader, err := this.store.GetObject(ctx, this.expiredPrefix+id.String())
+	if IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "Failed to check whether snapshot %s is expired", id.String())
+	}
+	reader.Close()
+	return true, nil
+}
+
+// volumeManifests returns the manifests of the snapshots of the volume, by ID.
+func (this *ProtectedEntityTypeManager) volumeManifests(ctx context.Context, id astrolabe.ProtectedEntityID) (map[string]manifest, error) {
+	keys, err := this.store.ListObjects(ctx, this.manifestPrefix+volumeID(id).String()+":")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list the manifests of volume %s", volumeID(id).String())
+	}
+	manifests := make(map[string]manifest, len(keys))
+	for _, key := range keys {
+		snapshotID, err := astrolabe.NewProtectedEntityIDFromString(strings.TrimPrefix(key, this.manifestPrefix))
+		if err != nil {
+			this.logger.WithError(err).Warnf("Skipping object %s that is not named after a ProtectedEntity", key)
+			continue
+		}
+		m, err := this.readManifest(ctx, snapshotID)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		manifests[snapshotID.String()] = m
+	}
+	return manifests, nil
+}
+
+func hasChildren(manifests map[string]manifest, id string) bool {
+	for _, m := range manifests {
+		if m.Parent == id {
+			return true
+		}
+	}
+	return false
+}
+
+// purgeExpired deletes the data of the expired snapshot unless a snapshot depends on it, and then of its expired
+// parents that no snapshot depends on anymore. It returns the number of snapshots purged.
+func (this *ProtectedEntityTypeManager) purgeExpired(ctx context.Context, id astrolabe.ProtectedEntityID) (int, error) {
+	manifests, err := this.volumeManifests(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for current := id; ; {
+		expired, err := this.isExpired(ctx, current)
+		if err != nil || !expired || hasChildren(manifests, current.String()) {
+			return purged, err
+		}
+		for _, key := range []string{this.manifestPrefix + current.String(), this.dataPrefix + current.String(),
+			this.expiredPrefix + current.String()} {
+			if err := this.store.DeleteObject(ctx, key); err != nil {
+				return purged, errors.Wrapf(err, "Failed to delete object %s of snapshot %s", key, current.String())
+			}
+		}
+		this.logger.WithField("peID", current.String()).Info("The data of the expired snapshot is deleted")
+		purged++
+
+		parent := manifests[current.String()].Parent
+		delete(manifests, current.String())
+		if parent == "" {
+			return purged, nil
+		}
+		if current, err = astrolabe.NewProtectedEntityIDFromString(parent); err != nil {
+			return purged, errors.Wrapf(err, "Invalid parent %s of snapshot %s", parent, id.String())
+		}
+	}
+}
+
+// Maintain merges the chains of incremental snapshots in the repository that are longer than maxChainDepth into
+// synthetic full snapshots, and purges the expired snapshots that no snapshot depends on. Chains are not merged if
+// maxChainDepth is 0. The checkpoints of abandoned uploads are deleted too. The volumes are maintained one after the
+// other, and the maintenance of the other volumes goes on if one fails.
+func (this *ProtectedEntityTypeManager) Maintain(ctx context.Context, maxChainDepth int) error {
+	if _, err := this.expireCheckpoints(ctx); err != nil {
+		this.logger.WithError(err).Warn("Failed to delete the checkpoints of abandoned uploads")
+	}
+
+	volumes := make(map[string]astrolabe.ProtectedEntityID)
+	for _, prefix := range []string{this.manifestPrefix, this.expiredPrefix} {
+		keys, err := this.store.ListObjects(ctx, prefix)
+		if err != nil {
+			return errors.Wrap(err, "Failed to list the snapshots in the repository")
+		}
+		for _, key := range keys {
+			id, err := astrolabe.NewProtectedEntityIDFromString(strings.TrimPrefix(key, prefix))
+			if err != nil {
+				continue
+			}
+			volumes[volumeID(id).String()] = id
+		}
+	}
+
+	var failed []string
+	for volume, id := range volumes {
+		if err := this.maintainVolume(ctx, id, maxChainDepth); err != nil {
+			this.logger.WithError(err).WithField("volume", volume).Error("Failed to maintain the snapshots of the volume")
+			failed = append(failed, volume)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return errors.Errorf("Failed to maintain the snapshots of volumes %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (this *ProtectedEntityTypeManager) maintainVolume(ctx context.Context, id astrolabe.ProtectedEntityID, maxChainDepth int) error {
+	log := this.logger.WithField("volume", volumeID(id).String())
+	manifests, err := this.volumeManifests(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Parents are consolidated before their children, whose depth then decreases.
+	snapshots := make([]string, 0, len(manifests))
+	initialDepths := make(map[string]int, len(manifests))
+	for snapshot := range manifests {
+		snapshots = append(snapshots, snapshot)
+		depth := 0
+		for parent := manifests[snapshot].Parent; parent != "" && depth <= len(manifests); parent = manifests[parent].Parent {
+			depth++
+		}
+		initialDepths[snapshot] = depth
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return initialDepths[snapshots[i]] < initialDepths[snapshots[j]]
+	})
+	depths := make(map[string]int, len(manifests))
+	consolidated := 0
+	for _, snapshot := range snapshots {
+		m := manifests[snapshot]
+		depth := 0
+		if m.Parent != "" {
+			depth = depths[m.Parent] + 1
+		}
+		if maxChainDepth > 0 && depth > maxChainDepth {
+			snapshotID, err := astrolabe.NewProtectedEntityIDFromString(snapshot)
+			if err != nil {
+				return err
+			}
+			if err = this.consolidate(ctx, snapshotID); err != nil {
+				return err
+			}
+			depth = 0
+			consolidated++
+		}
+		depths[snapshot] = depth
+	}
+
+	expiredKeys, err := this.store.ListObjects(ctx, this.expiredPrefix+volumeID(id).String()+":")
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list the expired snapshots of volume %s", volumeID(id).String())
+	}
+	purged := 0
+	for _, key := range expiredKeys {
+		expiredID, err := astrolabe.NewProtectedEntityIDFromString(strings.TrimPrefix(key, this.expiredPrefix))
+		if err != nil {
+			continue
+		}
+		n, err := this.purgeExpired(ctx, expiredID)
+		purged += n
+		if err != nil {
+			return err
+		}
+	}
+	if purged > 0 {
+		if _, err := this.CollectGarbage(ctx, id); err != nil {
+			return err
+		}
+	}
+	log.Infof("%d snapshots are consolidated, %d expired snapshots are purged, %d expired snapshots are kept", consolidated, purged, len(expiredKeys)-purged)
+	return nil
+}
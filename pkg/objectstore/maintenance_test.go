@@ -0,0 +1,84 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"strconv"
+	"testing"
+)
+
+func TestMaintain(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	petm := newChunkedPETM(store)
+
+	// A chain of 6 snapshots, each one changing one more chunk
+	var ids []astrolabe.ProtectedEntityID
+	data := []byte("aaaaaaaaaaaaaaaaaaaaaaaa")
+	for i := 0; i < 6; i++ {
+		ids = append(ids, snapshotID("snap-"+strconv.Itoa(i)))
+		copy(data[4*i:], "bbbb")
+		copySnapshot(t, petm, ids[i], string(data))
+	}
+	otherVolume := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-2", astrolabe.NewProtectedEntitySnapshotID("snap-0"))
+	copySnapshot(t, petm, otherVolume, "cccc")
+
+	pe, err := petm.GetProtectedEntity(ctx, ids[0])
+	require.NoError(t, err)
+	for _, id := range ids[:2] {
+		_, err = pe.DeleteSnapshot(ctx, id.GetSnapshotID())
+		require.NoError(t, err)
+	}
+
+	// Nothing is merged without a maximum depth, and the expired snapshots are still needed
+	require.NoError(t, petm.Maintain(ctx, 0))
+	for i, id := range ids {
+		m, err := petm.readManifest(ctx, id)
+		require.NoError(t, err)
+		if i > 0 {
+			assert.Equal(t, ids[i-1].String(), m.Parent)
+		}
+	}
+
+	// snap-2 and snap-4 are merged, so that snap-0 and snap-1 are no longer needed
+	require.NoError(t, petm.Maintain(ctx, 1))
+	for _, id := range ids[:2] {
+		_, err = petm.readManifest(ctx, id)
+		assert.True(t, IsNotFound(err), id.String())
+	}
+	for i, parent := range []string{"", ids[2].String(), "", ids[4].String()} {
+		m, err := petm.readManifest(ctx, ids[i+2])
+		require.NoError(t, err)
+		assert.Equal(t, parent, m.Parent, ids[i+2].String())
+	}
+
+	expired, err := store.ListObjects(ctx, petm.expiredPrefix)
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+	for i := 2; i < 6; i++ {
+		expected := []byte("aaaaaaaaaaaaaaaaaaaaaaaa")
+		for j := 0; j <= i; j++ {
+			copy(expected[4*j:], "bbbb")
+		}
+		assert.Equal(t, string(expected), readSnapshot(t, petm, ids[i]))
+	}
+	assert.Equal(t, "cccc", readSnapshot(t, petm, otherVolume))
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// ErrObjectNotFound is returned by an ObjectStore when the object with the given key does not exist.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectStore is the set of object operations a remote repository is built on. Keys are slash separated paths
+// relative to the bucket or container of the store.
+type ObjectStore interface {
+	// PutObject writes the object with the given key, streaming its content from body.
+	PutObject(ctx context.Context, key string, body io.Reader) error
+
+	// GetObject returns a reader for the content of the object. ErrObjectNotFound is returned if there is no
+	// object with the given key.
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// DeleteObject deletes the object. Deleting an object that does not exist is not an error.
+	DeleteObject(ctx context.Context, key string) error
+
+	// ListObjects returns the keys of the objects whose keys start with prefix.
+	ListObjects(ctx context.Context, prefix string) ([]string, error)
+}
+
+// IsNotFound returns true if err, or the cause of err, is ErrObjectNotFound.
+func IsNotFound(err error) bool {
+	return errors.Cause(err) == ErrObjectNotFound
+}
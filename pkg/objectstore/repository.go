@@ -0,0 +1,469 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// protectedEntityInfo is the content of the peinfo object of a snapshot in the repository.
+type protectedEntityInfo struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Size         int64    `json:"size"`
+	ComponentIDs []string `json:"componentIDs,omitempty"`
+}
+
+// SizedProtectedEntity is a ProtectedEntity that knows the size of its data, which astrolabe.ProtectedEntityInfo does
+// not carry. The size of the data of other ProtectedEntities is only known once it is read.
+type SizedProtectedEntity interface {
+	astrolabe.ProtectedEntity
+	GetSize(ctx context.Context) (int64, error)
+}
+
+// getSize returns the size of the data of the ProtectedEntity, or 0 if it is not known.
+func getSize(ctx context.Context, pe astrolabe.ProtectedEntity) (int64, error) {
+	sized, ok := pe.(SizedProtectedEntity)
+	if !ok {
+		return 0, nil
+	}
+	return sized.GetSize(ctx)
+}
+
+// ProtectedEntityTypeManager is a remote repository of snapshots kept in an ObjectStore. It uses the layout of the
+// S3 repository of astrolabe, i.e. the info and metadata of a snapshot are kept in the objects
+// <prefix>/<type>/peinfo/<peID> and <prefix>/<type>/md/<peID>, so that snapshot IDs are the same whichever object
+// store the repository is in. The data of a snapshot is kept in chunks, see chunks.go, snapshots are copied
+// incrementally to the previous snapshot of their volume, see incremental.go, an interrupted copy resumes from its
+// last checkpoint, see checkpoint.go, snapshots are indexed for the retention policies, see index.go, and can be
+// verified against the checksums recorded when they were copied, see verify.go. The data of snapshots copied by
+// previous versions is kept in <prefix>/<type>/data/<peID>, which is still read.
+type ProtectedEntityTypeManager struct {
+	typeName           string
+	store              ObjectStore
+	peinfoPrefix       string
+	mdPrefix           string
+	dataPrefix         string
+	manifestPrefix     string
+	chunkPrefix        string
+	pendingPrefix      string
+	expiredPrefix      string
+	latestPrefix       string
+	checkpointPrefix   string
+	indexPrefix        string
+	chunkSize          int
+	checkpointInterval int
+	logger             logrus.FieldLogger
+}
+
+func NewProtectedEntityTypeManager(typeName string, store ObjectStore, prefix string, logger logrus.FieldLogger) *ProtectedEntityTypeManager {
+	return &ProtectedEntityTypeManager{
+		typeName:           typeName,
+		store:              store,
+		peinfoPrefix:       path.Join(prefix, typeName, "peinfo") + "/",
+		mdPrefix:           path.Join(prefix, typeName, "md") + "/",
+		dataPrefix:         path.Join(prefix, typeName, "data") + "/",
+		manifestPrefix:     path.Join(prefix, typeName, "manifest") + "/",
+		chunkPrefix:        path.Join(prefix, typeName, "chunks") + "/",
+		pendingPrefix:      path.Join(prefix, typeName, "pending") + "/",
+		expiredPrefix:      path.Join(prefix, typeName, "expired") + "/",
+		latestPrefix:       path.Join(prefix, typeName, "latest") + "/",
+		checkpointPrefix:   path.Join(prefix, typeName, "checkpoint") + "/",
+		indexPrefix:        path.Join(prefix, typeName, "index") + "/",
+		chunkSize:          DefaultChunkSize,
+		checkpointInterval: DefaultCheckpointInterval,
+		logger:             logger,
+	}
+}
+
+func (this *ProtectedEntityTypeManager) GetTypeName() string {
+	return this.typeName
+}
+
+func (this *ProtectedEntityTypeManager) GetProtectedEntity(ctx context.Context, id astrolabe.ProtectedEntityID) (astrolabe.ProtectedEntity, error) {
+	if err := this.checkID(id); err != nil {
+		return nil, err
+	}
+	info, err := this.readInfo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return newProtectedEntity(this, id, info), nil
+}
+
+func (this *ProtectedEntityTypeManager) GetProtectedEntities(ctx context.Context) ([]astrolabe.ProtectedEntityID, error) {
+	keys, err := this.store.ListObjects(ctx, this.peinfoPrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list the ProtectedEntities in the repository")
+	}
+
+	ids := make([]astrolabe.ProtectedEntityID, 0, len(keys))
+	for _, key := range keys {
+		id, err := astrolabe.NewProtectedEntityIDFromString(strings.TrimPrefix(key, this.peinfoPrefix))
+		if err != nil {
+			this.logger.WithError(err).Warnf("Skipping object %s that is not named after a ProtectedEntity", key)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Copy copies the snapshot of a ProtectedEntity to the repository. The data and metadata are written before the info,
+// so that a snapshot is only found in the repository once it is complete. The snapshot is copied incrementally to the
+// last snapshot of its volume in the repository if there is one, and only the chunks of the data that are not in the
+// repository yet are uploaded.
+func (this *ProtectedEntityTypeManager) Copy(ctx context.Context, pe astrolabe.ProtectedEntity, options astrolabe.CopyCreateOptions) (astrolabe.ProtectedEntity, error) {
+	return this.CopyWithCheckpoints(ctx, pe, options, nil)
+}
+
+// CopyWithCheckpoints is Copy, which resumes the upload of the data from its last checkpoint if a previous copy of the
+// snapshot was interrupted. checkpointed, if not nil, is called when the upload is checkpointed or resumed.
+func (this *ProtectedEntityTypeManager) CopyWithCheckpoints(ctx context.Context, pe astrolabe.ProtectedEntity, options astrolabe.CopyCreateOptions, checkpointed CheckpointFunc) (astrolabe.ProtectedEntity, error) {
+	id := pe.GetID()
+	if err := this.checkID(id); err != nil {
+		return nil, err
+	}
+	log := this.logger.WithField("peID", id.String())
+
+	if err := this.putPendingUpload(ctx, id); err != nil {
+		return nil, errors.Wrapf(err, "Failed to record the upload of ProtectedEntity %s", id.String())
+	}
+	defer func() {
+		if err := this.store.DeleteObject(ctx, this.pendingPrefix+id.String()); err != nil {
+			log.WithError(err).Warn("Failed to delete the record of the upload")
+		}
+	}()
+
+	peInfo, err := pe.GetInfo(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get the info of ProtectedEntity %s", id.String())
+	}
+	size, err := getSize(ctx, pe)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get the size of ProtectedEntity %s", id.String())
+	}
+	info := protectedEntityInfo{
+		ID:   id.String(),
+		Name: peInfo.GetName(),
+		Size: size,
+	}
+	for _, componentID := range peInfo.GetComponentIDs() {
+		info.ComponentIDs = append(info.ComponentIDs, componentID.String())
+	}
+
+	mdReader, err := pe.GetMetadataReader(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get the metadata reader of ProtectedEntity %s", id.String())
+	}
+	mdChecksum := ""
+	if mdReader != nil {
+		if mdReader, err = encryptMetadata(ctx, mdReader); err != nil {
+			return nil, errors.Wrapf(err, "Failed to encrypt the metadata of ProtectedEntity %s", id.String())
+		}
+		hash := sha256.New()
+		err = this.store.PutObject(ctx, this.mdPrefix+id.String(), io.TeeReader(mdReader, hash))
+		mdReader.Close()
+		mdChecksum = checksumPrefix + hex.EncodeToString(hash.Sum(nil))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to upload the metadata of ProtectedEntity %s", id.String())
+		}
+		log.Debug("The metadata is uploaded")
+	}
+
+	dataReader, err := pe.GetDataReader(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get the data reader of ProtectedEntity %s", id.String())
+	}
+	if dataReader != nil {
+		parent, err := this.findParent(ctx, pe)
+		if err != nil {
+			log.WithError(err).Warn("Failed to find the snapshot to copy the snapshot incrementally to, copying the snapshot in full")
+			parent = nil
+		}
+		resume := this.findCheckpoint(ctx, id, dataReader, parent)
+		m, err := this.putChunks(ctx, id, dataReader, info.Size, parent, resume, checkpointed)
+		dataReader.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to upload the data of ProtectedEntity %s", id.String())
+		}
+		info.Size = m.Size
+		if tracker, ok := pe.(ChangeTrackingProtectedEntity); ok {
+			if m.ChangeID, err = tracker.GetChangeID(ctx); err != nil {
+				log.WithError(err).Warn("Failed to get the change ID, the next snapshot will read all the data")
+			}
+		}
+		m.MetadataChecksum = mdChecksum
+		if err = this.putManifest(ctx, id, m); err != nil {
+			return nil, errors.Wrapf(err, "Failed to upload the manifest of ProtectedEntity %s", id.String())
+		}
+		if parent != nil {
+			// The parent may have been deleted while the data was uploaded.
+			if _, err := this.resolveManifest(ctx, id); err != nil {
+				log.WithError(err).Warnf("Failed to rebuild the data from %s, copying the manifest in full", parent.id.String())
+				if err = this.putManifest(ctx, id, fullManifest(m, parent)); err != nil {
+					return nil, errors.Wrapf(err, "Failed to upload the manifest of ProtectedEntity %s", id.String())
+				}
+			}
+		}
+		if err = this.checkChunks(ctx, id); err != nil {
+			// The next copy of the snapshot uploads the missing chunks again.
+			for _, key := range []string{this.manifestPrefix + id.String(), this.checkpointPrefix + id.String()} {
+				if err := this.store.DeleteObject(ctx, key); err != nil {
+					log.WithError(err).Warnf("Failed to delete object %s of the upload", key)
+				}
+			}
+			return nil, errors.Wrapf(err, "Failed to upload the data of ProtectedEntity %s", id.String())
+		}
+		log.Debug("The data is uploaded")
+	}
+
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to marshal the info of ProtectedEntity %s", id.String())
+	}
+	err = this.store.PutObject(ctx, this.peinfoPrefix+id.String(), bytes.NewReader(infoBytes))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to upload the info of ProtectedEntity %s", id.String())
+	}
+	if err = this.store.PutObject(ctx, this.latestPrefix+volumeID(id).String(), strings.NewReader(id.String())); err != nil {
+		log.WithError(err).Warn("Failed to record the snapshot as the last one of its volume, the next snapshot will be copied in full")
+	}
+	if err = this.putIndexEntry(ctx, id, snapshotOrigin(ctx)); err != nil {
+		log.WithError(err).Warn("Failed to index the snapshot, it is not expired by the retention policies")
+	}
+	if err = this.deleteCheckpoint(ctx, id); err != nil {
+		log.WithError(err).Warn("Failed to delete the checkpoint of the upload")
+	}
+	log.Info("ProtectedEntity is copied to the repository")
+
+	return newProtectedEntity(this, id, info), nil
+}
+
+func (this *ProtectedEntityTypeManager) CopyFromInfo(ctx context.Context, info astrolabe.ProtectedEntityInfo, options astrolabe.CopyCreateOptions) (astrolabe.ProtectedEntity, error) {
+	return nil, errors.New("CopyFromInfo is not supported by the object store repository")
+}
+
+// checkID returns an error unless id refers to a snapshot of the type of the repository. Only snapshots are kept in
+// the repository.
+func (this *ProtectedEntityTypeManager) checkID(id astrolabe.ProtectedEntityID) error {
+	if id.GetPeType() != this.typeName {
+		return errors.Errorf("ProtectedEntity %s is not of type %s", id.String(), this.typeName)
+	}
+	if !id.HasSnapshot() {
+		return errors.Errorf("ProtectedEntity %s does not refer to a snapshot", id.String())
+	}
+	return nil
+}
+
+func (this *ProtectedEntityTypeManager) readInfo(ctx context.Context, id astrolabe.ProtectedEntityID) (protectedEntityInfo, error) {
+	var info protectedEntityInfo
+	reader, err := this.store.GetObject(ctx, this.peinfoPrefix+id.String())
+	if err != nil {
+		return info, errors.Wrapf(err, "Failed to get the info of ProtectedEntity %s", id.String())
+	}
+	defer reader.Close()
+
+	infoBytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return info, errors.Wrapf(err, "Failed to read the info of ProtectedEntity %s", id.String())
+	}
+	if err = json.Unmarshal(infoBytes, &info); err != nil {
+		return info, errors.Wrapf(err, "Failed to unmarshal the info of ProtectedEntity %s", id.String())
+	}
+	return info, nil
+}
+
+// ProtectedEntity is a snapshot kept in an object store repository.
+type ProtectedEntity struct {
+	petm *ProtectedEntityTypeManager
+	id   astrolabe.ProtectedEntityID
+	info protectedEntityInfo
+}
+
+func newProtectedEntity(petm *ProtectedEntityTypeManager, id astrolabe.ProtectedEntityID, info protectedEntityInfo) *ProtectedEntity {
+	return &ProtectedEntity{
+		petm: petm,
+		id:   id,
+		info: info,
+	}
+}
+
+func (this *ProtectedEntity) GetInfo(ctx context.Context) (astrolabe.ProtectedEntityInfo, error) {
+	componentIDs := make([]astrolabe.ProtectedEntityID, 0, len(this.info.ComponentIDs))
+	for _, componentIDStr := range this.info.ComponentIDs {
+		componentID, err := astrolabe.NewProtectedEntityIDFromString(componentIDStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid component %s of ProtectedEntity %s", componentIDStr, this.id.String())
+		}
+		componentIDs = append(componentIDs, componentID)
+	}
+	return astrolabe.NewProtectedEntityInfo(this.id, this.info.Name,
+		[]astrolabe.DataTransport{}, []astrolabe.DataTransport{}, []astrolabe.DataTransport{}, componentIDs), nil
+}
+
+// GetSize returns the size of the data of the snapshot, as recorded when it was copied to the repository.
+func (this *ProtectedEntity) GetSize(ctx context.Context) (int64, error) {
+	return this.info.Size, nil
+}
+
+func (this *ProtectedEntity) GetCombinedInfo(ctx context.Context) ([]astrolabe.ProtectedEntityInfo, error) {
+	info, err := this.GetInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []astrolabe.ProtectedEntityInfo{info}, nil
+}
+
+func (this *ProtectedEntity) Snapshot(ctx context.Context) (astrolabe.ProtectedEntitySnapshotID, error) {
+	return astrolabe.ProtectedEntitySnapshotID{}, errors.New("Snapshots cannot be taken in the object store repository")
+}
+
+// ListSnapshots returns the snapshots of the ProtectedEntity that are kept in the repository.
+func (this *ProtectedEntity) ListSnapshots(ctx context.Context) ([]astrolabe.ProtectedEntitySnapshotID, error) {
+	prefix := this.petm.peinfoPrefix + astrolabe.NewProtectedEntityID(this.id.GetPeType(), this.id.GetID()).String() + ":"
+	keys, err := this.petm.store.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list the snapshots of ProtectedEntity %s", this.id.String())
+	}
+
+	snapshotIDs := make([]astrolabe.ProtectedEntitySnapshotID, 0, len(keys))
+	for _, key := range keys {
+		id, err := astrolabe.NewProtectedEntityIDFromString(strings.TrimPrefix(key, this.petm.peinfoPrefix))
+		if err != nil || !id.HasSnapshot() {
+			continue
+		}
+		snapshotIDs = append(snapshotIDs, id.GetSnapshotID())
+	}
+	return snapshotIDs, nil
+}
+
+// DeleteSnapshot deletes a snapshot of the ProtectedEntity from the repository. The snapshot is marked as expired and
+// its info is deleted first, so that a snapshot that is partially deleted is no longer found. The data of the snapshot
+// is only deleted once no incremental snapshot depends on it, see maintenance.go. The chunks that are no longer
+// referenced by any snapshot of the volume are deleted afterwards.
+func (this *ProtectedEntity) DeleteSnapshot(ctx context.Context, snapshotToDelete astrolabe.ProtectedEntitySnapshotID) (bool, error) {
+	id := astrolabe.NewProtectedEntityIDWithSnapshotID(this.id.GetPeType(), this.id.GetID(), snapshotToDelete)
+	log := this.petm.logger.WithField("peID", id.String())
+	if err := this.petm.putExpired(ctx, id); err != nil {
+		return false, errors.Wrapf(err, "Failed to mark snapshot %s as expired", id.String())
+	}
+	for _, key := range []string{this.petm.peinfoPrefix + id.String(), this.petm.mdPrefix + id.String(),
+		this.petm.checkpointPrefix + id.String(), this.petm.indexPrefix + id.String()} {
+		if err := this.petm.store.DeleteObject(ctx, key); err != nil {
+			return false, errors.Wrapf(err, "Failed to delete object %s of snapshot %s", key, id.String())
+		}
+	}
+	if err := this.petm.forgetLatest(ctx, id); err != nil {
+		log.WithError(err).Warn("Failed to delete the record of the last snapshot of the volume")
+	}
+	log.Info("Snapshot is deleted from the repository")
+
+	// The expired snapshot is purged by the maintenance of the repository if it cannot be purged now.
+	purged, err := this.petm.purgeExpired(ctx, id)
+	if err != nil {
+		log.WithError(err).Warn("Failed to delete the data of the snapshot")
+		return true, nil
+	}
+	if purged == 0 {
+		log.Info("The data of the snapshot is kept until no incremental snapshot depends on it")
+		return true, nil
+	}
+
+	// The snapshot is deleted even if its chunks are not, they are collected when the next snapshot is deleted.
+	if _, err := this.petm.CollectGarbage(ctx, id); err != nil {
+		log.WithError(err).Warn("Failed to delete the chunks that are no longer referenced")
+	}
+	return true, nil
+}
+
+func (this *ProtectedEntity) GetInfoForSnapshot(ctx context.Context, snapshotID astrolabe.ProtectedEntitySnapshotID) (*astrolabe.ProtectedEntityInfo, error) {
+	id := astrolabe.NewProtectedEntityIDWithSnapshotID(this.id.GetPeType(), this.id.GetID(), snapshotID)
+	info, err := this.petm.readInfo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	peInfo, err := newProtectedEntity(this.petm, id, info).GetInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &peInfo, nil
+}
+
+func (this *ProtectedEntity) GetComponents(ctx context.Context) ([]astrolabe.ProtectedEntity, error) {
+	return []astrolabe.ProtectedEntity{}, nil
+}
+
+func (this *ProtectedEntity) GetID() astrolabe.ProtectedEntityID {
+	return this.id
+}
+
+// GetDataReader decrypts encrypted data with the key provider of the context. It fails with an error wrapping
+// encryption.ErrKeyNotFound if the key the data is encrypted with is not available.
+func (this *ProtectedEntity) GetDataReader(ctx context.Context) (io.ReadCloser, error) {
+	m, err := this.petm.readManifest(ctx, this.id)
+	if IsNotFound(err) {
+		// Copied by a previous version
+		return this.petm.store.GetObject(ctx, this.petm.dataPrefix+this.id.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.Parent != "" {
+		if m, err = this.petm.resolveManifest(ctx, this.id); err != nil {
+			return nil, err
+		}
+	}
+	return this.petm.newChunkReader(ctx, this.id, m)
+}
+
+// GetMetadataReader returns nil if the ProtectedEntity was copied without metadata. Encrypted metadata is decrypted
+// with the key provider of the context.
+func (this *ProtectedEntity) GetMetadataReader(ctx context.Context) (io.ReadCloser, error) {
+	reader, err := this.petm.store.GetObject(ctx, this.petm.mdPrefix+this.id.String())
+	if IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return decryptMetadata(ctx, reader)
+}
+
+// GetEncryptionKeyID returns the ID of the key the data of the snapshot is encrypted with, or "" if it is not
+// encrypted.
+func (this *ProtectedEntity) GetEncryptionKeyID(ctx context.Context) (string, error) {
+	m, err := this.petm.readManifest(ctx, this.id)
+	if IsNotFound(err) {
+		// Copied by a previous version
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return m.EncryptionKeyID, nil
+}
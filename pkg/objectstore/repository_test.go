@@ -0,0 +1,185 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memStore is an ObjectStore in memory.
+type memStore struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemStore() *memStore {
+	return &memStore{objects: make(map[string][]byte)}
+}
+
+func (this *memStore) PutObject(ctx context.Context, key string, body io.Reader) error {
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.objects[key] = content
+	return nil
+}
+
+func (this *memStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	content, ok := this.objects[key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (this *memStore) DeleteObject(ctx context.Context, key string) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	delete(this.objects, key)
+	return nil
+}
+
+func (this *memStore) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	var keys []string
+	for key := range this.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func TestRepositoryInMemory(t *testing.T) {
+	testRepository(t, newMemStore())
+}
+
+func TestGetProtectedEntityWithoutSnapshot(t *testing.T) {
+	petm := NewProtectedEntityTypeManager("ivd", newMemStore(), "repo", veleroplugintest.NewLogger())
+	_, err := petm.GetProtectedEntity(context.Background(), astrolabe.NewProtectedEntityID("ivd", "vol-1"))
+	assert.Error(t, err)
+}
+
+// testRepository copies a snapshot to a repository in the store, reads it back and deletes it. It is run against
+// each ObjectStore implementation.
+func testRepository(t *testing.T, store ObjectStore) {
+	ctx := context.Background()
+	logger := veleroplugintest.NewLogger()
+	id := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+	otherID := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-10", astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+
+	// The source of the copy is a repository in memory.
+	source := newMemStore()
+	for _, sourceID := range []astrolabe.ProtectedEntityID{id, otherID} {
+		require.NoError(t, source.PutObject(ctx, "source/ivd/peinfo/"+sourceID.String(),
+			strings.NewReader(`{"id":"`+sourceID.String()+`","name":"pvc-1","size":4}`)))
+		require.NoError(t, source.PutObject(ctx, "source/ivd/md/"+sourceID.String(), strings.NewReader("metadata")))
+		require.NoError(t, source.PutObject(ctx, "source/ivd/data/"+sourceID.String(), strings.NewReader("data")))
+	}
+	sourcePETM := NewProtectedEntityTypeManager("ivd", source, "source", logger)
+
+	petm := NewProtectedEntityTypeManager("ivd", store, "plugins/vsphere-astrolabe-repo", logger)
+	for _, sourceID := range []astrolabe.ProtectedEntityID{id, otherID} {
+		sourcePE, err := sourcePETM.GetProtectedEntity(ctx, sourceID)
+		require.NoError(t, err)
+		copied, err := petm.Copy(ctx, sourcePE, astrolabe.AllocateNewObject)
+		require.NoError(t, err)
+		assert.Equal(t, sourceID, copied.GetID())
+	}
+
+	// The SHA-256 of "data"
+	chunk := "3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"
+	keys, err := store.ListObjects(ctx, "plugins/vsphere-astrolabe-repo/ivd/")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"plugins/vsphere-astrolabe-repo/ivd/chunks/ivd:vol-1/" + chunk,
+		"plugins/vsphere-astrolabe-repo/ivd/chunks/ivd:vol-10/" + chunk,
+		"plugins/vsphere-astrolabe-repo/ivd/index/" + id.String(),
+		"plugins/vsphere-astrolabe-repo/ivd/index/" + otherID.String(),
+		"plugins/vsphere-astrolabe-repo/ivd/latest/ivd:vol-1",
+		"plugins/vsphere-astrolabe-repo/ivd/latest/ivd:vol-10",
+		"plugins/vsphere-astrolabe-repo/ivd/manifest/" + id.String(),
+		"plugins/vsphere-astrolabe-repo/ivd/manifest/" + otherID.String(),
+		"plugins/vsphere-astrolabe-repo/ivd/md/" + id.String(),
+		"plugins/vsphere-astrolabe-repo/ivd/md/" + otherID.String(),
+		"plugins/vsphere-astrolabe-repo/ivd/peinfo/" + id.String(),
+		"plugins/vsphere-astrolabe-repo/ivd/peinfo/" + otherID.String(),
+	}, keys)
+
+	ids, err := petm.GetProtectedEntities(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []astrolabe.ProtectedEntityID{id, otherID}, ids)
+
+	pe, err := petm.GetProtectedEntity(ctx, id)
+	require.NoError(t, err)
+	info, err := pe.GetInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "pvc-1", info.GetName())
+	size, err := pe.(SizedProtectedEntity).GetSize(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), size)
+
+	reader, err := pe.GetDataReader(ctx)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	reader, err = pe.GetMetadataReader(ctx)
+	require.NoError(t, err)
+	metadata, err := ioutil.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "metadata", string(metadata))
+
+	snapshotIDs, err := pe.ListSnapshots(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []astrolabe.ProtectedEntitySnapshotID{id.GetSnapshotID()}, snapshotIDs)
+
+	deleted, err := pe.DeleteSnapshot(ctx, id.GetSnapshotID())
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, err = petm.GetProtectedEntity(ctx, id)
+	assert.True(t, IsNotFound(err))
+	_, err = petm.GetProtectedEntity(ctx, otherID)
+	assert.NoError(t, err)
+
+	// The chunk of the deleted snapshot is no longer referenced
+	keys, err = store.ListObjects(ctx, "plugins/vsphere-astrolabe-repo/ivd/chunks/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"plugins/vsphere-astrolabe-repo/ivd/chunks/ivd:vol-10/" + chunk}, keys)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"io"
+)
+
+// S3Store is an ObjectStore in an S3 bucket.
+type S3Store struct {
+	client   *s3.S3
+	uploader *s3manager.Uploader
+	bucket   string
+}
+
+func NewS3Store(sess *session.Session, bucket string) *S3Store {
+	client := s3.New(sess)
+	return &S3Store{
+		client:   client,
+		uploader: s3manager.NewUploaderWithClient(client),
+		bucket:   bucket,
+	}
+}
+
+func (this *S3Store) PutObject(ctx context.Context, key string, body io.Reader) error {
+	_, err := this.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: aws.String(this.bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	})
+	return err
+}
+
+func (this *S3Store) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := this.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(this.bucket),
+		Key:    aws.String(key),
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+// DeleteObject deletes the object. S3 does not fail to delete an object that does not exist.
+func (this *S3Store) DeleteObject(ctx context.Context, key string) error {
+	_, err := this.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(this.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (this *S3Store) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := this.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(this.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			keys = append(keys, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
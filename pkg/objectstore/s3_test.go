@@ -0,0 +1,57 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+// TestS3Store runs against MinIO, e.g. started with
+// docker run -p 9000:9000 -e MINIO_ACCESS_KEY=minio -e MINIO_SECRET_KEY=minio123 minio/minio server /data
+// and MINIO_ENDPOINT=http://127.0.0.1:9000
+func TestS3Store(t *testing.T) {
+	endpoint := os.Getenv("MINIO_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("MINIO_ENDPOINT is not set")
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:           aws.String("minio"),
+		Endpoint:         aws.String(endpoint),
+		S3ForcePathStyle: aws.Bool(true),
+		Credentials:      credentials.NewStaticCredentials("minio", "minio123", ""),
+	})
+	require.NoError(t, err)
+	_, err = s3.New(sess).CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("velero")})
+	if err != nil && !isBucketAlreadyOwned(err) {
+		require.NoError(t, err)
+	}
+
+	testRepository(t, NewS3Store(sess, "velero"))
+}
+
+func isBucketAlreadyOwned(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == s3.ErrCodeBucketAlreadyOwnedByYou
+}
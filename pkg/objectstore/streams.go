@@ -0,0 +1,143 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/encryption"
+	"sync"
+)
+
+// The chunks of the data of a snapshot are separate objects, so they are uploaded and downloaded with a number of
+// concurrent streams. The data is still read from the ProtectedEntity, and written to it on restore, in order, while
+// the chunks are uploaded, or downloaded ahead of the reads, in parallel. The number of streams of a copy is set in
+// the context of the copy with WithTransferStreams, as the ProtectedEntityTypeManager interface has no room for it.
+
+// DefaultTransferStreams is the number of concurrent streams a snapshot is transferred with by default.
+const DefaultTransferStreams = 4
+
+type transferStreamsKey struct{}
+
+// WithTransferStreams returns a context in which the data of snapshots is transferred with the given number of
+// concurrent streams. The default number of streams is used if streams is not positive.
+func WithTransferStreams(ctx context.Context, streams int) context.Context {
+	if streams <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, transferStreamsKey{}, streams)
+}
+
+func transferStreams(ctx context.Context) int {
+	if streams, ok := ctx.Value(transferStreamsKey{}).(int); ok {
+		return streams
+	}
+	return DefaultTransferStreams
+}
+
+// chunkUploader compresses, encrypts if it has a cipher, and uploads chunks in the background, with a number of
+// concurrent streams.
+type chunkUploader struct {
+	ctx     context.Context
+	petm    *ProtectedEntityTypeManager
+	id      astrolabe.ProtectedEntityID
+	encoder *zstd.Encoder
+	cipher  *encryption.ChunkCipher
+	buffers chan []byte
+	pending sync.WaitGroup
+
+	mutex sync.Mutex
+	err   error
+}
+
+func (this *ProtectedEntityTypeManager) newChunkUploader(ctx context.Context, id astrolabe.ProtectedEntityID, encoder *zstd.Encoder, chunkCipher *encryption.ChunkCipher) *chunkUploader {
+	streams := transferStreams(ctx)
+	buffers := make(chan []byte, streams)
+	for i := 0; i < streams; i++ {
+		buffers <- make([]byte, 0, this.chunkSize)
+	}
+	return &chunkUploader{
+		ctx:     ctx,
+		petm:    this,
+		id:      id,
+		encoder: encoder,
+		cipher:  chunkCipher,
+		buffers: buffers,
+	}
+}
+
+// upload starts uploading the chunk with the given hash once a stream is available. The chunk is copied, so the
+// caller may reuse it. The error of a previous upload that failed is returned, if any.
+func (this *chunkUploader) upload(hash string, chunk []byte) error {
+	if err := this.failed(); err != nil {
+		return err
+	}
+	var buffer []byte
+	select {
+	case buffer = <-this.buffers:
+	case <-this.ctx.Done():
+		return this.ctx.Err()
+	}
+	buffer = append(buffer[:0], chunk...)
+
+	this.pending.Add(1)
+	go func() {
+		defer this.pending.Done()
+		defer func() {
+			this.buffers <- buffer
+		}()
+		compressed := this.encoder.EncodeAll(buffer, nil)
+		if this.cipher != nil {
+			compressed = this.cipher.Seal(nil, hash, compressed)
+		}
+		err := this.petm.store.PutObject(this.ctx, this.petm.volumeChunkPrefix(this.id)+hash, limitReader(this.ctx, bytes.NewReader(compressed)))
+		if err != nil {
+			this.fail(errors.Wrapf(err, "Failed to upload chunk %s of ProtectedEntity %s", hash, this.id.String()))
+		}
+	}()
+	return nil
+}
+
+// flush waits for the chunks being uploaded, and returns the error of the first upload that failed, if any.
+func (this *chunkUploader) flush() error {
+	this.pending.Wait()
+	return this.failed()
+}
+
+func (this *chunkUploader) fail(err error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.err == nil {
+		this.err = err
+	}
+}
+
+func (this *chunkUploader) failed() error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.err
+}
+
+// chunkDownload is a chunk being downloaded ahead of the reads of the data.
+type chunkDownload struct {
+	done  chan struct{}
+	chunk []byte
+	err   error
+}
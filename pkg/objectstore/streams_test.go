@@ -0,0 +1,68 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTransferStreams(t *testing.T) {
+	data := "aaaabbbbccccdddd\x00\x00\x00\x00eeeeaaaaff"
+	for _, streams := range []int{1, 3, 16} {
+		t.Run(strconv.Itoa(streams), func(t *testing.T) {
+			ctx := WithTransferStreams(context.Background(), streams)
+			petm := newChunkedPETM(newMemStore())
+			id := snapshotID("snap-1")
+
+			source := newMemStore()
+			require.NoError(t, source.PutObject(ctx, "source/ivd/peinfo/"+id.String(),
+				strings.NewReader(`{"id":"`+id.String()+`","name":"pvc-1","size":`+strconv.Itoa(len(data))+`}`)))
+			require.NoError(t, source.PutObject(ctx, "source/ivd/data/"+id.String(), strings.NewReader(data)))
+			sourcePE, err := NewProtectedEntityTypeManager("ivd", source, "source", petm.logger).GetProtectedEntity(ctx, id)
+			require.NoError(t, err)
+			_, err = petm.Copy(ctx, sourcePE, astrolabe.AllocateNewObject)
+			require.NoError(t, err)
+			chunks, err := petm.listChunks(ctx, id)
+			require.NoError(t, err)
+			assert.Len(t, chunks, 6)
+
+			pe, err := petm.GetProtectedEntity(ctx, id)
+			require.NoError(t, err)
+			reader, err := pe.GetDataReader(ctx)
+			require.NoError(t, err)
+			read, err := ioutil.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, data, string(read))
+			require.NoError(t, reader.Close())
+
+			// Closing the reader stops the downloads ahead of the reads
+			reader, err = pe.GetDataReader(ctx)
+			require.NoError(t, err)
+			_, err = io.ReadFull(reader, make([]byte, 6))
+			require.NoError(t, err)
+			require.NoError(t, reader.Close())
+		})
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"io"
+	"io/ioutil"
+)
+
+// The checksums of a snapshot are recorded in its manifest when it is copied. Each chunk is named after the SHA-256 of
+// its data, or its keyed hash if it is encrypted, and the checksum of the data of the snapshot covers the size of the
+// data and the chunks in order, so that it is computed without reading the chunks an incremental copy skips. A snapshot
+// is verified by reading all its chunks back from the object store, which checks the hash of each chunk, and comparing
+// the chunks and the metadata against the checksums.
+
+const checksumPrefix = "sha256:"
+
+// dataChecksum returns the checksum of data of the given size made of the chunks with the given hashes, the hash of a
+// chunk of zeros being empty.
+func dataChecksum(size int64, chunks []string) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n", size)
+	for _, chunk := range chunks {
+		fmt.Fprintf(hash, "%s\n", chunk)
+	}
+	return checksumPrefix + hex.EncodeToString(hash.Sum(nil))
+}
+
+// SnapshotVerification is the result of the verification of a snapshot in the repository.
+type SnapshotVerification struct {
+	ID astrolabe.ProtectedEntityID
+	// Checksum is the checksum of the data recorded when the snapshot was copied. It is empty if the snapshot was
+	// copied by a previous version, whose data is then only read back.
+	Checksum string
+	// Size is the number of bytes of the data read back.
+	Size int64
+	// Err tells why the snapshot cannot be restored, it is nil if the snapshot is verified.
+	Err error
+}
+
+// Verified returns true if the snapshot can be restored.
+func (this SnapshotVerification) Verified() bool {
+	return this.Err == nil
+}
+
+// Checksum returns the checksum of the data of the snapshot recorded when it was copied, or "" if the snapshot was
+// copied by a previous version.
+func (this *ProtectedEntityTypeManager) Checksum(ctx context.Context, id astrolabe.ProtectedEntityID) (string, error) {
+	m, err := this.readManifest(ctx, id)
+	if IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return m.Checksum, nil
+}
+
+// VerifySnapshot reads the data and the metadata of the snapshot back from the object store and compares them against
+// the checksums recorded when the snapshot was copied. The data is read with the transfer streams and bandwidth
+// limiters of the context, as it is restored.
+func (this *ProtectedEntityTypeManager) VerifySnapshot(ctx context.Context, id astrolabe.ProtectedEntityID) SnapshotVerification {
+	verification := SnapshotVerification{ID: id}
+	verification.Err = this.verifySnapshot(ctx, &verification)
+	log := this.logger.WithField("peID", id.String())
+	if verification.Err != nil {
+		log.WithError(verification.Err).Error("The snapshot failed the verification")
+	} else {
+		log.Infof("The snapshot is verified, %d bytes are read back", verification.Size)
+	}
+	return verification
+}
+
+func (this *ProtectedEntityTypeManager) verifySnapshot(ctx context.Context, verification *SnapshotVerification) error {
+	id := verification.ID
+	if err := this.checkID(id); err != nil {
+		return err
+	}
+	info, err := this.readInfo(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	m, err := this.readManifest(ctx, id)
+	if IsNotFound(err) {
+		// Copied by a previous version
+		reader, err := this.store.GetObject(ctx, this.dataPrefix+id.String())
+		if err != nil {
+			return errors.Wrapf(err, "Failed to get the data of ProtectedEntity %s", id.String())
+		}
+		defer reader.Close()
+		return this.readBack(verification, limitReader(ctx, reader), info.Size)
+	}
+	if err != nil {
+		return err
+	}
+	verification.Checksum = m.Checksum
+
+	resolved, err := this.resolveManifest(ctx, id)
+	if err != nil {
+		return err
+	}
+	if m.Checksum != "" && dataChecksum(resolved.Size, resolved.Chunks) != m.Checksum {
+		return errors.Errorf("The chunks of ProtectedEntity %s do not match checksum %s", id.String(), m.Checksum)
+	}
+	reader, err := this.newChunkReader(ctx, id, resolved)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err = this.readBack(verification, reader, info.Size); err != nil {
+		return err
+	}
+
+	if m.MetadataChecksum == "" {
+		return nil
+	}
+	mdReader, err := this.store.GetObject(ctx, this.mdPrefix+id.String())
+	if err != nil {
+		return errors.Wrapf(err, "Failed to get the metadata of ProtectedEntity %s", id.String())
+	}
+	defer mdReader.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(hash, limitReader(ctx, mdReader)); err != nil {
+		return errors.Wrapf(err, "Failed to read the metadata of ProtectedEntity %s", id.String())
+	}
+	if checksum := checksumPrefix + hex.EncodeToString(hash.Sum(nil)); checksum != m.MetadataChecksum {
+		return errors.Errorf("The metadata of ProtectedEntity %s has checksum %s instead of %s", id.String(), checksum, m.MetadataChecksum)
+	}
+	return nil
+}
+
+// readBack reads all the data and checks that it has the size of the snapshot.
+func (this *ProtectedEntityTypeManager) readBack(verification *SnapshotVerification, data io.Reader, size int64) error {
+	n, err := io.Copy(ioutil.Discard, data)
+	verification.Size = n
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read the data of ProtectedEntity %s", verification.ID.String())
+	}
+	if n != size {
+		return errors.Errorf("The data of ProtectedEntity %s has %d bytes instead of %d", verification.ID.String(), n, size)
+	}
+	return nil
+}
+
+// VerifySnapshots verifies all the snapshots kept in the repository. A snapshot that fails the verification does not
+// stop the verification of the others, and the snapshots deleted while they are verified are left out.
+func (this *ProtectedEntityTypeManager) VerifySnapshots(ctx context.Context) ([]SnapshotVerification, error) {
+	ids, err := this.GetProtectedEntities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	verifications := make([]SnapshotVerification, 0, len(ids))
+	for _, id := range ids {
+		if !id.HasSnapshot() {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return verifications, err
+		}
+		verification := this.VerifySnapshot(ctx, id)
+		if _, err := this.readInfo(ctx, id); IsNotFound(err) {
+			// Deleted while it was verified
+			continue
+		}
+		verifications = append(verifications, verification)
+	}
+	return verifications, nil
+}
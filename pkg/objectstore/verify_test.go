@@ -0,0 +1,85 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"strings"
+	"testing"
+)
+
+func TestVerifySnapshots(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	petm := newChunkedPETM(store)
+	first := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+	second := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-1", astrolabe.NewProtectedEntitySnapshotID("snap-2"))
+	legacy := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-2", astrolabe.NewProtectedEntitySnapshotID("snap-0"))
+
+	copySnapshot(t, petm, first, "aaaabbbb\x00\x00\x00\x00cc")
+	// The second snapshot is incremental to the first one
+	copySnapshot(t, petm, second, "aaaadddd\x00\x00\x00\x00cc")
+	require.NoError(t, store.PutObject(ctx, "repo/ivd/peinfo/"+legacy.String(),
+		strings.NewReader(`{"id":"`+legacy.String()+`","name":"pvc-2","size":4}`)))
+	require.NoError(t, store.PutObject(ctx, "repo/ivd/data/"+legacy.String(), strings.NewReader("data")))
+	require.NoError(t, store.PutObject(ctx, "repo/ivd/md/"+second.String(), strings.NewReader("metadata")))
+
+	checksum, err := petm.Checksum(ctx, second)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(checksum, checksumPrefix))
+
+	verifications, err := petm.VerifySnapshots(ctx)
+	require.NoError(t, err)
+	require.Len(t, verifications, 3)
+	for _, verification := range verifications {
+		assert.True(t, verification.Verified(), verification.ID.String())
+	}
+	assert.Equal(t, int64(14), verifications[0].Size)
+	assert.Equal(t, checksum, verifications[1].Checksum)
+	assert.Empty(t, verifications[2].Checksum)
+
+	// The metadata that was not copied with the snapshot is not verified, a change to the one that was is detected.
+	m, err := petm.readManifest(ctx, second)
+	require.NoError(t, err)
+	m.MetadataChecksum = dataChecksum(0, nil)
+	require.NoError(t, petm.putManifest(ctx, second, m))
+	assert.Error(t, petm.VerifySnapshot(ctx, second).Err)
+	m.MetadataChecksum = ""
+
+	// A manifest that lists other chunks does not match the checksum.
+	m.Changes[0].Hash = m.Changes[0].Hash[1:] + "0"
+	require.NoError(t, petm.putManifest(ctx, second, m))
+	assert.Error(t, petm.VerifySnapshot(ctx, second).Err)
+
+	// A chunk that is corrupted fails the verification of the snapshots that reference it.
+	keys, err := store.ListObjects(ctx, petm.volumeChunkPrefix(first))
+	require.NoError(t, err)
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer encoder.Close()
+	for _, key := range keys {
+		require.NoError(t, store.PutObject(ctx, key, bytes.NewReader(encoder.EncodeAll([]byte("eeee"), nil))))
+	}
+	verification := petm.VerifySnapshot(ctx, first)
+	assert.False(t, verification.Verified())
+	assert.NotEmpty(t, verification.Checksum)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+const (
+	// The parameters of the StorageClasses of the vSphere CSI driver that restrict where its volumes are placed. The
+	// driver matches them case-insensitively.
+	StoragePolicyNameParameter = "storagepolicyname"
+	DatastoreURLParameter      = "datastoreurl"
+)
+
+// ErrNoCompatibleDatastore is returned when no datastore of the vCenter is compatible with a Request. Retrying does not
+// help until the StorageClass or the datastores are changed.
+var ErrNoCompatibleDatastore = errors.New("no compatible datastore")
+
+// Request is where a restored volume is to be placed, as the StorageClass of its PersistentVolumeClaim asks for.
+type Request struct {
+	// StorageClass is the name of the StorageClass the Request is taken from.
+	StorageClass string
+	// StoragePolicyName is the name of the SPBM policy the datastore must be compatible with, if any.
+	StoragePolicyName string
+	// DatastoreURL is the URL of the datastore, if any, such as "ds:///vmfs/volumes/vsan:52c1c1b7f3ad43d3-9f3fb4a1a9e54cd6/".
+	DatastoreURL string
+}
+
+// RequestFromStorageClass returns the Request of the parameters of the StorageClass.
+func RequestFromStorageClass(storageClass *storagev1.StorageClass) Request {
+	request := Request{StorageClass: storageClass.Name}
+	for key, value := range storageClass.Parameters {
+		switch strings.ToLower(key) {
+		case StoragePolicyNameParameter:
+			request.StoragePolicyName = strings.TrimSpace(value)
+		case DatastoreURLParameter:
+			request.DatastoreURL = strings.TrimSpace(value)
+		}
+	}
+	return request
+}
+
+// Empty returns whether the Request leaves the volume on any datastore.
+func (r Request) Empty() bool {
+	return r.StoragePolicyName == "" && r.DatastoreURL == ""
+}
+
+// Datastore is a datastore of the vCenter volumes are restored to.
+type Datastore struct {
+	// ID is the managed object ID of the datastore, such as "datastore-1034".
+	ID        string
+	Name      string
+	URL       string
+	FreeSpace int64
+}
+
+// Choose returns the datastore a volume is placed on among the datastores, those that have the URL of the Request, and
+// whose IDs are in compatible if the Request has a storage policy. It returns the one with the most free space. The
+// error wraps ErrNoCompatibleDatastore if no datastore is compatible.
+func Choose(request Request, datastores []Datastore, compatible map[string]bool) (Datastore, error) {
+	candidates := datastores
+	if request.DatastoreURL != "" {
+		candidates = nil
+		for _, datastore := range datastores {
+			if sameURL(datastore.URL, request.DatastoreURL) {
+				candidates = append(candidates, datastore)
+			}
+		}
+		if len(candidates) == 0 {
+			return Datastore{}, errors.Wrapf(ErrNoCompatibleDatastore, "no datastore has URL %s of StorageClass %s",
+				request.DatastoreURL, request.StorageClass)
+		}
+	}
+	if request.StoragePolicyName != "" {
+		var compliant []Datastore
+		for _, datastore := range candidates {
+			if compatible[datastore.ID] {
+				compliant = append(compliant, datastore)
+			}
+		}
+		if len(compliant) == 0 {
+			return Datastore{}, errors.Wrapf(ErrNoCompatibleDatastore, "no datastore is compatible with storage policy %s of StorageClass %s",
+				request.StoragePolicyName, request.StorageClass)
+		}
+		candidates = compliant
+	}
+	if len(candidates) == 0 {
+		return Datastore{}, errors.Wrapf(ErrNoCompatibleDatastore, "no datastore is accessible")
+	}
+
+	sorted := append([]Datastore(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].FreeSpace != sorted[j].FreeSpace {
+			return sorted[i].FreeSpace > sorted[j].FreeSpace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted[0], nil
+}
+
+// sameURL compares the URLs of datastores, with or without their trailing slash.
+func sameURL(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRequestFromStorageClass(t *testing.T) {
+	request := RequestFromStorageClass(&storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gold"},
+		Parameters: map[string]string{
+			"StoragePolicyName": " vSAN Default Storage Policy ",
+			"datastoreURL":      "ds:///vmfs/volumes/vsan:52c1/",
+			"fstype":            "ext4",
+		},
+	})
+	assert.Equal(t, Request{
+		StorageClass:      "gold",
+		StoragePolicyName: "vSAN Default Storage Policy",
+		DatastoreURL:      "ds:///vmfs/volumes/vsan:52c1/",
+	}, request)
+	assert.False(t, request.Empty())
+	assert.True(t, RequestFromStorageClass(&storagev1.StorageClass{}).Empty())
+}
+
+func TestChoose(t *testing.T) {
+	datastores := []Datastore{
+		{ID: "datastore-1", Name: "vsanDatastore", URL: "ds:///vmfs/volumes/vsan:52c1/", FreeSpace: 100},
+		{ID: "datastore-2", Name: "nfs-1", URL: "ds:///vmfs/volumes/1111-2222/", FreeSpace: 300},
+		{ID: "datastore-3", Name: "nfs-2", URL: "ds:///vmfs/volumes/3333-4444/", FreeSpace: 200},
+	}
+
+	// Without a storage policy or a datastore URL, the datastore with the most free space is chosen.
+	datastore, err := Choose(Request{}, datastores, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "datastore-2", datastore.ID)
+
+	datastore, err = Choose(Request{DatastoreURL: "ds:///vmfs/volumes/vsan:52c1"}, datastores, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "datastore-1", datastore.ID)
+
+	compatible := map[string]bool{"datastore-1": true, "datastore-3": true}
+	datastore, err = Choose(Request{StoragePolicyName: "gold"}, datastores, compatible)
+	require.NoError(t, err)
+	assert.Equal(t, "datastore-3", datastore.ID)
+
+	_, err = Choose(Request{StoragePolicyName: "gold", DatastoreURL: "ds:///vmfs/volumes/1111-2222/"}, datastores, compatible)
+	assert.True(t, errors.Is(err, ErrNoCompatibleDatastore))
+	_, err = Choose(Request{StorageClass: "silver", DatastoreURL: "ds:///vmfs/volumes/5555/"}, datastores, nil)
+	assert.True(t, errors.Is(err, ErrNoCompatibleDatastore))
+	assert.Contains(t, err.Error(), "StorageClass silver")
+	_, err = Choose(Request{}, nil, nil)
+	assert.True(t, errors.Is(err, ErrNoCompatibleDatastore))
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/ivd"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/pbm"
+	pbmtypes "github.com/vmware/govmomi/pbm/types"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Placer places restored volumes on the datastores of a vCenter, and deletes the restored volumes that are not used.
+// It logs in to the vCenter for every call, as volumes are rarely restored.
+type Placer struct {
+	params map[string]interface{}
+	logger logrus.FieldLogger
+}
+
+// NewPlacer returns a Placer for the vCenter of the params, as retrieved by utils.RetrieveVcConfigSecret.
+func NewPlacer(params map[string]interface{}, logger logrus.FieldLogger) *Placer {
+	return &Placer{
+		params: params,
+		logger: logger,
+	}
+}
+
+// Place returns the accessible datastore the volume of the Request is placed on. The error wraps
+// ErrNoCompatibleDatastore if no datastore is compatible with the Request.
+func (this *Placer) Place(ctx context.Context, request Request) (Datastore, error) {
+	client, logout, err := this.login(ctx)
+	if err != nil {
+		return Datastore{}, err
+	}
+	defer logout()
+
+	managedDatastores, err := this.accessibleDatastores(ctx, client)
+	if err != nil {
+		return Datastore{}, err
+	}
+	var datastores []Datastore
+	var hubs []pbmtypes.PbmPlacementHub
+	for _, managedDatastore := range managedDatastores {
+		reference := managedDatastore.Reference()
+		datastores = append(datastores, Datastore{
+			ID:        reference.Value,
+			Name:      managedDatastore.Summary.Name,
+			URL:       managedDatastore.Summary.Url,
+			FreeSpace: managedDatastore.Summary.FreeSpace,
+		})
+		hubs = append(hubs, pbmtypes.PbmPlacementHub{HubType: reference.Type, HubId: reference.Value})
+	}
+
+	var compatible map[string]bool
+	if request.StoragePolicyName != "" && len(hubs) > 0 {
+		if compatible, err = this.compatibleDatastores(ctx, client, request, hubs); err != nil {
+			return Datastore{}, err
+		}
+	}
+	return Choose(request, datastores, compatible)
+}
+
+// DeleteVolume deletes the First Class Disk with the given ID from the accessible datastore it is on. A volume that is
+// on none of them is already deleted.
+func (this *Placer) DeleteVolume(ctx context.Context, volumeID string) error {
+	client, logout, err := this.login(ctx)
+	if err != nil {
+		return err
+	}
+	defer logout()
+
+	manager := client.ServiceContent.VStorageObjectManager
+	if manager == nil {
+		return errors.New("vCenter has no storage object manager")
+	}
+	managedDatastores, err := this.accessibleDatastores(ctx, client)
+	if err != nil {
+		return err
+	}
+	id := types.ID{Id: volumeID}
+	var retrieveErr error
+	for _, managedDatastore := range managedDatastores {
+		retrieve := types.RetrieveVStorageObject{This: *manager, Id: id, Datastore: managedDatastore.Reference()}
+		if _, err := methods.RetrieveVStorageObject(ctx, client.Client, &retrieve); err != nil {
+			if !isNotFound(err) {
+				retrieveErr = err
+			}
+			continue
+		}
+		request := types.DeleteVStorageObject_Task{This: *manager, Id: id, Datastore: managedDatastore.Reference()}
+		response, err := methods.DeleteVStorageObject_Task(ctx, client.Client, &request)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to delete volume %s", volumeID)
+		}
+		if err := object.NewTask(client.Client, response.Returnval).Wait(ctx); err != nil {
+			return errors.Wrapf(err, "Failed to delete volume %s", volumeID)
+		}
+		this.logger.Infof("Volume %s is deleted from datastore %s", volumeID, managedDatastore.Summary.Name)
+		return nil
+	}
+	if retrieveErr != nil {
+		return errors.Wrapf(retrieveErr, "Failed to find volume %s", volumeID)
+	}
+	this.logger.Infof("Volume %s is not found, it is already deleted", volumeID)
+	return nil
+}
+
+// login logs in to the vCenter, and returns the client along with the func that logs out of it.
+func (this *Placer) login(ctx context.Context) (*govmomi.Client, func(), error) {
+	vcURL, insecure, err := ivd.VcenterURL(this.params)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := govmomi.NewClient(ctx, vcURL, insecure)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Failed to log in to vCenter %s", vcURL.Host)
+	}
+	logout := func() {
+		if err := client.Logout(ctx); err != nil {
+			this.logger.WithError(err).Warnf("Failed to log out of vCenter %s", vcURL.Host)
+		}
+	}
+	return client, logout, nil
+}
+
+// accessibleDatastores returns the datastores of the vCenter that are accessible, with their summary.
+func (this *Placer) accessibleDatastores(ctx context.Context, client *govmomi.Client) ([]mo.Datastore, error) {
+	manager := view.NewManager(client.Client)
+	containerView, err := manager.CreateContainerView(ctx, client.ServiceContent.RootFolder, []string{"Datastore"}, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list the datastores")
+	}
+	defer containerView.Destroy(ctx)
+	var managedDatastores []mo.Datastore
+	if err := containerView.Retrieve(ctx, []string{"Datastore"}, []string{"summary"}, &managedDatastores); err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve the datastores")
+	}
+	var accessible []mo.Datastore
+	for _, managedDatastore := range managedDatastores {
+		if managedDatastore.Summary.Accessible {
+			accessible = append(accessible, managedDatastore)
+		}
+	}
+	return accessible, nil
+}
+
+// compatibleDatastores returns the IDs of the hubs that are compatible with the storage policy of the Request.
+func (this *Placer) compatibleDatastores(ctx context.Context, client *govmomi.Client, request Request, hubs []pbmtypes.PbmPlacementHub) (map[string]bool, error) {
+	pbmClient, err := pbm.NewClient(ctx, client.Client)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to connect to the storage policy service")
+	}
+	profileID, err := pbmClient.ProfileIDByName(ctx, request.StoragePolicyName)
+	if err != nil {
+		return nil, errors.Wrapf(ErrNoCompatibleDatastore, "storage policy %s of StorageClass %s is not found: %v",
+			request.StoragePolicyName, request.StorageClass, err)
+	}
+	requirements := []pbmtypes.BasePbmPlacementRequirement{
+		&pbmtypes.PbmPlacementCapabilityProfileRequirement{
+			ProfileId: pbmtypes.PbmProfileId{UniqueId: profileID},
+		},
+	}
+	result, err := pbmClient.CheckRequirements(ctx, hubs, nil, requirements)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to check the datastores against storage policy %s", request.StoragePolicyName)
+	}
+	compatible := make(map[string]bool)
+	for _, hub := range result.CompatibleDatastores() {
+		compatible[hub.HubId] = true
+	}
+	return compatible, nil
+}
+
+// isNotFound returns whether the error is the NotFound fault of a storage object that is not on a datastore.
+func isNotFound(err error) bool {
+	if !soap.IsSoapFault(err) {
+		return false
+	}
+	_, ok := soap.ToSoapFault(err).VimFault().(types.NotFound)
+	return ok
+}
@@ -20,19 +20,36 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/migration"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/snapshotmgr"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
 	"github.com/vmware-tanzu/velero/pkg/plugin/velero"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"os"
 )
 
+// restoreMappingConfigMapKey is the key of the VolumeSnapshotLocation config that names the restore mapping ConfigMap,
+// whose datacenters entry maps the regions of the restored PersistentVolumes.
+const restoreMappingConfigMapKey = "restoreMappingConfigMap"
+
+// changeStorageClassSelector selects the ConfigMap of the Velero change-storage-class restore item action, whose data
+// maps the StorageClasses of the restored PersistentVolumes and PersistentVolumeClaims.
+const changeStorageClassSelector = "velero.io/plugin-config,velero.io/change-storage-class=RestoreItemAction"
+
 // NewVolumeSnapshotter is a plugin for containing state for the blockstore
 type NewVolumeSnapshotter struct {
 	config map[string]string
 	logrus.FieldLogger
-	snapMgr *snapshotmgr.SnapshotManager
+	snapMgr        *snapshotmgr.SnapshotManager
+	kubeClient     kubernetes.Interface
+	veleroNs       string
+	restoreMapping *migration.Source
 }
 
 var _ velero.VolumeSnapshotter = (*NewVolumeSnapshotter)(nil)
@@ -59,6 +76,25 @@ func (p *NewVolumeSnapshotter) Init(config map[string]string) error {
 		return err
 	}
 
+	restoreMappingConfigMap, ok := config[restoreMappingConfigMapKey]
+	if !ok {
+		restoreMappingConfigMap = migration.DefaultConfigMapName
+	}
+	veleroNs, exist := os.LookupEnv("VELERO_NAMESPACE")
+	if !exist {
+		err = errors.New("Failed to lookup the env variable for velero namespace")
+		p.WithError(err).Errorf("Failed to initialize the restore mapping")
+		return err
+	}
+	p.veleroNs = veleroNs
+	p.kubeClient, err = newKubeClient()
+	if err != nil {
+		p.WithError(err).Errorf("Failed to get k8s clientset")
+		return err
+	}
+	p.restoreMapping = migration.NewSource(p.kubeClient, veleroNs, restoreMappingConfigMap)
+	p.snapMgr.SetRestoreMapping(p.restoreMapping)
+
 	p.Infof("vSphere VolumeSnapshotter is initialized")
 	return nil
 }
@@ -71,13 +107,39 @@ func (p *NewVolumeSnapshotter) CreateVolumeFromSnapshot(snapshotID, volumeType,
 	var returnVolumeID, returnVolumeType string
 
 	var peId, returnPeId astrolabe.ProtectedEntityID
-	var err error
-	peId, err = astrolabe.NewProtectedEntityIDFromString(snapshotID)
+	peId, bslName, err := utils.ParseSnapshotID(snapshotID)
 	if err != nil {
 		p.WithError(err).Errorf("Fail to construct new PE ID from string %s", snapshotID)
 		return returnVolumeID, err
 	}
-	returnPeId, err = p.snapMgr.CreateVolumeFromSnapshot(peId)
+
+	// Read from the backup storage location the snapshot was uploaded to. The snapshot IDs of the backups taken by
+	// previous versions do not record it, which is then the location of the backup being restored.
+	if bslName == "" {
+		bslName, err = utils.RetrieveBSLNameOfRestoresInProgress(p.FieldLogger)
+		if err != nil {
+			p.WithError(err).Errorf("Failed to retrieve the backup storage location of the restore")
+			return returnVolumeID, err
+		}
+	}
+	p.Infof("Restoring from the backup storage location %q", bslName)
+
+	// The volume type is the StorageClass of the PV at the time of the backup, see GetVolumeInfo. The backups taken
+	// before it was recorded have the type of the volume instead, whose placement is left to the data manager.
+	storageClass := volumeType
+	if storageClass == utils.CnsBlockVolumeType {
+		storageClass = ""
+	}
+	// The restored PV is given the StorageClass that the change-storage-class restore item action changes it to,
+	// which the volume is placed for.
+	if storageClass != "" {
+		storageClass, err = p.changeStorageClass(storageClass)
+		if err != nil {
+			p.WithError(err).Errorf("Failed to change the StorageClass of snapshot %s", snapshotID)
+			return returnVolumeID, err
+		}
+	}
+	returnPeId, err = p.snapMgr.CreateVolumeFromSnapshot(peId, backuprepository.NewReference("", bslName, nil), storageClass)
 	if err != nil {
 		p.WithError(err).Errorf("Failed at calling SnapshotManager CreateVolumeFromSnapshot with peId %v", peId)
 		return returnVolumeID, err
@@ -91,13 +153,75 @@ func (p *NewVolumeSnapshotter) CreateVolumeFromSnapshot(snapshotID, volumeType,
 	return returnVolumeID, nil
 }
 
+// changeStorageClass returns the StorageClass that the ConfigMap of the change-storage-class restore item action
+// changes the StorageClass with the given name to, or the StorageClass itself if it is not changed.
+func (p *NewVolumeSnapshotter) changeStorageClass(storageClass string) (string, error) {
+	configMaps, err := p.kubeClient.CoreV1().ConfigMaps(p.veleroNs).List(metav1.ListOptions{LabelSelector: changeStorageClassSelector})
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to list the ConfigMaps of the change-storage-class restore item action")
+	}
+	if len(configMaps.Items) == 0 {
+		return storageClass, nil
+	}
+	if len(configMaps.Items) > 1 {
+		return "", errors.Errorf("Found %d ConfigMaps matching %s", len(configMaps.Items), changeStorageClassSelector)
+	}
+	if target, ok := configMaps.Items[0].Data[storageClass]; ok && target != "" {
+		p.Infof("StorageClass %s is changed to %s by ConfigMap %s", storageClass, target, configMaps.Items[0].Name)
+		return target, nil
+	}
+	return storageClass, nil
+}
+
 // GetVolumeInfo returns the type and IOPS (if using provisioned IOPS) for
-// the specified volume in the given availability zone.
+// the specified volume in the given availability zone. The type is the
+// StorageClass of the PV of the volume, which Velero passes back to
+// CreateVolumeFromSnapshot on restore to place the volume for it.
 func (p *NewVolumeSnapshotter) GetVolumeInfo(volumeID, volumeAZ string) (string, *int64, error) {
 	p.Infof("GetVolumeInfo called with volumeID %s, volumeAZ %s", volumeID, volumeAZ)
 	var iops int64
 	iops = 100 // dummy iops is applied
-	return utils.CnsBlockVolumeType, &iops, nil
+	volumeType := utils.CnsBlockVolumeType
+	if pv := p.persistentVolumeOf(volumeID); pv != nil {
+		if storageClass := p.storageClassOf(pv); storageClass != "" {
+			volumeType = storageClass
+		}
+	}
+	return volumeType, &iops, nil
+}
+
+// persistentVolumeOf returns the CSI PV of the volume with the given ID, or nil if it is not found.
+func (p *NewVolumeSnapshotter) persistentVolumeOf(volumeID string) *v1.PersistentVolume {
+	if p.kubeClient == nil {
+		return nil
+	}
+	pvs, err := p.kubeClient.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+	if err != nil {
+		p.WithError(err).Warnf("Failed to list the PVs to find the PV of volume %s", volumeID)
+		return nil
+	}
+	for i := range pvs.Items {
+		if pvs.Items[i].Spec.CSI != nil && pvs.Items[i].Spec.CSI.VolumeHandle == volumeID {
+			return &pvs.Items[i]
+		}
+	}
+	return nil
+}
+
+// storageClassOf returns the StorageClass of the PV, or else of its PVC, or an empty string if neither has one.
+func (p *NewVolumeSnapshotter) storageClassOf(pv *v1.PersistentVolume) string {
+	if pv.Spec.StorageClassName != "" || pv.Spec.ClaimRef == nil {
+		return pv.Spec.StorageClassName
+	}
+	pvc, err := p.kubeClient.CoreV1().PersistentVolumeClaims(pv.Spec.ClaimRef.Namespace).Get(pv.Spec.ClaimRef.Name, metav1.GetOptions{})
+	if err != nil {
+		p.WithError(err).Warnf("Failed to get PVC %s/%s of PV %s", pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name, pv.Name)
+		return ""
+	}
+	if pvc.Spec.StorageClassName == nil {
+		return ""
+	}
+	return *pvc.Spec.StorageClassName
 }
 
 // IsVolumeReady Check if the volume is ready.
@@ -112,6 +236,16 @@ func (p *NewVolumeSnapshotter) CreateSnapshot(volumeID, volumeAZ string, tags ma
 	p.Infof("CreateSnapshot called with volumeID %s, volumeAZ %s, tags %v", volumeID, volumeAZ, tags)
 	var snapshotID string
 
+	// Upload to the backup storage location of the backup in progress
+	if backupName, ok := tags[utils.SnapshotTagVeleroBackup]; ok {
+		bslName, err := utils.RetrieveBSLNameOfBackup(backupName, p.FieldLogger)
+		if err != nil {
+			p.WithError(err).Errorf("Failed to retrieve the backup storage location of backup %s", backupName)
+			return "", err
+		}
+		tags[utils.SnapshotTagBackupStorageLocation] = bslName
+	}
+
 	// call SnapshotMgr CreateSnapshot API
 	peID := astrolabe.NewProtectedEntityID("ivd", volumeID)
 	peID, err := p.snapMgr.CreateSnapshot(peID, tags)
@@ -121,8 +255,8 @@ func (p *NewVolumeSnapshotter) CreateSnapshot(volumeID, volumeAZ string, tags ma
 	}
 
 	// Construct the snapshotID for cns volume
-	snapshotID = peID.String()
-	p.Debugf("The snapshotID depends on the Astrolabe PE ID in the format, <peType>:<id>:<snapshotID>, %s", snapshotID)
+	snapshotID = utils.NewSnapshotID(peID, tags[utils.SnapshotTagBackupStorageLocation])
+	p.Debugf("The snapshotID depends on the Astrolabe PE ID in the format, <peType>:<id>:<snapshotID>@<bsl>, %s", snapshotID)
 
 	p.Infof("CreateSnapshot completed with snapshotID, %s", snapshotID)
 	return snapshotID, nil
@@ -131,13 +265,13 @@ func (p *NewVolumeSnapshotter) CreateSnapshot(volumeID, volumeAZ string, tags ma
 // DeleteSnapshot deletes the specified volume snapshot.
 func (p *NewVolumeSnapshotter) DeleteSnapshot(snapshotID string) error {
 	p.Infof("DeleteSnapshot called with snapshotID %s", snapshotID)
-	peID, err := astrolabe.NewProtectedEntityIDFromString(snapshotID)
+	peID, bslName, err := utils.ParseSnapshotID(snapshotID)
 	if err != nil {
 		p.WithError(err).Errorf("Fail to construct new Protected Entity ID from string %s", snapshotID)
 		return err
 	}
 
-	err = p.snapMgr.DeleteSnapshot(peID)
+	err = p.snapMgr.DeleteSnapshot(peID, backuprepository.NewReference("", bslName, nil))
 	if err != nil {
 		p.WithError(err).Errorf("Failed at calling SnapshotManager DeleteSnapshot for peID %v", peID)
 		return err
@@ -186,6 +320,21 @@ func (p *NewVolumeSnapshotter) SetVolumeID(unstructuredPV runtime.Unstructured,
 	p.Debugf("Set VolumeID, %s, to vSphere CSI VolumeHandle", volumeID)
 	pv.Spec.CSI.VolumeHandle = volumeID
 
+	// The PV may be restored from a backup of a cluster in another datacenter
+	mapping, err := p.restoreMapping.Get()
+	if err != nil {
+		p.WithError(err).Errorf("Failed to get the restore mapping")
+		return nil, err
+	}
+	relocated, err := mapping.RelocatePersistentVolume(pv)
+	if err != nil {
+		p.WithError(err).Errorf("Failed to map the region of PV %s", pv.Name)
+		return nil, err
+	}
+	if relocated {
+		p.Infof("The region of PV %s is mapped to a datacenter of this cluster", pv.Name)
+	}
+
 	res, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pv)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -196,3 +345,11 @@ func (p *NewVolumeSnapshotter) SetVolumeID(unstructuredPV runtime.Unstructured,
 
 	return unstructuredPV, nil
 }
+
+func newKubeClient() (kubernetes.Interface, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
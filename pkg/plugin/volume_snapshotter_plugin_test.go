@@ -0,0 +1,111 @@
+/*
+ * Copyright 2020 the Velero contributors
+ * SPDX-License-Identifier: Apache-2.0
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newPV(name, volumeHandle, storageClass string, claimRef *corev1.ObjectReference) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: "csi.vsphere.vmware.com", VolumeHandle: volumeHandle},
+			},
+			StorageClassName: storageClass,
+			ClaimRef:         claimRef,
+		},
+	}
+}
+
+func TestGetVolumeInfo(t *testing.T) {
+	silver := "silver"
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "data"},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &silver},
+	}
+	nfs := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-nfs"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{NFS: &corev1.NFSVolumeSource{Server: "nfs", Path: "/"}},
+			StorageClassName:       "nfs",
+		},
+	}
+	p := &NewVolumeSnapshotter{FieldLogger: logrus.New(), kubeClient: kubefake.NewSimpleClientset(
+		pvc,
+		nfs,
+		newPV("pv-gold", "fcd-gold", "gold", nil),
+		newPV("pv-claimed", "fcd-claimed", "", &corev1.ObjectReference{Namespace: "app", Name: "data"}),
+		newPV("pv-static", "fcd-static", "", nil),
+	)}
+
+	for volumeID, expected := range map[string]string{
+		"fcd-gold":    "gold",
+		"fcd-claimed": "silver",
+		"fcd-static":  utils.CnsBlockVolumeType,
+		"fcd-unknown": utils.CnsBlockVolumeType,
+	} {
+		volumeType, _, err := p.GetVolumeInfo(volumeID, "")
+		require.NoError(t, err)
+		assert.Equal(t, expected, volumeType, volumeID)
+	}
+}
+
+func TestChangeStorageClass(t *testing.T) {
+	changeStorageClass := func(name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "velero",
+				Name:      name,
+				Labels:    map[string]string{"velero.io/plugin-config": "", "velero.io/change-storage-class": "RestoreItemAction"},
+			},
+			Data: map[string]string{"gold": "vsan-gold"},
+		}
+	}
+	other := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "other", Labels: map[string]string{"velero.io/plugin-config": ""}},
+		Data:       map[string]string{"gold": "bronze"},
+	}
+
+	// Without the ConfigMap, the StorageClasses are not changed
+	p := &NewVolumeSnapshotter{FieldLogger: logrus.New(), kubeClient: kubefake.NewSimpleClientset(other), veleroNs: "velero"}
+	storageClass, err := p.changeStorageClass("gold")
+	require.NoError(t, err)
+	assert.Equal(t, "gold", storageClass)
+
+	p.kubeClient = kubefake.NewSimpleClientset(other, changeStorageClass("change-storage-class"))
+	storageClass, err = p.changeStorageClass("gold")
+	require.NoError(t, err)
+	assert.Equal(t, "vsan-gold", storageClass)
+	storageClass, err = p.changeStorageClass("silver")
+	require.NoError(t, err)
+	assert.Equal(t, "silver", storageClass)
+
+	// Velero fails the restore item action too
+	p.kubeClient = kubefake.NewSimpleClientset(changeStorageClass("a"), changeStorageClass("b"))
+	_, err = p.changeStorageClass("gold")
+	assert.Error(t, err)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retention
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+)
+
+// Decision tells whether a snapshot of a repository is deleted, and why.
+type Decision struct {
+	Snapshot objectstore.IndexedSnapshot
+	Delete   bool
+	Reason   string
+}
+
+// Plan decides which of the snapshots of a repository are deleted. The snapshots of Velero backups are kept as long as
+// their backup exists, Velero deletes them with the backup, and are deleted once it does not exist anymore if
+// deleteOrphans is set. backups are the names of the Velero backups that exist, as the snapshots are labeled with. The
+// policy applies to the other snapshots, e.g. those taken for backupdriver Snapshots, but the last of them of each
+// volume is always kept, as the next snapshot of the volume is copied incrementally to it. The snapshots that were
+// copied before the snapshots were indexed are kept. The decisions are in the order of the IDs of the snapshots.
+func Plan(snapshots []objectstore.IndexedSnapshot, policy Policy, deleteOrphans bool, backups map[string]bool, now time.Time) []Decision {
+	decisions := make([]Decision, 0, len(snapshots))
+	volumes := make(map[string][]objectstore.IndexedSnapshot)
+	for _, snapshot := range snapshots {
+		switch {
+		case snapshot.Origin == nil:
+			decisions = append(decisions, Decision{Snapshot: snapshot, Reason: "the snapshot is not indexed"})
+		case snapshot.Origin.Backup != "" && backups[snapshot.Origin.Backup]:
+			decisions = append(decisions, Decision{Snapshot: snapshot, Reason: fmt.Sprintf("backup %s exists", snapshot.Origin.Backup)})
+		case snapshot.Origin.Backup != "" && deleteOrphans:
+			decisions = append(decisions, Decision{Snapshot: snapshot, Delete: true,
+				Reason: fmt.Sprintf("backup %s does not exist anymore", snapshot.Origin.Backup)})
+		case snapshot.Origin.Backup != "":
+			decisions = append(decisions, Decision{Snapshot: snapshot,
+				Reason: fmt.Sprintf("backup %s does not exist anymore, but orphaned snapshots are kept", snapshot.Origin.Backup)})
+		default:
+			volume := snapshot.ID.GetID()
+			volumes[volume] = append(volumes[volume], snapshot)
+		}
+	}
+	for _, volumeSnapshots := range volumes {
+		decisions = append(decisions, planVolume(volumeSnapshots, policy, now)...)
+	}
+
+	sort.Slice(decisions, func(i, j int) bool {
+		return decisions[i].Snapshot.ID.String() < decisions[j].Snapshot.ID.String()
+	})
+	return decisions
+}
+
+// planVolume applies the policy to the indexed snapshots of a volume.
+func planVolume(snapshots []objectstore.IndexedSnapshot, policy Policy, now time.Time) []Decision {
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Origin.Timestamp.After(snapshots[j].Origin.Timestamp)
+	})
+
+	keptByCounts := make(map[int]string)
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	for i, snapshot := range snapshots {
+		timestamp := snapshot.Origin.Timestamp.UTC()
+		day := timestamp.Format("2006-01-02")
+		if !days[day] && len(days) < policy.KeepDaily {
+			days[day] = true
+			keptByCounts[i] = "daily"
+		}
+		year, week := timestamp.ISOWeek()
+		weekName := fmt.Sprintf("%d-W%02d", year, week)
+		if !weeks[weekName] && len(weeks) < policy.KeepWeekly {
+			weeks[weekName] = true
+			if _, ok := keptByCounts[i]; !ok {
+				keptByCounts[i] = "weekly"
+			}
+		}
+	}
+
+	decisions := make([]Decision, 0, len(snapshots))
+	for i, snapshot := range snapshots {
+		decision := Decision{Snapshot: snapshot}
+		age := now.Sub(snapshot.Origin.Timestamp)
+		rule, kept := keptByCounts[i]
+		switch {
+		case i == 0:
+			decision.Reason = "it is the last snapshot of the volume"
+		case policy.IsZero():
+			decision.Reason = "there is no retention policy"
+		case policy.MaxAge > 0 && age > policy.MaxAge:
+			decision.Delete = true
+			decision.Reason = fmt.Sprintf("it is older than %s", policy.MaxAge)
+		case policy.KeepDaily == 0 && policy.KeepWeekly == 0:
+			decision.Reason = fmt.Sprintf("it is not older than %s", policy.MaxAge)
+		case kept:
+			decision.Reason = fmt.Sprintf("it is a %s snapshot the policy keeps", rule)
+		default:
+			decision.Delete = true
+			decision.Reason = "it is not one of the daily or weekly snapshots the policy keeps"
+		}
+		decisions = append(decisions, decision)
+	}
+	return decisions
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func snapshotAt(volume string, snapshot string, timestamp time.Time, backup string) objectstore.IndexedSnapshot {
+	return objectstore.IndexedSnapshot{
+		ID:     astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", volume, astrolabe.NewProtectedEntitySnapshotID(snapshot)),
+		Origin: &objectstore.SnapshotOrigin{Timestamp: timestamp, Backup: backup},
+	}
+}
+
+func deleted(decisions []Decision) []string {
+	var ids []string
+	for _, decision := range decisions {
+		if decision.Delete {
+			ids = append(ids, decision.Snapshot.ID.GetSnapshotID().String())
+		}
+	}
+	return ids
+}
+
+func TestPlan(t *testing.T) {
+	// Wednesday
+	now := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int, hour int) time.Time {
+		return time.Date(2020, 7, 1-days, hour, 0, 0, 0, time.UTC)
+	}
+	snapshots := []objectstore.IndexedSnapshot{
+		snapshotAt("vol-1", "snap-today", daysAgo(0, 2), ""),
+		snapshotAt("vol-1", "snap-yesterday-late", daysAgo(1, 22), ""),
+		snapshotAt("vol-1", "snap-yesterday-early", daysAgo(1, 2), ""),
+		snapshotAt("vol-1", "snap-last-week", daysAgo(7, 2), ""),
+		snapshotAt("vol-1", "snap-two-weeks-ago", daysAgo(14, 2), ""),
+		snapshotAt("vol-1", "snap-a-year-ago", daysAgo(365, 2), ""),
+		snapshotAt("vol-2", "snap-old", daysAgo(365, 2), ""),
+		snapshotAt("vol-3", "snap-backup", daysAgo(365, 2), "backup-1"),
+		snapshotAt("vol-3", "snap-orphan", daysAgo(365, 2), "deleted-backup"),
+		{ID: astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "vol-4", astrolabe.NewProtectedEntitySnapshotID("snap-legacy"))},
+	}
+	backups := map[string]bool{"backup-1": true}
+
+	tests := []struct {
+		name          string
+		policy        Policy
+		deleteOrphans bool
+		deleted       []string
+	}{
+		{
+			name:          "Without a policy only orphans are deleted",
+			deleteOrphans: true,
+			deleted:       []string{"snap-orphan"},
+		},
+		{
+			name:    "Orphans are kept unless they are deleted",
+			policy:  Policy{},
+			deleted: nil,
+		},
+		{
+			name:    "Daily snapshots",
+			policy:  Policy{KeepDaily: 2},
+			deleted: []string{"snap-a-year-ago", "snap-last-week", "snap-two-weeks-ago", "snap-yesterday-early"},
+		},
+		{
+			name:    "Daily and weekly snapshots",
+			policy:  Policy{KeepDaily: 2, KeepWeekly: 3},
+			deleted: []string{"snap-a-year-ago", "snap-yesterday-early"},
+		},
+		{
+			name:    "Max age",
+			policy:  Policy{MaxAge: 10 * 24 * time.Hour},
+			deleted: []string{"snap-a-year-ago", "snap-two-weeks-ago"},
+		},
+		{
+			name:          "Max age applies to the snapshots kept by the counts",
+			policy:        Policy{KeepDaily: 30, MaxAge: 36 * time.Hour},
+			deleteOrphans: true,
+			deleted:       []string{"snap-a-year-ago", "snap-last-week", "snap-orphan", "snap-two-weeks-ago", "snap-yesterday-early"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decisions := Plan(append([]objectstore.IndexedSnapshot(nil), snapshots...), test.policy, test.deleteOrphans, backups, now)
+			require.Len(t, decisions, len(snapshots))
+			assert.ElementsMatch(t, test.deleted, deleted(decisions))
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("keepDaily=7, keepWeekly=4,maxAge=2160h")
+	require.NoError(t, err)
+	assert.Equal(t, Policy{KeepDaily: 7, KeepWeekly: 4, MaxAge: 2160 * time.Hour}, policy)
+
+	policy, err = ParsePolicy("")
+	require.NoError(t, err)
+	assert.True(t, policy.IsZero())
+
+	for _, invalid := range []string{"keepDaily", "keepDaily=-1", "keepMonthly=2", "maxAge=90d"} {
+		_, err := ParsePolicy(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	policies, err := ParseRepositoryPolicies("# nightly backups\nBackupStorageLocation/default: keepDaily=7\n\nBackupRepository/br-1: maxAge=720h\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Policy{
+		"BackupStorageLocation/default": {KeepDaily: 7},
+		"BackupRepository/br-1":         {MaxAge: 720 * time.Hour},
+	}, policies)
+}
+
+func TestSource(t *testing.T) {
+	defaults := Config{Default: Policy{KeepDaily: 7}, DeleteOrphans: true}
+	kubeClient := kubefake.NewSimpleClientset()
+	source := NewSource(kubeClient, "velero", "retention-policy", defaults)
+
+	config, err := source.Get()
+	require.NoError(t, err)
+	assert.Equal(t, defaults, config)
+
+	_, err = kubeClient.CoreV1().ConfigMaps("velero").Create(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "retention-policy"},
+		Data: map[string]string{
+			ConfigMapRepositoryPoliciesKey: "BackupStorageLocation/secondary: keepWeekly=8",
+			ConfigMapDryRunKey:             "true",
+		},
+	})
+	require.NoError(t, err)
+	config, err = source.Get()
+	require.NoError(t, err)
+	assert.True(t, config.DryRun)
+	assert.True(t, config.DeleteOrphans)
+	assert.Equal(t, Policy{KeepWeekly: 8}, config.PolicyFor("BackupStorageLocation/secondary"))
+	assert.Equal(t, Policy{KeepDaily: 7}, config.PolicyFor("BackupStorageLocation/default"))
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retention
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// The entries of the retention policy ConfigMap. Every entry is optional, and overrides the configuration the
+	// data manager was started with.
+	ConfigMapDefaultPolicyKey      = "defaultPolicy"
+	ConfigMapRepositoryPoliciesKey = "repositoryPolicies"
+	ConfigMapDeleteOrphansKey      = "deleteOrphans"
+	ConfigMapDryRunKey             = "dryRun"
+)
+
+// Policy tells which snapshots of a volume are kept in a repository. The last snapshot of each day is kept for the
+// last KeepDaily days that have one, and the last snapshot of each week for the last KeepWeekly weeks that have one.
+// All the snapshots are kept by the counts if both are 0. The snapshots older than MaxAge are expired whether or not
+// the counts keep them, unless MaxAge is 0. The zero Policy keeps all the snapshots.
+type Policy struct {
+	KeepDaily  int
+	KeepWeekly int
+	MaxAge     time.Duration
+}
+
+func (p Policy) IsZero() bool {
+	return p == Policy{}
+}
+
+func (p Policy) String() string {
+	return "keepDaily=" + strconv.Itoa(p.KeepDaily) + ",keepWeekly=" + strconv.Itoa(p.KeepWeekly) + ",maxAge=" + p.MaxAge.String()
+}
+
+// ParsePolicy parses a comma separated list of settings, such as "keepDaily=7,keepWeekly=4,maxAge=2160h". The
+// settings that are not listed are 0. "" is the zero Policy.
+func ParsePolicy(s string) (Policy, error) {
+	var policy Policy
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		setting := strings.SplitN(field, "=", 2)
+		if len(setting) != 2 {
+			return Policy{}, errors.Errorf("invalid retention setting %q, expected <name>=<value>", field)
+		}
+		name, value := strings.TrimSpace(setting[0]), strings.TrimSpace(setting[1])
+		var err error
+		switch name {
+		case "keepDaily":
+			policy.KeepDaily, err = strconv.Atoi(value)
+		case "keepWeekly":
+			policy.KeepWeekly, err = strconv.Atoi(value)
+		case "maxAge":
+			policy.MaxAge, err = time.ParseDuration(value)
+		default:
+			return Policy{}, errors.Errorf("unknown retention setting %q, expected keepDaily, keepWeekly or maxAge", name)
+		}
+		if err != nil {
+			return Policy{}, errors.Wrapf(err, "invalid retention setting %q", field)
+		}
+	}
+	if policy.KeepDaily < 0 || policy.KeepWeekly < 0 || policy.MaxAge < 0 {
+		return Policy{}, errors.Errorf("invalid retention policy %q, the settings must not be negative", s)
+	}
+	return policy, nil
+}
+
+// ParseRepositoryPolicies parses one "<repository>: <policy>" entry per line, such as
+// "BackupStorageLocation/default: keepDaily=7,keepWeekly=4".
+func ParseRepositoryPolicies(s string) (map[string]Policy, error) {
+	policies := make(map[string]Policy)
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// The policy has no colon, while the name of the repository may have one.
+		separator := strings.LastIndex(line, ":")
+		if separator < 0 {
+			return nil, errors.Errorf("invalid repository retention policy %q, expected <repository>: <policy>", line)
+		}
+		policy, err := ParsePolicy(line[separator+1:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid retention policy of repository %s", strings.TrimSpace(line[:separator]))
+		}
+		policies[strings.TrimSpace(line[:separator])] = policy
+	}
+	return policies, nil
+}
+
+// Config is the retention configuration of the remote repositories.
+type Config struct {
+	// Default is the policy of the repositories that are not in Repositories.
+	Default Policy
+	// Repositories are the policies of repositories, by the name of the repository as printed by
+	// backuprepository.Reference.
+	Repositories map[string]Policy
+	// DeleteOrphans deletes the snapshots of the Velero backups that do not exist anymore.
+	DeleteOrphans bool
+	// DryRun only reports the snapshots that would be deleted.
+	DryRun bool
+}
+
+// PolicyFor returns the policy of the repository with the given name.
+func (c Config) PolicyFor(repository string) Policy {
+	if policy, ok := c.Repositories[repository]; ok {
+		return policy
+	}
+	return c.Default
+}
+
+// FromConfigMap returns the configuration with the entries of the ConfigMap that are set replacing those of the given
+// configuration.
+func FromConfigMap(configMap *corev1.ConfigMap, config Config) (Config, error) {
+	var err error
+	if value, ok := configMap.Data[ConfigMapDefaultPolicyKey]; ok {
+		if config.Default, err = ParsePolicy(value); err != nil {
+			return Config{}, err
+		}
+	}
+	if value, ok := configMap.Data[ConfigMapRepositoryPoliciesKey]; ok {
+		if config.Repositories, err = ParseRepositoryPolicies(value); err != nil {
+			return Config{}, err
+		}
+	}
+	if value, ok := configMap.Data[ConfigMapDeleteOrphansKey]; ok {
+		if config.DeleteOrphans, err = strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return Config{}, errors.Wrapf(err, "invalid %s", ConfigMapDeleteOrphansKey)
+		}
+	}
+	if value, ok := configMap.Data[ConfigMapDryRunKey]; ok {
+		if config.DryRun, err = strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return Config{}, errors.Wrapf(err, "invalid %s", ConfigMapDryRunKey)
+		}
+	}
+	return config, nil
+}
+
+// Source supplies the retention configuration of a data manager, from the configuration it was started with and an
+// optional ConfigMap, which is read on every call.
+type Source struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	name       string
+	defaults   Config
+}
+
+// NewSource returns a Source that supplies the defaults with the entries of the ConfigMap with the given name in the
+// namespace, if name is not empty and the ConfigMap exists.
+func NewSource(kubeClient kubernetes.Interface, namespace string, name string, defaults Config) *Source {
+	return &Source{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+		defaults:   defaults,
+	}
+}
+
+func (this *Source) Get() (Config, error) {
+	if this.name == "" {
+		return this.defaults, nil
+	}
+	configMap, err := this.kubeClient.CoreV1().ConfigMaps(this.namespace).Get(this.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return this.defaults, nil
+	}
+	if err != nil {
+		return Config{}, errors.Wrapf(err, "Failed to get retention policy config map %s/%s", this.namespace, this.name)
+	}
+	config, err := FromConfigMap(configMap, this.defaults)
+	if err != nil {
+		return Config{}, errors.Wrapf(err, "Invalid retention policy config map %s/%s", this.namespace, this.name)
+	}
+	return config, nil
+}
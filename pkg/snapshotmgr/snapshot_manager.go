@@ -23,11 +23,19 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
 	"github.com/vmware-tanzu/astrolabe/pkg/ivd"
-	"github.com/vmware-tanzu/astrolabe/pkg/s3repository"
 	v1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/backuprepository"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/builder"
 	plugin_clientset "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned"
+	pluginv1client "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/typed/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/metrics"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/migration"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/placement"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/utils"
+	velerov1api "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"github.com/vmware-tanzu/velero/pkg/label"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
@@ -39,9 +47,16 @@ import (
 
 type SnapshotManager struct {
 	logrus.FieldLogger
-	config  map[string]string
-	ivdPETM *ivd.IVDProtectedEntityTypeManager
-	s3PETM  *s3repository.ProtectedEntityTypeManager
+	config       map[string]string
+	ivdPETM      *ivd.IVDProtectedEntityTypeManager
+	repositories *backuprepository.PETMCache
+	// metrics records the latency of the local snapshots taken on nodeName, nothing is recorded if it is nil
+	metrics  *metrics.ServerMetrics
+	nodeName string
+	// restoreMapping maps the StorageClasses of the restored volumes, nothing is mapped if it is nil
+	restoreMapping *migration.Source
+	// placer deletes the volumes created from snapshots that are not used
+	placer *placement.Placer
 }
 
 func NewSnapshotManagerFromCluster(params map[string]interface{}, config map[string]string, logger logrus.FieldLogger) (*SnapshotManager, error) {
@@ -55,7 +70,7 @@ func NewSnapshotManagerFromCluster(params map[string]interface{}, config map[str
 		logger.Infof("SnapshotManager: vSphere VC credential is retrieved")
 	}
 
-	var s3PETM *s3repository.ProtectedEntityTypeManager
+	var repositoryPETM astrolabe.ProtectedEntityTypeManager
 	var ivdPETM *ivd.IVDProtectedEntityTypeManager
 	var err error
 
@@ -67,8 +82,12 @@ func NewSnapshotManagerFromCluster(params map[string]interface{}, config map[str
 	if !isLocalMode {
 		region, isRegionExist := config["region"]
 		bucket, isBucketExist := config["bucket"]
+		repositoryPath := config[utils.VolumeSnapshotterRepositoryPath]
 
-		if isRegionExist && isBucketExist {
+		if repositoryPath != "" {
+			params["provider"] = utils.ProviderFileSystem
+			params["path"] = repositoryPath
+		} else if isRegionExist && isBucketExist {
 			params["region"] = region
 			params["bucket"] = bucket
 		} else {
@@ -79,15 +98,21 @@ func NewSnapshotManagerFromCluster(params map[string]interface{}, config map[str
 			}
 		}
 
-		logger.Infof("SnapshotManager: Velero Backup Storage Location is retrieved, region=%v, bucket=%v", params["region"], params["bucket"])
+		logger.Infof("SnapshotManager: Velero Backup Storage Location is retrieved, provider=%v, bucket=%v", utils.GetProviderFromParamsMap(params), params["bucket"])
 
-		s3PETM, err = utils.GetS3PETMFromParamsMap(params, logger)
+		err = utils.RetrieveCredentialsFromSecret(params, logger)
 		if err != nil {
-			logger.WithError(err).Errorf("Failed to get s3PETM from params map: region=%v, bucket=%v",
-				params["region"], params["bucket"])
+			logger.WithError(err).Errorf("Could not retrieve the credentials of the backup storage location")
 			return nil, err
 		}
-		logger.Infof("SnapshotManager: Get s3PETM from the params map")
+
+		repositoryPETM, err = utils.GetRepositoryPETMFromParamsMap(params, logger)
+		if err != nil {
+			logger.WithError(err).Errorf("Failed to get the remote repository PETM from params map: provider=%v, bucket=%v",
+				utils.GetProviderFromParamsMap(params), params["bucket"])
+			return nil, err
+		}
+		logger.Infof("SnapshotManager: Get the remote repository PETM from the params map")
 	}
 
 	// If the local mode is enabled, we don't need to specify remote storage location
@@ -101,16 +126,24 @@ func NewSnapshotManagerFromCluster(params map[string]interface{}, config map[str
 	logger.Infof("SnapshotManager: Get ivdPETM from the params map, VirtualCenter=%v, port=%v", params["VirtualCenter"], params["port"])
 
 	snapMgr := SnapshotManager{
-		FieldLogger: logger,
-		config:      config,
-		ivdPETM:     ivdPETM,
-		s3PETM:      s3PETM,
+		FieldLogger:  logger,
+		config:       config,
+		ivdPETM:      ivdPETM,
+		repositories: backuprepository.NewPETMCache(repositoryPETM, logger),
+		placer:       placement.NewPlacer(params, logger),
 	}
 	logger.Infof("SnapshotManager is initialized with the configuration: %v", config)
 
 	return &snapMgr, nil
 }
 
+// SetMetrics records the latency of the creation and deletion of the local snapshots in serverMetrics, labeled with
+// nodeName.
+func (this *SnapshotManager) SetMetrics(serverMetrics *metrics.ServerMetrics, nodeName string) {
+	this.metrics = serverMetrics
+	this.nodeName = nodeName
+}
+
 func (this *SnapshotManager) CreateSnapshot(peID astrolabe.ProtectedEntityID, tags map[string]string) (astrolabe.ProtectedEntityID, error) {
 	this.Infof("SnapshotManager.CreateSnapshot Called with peID %s, tags %v", peID.String(), tags)
 	this.Infof("Step 1: Creating a snapshot in local repository")
@@ -124,6 +157,7 @@ func (this *SnapshotManager) CreateSnapshot(peID astrolabe.ProtectedEntityID, ta
 
 	var peSnapID astrolabe.ProtectedEntitySnapshotID
 	this.Infof("Ready to call astrolabe Snapshot API. Will retry on InvalidState error once per second for an hour at maximum")
+	startTime := time.Now()
 	err = wait.PollImmediate(time.Second, time.Hour, func() (bool, error) {
 		peSnapID, err = pe.Snapshot(ctx)
 
@@ -143,6 +177,7 @@ func (this *SnapshotManager) CreateSnapshot(peID astrolabe.ProtectedEntityID, ta
 		this.WithError(err).Errorf("Failed to Snapshot PE for %s", peID.String())
 		return astrolabe.ProtectedEntityID{}, err
 	}
+	this.metrics.ObserveSnapshotCreate(this.nodeName, time.Since(startTime))
 
 	this.Debugf("constructing the returned PE snapshot id, %s", peSnapID.GetID())
 	updatedPeID = astrolabe.NewProtectedEntityIDWithSnapshotID(peID.GetPeType(), peID.GetID(), peSnapID)
@@ -175,7 +210,27 @@ func (this *SnapshotManager) CreateSnapshot(peID astrolabe.ProtectedEntityID, ta
 		return updatedPeID, err
 	}
 
-	upload := builder.ForUpload(veleroNs, "upload-"+peSnapID.GetID()).BackupTimestamp(time.Now()).NextRetryTimestamp(time.Now()).SnapshotID(updatedPeID.String()).Phase(v1api.UploadPhaseNew).Result()
+	// Record the parameters of the backup storage location so that the data manager uploads to the location the backup is stored in.
+	repository := backuprepository.NewReference(tags[utils.SnapshotTagBackupRepository], tags[utils.SnapshotTagBackupStorageLocation], nil)
+	var repositoryParams map[string]string
+	if fileSystemParams := this.fileSystemRepositoryParameters(); fileSystemParams != nil && repository.BackupRepository == "" {
+		repository = backuprepository.NewReference("", "", fileSystemParams)
+		repositoryParams = fileSystemParams
+	} else {
+		repositoryParams, err = this.retrieveBSLParameters(repository.BackupStorageLocation)
+		if err != nil {
+			this.WithError(err).Errorf("CreateSnapshot: Failed to retrieve the backup storage location %s", repository.BackupStorageLocation)
+			return updatedPeID, err
+		}
+	}
+
+	uploadBuilder := builder.ForUpload(veleroNs, "upload-"+peSnapID.GetID()).BackupTimestamp(time.Now()).NextRetryTimestamp(time.Now()).SnapshotID(updatedPeID.String()).Phase(v1api.UploadPhaseNew).
+		BackupRepository(repository.BackupRepository).BackupStorageLocation(repository.BackupStorageLocation).RepositoryParameters(repositoryParams)
+	// Label the Upload with the Velero backup it is part of, so that the Uploads of the deleted backups can be reported.
+	if backupName, ok := tags[utils.SnapshotTagVeleroBackup]; ok {
+		uploadBuilder = uploadBuilder.ObjectMeta(builder.WithLabels(velerov1api.BackupNameLabel, label.GetValidName(backupName)))
+	}
+	upload := uploadBuilder.Result()
 	_, err = pluginClient.VeleropluginV1().Uploads(veleroNs).Create(upload)
 	if err != nil {
 		this.WithError(err).Errorf("CreateSnapshot: Failed to create Upload CR for PE %s", updatedPeID.String())
@@ -185,7 +240,10 @@ func (this *SnapshotManager) CreateSnapshot(peID astrolabe.ProtectedEntityID, ta
 	return updatedPeID, nil
 }
 
-func (this *SnapshotManager) DeleteSnapshot(peID astrolabe.ProtectedEntityID) error {
+// DeleteSnapshot cancels the Upload of the snapshot if it is still running, otherwise it deletes the local snapshot and
+// the snapshot copied to repository. The repository recorded in the Upload is used if repository is the default one,
+// as it is for the snapshot IDs taken by previous versions.
+func (this *SnapshotManager) DeleteSnapshot(peID astrolabe.ProtectedEntityID, repository backuprepository.Reference) error {
 	log := this.WithField("peID", peID.String())
 	log.Infof("Step 0: Cancel on-going upload.")
 	config, err := rest.InClusterConfig()
@@ -207,13 +265,15 @@ func (this *SnapshotManager) DeleteSnapshot(peID astrolabe.ProtectedEntityID) er
 	}
 	uploadName := "upload-" + peID.GetSnapshotID().GetID()
 	log.Infof("Searching for Upload CR: %v", uploadName)
-	uploadCR, err := pluginClient.VeleropluginV1().Uploads(veleroNs).Get(uploadName, metav1.GetOptions{})
+	uploadCR, err := getUpload(pluginClient.VeleropluginV1().Uploads(veleroNs), uploadName)
 	if err != nil {
-		log.WithError(err).Errorf(" Error while retrieving the upload CR %v", uploadName)
+		log.WithError(err).Errorf("Error while retrieving the upload CR %v", uploadName)
+		return err
 	}
-	// An upload is considered done when it's in either of the terminal stages- Completed, CleanupFailed, Canceled
-	uploadCompleted := this.isTerminalState(uploadCR)
-	if uploadCR != nil && !uploadCompleted {
+	// An upload is considered done when it's in either of the terminal stages- Completed, CleanupFailed, Canceled,
+	// or when it does not exist anymore
+	uploadCompleted := uploadCR == nil || this.isTerminalState(uploadCR)
+	if !uploadCompleted {
 		log.Infof("Found the Upload CR: %v, updating spec to indicate cancel upload.", uploadName)
 		timeNow := clock.RealClock{}
 		mutate := func(r *v1api.Upload) {
@@ -231,8 +291,10 @@ func (this *SnapshotManager) DeleteSnapshot(peID astrolabe.ProtectedEntityID) er
 		}
 		return nil
 	} else {
-		if uploadCompleted {
+		if uploadCR != nil {
 			log.Infof("The upload %v was in terminal stage, proceeding with snapshot deletes", uploadName)
+		} else {
+			log.Infof("The upload %v does not exist, proceeding with snapshot deletes", uploadName)
 		}
 		log.Infof("Step 1: Deleting the local snapshot")
 		err = this.DeleteLocalSnapshot(peID)
@@ -248,10 +310,13 @@ func (this *SnapshotManager) DeleteSnapshot(peID astrolabe.ProtectedEntityID) er
 			return nil
 		}
 
-		log.Infof("Step 2: Deleting the durable snapshot from s3")
-		err = this.DeleteRemoteSnapshot(peID)
+		log.Infof("Step 2: Deleting the durable snapshot from the remote repository")
+		if repository.IsDefault() && uploadCR != nil {
+			repository = backuprepository.NewReference(uploadCR.Spec.BackupRepository, uploadCR.Spec.BackupStorageLocation, uploadCR.Spec.RepositoryParameters)
+		}
+		err = this.DeleteRemoteSnapshot(peID, repository)
 		if err != nil {
-			if !strings.Contains(err.Error(), "The specified key does not exist") {
+			if !strings.Contains(err.Error(), "The specified key does not exist") && !objectstore.IsNotFound(err) {
 				log.WithError(err).Errorf("Failed to delete the durable snapshot for PEID")
 				return err
 			}
@@ -261,18 +326,40 @@ func (this *SnapshotManager) DeleteSnapshot(peID astrolabe.ProtectedEntityID) er
 	}
 }
 
+// getUpload returns the Upload with the given name, nil if it does not exist.
+func getUpload(uploads pluginv1client.UploadInterface, name string) (*v1api.Upload, error) {
+	upload, err := uploads.Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get Upload %s", name)
+	}
+	return upload, nil
+}
+
 func (this *SnapshotManager) isTerminalState(uploadCR *v1api.Upload) bool {
 	return uploadCR.Status.Phase == v1api.UploadPhaseCompleted || uploadCR.Status.Phase == v1api.UploadPhaseCleanupFailed || uploadCR.Status.Phase == v1api.UploadPhaseCanceled
 }
 
 func (this *SnapshotManager) DeleteLocalSnapshot(peID astrolabe.ProtectedEntityID) error {
 	this.WithField("peID", peID.String()).Infof("SnapshotManager.deleteLocalSnapshot Called")
-	return this.deleteSnapshotFromRepo(peID, this.ivdPETM)
+	startTime := time.Now()
+	if err := this.deleteSnapshotFromRepo(peID, this.ivdPETM); err != nil {
+		return err
+	}
+	this.metrics.ObserveSnapshotDelete(this.nodeName, time.Since(startTime))
+	return nil
 }
 
-func (this *SnapshotManager) DeleteRemoteSnapshot(peID astrolabe.ProtectedEntityID) error {
-	this.WithField("peID", peID.String()).Infof("SnapshotManager.deleteRemoteSnapshot Called")
-	return this.deleteSnapshotFromRepo(peID, this.s3PETM)
+func (this *SnapshotManager) DeleteRemoteSnapshot(peID astrolabe.ProtectedEntityID, repository backuprepository.Reference) error {
+	this.WithField("peID", peID.String()).WithField("repository", repository.String()).Infof("SnapshotManager.deleteRemoteSnapshot Called")
+	repositoryPETM, err := this.repositories.Get(repository)
+	if err != nil {
+		this.WithError(err).Errorf("Failed to get PETM of the remote repository %s", repository.String())
+		return err
+	}
+	return this.deleteSnapshotFromRepo(peID, repositoryPETM)
 }
 
 func (this *SnapshotManager) deleteSnapshotFromRepo(peID astrolabe.ProtectedEntityID, petm astrolabe.ProtectedEntityTypeManager) error {
@@ -323,9 +410,62 @@ func (this *SnapshotManager) deleteSnapshotFromRepo(peID astrolabe.ProtectedEnti
 	return nil
 }
 
+// retrieveBSLParameters returns the parameters of the backup storage location to be recorded on an Upload or Download.
+func (this *SnapshotManager) retrieveBSLParameters(bslName string) (map[string]string, error) {
+	if bslName == "" {
+		return nil, nil
+	}
+
+	params := make(map[string]interface{})
+	err := utils.RetrieveParamsFromBSL(bslName, params, this.FieldLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	repositoryParams := make(map[string]string)
+	for key, value := range params {
+		if str, ok := value.(string); ok && str != "" {
+			repositoryParams[key] = str
+		}
+	}
+	return repositoryParams, nil
+}
+
+// fileSystemRepositoryParameters returns the parameters of the filesystem repository configured in the plugin, which
+// replaces the backup storage location, or nil if there is none.
+func (this *SnapshotManager) fileSystemRepositoryParameters() map[string]string {
+	repositoryPath := this.config[utils.VolumeSnapshotterRepositoryPath]
+	if repositoryPath == "" {
+		return nil
+	}
+	return map[string]string{
+		"provider": utils.ProviderFileSystem,
+		"path":     repositoryPath,
+	}
+}
+
+// DeleteVolume deletes the volume with the given ID that was created by CreateVolumeFromSnapshot, when nothing uses it.
+// A volume that does not exist is already deleted.
+func (this *SnapshotManager) DeleteVolume(volumeID string) error {
+	this.Infof("SnapshotManager.DeleteVolume Called with volume %s", volumeID)
+	if err := this.placer.DeleteVolume(context.Background(), volumeID); err != nil {
+		this.WithError(err).Errorf("Failed to delete volume %s", volumeID)
+		return err
+	}
+	return nil
+}
+
+// SetRestoreMapping maps the StorageClasses the volumes are created from snapshots for with the Mapping of source.
+func (this *SnapshotManager) SetRestoreMapping(source *migration.Source) {
+	this.restoreMapping = source
+}
+
 const PollLogInterval = time.Minute
 
-func (this *SnapshotManager) CreateVolumeFromSnapshot(peID astrolabe.ProtectedEntityID) (updatedID astrolabe.ProtectedEntityID, err error) {
+// CreateVolumeFromSnapshot creates a new volume from the snapshot in the repository with a Download, and waits for it.
+// The volume is placed on a datastore compatible with the StorageClass with the given name, if it is not empty, as it
+// is mapped by the restore mapping.
+func (this *SnapshotManager) CreateVolumeFromSnapshot(peID astrolabe.ProtectedEntityID, repository backuprepository.Reference, storageClass string) (updatedID astrolabe.ProtectedEntityID, err error) {
 	this.Infof("Start creating Download CR for %s", peID.String())
 	config, err := rest.InClusterConfig()
 	if err != nil {
@@ -344,10 +484,43 @@ func (this *SnapshotManager) CreateVolumeFromSnapshot(peID astrolabe.ProtectedEn
 		return
 	}
 
+	if storageClass != "" {
+		var mapping migration.Mapping
+		mapping, err = this.restoreMapping.Get()
+		if err != nil {
+			this.WithError(err).Errorf("CreateVolumeFromSnapshot: Failed to get the restore mapping")
+			return
+		}
+		var targetStorageClass string
+		targetStorageClass, err = mapping.StorageClass(storageClass)
+		if err != nil {
+			this.WithError(err).Errorf("CreateVolumeFromSnapshot: Failed to map the StorageClass of %s", peID.String())
+			return
+		}
+		if targetStorageClass != storageClass {
+			this.Infof("CreateVolumeFromSnapshot: StorageClass %s is mapped to %s", storageClass, targetStorageClass)
+			storageClass = targetStorageClass
+		}
+	}
+
+	if fileSystemParams := this.fileSystemRepositoryParameters(); fileSystemParams != nil && repository.BackupRepository == "" {
+		repository = backuprepository.NewReference("", "", fileSystemParams)
+	}
+	repositoryParams := repository.Parameters
+	if len(repositoryParams) == 0 {
+		repositoryParams, err = this.retrieveBSLParameters(repository.BackupStorageLocation)
+		if err != nil {
+			this.WithError(err).Errorf("CreateVolumeFromSnapshot: Failed to retrieve the backup storage location %s", repository.BackupStorageLocation)
+			return
+		}
+	}
+
 	uuid, _ := uuid.NewRandom()
 	downloadRecordName := "download-" + peID.GetSnapshotID().GetID() + "-" + uuid.String()
 	download := builder.ForDownload(veleroNs, downloadRecordName).
-		RestoreTimestamp(time.Now()).NextRetryTimestamp(time.Now()).SnapshotID(peID.String()).Phase(v1api.DownloadPhaseNew).Result()
+		RestoreTimestamp(time.Now()).NextRetryTimestamp(time.Now()).SnapshotID(peID.String()).Phase(v1api.DownloadPhaseNew).
+		BackupRepository(repository.BackupRepository).BackupStorageLocation(repository.BackupStorageLocation).RepositoryParameters(repositoryParams).
+		StorageClass(storageClass).Result()
 	_, err = pluginClient.VeleropluginV1().Downloads(veleroNs).Create(download)
 	if err != nil {
 		this.WithError(err).Errorf("CreateVolumeFromSnapshot: Failed to create Download CR for %s", peID.String())
@@ -372,7 +545,7 @@ func (this *SnapshotManager) CreateVolumeFromSnapshot(peID astrolabe.ProtectedEn
 			this.Infof("Download record %s completed", downloadRecordName)
 			return true, nil
 		} else if download.Status.Phase == v1api.DownloadPhaseFailed {
-			return false, errors.Errorf("Create download cr failed.")
+			return false, errors.Errorf("Download %s failed: %s", downloadRecordName, download.Status.Message)
 		} else {
 			if infoLog {
 				this.Infof("Retrieve phase %s for download record %s", download.Status.Phase, downloadRecordName)
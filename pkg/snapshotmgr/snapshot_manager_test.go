@@ -17,6 +17,8 @@
 package snapshotmgr
 
 import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/builder"
 	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/fake"
@@ -56,3 +58,19 @@ func TestDownload_Creation(t *testing.T) {
 
 	pluginClient.VeleropluginV1().Downloads("velero").Create(download)
 }
+
+func TestGetUpload(t *testing.T) {
+	pluginClient := fake.NewSimpleClientset()
+	_, err := pluginClient.VeleropluginV1().Uploads("velero").Create(builder.ForUpload("velero", "upload-1").SnapshotID("ssid-1").Phase(v1api.UploadPhaseInProgress).Result())
+	require.NoError(t, err)
+
+	upload, err := getUpload(pluginClient.VeleropluginV1().Uploads("velero"), "upload-1")
+	require.NoError(t, err)
+	require.NotNil(t, upload)
+	assert.Equal(t, v1api.UploadPhaseInProgress, upload.Status.Phase)
+
+	// The Upload that was garbage collected is not canceled
+	upload, err = getUpload(pluginClient.VeleropluginV1().Uploads("velero"), "upload-gone")
+	require.NoError(t, err)
+	assert.Nil(t, upload)
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transferpolicy
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// The entries of the transfer policy ConfigMap. Every entry is optional, and overrides the policy the data
+	// manager was started with.
+	ConfigMapBandwidthLimitKey            = "bandwidthLimit"
+	ConfigMapRepositoryBandwidthLimitsKey = "repositoryBandwidthLimits"
+	ConfigMapTransferWindowsKey           = "transferWindows"
+)
+
+// Policy limits how fast, and when, a data manager transfers the data of snapshots.
+type Policy struct {
+	// BandwidthLimit is the number of bytes per second all the transfers of the data manager share, 0 if unlimited.
+	BandwidthLimit int64
+	// RepositoryBandwidthLimits are the number of bytes per second the transfers to and from a repository share,
+	// by the name of the repository as printed by backuprepository.Reference. They apply on top of BandwidthLimit.
+	RepositoryBandwidthLimits map[string]int64
+	// Windows are the times of the day uploads are started in, uploads are started at any time if it is empty.
+	Windows Windows
+}
+
+// ParseBandwidth parses a number of bytes per second, such as "100Mi". "" and "0" mean unlimited.
+func ParseBandwidth(s string) (int64, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	quantity, err := resource.ParseQuantity(strings.TrimSpace(s))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid bandwidth %q", s)
+	}
+	bandwidth := quantity.Value()
+	if bandwidth < 0 {
+		return 0, errors.Errorf("invalid bandwidth %q, it must not be negative", s)
+	}
+	return bandwidth, nil
+}
+
+// ParseRepositoryBandwidthLimits parses one "<repository>: <bandwidth>" entry per line, such as
+// "BackupStorageLocation/default: 50Mi".
+func ParseRepositoryBandwidthLimits(s string) (map[string]int64, error) {
+	limits := make(map[string]int64)
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		separator := strings.LastIndex(line, ":")
+		if separator < 0 {
+			return nil, errors.Errorf("invalid repository bandwidth limit %q, expected <repository>: <bandwidth>", line)
+		}
+		bandwidth, err := ParseBandwidth(line[separator+1:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid bandwidth limit of repository %s", strings.TrimSpace(line[:separator]))
+		}
+		limits[strings.TrimSpace(line[:separator])] = bandwidth
+	}
+	return limits, nil
+}
+
+// FromConfigMap returns the policy with the entries of the ConfigMap that are set replacing those of the given policy.
+func FromConfigMap(configMap *corev1.ConfigMap, policy Policy) (Policy, error) {
+	var err error
+	if value, ok := configMap.Data[ConfigMapBandwidthLimitKey]; ok {
+		if policy.BandwidthLimit, err = ParseBandwidth(value); err != nil {
+			return Policy{}, err
+		}
+	}
+	if value, ok := configMap.Data[ConfigMapRepositoryBandwidthLimitsKey]; ok {
+		if policy.RepositoryBandwidthLimits, err = ParseRepositoryBandwidthLimits(value); err != nil {
+			return Policy{}, err
+		}
+	}
+	if value, ok := configMap.Data[ConfigMapTransferWindowsKey]; ok {
+		if policy.Windows, err = ParseWindows(value); err != nil {
+			return Policy{}, err
+		}
+	}
+	return policy, nil
+}
+
+// Source supplies the transfer policy of a data manager, from the policy it was started with and an optional
+// ConfigMap. The ConfigMap is read on every call, so that changes to it are picked up without a restart. A nil Source
+// supplies an unlimited policy.
+type Source struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	name       string
+	defaults   Policy
+}
+
+// NewSource returns a Source that supplies the defaults with the entries of the ConfigMap with the given name in the
+// namespace, if name is not empty and the ConfigMap exists.
+func NewSource(kubeClient kubernetes.Interface, namespace string, name string, defaults Policy) *Source {
+	return &Source{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+		defaults:   defaults,
+	}
+}
+
+func (this *Source) Get() (Policy, error) {
+	if this == nil {
+		return Policy{}, nil
+	}
+	if this.name == "" {
+		return this.defaults, nil
+	}
+	configMap, err := this.kubeClient.CoreV1().ConfigMaps(this.namespace).Get(this.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return this.defaults, nil
+	}
+	if err != nil {
+		return Policy{}, errors.Wrapf(err, "Failed to get transfer policy config map %s/%s", this.namespace, this.name)
+	}
+	policy, err := FromConfigMap(configMap, this.defaults)
+	if err != nil {
+		return Policy{}, errors.Wrapf(err, "Invalid transfer policy config map %s/%s", this.namespace, this.name)
+	}
+	return policy, nil
+}
+
+// Window is a time of the day, from Start to End since midnight. A window whose End is before its Start spans
+// midnight.
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+func (w Window) String() string {
+	return fmt.Sprintf("%s-%s", formatTimeOfDay(w.Start), formatTimeOfDay(w.End))
+}
+
+// Windows are the times of the day transfers are allowed in. Empty Windows allow transfers at any time.
+type Windows []Window
+
+const day = 24 * time.Hour
+
+// ParseWindows parses a comma separated list of windows, such as "22:00-06:00,12:00-13:00", in the local time of the
+// data manager.
+func ParseWindows(s string) (Windows, error) {
+	var windows Windows
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		bounds := strings.Split(field, "-")
+		if len(bounds) != 2 {
+			return nil, errors.Errorf("invalid transfer window %q, expected HH:MM-HH:MM", field)
+		}
+		start, err := parseTimeOfDay(bounds[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid transfer window %q", field)
+		}
+		end, err := parseTimeOfDay(bounds[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid transfer window %q", field)
+		}
+		if start == end {
+			return nil, errors.Errorf("invalid transfer window %q, it is empty", field)
+		}
+		windows = append(windows, Window{Start: start, End: end})
+	}
+	return windows, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d", &hours, &minutes); err != nil {
+		return 0, errors.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	// 24:00 is the end of the day.
+	if hours < 0 || minutes < 0 || minutes > 59 || hours > 24 || (hours == 24 && minutes != 0) {
+		return 0, errors.Errorf("invalid time of day %q", s)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+func (windows Windows) String() string {
+	var s []string
+	for _, w := range windows {
+		s = append(s, w.String())
+	}
+	return strings.Join(s, ",")
+}
+
+// Open returns whether transfers are allowed at the given time.
+func (windows Windows) Open(t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	now := sinceMidnight(t)
+	for _, w := range windows {
+		if w.Start < w.End {
+			if now >= w.Start && now < w.End {
+				return true
+			}
+		} else if now >= w.Start || now < w.End {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the time the next window opens after the given time, or the time itself if a window is open.
+func (windows Windows) NextOpen(t time.Time) time.Time {
+	if windows.Open(t) {
+		return t
+	}
+	now := sinceMidnight(t)
+	wait := day
+	for _, w := range windows {
+		untilStart := w.Start - now
+		if untilStart < 0 {
+			untilStart += day
+		}
+		if untilStart < wait {
+			wait = untilStart
+		}
+	}
+	return t.Add(wait)
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	hour, min, sec := t.Clock()
+	return time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second +
+		time.Duration(t.Nanosecond())
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 the Velero contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transferpolicy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func at(hour, min int) time.Time {
+	return time.Date(2020, 7, 1, hour, min, 0, 0, time.Local)
+}
+
+func TestWindows(t *testing.T) {
+	windows, err := ParseWindows("22:00-06:00, 12:00-13:30")
+	require.NoError(t, err)
+	assert.Equal(t, "22:00-06:00,12:00-13:30", windows.String())
+
+	tests := []struct {
+		now      time.Time
+		open     bool
+		nextOpen time.Time
+	}{
+		{now: at(23, 0), open: true, nextOpen: at(23, 0)},
+		{now: at(5, 59), open: true, nextOpen: at(5, 59)},
+		{now: at(6, 0), open: false, nextOpen: at(12, 0)},
+		{now: at(12, 30), open: true, nextOpen: at(12, 30)},
+		{now: at(13, 30), open: false, nextOpen: at(22, 0)},
+	}
+	for _, test := range tests {
+		t.Run(test.now.Format("15:04"), func(t *testing.T) {
+			assert.Equal(t, test.open, windows.Open(test.now))
+			assert.Equal(t, test.nextOpen, windows.NextOpen(test.now))
+		})
+	}
+
+	// Without windows, transfers are allowed at any time.
+	assert.True(t, Windows(nil).Open(at(6, 0)))
+
+	for _, invalid := range []string{"22:00", "22:00-22:00", "25:00-06:00", "22:60-06:00", "10pm-6am"} {
+		_, err := ParseWindows(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestBandwidth(t *testing.T) {
+	bandwidth, err := ParseBandwidth("100Mi")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100*1024*1024), bandwidth)
+	bandwidth, err = ParseBandwidth("")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), bandwidth)
+	_, err = ParseBandwidth("-1M")
+	assert.Error(t, err)
+
+	limits, err := ParseRepositoryBandwidthLimits("BackupStorageLocation/default: 50M\n\n# slow link\nBackupRepository/br-1: 1Mi\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{
+		"BackupStorageLocation/default": 50000000,
+		"BackupRepository/br-1":         1024 * 1024,
+	}, limits)
+	_, err = ParseRepositoryBandwidthLimits("BackupStorageLocation/default 50M")
+	assert.Error(t, err)
+}
+
+func TestSource(t *testing.T) {
+	defaults := Policy{
+		BandwidthLimit: 1000,
+		Windows:        Windows{{Start: 22 * time.Hour, End: 6 * time.Hour}},
+	}
+	kubeClient := kubefake.NewSimpleClientset()
+	source := NewSource(kubeClient, "velero", "transfer-policy", defaults)
+
+	// The defaults are used until the ConfigMap is created.
+	policy, err := source.Get()
+	require.NoError(t, err)
+	assert.Equal(t, defaults, policy)
+
+	_, err = kubeClient.CoreV1().ConfigMaps("velero").Create(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "velero", Name: "transfer-policy"},
+		Data: map[string]string{
+			ConfigMapRepositoryBandwidthLimitsKey: "BackupStorageLocation/default: 500",
+			ConfigMapTransferWindowsKey:           "",
+		},
+	})
+	require.NoError(t, err)
+	policy, err = source.Get()
+	require.NoError(t, err)
+	assert.Equal(t, Policy{
+		BandwidthLimit:            1000,
+		RepositoryBandwidthLimits: map[string]int64{"BackupStorageLocation/default": 500},
+	}, policy)
+
+	// A nil Source is unlimited.
+	policy, err = (*Source)(nil).Get()
+	require.NoError(t, err)
+	assert.Equal(t, Policy{}, policy)
+}
@@ -21,6 +21,9 @@ import "time"
 const (
 	// supported volume type in plugin
 	CnsBlockVolumeType = "ivd"
+
+	// Name of the vSphere CSI driver
+	VSphereCSIDriverName = "csi.vsphere.vmware.com"
 )
 
 const (
@@ -39,6 +42,17 @@ const (
 	ResyncPeriod = 30 * time.Second
 )
 
+const (
+	// Minimum duration between two updates of the progress of an Upload or Download CR, which bounds the rate at
+	// which an ongoing copy writes to the API server.
+	ProgressUpdateInterval = 10 * time.Second
+)
+
+const (
+	// Duration after which an Upload or Download CR that waits for a transfer slot on its node is retried.
+	TransferSlotRetryPeriod = 5 * time.Second
+)
+
 // configuration constants for the volume snapshot plugin
 const (
 	// The key of SnapshotManager mode for data movement. Specifically, boolean string values are expected.
@@ -49,6 +63,10 @@ const (
 	// Valid values for the config with the VolumeSnapshotterManagerLocation key
 	VolumeSnapshotterPlugin     = "Plugin"
 	VolumeSnapshotterDataServer = "DataServer"
+
+	// The key of the path of a filesystem, mounted into the data manager, that snapshots are copied to instead of the
+	// backup storage location, e.g. an NFS share.
+	VolumeSnapshotterRepositoryPath = "RepositoryPath"
 )
 
 const (
@@ -68,9 +86,46 @@ const (
 	RETRY_WARNING_COUNT = 8
 )
 
+// tags passed to SnapshotManager.CreateSnapshot
+const (
+	// The name of the Velero backup in progress, set by Velero
+	SnapshotTagVeleroBackup = "velero.io/backup"
+
+	// The namespace/name of the Snapshot CR that requested the snapshot
+	SnapshotTagSnapshotName = "backupdriver.io/snapshot"
+
+	// The name of the BackupRepository to upload the snapshot to
+	SnapshotTagBackupRepository = "backupdriver.io/backup-repository"
+
+	// The name of the Velero BackupStorageLocation to upload the snapshot to
+	SnapshotTagBackupStorageLocation = "backupdriver.io/backup-storage-location"
+)
+
 // configuration constants for the S3 repository
 const (
 	DefaultS3RepoPrefix = "plugins/vsphere-astrolabe-repo"
+
+	// The secret and its key that hold the credentials file of Velero
+	VeleroCredentialsSecretName = "cloud-credentials"
+	VeleroCredentialsSecretKey  = "cloud"
+)
+
+// repository drivers supported in BackupRepository and BackupRepositoryClaim
+const (
+	S3RepositoryDriver         = "s3repository.astrolabe.vmware-tanzu.com"
+	AzureBlobRepositoryDriver  = "azureblobrepository.astrolabe.vmware-tanzu.com"
+	GCSRepositoryDriver        = "gcsrepository.astrolabe.vmware-tanzu.com"
+	FileSystemRepositoryDriver = "fsrepository.astrolabe.vmware-tanzu.com"
+)
+
+// object store providers of Velero backup storage locations that the remote repository can be kept in
+const (
+	ProviderAWS   = "aws"
+	ProviderAzure = "azure"
+	ProviderGCP   = "gcp"
+
+	// The remote repository is kept in a mounted filesystem rather than in an object store
+	ProviderFileSystem = "filesystem"
 )
 const (
 	// Minimum velero version number to meet velero plugin requirement
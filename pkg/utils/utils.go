@@ -17,26 +17,31 @@ limitations under the License.
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	jsonpatch "github.com/evanphx/json-patch"
 	pluginv1api "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/apis/veleroplugin/v1"
 	pluginv1client "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/generated/clientset/versioned/typed/veleroplugin/v1"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
 	"k8s.io/apimachinery/pkg/types"
 	"os"
+	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
 	"github.com/vmware-tanzu/astrolabe/pkg/ivd"
-	"github.com/vmware-tanzu/astrolabe/pkg/s3repository"
 	v1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	"github.com/vmware-tanzu/velero/pkg/generated/clientset/versioned"
 	k8sv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -124,8 +129,7 @@ func RetrieveVSLFromVeleroBSLs(params map[string]interface{}, logger logrus.Fiel
 
 	veleroNs, exist := os.LookupEnv("VELERO_NAMESPACE")
 	if !exist {
-		logger.Errorf("RetrieveVSLFromVeleroBSLs: Failed to lookup the env variable for velero namespace")
-		return err
+		return errors.New("RetrieveVSLFromVeleroBSLs: Failed to lookup the env variable for velero namespace")
 	}
 
 	defaultBackupLocation := "default"
@@ -141,34 +145,333 @@ func RetrieveVSLFromVeleroBSLs(params map[string]interface{}, logger logrus.Fiel
 			return err
 		}
 		// Select the first valid BackupStorageLocation from the list if there is no default BackupStorageLocation.
-		logger.Infof("RetrieveVSLFromVeleroBSLs: Picked up the first valid BackupStorageLocation from the BackupStorageLocationList")
-		for _, item := range backupStorageLocationList.Items {
-			backupStorageLocation = &item
-			provider := strings.ToLower(item.Spec.Provider)
-			if provider != "aws" {
-				logger.Warnf("RetrieveVSLFromVeleroBSLs: Object store providers, %v, other than AWS are not supported for the moment", provider)
-				continue
-			}
-			region := backupStorageLocation.Spec.Config["region"]
-			if region == "" {
-				logger.Warnf("RetrieveVSLFromVeleroBSLs: The region field is missing in the Backup Storage Location. Skiping.")
-				continue
-			}
-		}
+		backupStorageLocation = firstValidBSL(backupStorageLocationList.Items, logger)
 	}
 
 	if backupStorageLocation == nil {
 		return errors.New("RetrieveVSLFromVeleroBSLs: No valid Backup Storage Location can be retrieved")
 	}
 
-	params["region"] = backupStorageLocation.Spec.Config["region"]
+	setParamsFromBSL(backupStorageLocation, params)
+
+	return nil
+}
+
+/*
+ * Retrieve the parameters of the remote storage location from the Velero
+ * Backup Storage Location(BSL) with the given name.
+ */
+func RetrieveParamsFromBSL(bslName string, params map[string]interface{}, logger logrus.FieldLogger) error {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return err
+	}
+
+	veleroClient, err := versioned.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	veleroNs, exist := os.LookupEnv("VELERO_NAMESPACE")
+	if !exist {
+		return errors.New("RetrieveParamsFromBSL: Failed to lookup the env variable for velero namespace")
+	}
+
+	backupStorageLocation, err := veleroClient.VeleroV1().BackupStorageLocations(veleroNs).Get(bslName, metav1.GetOptions{})
+	if err != nil {
+		logger.WithError(err).Errorf("RetrieveParamsFromBSL: Failed to get Velero backup storage location %s", bslName)
+		return err
+	}
+
+	provider := strings.ToLower(backupStorageLocation.Spec.Provider)
+	if !IsSupportedProvider(provider) {
+		return errors.Errorf("RetrieveParamsFromBSL: Object store provider %v of backup storage location %s is not supported", provider, bslName)
+	}
+
+	setParamsFromBSL(backupStorageLocation, params)
+
+	return nil
+}
+
+func firstValidBSL(backupStorageLocations []v1.BackupStorageLocation, logger logrus.FieldLogger) *v1.BackupStorageLocation {
+	for i := range backupStorageLocations {
+		item := &backupStorageLocations[i]
+		provider := strings.ToLower(item.Spec.Provider)
+		if !IsSupportedProvider(provider) {
+			logger.Warnf("RetrieveVSLFromVeleroBSLs: Object store provider %v of the Backup Storage Location %s is not supported for the moment. Skiping.", provider, item.Name)
+			continue
+		}
+		requiredConfigKey := ""
+		switch provider {
+		case ProviderAWS:
+			requiredConfigKey = "region"
+		case ProviderAzure:
+			requiredConfigKey = "storageAccount"
+		}
+		if requiredConfigKey != "" && item.Spec.Config[requiredConfigKey] == "" {
+			logger.Warnf("RetrieveVSLFromVeleroBSLs: The %s field is missing in the Backup Storage Location %s. Skiping.", requiredConfigKey, item.Name)
+			continue
+		}
+		logger.Infof("RetrieveVSLFromVeleroBSLs: Picked up the first valid BackupStorageLocation %s from the BackupStorageLocationList", item.Name)
+		return item
+	}
+	return nil
+}
+
+func setParamsFromBSL(backupStorageLocation *v1.BackupStorageLocation, params map[string]interface{}) {
+	provider := strings.ToLower(backupStorageLocation.Spec.Provider)
+	params["provider"] = provider
 	params["bucket"] = backupStorageLocation.Spec.ObjectStorage.Bucket
-	params["s3ForcePathStyle"] = backupStorageLocation.Spec.Config["s3ForcePathStyle"]
-	params["s3Url"] = backupStorageLocation.Spec.Config["s3Url"]
+	params["prefix"] = backupStorageLocation.Spec.ObjectStorage.Prefix
+	switch provider {
+	case ProviderAzure:
+		params["storageAccount"] = backupStorageLocation.Spec.Config["storageAccount"]
+		params["storageAccountKeyEnvVar"] = backupStorageLocation.Spec.Config["storageAccountKeyEnvVar"]
+	case ProviderGCP:
+	default:
+		params["region"] = backupStorageLocation.Spec.Config["region"]
+		params["s3ForcePathStyle"] = backupStorageLocation.Spec.Config["s3ForcePathStyle"]
+		params["s3Url"] = backupStorageLocation.Spec.Config["s3Url"]
+		params["profile"] = backupStorageLocation.Spec.Config["profile"]
+	}
+}
+
+/*
+ * Retrieve the name of the Backup Storage Location(BSL) that the Velero backup
+ * with the given name is stored in.
+ */
+func RetrieveBSLNameOfBackup(backupName string, logger logrus.FieldLogger) (string, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return "", err
+	}
+
+	veleroClient, err := versioned.NewForConfig(config)
+	if err != nil {
+		return "", err
+	}
 
+	veleroNs, exist := os.LookupEnv("VELERO_NAMESPACE")
+	if !exist {
+		return "", errors.New("RetrieveBSLNameOfBackup: Failed to lookup the env variable for velero namespace")
+	}
+
+	backup, err := veleroClient.VeleroV1().Backups(veleroNs).Get(backupName, metav1.GetOptions{})
+	if err != nil {
+		logger.WithError(err).Errorf("RetrieveBSLNameOfBackup: Failed to get Velero backup %s", backupName)
+		return "", err
+	}
+
+	return backup.Spec.StorageLocation, nil
+}
+
+/*
+ * Retrieve the name of the Backup Storage Location(BSL) of the backup being restored
+ * by the Velero restores in progress. An empty name is returned if there is no restore
+ * in progress, and an error if the restores in progress read from different BSLs.
+ */
+func RetrieveBSLNameOfRestoresInProgress(logger logrus.FieldLogger) (string, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return "", err
+	}
+
+	veleroClient, err := versioned.NewForConfig(config)
+	if err != nil {
+		return "", err
+	}
+
+	veleroNs, exist := os.LookupEnv("VELERO_NAMESPACE")
+	if !exist {
+		return "", errors.New("RetrieveBSLNameOfRestoresInProgress: Failed to lookup the env variable for velero namespace")
+	}
+
+	restores, err := veleroClient.VeleroV1().Restores(veleroNs).List(metav1.ListOptions{})
+	if err != nil {
+		logger.WithError(err).Errorf("RetrieveBSLNameOfRestoresInProgress: Failed to list Velero restores")
+		return "", err
+	}
+
+	bslName := ""
+	for _, restore := range restores.Items {
+		if restore.Status.Phase != v1.RestorePhaseInProgress {
+			continue
+		}
+		backup, err := veleroClient.VeleroV1().Backups(veleroNs).Get(restore.Spec.BackupName, metav1.GetOptions{})
+		if err != nil {
+			logger.WithError(err).Errorf("RetrieveBSLNameOfRestoresInProgress: Failed to get Velero backup %s of restore %s", restore.Spec.BackupName, restore.Name)
+			return "", err
+		}
+		if bslName != "" && bslName != backup.Spec.StorageLocation {
+			return "", errors.Errorf("RetrieveBSLNameOfRestoresInProgress: Restores in progress read from different backup storage locations, %s and %s", bslName, backup.Spec.StorageLocation)
+		}
+		bslName = backup.Spec.StorageLocation
+	}
+
+	return bslName, nil
+}
+
+/*
+ * Retrieve the credentials of the object store provider in params from the Velero
+ * credentials secret. For AWS, the credentials of the profile in params, or of the
+ * default profile, are retrieved. For Azure, the storage account key is read from the
+ * variable named by storageAccountKeyEnvVar. For GCP, the secret holds the JSON key of
+ * a service account. The params are left unchanged if there is no such secret, e.g. if
+ * Velero was installed with --no-secret, so that the ambient credentials are used.
+ */
+func RetrieveCredentialsFromSecret(params map[string]interface{}, logger logrus.FieldLogger) error {
+	if GetProviderFromParamsMap(params) == ProviderFileSystem {
+		// No credentials are needed to access a mounted filesystem.
+		return nil
+	}
+
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		logger.WithError(err).Errorf("Failed to get k8s inClusterConfig")
+		return err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		logger.WithError(err).Errorf("Failed to get k8s clientset from the given config: %v", config)
+		return err
+	}
+
+	veleroNs, exist := os.LookupEnv("VELERO_NAMESPACE")
+	if !exist {
+		return errors.New("RetrieveCredentialsFromSecret: Failed to lookup the env variable for velero namespace")
+	}
+
+	secret, err := clientset.CoreV1().Secrets(veleroNs).Get(VeleroCredentialsSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		logger.Infof("No Velero credentials secret %s/%s, falling back to the ambient credentials", veleroNs, VeleroCredentialsSecretName)
+		return nil
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("Failed to get Velero credentials secret %s/%s", veleroNs, VeleroCredentialsSecretName)
+		return err
+	}
+	data := secret.Data[VeleroCredentialsSecretKey]
+
+	switch GetProviderFromParamsMap(params) {
+	case ProviderAzure:
+		keyEnvVar, _ := params["storageAccountKeyEnvVar"].(string)
+		if keyEnvVar == "" {
+			return errors.New("The storageAccountKeyEnvVar config of the backup storage location is required for Azure")
+		}
+		storageAccountKey, ok := parseAzureCredentials(data)[keyEnvVar]
+		if !ok {
+			return errors.Errorf("%s is not found in Velero credentials secret %s/%s", keyEnvVar, veleroNs, VeleroCredentialsSecretName)
+		}
+		params["storageAccountKey"] = storageAccountKey
+	case ProviderGCP:
+		params["credentialsJSON"] = string(data)
+	default:
+		profile, _ := params["profile"].(string)
+		if profile == "" {
+			profile = "default"
+		}
+		accessKeyID, secretAccessKey, sessionToken, err := parseAWSCredentials(data, profile)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to parse Velero credentials secret %s/%s", veleroNs, VeleroCredentialsSecretName)
+		}
+
+		params["accessKeyId"] = accessKeyID
+		params["secretAccessKey"] = secretAccessKey
+		params["sessionToken"] = sessionToken
+	}
 	return nil
 }
 
+// parseAzureCredentials returns the variables of the credentials file of the Velero Azure plugin, which has one
+// NAME=value line per variable.
+func parseAzureCredentials(data []byte) map[string]string {
+	variables := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		variables[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return variables
+}
+
+// parseAWSCredentials returns the credentials of the profile in the AWS shared credentials file format.
+func parseAWSCredentials(data []byte, profile string) (accessKeyID string, secretAccessKey string, sessionToken string, err error) {
+	found := false
+	section := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == profile {
+				found = true
+			}
+			continue
+		}
+		if section != profile {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		switch strings.TrimSpace(parts[0]) {
+		case "aws_access_key_id":
+			accessKeyID = value
+		case "aws_secret_access_key":
+			secretAccessKey = value
+		case "aws_session_token":
+			sessionToken = value
+		}
+	}
+
+	if !found {
+		return "", "", "", errors.Errorf("profile %s is not found", profile)
+	}
+	if accessKeyID == "" || secretAccessKey == "" {
+		return "", "", "", errors.Errorf("credentials of profile %s are incomplete", profile)
+	}
+	return accessKeyID, secretAccessKey, sessionToken, nil
+}
+
+/*
+ * The snapshot IDs returned to Velero are the IDs of the Protected Entities of the snapshots,
+ * followed by the name of the Backup Storage Location(BSL) they are uploaded to, as in
+ * <peType>:<id>:<snapshotID>@<bsl>, so that a snapshot is restored from the BSL it is in
+ * whatever the restores in progress. The snapshot IDs returned by previous versions have
+ * no BSL.
+ */
+const snapshotIDLocationSeparator = "@"
+
+// NewSnapshotID returns the snapshot ID of the snapshot with the given Protected Entity ID, uploaded to the given BSL.
+func NewSnapshotID(peID astrolabe.ProtectedEntityID, bslName string) string {
+	if bslName == "" {
+		return peID.String()
+	}
+	return peID.String() + snapshotIDLocationSeparator + bslName
+}
+
+// ParseSnapshotID returns the Protected Entity ID and the name of the BSL of the snapshot ID, which is empty if the
+// snapshot ID does not record it.
+func ParseSnapshotID(snapshotID string) (astrolabe.ProtectedEntityID, string, error) {
+	bslName := ""
+	if i := strings.LastIndex(snapshotID, snapshotIDLocationSeparator); i >= 0 {
+		snapshotID, bslName = snapshotID[:i], snapshotID[i+len(snapshotIDLocationSeparator):]
+	}
+	peID, err := astrolabe.NewProtectedEntityIDFromString(snapshotID)
+	return peID, bslName, err
+}
+
 func GetIVDPETMFromParamsMap(params map[string]interface{}, logger logrus.FieldLogger) (*ivd.IVDProtectedEntityTypeManager, error) {
 	// Largely a dummy s3Config - s3Config is to enable access to astrolabe objects via S3 which we don't support from
 	// here
@@ -191,7 +494,7 @@ func GetIVDPETMFromParamsMap(params map[string]interface{}, logger logrus.FieldL
 	return ivdPETM, nil
 }
 
-func GetS3PETMFromParamsMap(params map[string]interface{}, logger logrus.FieldLogger) (*s3repository.ProtectedEntityTypeManager, error) {
+func GetS3PETMFromParamsMap(params map[string]interface{}, logger logrus.FieldLogger) (*objectstore.ProtectedEntityTypeManager, error) {
 	serviceType := "ivd"
 	region, ok := GetStringFromParamsMap(params, "region", logger)
 	if !ok {
@@ -223,18 +526,235 @@ func GetS3PETMFromParamsMap(params map[string]interface{}, logger logrus.FieldLo
 		}
 	}
 
-	prefix, ok := params["prefix"].(string)
+	// Static credentials are only used when both keys are given, e.g. in the parameters of a BackupRepository.
+	// Otherwise the credentials are looked up by the AWS SDK as usual, from the given profile if any.
+	accessKeyID, _ := params["accessKeyId"].(string)
+	secretAccessKey, _ := params["secretAccessKey"].(string)
+	sessionToken, _ := params["sessionToken"].(string)
+	profile, _ := params["profile"].(string)
+	if accessKeyID != "" && secretAccessKey != "" {
+		sess.Config.Credentials = credentials.NewStaticCredentials(accessKeyID, secretAccessKey, sessionToken)
+	} else if profile != "" {
+		sess.Config.Credentials = credentials.NewSharedCredentials("", profile)
+	}
+
+	store := objectstore.NewS3Store(sess, bucket)
+	prefix, err := repositoryPrefix(store, serviceType, params, logger)
+	if err != nil {
+		return nil, err
+	}
+	return objectstore.NewProtectedEntityTypeManager(serviceType, store, prefix, logger), nil
+}
+
+func GetAzurePETMFromParamsMap(params map[string]interface{}, logger logrus.FieldLogger) (*objectstore.ProtectedEntityTypeManager, error) {
+	storageAccount, ok := GetStringFromParamsMap(params, "storageAccount", logger)
+	if !ok {
+		return nil, errors.New("Missing storageAccount param, cannot initialize Azure PETM")
+	}
+
+	container, ok := GetStringFromParamsMap(params, "bucket", logger)
+	if !ok {
+		return nil, errors.New("Missing bucket param, cannot initialize Azure PETM")
+	}
+
+	// Without the Velero credentials secret, the key is read from the environment of the data manager.
+	storageAccountKey, _ := params["storageAccountKey"].(string)
+	if keyEnvVar, _ := params["storageAccountKeyEnvVar"].(string); storageAccountKey == "" && keyEnvVar != "" {
+		storageAccountKey = os.Getenv(keyEnvVar)
+	}
+	if storageAccountKey == "" {
+		return nil, errors.Errorf("No key of storage account %s, cannot initialize Azure PETM", storageAccount)
+	}
+
+	blobEndpoint, _ := params["blobEndpoint"].(string)
+	store, err := objectstore.NewAzureBlobStore(storageAccount, storageAccountKey, container, blobEndpoint)
+	if err != nil {
+		logger.WithError(err).Errorf("Error at creating Azure blob store from storageAccount: %s, container: %s",
+			storageAccount, container)
+		return nil, err
+	}
+
+	prefix, err := repositoryPrefix(store, CnsBlockVolumeType, params, logger)
+	if err != nil {
+		return nil, err
+	}
+	return objectstore.NewProtectedEntityTypeManager(CnsBlockVolumeType, store, prefix, logger), nil
+}
+
+func GetGCSPETMFromParamsMap(params map[string]interface{}, logger logrus.FieldLogger) (*objectstore.ProtectedEntityTypeManager, error) {
+	bucket, ok := GetStringFromParamsMap(params, "bucket", logger)
 	if !ok {
-		prefix = DefaultS3RepoPrefix
+		return nil, errors.New("Missing bucket param, cannot initialize GCS PETM")
+	}
+
+	credentialsJSON, _ := params["credentialsJSON"].(string)
+	endpoint, _ := params["endpoint"].(string)
+	store, err := objectstore.NewGCSStore(context.Background(), bucket, credentialsJSON, endpoint)
+	if err != nil {
+		logger.WithError(err).Errorf("Error at creating GCS store from bucket: %s", bucket)
+		return nil, err
+	}
+
+	prefix, err := repositoryPrefix(store, CnsBlockVolumeType, params, logger)
+	if err != nil {
+		return nil, err
+	}
+	return objectstore.NewProtectedEntityTypeManager(CnsBlockVolumeType, store, prefix, logger), nil
+}
+
+func GetFileSystemPETMFromParamsMap(params map[string]interface{}, logger logrus.FieldLogger) (*objectstore.ProtectedEntityTypeManager, error) {
+	root, ok := GetStringFromParamsMap(params, "path", logger)
+	if !ok || root == "" {
+		return nil, errors.New("Missing path param, cannot initialize filesystem PETM")
+	}
+	if !filepath.IsAbs(root) {
+		return nil, errors.Errorf("The path param %s is not absolute, cannot initialize filesystem PETM", root)
 	}
-	s3PETM, err := s3repository.NewS3RepositoryProtectedEntityTypeManager(serviceType, *sess, bucket, prefix, logger)
+
+	store := objectstore.NewFileSystemStore(root)
+	prefix, err := repositoryPrefix(store, CnsBlockVolumeType, params, logger)
 	if err != nil {
-		logger.WithError(err).Errorf("Error at creating new S3 PETM from serviceType: %s, region: %s, bucket: %s",
-			serviceType, region, bucket)
 		return nil, err
 	}
+	return objectstore.NewProtectedEntityTypeManager(CnsBlockVolumeType, store, prefix, logger), nil
+}
+
+// repositoryPrefix returns the prefix the repository of the given type is kept under in the store, which is under the
+// prefix of the backup storage location, next to the data of Velero. Previous versions of the plugin kept the
+// repository under DefaultS3RepoPrefix at the root of the bucket whatever the prefix of the backup storage location,
+// where it is still kept as long as it holds snapshots and no snapshot is under the prefix of the backup storage
+// location yet, so that the snapshots taken by previous versions are still restored and deleted.
+func repositoryPrefix(store objectstore.ObjectStore, typeName string, params map[string]interface{}, logger logrus.FieldLogger) (string, error) {
+	bslPrefix, _ := params["prefix"].(string)
+	if bslPrefix == "" {
+		return DefaultS3RepoPrefix, nil
+	}
+	prefix := path.Join(bslPrefix, DefaultS3RepoPrefix)
+	hasSnapshots := func(prefix string) (bool, error) {
+		keys, err := store.ListObjects(context.Background(), path.Join(prefix, typeName, "peinfo")+"/")
+		if err != nil {
+			return false, errors.Wrapf(err, "Failed to list the snapshots under %s", prefix)
+		}
+		return len(keys) > 0, nil
+	}
+	if found, err := hasSnapshots(prefix); err != nil || found {
+		return prefix, err
+	}
+	legacy, err := hasSnapshots(DefaultS3RepoPrefix)
+	if err != nil {
+		return "", err
+	}
+	if legacy {
+		logger.Infof("The repository is kept under the legacy prefix %s instead of %s until it is moved", DefaultS3RepoPrefix, prefix)
+		return DefaultS3RepoPrefix, nil
+	}
+	return prefix, nil
+}
+
+// A RepositoryBackend creates the PETM of a remote repository from the params map.
+type RepositoryBackend func(params map[string]interface{}, logger logrus.FieldLogger) (astrolabe.ProtectedEntityTypeManager, error)
+
+// The repository backends by object store provider. The provider of a backup storage location picks the backend
+// its repository is kept in.
+var repositoryBackends = map[string]RepositoryBackend{
+	ProviderAWS: func(params map[string]interface{}, logger logrus.FieldLogger) (astrolabe.ProtectedEntityTypeManager, error) {
+		petm, err := GetS3PETMFromParamsMap(params, logger)
+		if err != nil {
+			return nil, err
+		}
+		return petm, nil
+	},
+	ProviderAzure: func(params map[string]interface{}, logger logrus.FieldLogger) (astrolabe.ProtectedEntityTypeManager, error) {
+		petm, err := GetAzurePETMFromParamsMap(params, logger)
+		if err != nil {
+			return nil, err
+		}
+		return petm, nil
+	},
+	ProviderGCP: func(params map[string]interface{}, logger logrus.FieldLogger) (astrolabe.ProtectedEntityTypeManager, error) {
+		petm, err := GetGCSPETMFromParamsMap(params, logger)
+		if err != nil {
+			return nil, err
+		}
+		return petm, nil
+	},
+	ProviderFileSystem: func(params map[string]interface{}, logger logrus.FieldLogger) (astrolabe.ProtectedEntityTypeManager, error) {
+		petm, err := GetFileSystemPETMFromParamsMap(params, logger)
+		if err != nil {
+			return nil, err
+		}
+		return petm, nil
+	},
+}
+
+// RegisterRepositoryBackend makes a repository backend available for the given provider. It is meant to be called
+// at initialization time.
+func RegisterRepositoryBackend(provider string, backend RepositoryBackend) {
+	repositoryBackends[provider] = backend
+}
+
+func IsSupportedProvider(provider string) bool {
+	_, ok := repositoryBackends[provider]
+	return ok
+}
+
+// GetProviderFromParamsMap returns the object store provider in params. Params without a provider, e.g. the ones
+// recorded by previous versions of the plugin, are for AWS.
+func GetProviderFromParamsMap(params map[string]interface{}) string {
+	provider, _ := params["provider"].(string)
+	if provider == "" {
+		return ProviderAWS
+	}
+	return strings.ToLower(provider)
+}
+
+// GetRepositoryPETMFromParamsMap creates the PETM of the remote repository with the backend of the provider in params.
+func GetRepositoryPETMFromParamsMap(params map[string]interface{}, logger logrus.FieldLogger) (astrolabe.ProtectedEntityTypeManager, error) {
+	provider := GetProviderFromParamsMap(params)
+	backend, ok := repositoryBackends[provider]
+	if !ok {
+		return nil, errors.Errorf("Object store provider %s is not supported", provider)
+	}
+	return backend(params, logger)
+}
 
-	return s3PETM, nil
+// ValidateBackupRepositoryParameters checks that the parameters of a BackupRepository or BackupRepositoryClaim
+// carry what the repository driver needs to create its PETM.
+func ValidateBackupRepositoryParameters(driver string, params map[string]string) error {
+	var required []string
+	switch driver {
+	case S3RepositoryDriver:
+		required = []string{"region", "bucket"}
+	case AzureBlobRepositoryDriver:
+		required = []string{"storageAccount", "bucket"}
+	case GCSRepositoryDriver:
+		required = []string{"bucket"}
+	case FileSystemRepositoryDriver:
+		required = []string{"path"}
+	default:
+		return errors.Errorf("Unsupported repository driver %s", driver)
+	}
+
+	for _, key := range required {
+		if params[key] == "" {
+			return errors.Errorf("Missing %s param for repository driver %s", key, driver)
+		}
+	}
+	return nil
+}
+
+// GetProviderOfRepositoryDriver returns the object store provider of the backend of a repository driver.
+func GetProviderOfRepositoryDriver(driver string) string {
+	switch driver {
+	case AzureBlobRepositoryDriver:
+		return ProviderAzure
+	case GCSRepositoryDriver:
+		return ProviderGCP
+	case FileSystemRepositoryDriver:
+		return ProviderFileSystem
+	default:
+		return ProviderAWS
+	}
 }
 
 func GetStringFromParamsMap(params map[string]interface{}, key string, logger logrus.FieldLogger) (value string, ok bool) {
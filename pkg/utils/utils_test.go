@@ -17,9 +17,18 @@ limitations under the License.
 package utils
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+
 	"github.com/stretchr/testify/assert"
         "github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/astrolabe/pkg/astrolabe"
+	"github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/objectstore"
 	veleroplugintest "github.com/vmware-tanzu/velero-plugin-for-vsphere/pkg/test"
+	v1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"testing"
 )
 
@@ -103,3 +112,239 @@ func TestGetBool(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateBackupRepositoryParameters(t *testing.T) {
+	tests := []struct {
+		name      string
+		driver    string
+		params    map[string]string
+		expectErr bool
+	}{
+		{
+			name:   "Valid S3 parameters",
+			driver: S3RepositoryDriver,
+			params: map[string]string{"region": "us-west-1", "bucket": "velero"},
+		},
+		{
+			name:      "Missing bucket",
+			driver:    S3RepositoryDriver,
+			params:    map[string]string{"region": "us-west-1"},
+			expectErr: true,
+		},
+		{
+			name:   "Valid Azure parameters",
+			driver: AzureBlobRepositoryDriver,
+			params: map[string]string{"storageAccount": "velero", "bucket": "velero"},
+		},
+		{
+			name:      "Missing storage account",
+			driver:    AzureBlobRepositoryDriver,
+			params:    map[string]string{"bucket": "velero"},
+			expectErr: true,
+		},
+		{
+			name:   "Valid GCS parameters",
+			driver: GCSRepositoryDriver,
+			params: map[string]string{"bucket": "velero"},
+		},
+		{
+			name:      "Missing filesystem path",
+			driver:    FileSystemRepositoryDriver,
+			params:    map[string]string{},
+			expectErr: true,
+		},
+		{
+			name:      "Unsupported driver",
+			driver:    "unknown",
+			params:    map[string]string{"region": "us-west-1", "bucket": "velero"},
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateBackupRepositoryParameters(test.driver, test.params)
+			require.Equal(t, test.expectErr, err != nil)
+		})
+	}
+}
+
+func TestFirstValidBSL(t *testing.T) {
+	bsl := func(name, provider, region string) v1.BackupStorageLocation {
+		return v1.BackupStorageLocation{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1.BackupStorageLocationSpec{
+				Provider: provider,
+				Config:   map[string]string{"region": region},
+			},
+		}
+	}
+	tests := []struct {
+		name         string
+		bsls         []v1.BackupStorageLocation
+		expectedName string
+	}{
+		{
+			name:         "First valid BSL is picked up",
+			bsls:         []v1.BackupStorageLocation{bsl("ibm", "ibm", "us"), bsl("aws-1", "aws", "us-west-1"), bsl("aws-2", "aws", "us-east-1")},
+			expectedName: "aws-1",
+		},
+		{
+			name:         "Invalid BSLs after the valid one are skipped",
+			bsls:         []v1.BackupStorageLocation{bsl("aws-1", "aws", "us-west-1"), bsl("no-region", "aws", "")},
+			expectedName: "aws-1",
+		},
+		{
+			name:         "GCP BSL is picked up",
+			bsls:         []v1.BackupStorageLocation{bsl("no-region", "aws", ""), bsl("gcp", "gcp", "")},
+			expectedName: "gcp",
+		},
+		{
+			name: "Azure BSL without storage account is skipped",
+			bsls: []v1.BackupStorageLocation{bsl("azure", "azure", "")},
+		},
+		{
+			name: "No valid BSL",
+			bsls: []v1.BackupStorageLocation{bsl("ibm", "ibm", "us"), bsl("no-region", "aws", "")},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := firstValidBSL(test.bsls, veleroplugintest.NewLogger())
+			if test.expectedName == "" {
+				require.Nil(t, res)
+			} else {
+				require.Equal(t, test.expectedName, res.Name)
+			}
+		})
+	}
+}
+
+func TestParseAWSCredentials(t *testing.T) {
+	data := []byte(`
+[default]
+aws_access_key_id = default-id
+aws_secret_access_key = default-secret
+
+# credentials of the second IAM user
+[backup]
+aws_access_key_id=backup-id
+aws_secret_access_key=backup-secret
+aws_session_token=backup-token
+
+[incomplete]
+aws_access_key_id = incomplete-id
+`)
+	tests := []struct {
+		name           string
+		profile        string
+		expectedID     string
+		expectedSecret string
+		expectedToken  string
+		expectErr      bool
+	}{
+		{
+			name:           "Default profile",
+			profile:        "default",
+			expectedID:     "default-id",
+			expectedSecret: "default-secret",
+		},
+		{
+			name:           "Named profile with session token",
+			profile:        "backup",
+			expectedID:     "backup-id",
+			expectedSecret: "backup-secret",
+			expectedToken:  "backup-token",
+		},
+		{
+			name:      "Missing profile",
+			profile:   "missing",
+			expectErr: true,
+		},
+		{
+			name:      "Incomplete profile",
+			profile:   "incomplete",
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id, secret, token, err := parseAWSCredentials(data, test.profile)
+			require.Equal(t, test.expectErr, err != nil)
+			require.Equal(t, test.expectedID, id)
+			require.Equal(t, test.expectedSecret, secret)
+			require.Equal(t, test.expectedToken, token)
+		})
+	}
+}
+
+func TestParseAzureCredentials(t *testing.T) {
+	data := []byte(`
+AZURE_SUBSCRIPTION_ID=subscription
+AZURE_RESOURCE_GROUP=group
+# comment
+AZURE_STORAGE_ACCOUNT_ACCESS_KEY=key==
+`)
+	variables := parseAzureCredentials(data)
+	assert.Equal(t, "key==", variables["AZURE_STORAGE_ACCOUNT_ACCESS_KEY"])
+	assert.Equal(t, "group", variables["AZURE_RESOURCE_GROUP"])
+	assert.Len(t, variables, 3)
+}
+
+func TestGetProviderFromParamsMap(t *testing.T) {
+	assert.Equal(t, ProviderAWS, GetProviderFromParamsMap(map[string]interface{}{"region": "us-west-1"}))
+	assert.Equal(t, ProviderAzure, GetProviderFromParamsMap(map[string]interface{}{"provider": "Azure"}))
+
+	_, err := GetRepositoryPETMFromParamsMap(map[string]interface{}{"provider": "ibm"}, veleroplugintest.NewLogger())
+	assert.Error(t, err)
+}
+
+func TestGetFileSystemPETMFromParamsMap(t *testing.T) {
+	_, err := GetFileSystemPETMFromParamsMap(map[string]interface{}{"path": "repository"}, veleroplugintest.NewLogger())
+	assert.Error(t, err)
+
+	petm, err := GetRepositoryPETMFromParamsMap(map[string]interface{}{"provider": ProviderFileSystem, "path": "/repository"}, veleroplugintest.NewLogger())
+	require.NoError(t, err)
+	assert.Equal(t, CnsBlockVolumeType, petm.GetTypeName())
+}
+
+func TestRepositoryPrefix(t *testing.T) {
+	ctx := context.Background()
+	logger := veleroplugintest.NewLogger()
+	root, err := ioutil.TempDir("", "fsrepository")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	store := objectstore.NewFileSystemStore(root)
+	params := map[string]interface{}{"prefix": "cluster-1"}
+
+	prefix, err := repositoryPrefix(store, CnsBlockVolumeType, map[string]interface{}{}, logger)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultS3RepoPrefix, prefix)
+
+	prefix, err = repositoryPrefix(store, CnsBlockVolumeType, params, logger)
+	require.NoError(t, err)
+	assert.Equal(t, "cluster-1/"+DefaultS3RepoPrefix, prefix)
+
+	// The repository of previous versions is kept at the root of the bucket until it is moved
+	require.NoError(t, store.PutObject(ctx, DefaultS3RepoPrefix+"/ivd/peinfo/ivd:fcd-1:snap-1", strings.NewReader("{}")))
+	prefix, err = repositoryPrefix(store, CnsBlockVolumeType, params, logger)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultS3RepoPrefix, prefix)
+
+	require.NoError(t, store.PutObject(ctx, "cluster-1/"+DefaultS3RepoPrefix+"/ivd/peinfo/ivd:fcd-1:snap-1", strings.NewReader("{}")))
+	prefix, err = repositoryPrefix(store, CnsBlockVolumeType, params, logger)
+	require.NoError(t, err)
+	assert.Equal(t, "cluster-1/"+DefaultS3RepoPrefix, prefix)
+}
+
+func TestSnapshotID(t *testing.T) {
+	peID := astrolabe.NewProtectedEntityIDWithSnapshotID("ivd", "fcd-1", astrolabe.NewProtectedEntitySnapshotID("snap-1"))
+	assert.Equal(t, "ivd:fcd-1:snap-1", NewSnapshotID(peID, ""))
+	assert.Equal(t, "ivd:fcd-1:snap-1@secondary", NewSnapshotID(peID, "secondary"))
+
+	for snapshotID, bslName := range map[string]string{"ivd:fcd-1:snap-1": "", "ivd:fcd-1:snap-1@secondary": "secondary"} {
+		parsed, parsedBSLName, err := ParseSnapshotID(snapshotID)
+		require.NoError(t, err)
+		assert.Equal(t, peID.String(), parsed.String())
+		assert.Equal(t, bslName, parsedBSLName)
+	}
+}